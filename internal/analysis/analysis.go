@@ -2,6 +2,7 @@ package analysis
 
 import (
 	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/findings"
 	"apache2buddy-go/internal/process"
 	"apache2buddy-go/internal/system"
 )
@@ -11,38 +12,117 @@ type MemoryStats struct {
 	LargestMB    float64
 	AverageMB    float64
 	TotalMB      float64
-	ProcessCount int
+	LargestPSSMB float64 // 0 when PSS could not be read
+	ProcessCount int     // Number of processes with a usable measurement
+
+	// Distribution of worker sizes; percentiles are nearest rank
+	MedianMB  float64
+	P90MB     float64
+	P95MB     float64
+	P99MB     float64
+	StdDevMB  float64 // Population standard deviation
+	Histogram []HistogramBucket
+	Outliers  []Outlier // Largest first, nil when there are none
+
+	// Statistic SizingMB is based on (Size* constants, largest when empty)
+	Statistic string
+
+	// Largest worker size projected from sampled growth, 0 when not sampled
+	ProjectedPeakMB float64
+
+	// Largest worker of the history window, 0 when there is no history
+	HistoryPeakMB float64
+
+	// Worker memory swapped out (VmSwap), not included in the figures above
+	SwapMB         float64
+	SwappedWorkers int
+
+	// Measurement quality
+	MethodCounts map[string]int        // measurement method -> process count
+	Skipped      []process.ProcessInfo // processes excluded because they could not be measured
 }
 
+// Confidence levels for memory measurements
+const (
+	ConfidenceHigh   = "high"
+	ConfidenceMedium = "medium"
+	ConfidenceLow    = "low"
+)
+
 type Recommendations struct {
 	CurrentMaxClients     int
 	RecommendedMaxClients int
 	MinRecommended        int
 	MaxRecommended        int
-	Status                string
-	Message               string
+	Status                string // OK, WARNING or CRITICAL from the findings; ERROR when nothing could be analysed
 	UtilizationPercent    float64
-	VHostWarning          bool
-	MPMNote               string
+	VirtualHosts          int
+	Confidence            string // How trustworthy the memory figures are (Confidence* constants)
+
+	// Settings that go with RecommendedMaxClients, 0 when not needed. Under
+	// worker and event ServerLimit × ThreadsPerChild = RecommendedMaxClients.
+	ServerLimit     int
+	ThreadsPerChild int
+	ThreadLimit     int
+
+	// NoRoom is set when the budget cannot hold a single child; the
+	// recommendation is then clamped to one child anyway
+	NoRoom bool
+
+	Lifetime *WorkerLifetime // Worker age vs. memory analysis, nil when not enough data
+	Growth   *GrowthAnalysis // Sampled memory growth, nil unless sampling was enabled
+	Pressure *MemoryPressure // Swap and OOM-killer evidence, nil when not assessed
+	Policy   *Policy         // Policy the recommendation was made with
+
+	CPU     *CPUAnalysis // CPU ceiling, nil when the CPUs could not be read
+	Binding string       // Binding* ceiling the recommendation comes from
+
+	Limits []system.LimitCheck // Kernel and service limits, nil when not audited
+	Demand *DemandAnalysis     // Little's law estimate, nil without mod_status request durations
+
+	History *HistoryPeak // Peaks of past runs, nil without history
+
+	MPMBlock  []MPMDirective   // Complete MPM block, nil until RecommendMPMBlock runs
+	Migration *MigrationAdvice // prefork + mod_php to event + PHP-FPM, nil when not applicable
+
+	Findings []findings.Finding // Worst first, nil until Evaluate runs
 }
 
 func CalculateMemoryStats(processes []process.ProcessInfo) *MemoryStats {
-	if len(processes) == 0 {
-		return &MemoryStats{
-			ProcessCount: 0,
+	stats := &MemoryStats{
+		MethodCounts: make(map[string]int),
+	}
+
+	// Processes that could not be measured must not feed into the statistics
+	var measured []process.ProcessInfo
+	for _, proc := range processes {
+		if proc.Method == process.MethodUnavailable {
+			stats.Skipped = append(stats.Skipped, proc)
+			continue
+		}
+		if proc.Method != "" {
+			stats.MethodCounts[proc.Method]++
 		}
+		measured = append(measured, proc)
 	}
+	processes = measured
 
-	stats := &MemoryStats{
-		SmallestMB:   processes[0].MemoryMB,
-		LargestMB:    processes[0].MemoryMB,
-		ProcessCount: len(processes),
+	if len(processes) == 0 {
+		return stats
 	}
 
+	stats.SmallestMB = processes[0].MemoryMB
+	stats.LargestMB = processes[0].MemoryMB
+	stats.ProcessCount = len(processes)
+
 	var totalMemory float64
 
 	for _, proc := range processes {
 		totalMemory += proc.MemoryMB
+		if proc.SwapMB > 0 {
+			stats.SwapMB += proc.SwapMB
+			stats.SwappedWorkers++
+		}
 
 		if proc.MemoryMB < stats.SmallestMB {
 			stats.SmallestMB = proc.MemoryMB
@@ -56,15 +136,57 @@ func CalculateMemoryStats(processes []process.ProcessInfo) *MemoryStats {
 	stats.TotalMB = totalMemory
 	stats.AverageMB = totalMemory / float64(len(processes))
 
+	for _, proc := range processes {
+		if proc.PSSMB > stats.LargestPSSMB {
+			stats.LargestPSSMB = proc.PSSMB
+		}
+	}
+	stats.addDistribution(processes)
+
 	return stats
 }
 
+// SizingMB is the per-worker size used for capacity calculations: the
+// chosen statistic, or the projected peak of leaking workers when that is
+// larger
+func (stats *MemoryStats) SizingMB() float64 {
+	size := stats.StatisticMB()
+	if stats.ProjectedPeakMB > size {
+		return stats.ProjectedPeakMB
+	}
+	return size
+}
+
+// StatisticMB returns the current value of the chosen Statistic. Sizing on
+// PSS falls back to the largest RSS when PSS is unavailable, sizing on the
+// history to the largest worker now when it is larger or there is no history.
+func (stats *MemoryStats) StatisticMB() float64 {
+	switch stats.Statistic {
+	case SizeMedian:
+		return stats.MedianMB
+	case SizeP90:
+		return stats.P90MB
+	case SizeP95:
+		return stats.P95MB
+	case SizeP99:
+		return stats.P99MB
+	case SizeAverage:
+		return stats.AverageMB
+	case SizePSS:
+		if stats.LargestPSSMB > 0 {
+			return stats.LargestPSSMB
+		}
+	case SizeHistory:
+		if stats.HistoryPeakMB > stats.LargestMB {
+			return stats.HistoryPeakMB
+		}
+	}
+	return stats.LargestMB
+}
+
 func GenerateRecommendations(sysInfo *system.SystemInfo, memStats *MemoryStats, config *config.ApacheConfig) *Recommendations {
 	if memStats.ProcessCount == 0 {
-		return &Recommendations{
-			Status:  "ERROR",
-			Message: "No processes to analyze",
-		}
+		return &Recommendations{Status: "ERROR"}
 	}
 
 	// Use largest process memory for conservative calculation
@@ -81,82 +203,116 @@ func GenerateRecommendations(sysInfo *system.SystemInfo, memStats *MemoryStats,
 	utilizationPercent := (potentialUsage / float64(sysInfo.AvailableMemoryMB)) * 100
 
 	// Determine status
-	var status, message string
-	if currentMaxClients <= recommendedMaxClients {
-		status = "OK"
-		message = "Configuration appears acceptable"
-	} else {
+	status := "OK"
+	if currentMaxClients > recommendedMaxClients {
 		status = "HIGH"
-		message = "Consider reducing MaxClients/MaxRequestWorkers"
 	}
 
 	return &Recommendations{
 		CurrentMaxClients:     currentMaxClients,
 		RecommendedMaxClients: recommendedMaxClients,
 		Status:                status,
-		Message:               message,
 		UtilizationPercent:    utilizationPercent,
 	}
 }
 
-// GenerateEnhancedRecommendations provides comprehensive analysis like original apache2buddy.pl
+// GenerateEnhancedRecommendations provides comprehensive analysis like
+// original apache2buddy.pl, using the default policy
 func GenerateEnhancedRecommendations(sysInfo *system.SystemInfo, memStats *MemoryStats, config *config.ApacheConfig, statusInfo interface{}, vhostCount int) *Recommendations {
+	return GenerateRecommendationsWithPolicy(sysInfo, memStats, config, statusInfo, vhostCount, DefaultPolicy())
+}
+
+// GenerateRecommendationsWithPolicy sizes MaxRequestWorkers with the margin
+// band of policy. memStats.Statistic selects the per-worker size.
+func GenerateRecommendationsWithPolicy(sysInfo *system.SystemInfo, memStats *MemoryStats, config *config.ApacheConfig, statusInfo interface{}, vhostCount int, policy Policy) *Recommendations {
 	if memStats.ProcessCount == 0 {
-		return &Recommendations{
-			Status:  "ERROR",
-			Message: "No processes to analyze",
-		}
+		return &Recommendations{Status: "ERROR"}
 	}
 
-	// Use largest process memory for conservative calculation
-	largestMB := memStats.LargestMB
+	// Use the policy's worker size (or the projected peak) for the calculation
+	largestMB := memStats.SizingMB()
 
-	// Calculate range recommendations (90-100% of remaining RAM)
-	maxRecommended := int(float64(sysInfo.AvailableMemoryMB) / largestMB)       // 100%
-	minRecommended := int(float64(sysInfo.AvailableMemoryMB) / largestMB * 0.9) // 90%
+	// Calculate range recommendations (the policy's band of the budget). The
+	// budget holds whole processes; under worker and event each serves
+	// ThreadsPerChild requests.
+	threads := ThreadsPerChild(config)
+	maxRecommended := policy.LimitWorkers(sysInfo.AvailableMemoryMB, largestMB) * threads
+	minRecommended := policy.TargetWorkers(sysInfo.AvailableMemoryMB, largestMB) * threads
 
 	// Get actual current MaxClients from config
 	currentMaxClients := config.GetCurrentMaxClients()
 
 	// Calculate utilization
-	potentialUsage := float64(currentMaxClients) * largestMB
+	potentialUsage := WorkerMemoryMB(currentMaxClients, config, memStats)
 	utilizationPercent := (potentialUsage / float64(sysInfo.AvailableMemoryMB)) * 100
 
-	// Enhanced status determination
-	var status, message, mpmNote string
-	var vhostWarning bool
+	// Status from the memory arithmetic alone; Evaluate replaces it with the
+	// status of all findings
+	status := "OK"
+	if currentMaxClients > maxRecommended {
+		status = "CRITICAL"
+	} else if currentMaxClients > minRecommended {
+		status = "WARNING"
+	}
 
-	// Check virtual host vs MaxClients relationship
-	if vhostCount > maxRecommended {
-		vhostWarning = true
+	rec := &Recommendations{
+		CurrentMaxClients:  currentMaxClients,
+		MinRecommended:     minRecommended,
+		MaxRecommended:     maxRecommended,
+		Status:             status,
+		UtilizationPercent: utilizationPercent,
+		VirtualHosts:       vhostCount,
+		Confidence:         MeasurementConfidence(memStats),
+		Policy:             &policy,
+	}
+	if IsThreaded(config.MPMModel) {
+		rec.ThreadsPerChild = threads
 	}
+	rec.setWorkers(minRecommended) // Conservative recommendation
+	return rec
+}
 
-	// MPM-specific notes (like original apache2buddy.pl)
-	if config.MPMModel == "worker" || config.MPMModel == "event" {
-		mpmNote = "Apache is running in " + config.MPMModel + " mode. Check manually for backend processes such as PHP-FPM and pm.max_children."
+// SplitAvailableMemory divides availableMB between Apache instances in
+// proportion to weights (normally each instance's current memory use). If no
+// instance has a positive weight the memory is split evenly.
+func SplitAvailableMemory(availableMB int, weights []float64) []int {
+	shares := make([]int, len(weights))
+	if len(weights) == 0 {
+		return shares
 	}
 
-	// Determine overall status
-	if currentMaxClients <= minRecommended {
-		status = "OK"
-		message = "Configuration appears acceptable"
-	} else if currentMaxClients <= maxRecommended {
-		status = "WARNING"
-		message = "Configuration is on the high side but acceptable"
-	} else {
-		status = "CRITICAL"
-		message = "Consider reducing MaxClients/MaxRequestWorkers to avoid memory issues"
+	var total float64
+	for _, weight := range weights {
+		if weight > 0 {
+			total += weight
+		}
 	}
 
-	return &Recommendations{
-		CurrentMaxClients:     currentMaxClients,
-		RecommendedMaxClients: minRecommended, // Conservative recommendation
-		MinRecommended:        minRecommended,
-		MaxRecommended:        maxRecommended,
-		Status:                status,
-		Message:               message,
-		UtilizationPercent:    utilizationPercent,
-		VHostWarning:          vhostWarning,
-		MPMNote:               mpmNote,
+	for i, weight := range weights {
+		switch {
+		case total == 0:
+			shares[i] = availableMB / len(weights)
+		case weight > 0:
+			shares[i] = int(float64(availableMB) * weight / total)
+		}
+	}
+	return shares
+}
+
+// MeasurementConfidence rates the memory figures: high when every process was
+// read from /proc, medium when ps had to be used or some processes were
+// skipped, low when ps was the only source or most processes were skipped.
+func MeasurementConfidence(memStats *MemoryStats) string {
+	skipped := len(memStats.Skipped)
+	total := memStats.ProcessCount + skipped
+	psCount := memStats.MethodCounts[process.MethodPS]
+
+	switch {
+	case memStats.ProcessCount == 0 || skipped*2 > total || psCount == memStats.ProcessCount:
+		return ConfidenceLow
+	case skipped > 0 || psCount > 0:
+		return ConfidenceMedium
+	default:
+		return ConfidenceHigh
 	}
 }
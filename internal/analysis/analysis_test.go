@@ -75,6 +75,127 @@ func TestCalculateMemoryStats(t *testing.T) {
 	}
 }
 
+func TestCalculateMemoryStats_SkipsUnmeasured(t *testing.T) {
+	processes := []process.ProcessInfo{
+		{PID: 1234, User: "www-data", MemoryMB: 20.0, Method: process.MethodSmaps},
+		{PID: 1235, User: "www-data", MemoryMB: 30.0, Method: process.MethodPS},
+		{PID: 1236, User: "www-data", Method: process.MethodUnavailable},
+	}
+
+	got := CalculateMemoryStats(processes)
+	if got.ProcessCount != 2 {
+		t.Errorf("ProcessCount = %d, want 2", got.ProcessCount)
+	}
+	if got.SmallestMB != 20.0 || got.AverageMB != 25.0 {
+		t.Errorf("stats include unmeasured process: smallest %f, average %f", got.SmallestMB, got.AverageMB)
+	}
+	if len(got.Skipped) != 1 || got.Skipped[0].PID != 1236 {
+		t.Errorf("Skipped = %+v, want PID 1236", got.Skipped)
+	}
+	if got.MethodCounts[process.MethodSmaps] != 1 || got.MethodCounts[process.MethodPS] != 1 {
+		t.Errorf("MethodCounts = %v", got.MethodCounts)
+	}
+}
+
+func TestMeasurementConfidence(t *testing.T) {
+	tests := []struct {
+		name     string
+		memStats *MemoryStats
+		want     string
+	}{
+		{
+			name: "all from proc",
+			memStats: &MemoryStats{
+				ProcessCount: 4,
+				MethodCounts: map[string]int{process.MethodSmaps: 3, process.MethodStatus: 1},
+			},
+			want: ConfidenceHigh,
+		},
+		{
+			name: "some via ps",
+			memStats: &MemoryStats{
+				ProcessCount: 4,
+				MethodCounts: map[string]int{process.MethodSmaps: 3, process.MethodPS: 1},
+			},
+			want: ConfidenceMedium,
+		},
+		{
+			name: "one skipped",
+			memStats: &MemoryStats{
+				ProcessCount: 4,
+				MethodCounts: map[string]int{process.MethodSmaps: 4},
+				Skipped:      []process.ProcessInfo{{PID: 99}},
+			},
+			want: ConfidenceMedium,
+		},
+		{
+			name: "only ps",
+			memStats: &MemoryStats{
+				ProcessCount: 2,
+				MethodCounts: map[string]int{process.MethodPS: 2},
+			},
+			want: ConfidenceLow,
+		},
+		{
+			name: "mostly skipped",
+			memStats: &MemoryStats{
+				ProcessCount: 1,
+				MethodCounts: map[string]int{process.MethodSmaps: 1},
+				Skipped:      []process.ProcessInfo{{PID: 98}, {PID: 99}},
+			},
+			want: ConfidenceLow,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := MeasurementConfidence(tt.memStats); got != tt.want {
+				t.Errorf("MeasurementConfidence() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestSplitAvailableMemory(t *testing.T) {
+	tests := []struct {
+		name      string
+		available int
+		weights   []float64
+		want      []int
+	}{
+		{
+			name:      "single instance gets everything",
+			available: 1500,
+			weights:   []float64{300},
+			want:      []int{1500},
+		},
+		{
+			name:      "proportional to usage",
+			available: 1200,
+			weights:   []float64{300, 100},
+			want:      []int{900, 300},
+		},
+		{
+			name:      "even split without usage data",
+			available: 1200,
+			weights:   []float64{0, 0, 0},
+			want:      []int{400, 400, 400},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := SplitAvailableMemory(tt.available, tt.weights)
+			for i := range tt.want {
+				if got[i] != tt.want[i] {
+					t.Errorf("SplitAvailableMemory() = %v, want %v", got, tt.want)
+					break
+				}
+			}
+		})
+	}
+}
+
 func TestGenerateRecommendations(t *testing.T) {
 	tests := []struct {
 		name     string
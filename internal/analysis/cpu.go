@@ -0,0 +1,161 @@
+package analysis
+
+import (
+	"time"
+
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/status"
+	"apache2buddy-go/internal/system"
+)
+
+// minWorkerCores is the least CPU a busy worker is assumed to need, so that
+// mostly idle workers cannot push the CPU ceiling towards infinity
+const minWorkerCores = 0.05
+
+// Samples below these are too small to spread Apache's CPU use over: the
+// requests mod_status's average covers, and the busy workers in a snapshot
+// after the status request itself is taken out
+const (
+	minBusyRequests = 1000
+	minBusySnapshot = 5
+)
+
+// Sources of the busy worker count
+const (
+	BusyAverage  = "mod_status request rate × duration"
+	BusySnapshot = "mod_status snapshot"
+	BusyPrefork  = "every prefork worker"
+)
+
+// Binding ceilings of a recommendation
+const (
+	BindingMemory = "memory"
+	BindingCPU    = "CPU"
+)
+
+// CPUAnalysis is how many busy workers the instance's CPUs can serve
+type CPUAnalysis struct {
+	CPU        *system.CPUInfo
+	CPUs       float64 // Effective core count
+	Load1      float64
+	Load5      float64
+	Load15     float64
+	LoadSource string // "mod_status" or "/proc/loadavg"
+
+	ApacheCores    float64 // Cores Apache used on average
+	ApacheSource   string  // "worker CPU time" or "mod_status CPULoad"
+	BusyWorkers    float64 // Workers the usage is spread over
+	BusySource     string  // One of the Busy* sources, "" when no sample is usable
+	CoresPerWorker float64 // CPU a busy worker needs, at least minWorkerCores
+
+	// MaxRequestWorkers the CPUs can serve within the policy's margin band,
+	// 0 when worker CPU usage is unknown
+	TargetWorkers int
+	LimitWorkers  int
+	MemoryWorkers int // The memory-based recommendation, before the CPU ceiling
+
+	// The memory margin band, before the CPU ceiling
+	MemoryMinWorkers int
+	MemoryMaxWorkers int
+}
+
+// Saturated reports whether the 5-minute load exceeds the core count
+func (c *CPUAnalysis) Saturated() bool {
+	return c.CPUs > 0 && c.Load5 > c.CPUs
+}
+
+// AnalyzeCPU derives a CPU ceiling for MaxRequestWorkers from the cores in
+// cpu and the CPU time workers have used over their lifetime. That is an
+// average, so it is spread over the average busy workers since the restart
+// (mod_status's request rate × duration). Without ExtendedStatus a
+// snapshot of busy workers stands in, less the status request and only when
+// enough are busy; failing that every prefork worker is assumed busy, and
+// no ceiling is derived for threaded MPMs.
+func AnalyzeCPU(cpu *system.CPUInfo, workers []process.ProcessInfo, statusInfo *status.ApacheStatus, mpm string, policy Policy, now time.Time) *CPUAnalysis {
+	if cpu == nil {
+		return nil
+	}
+	analysis := &CPUAnalysis{
+		CPU:        cpu,
+		CPUs:       cpu.CPUs(),
+		Load1:      cpu.Load1,
+		Load5:      cpu.Load5,
+		Load15:     cpu.Load15,
+		LoadSource: "/proc/loadavg",
+	}
+	if statusInfo != nil && statusInfo.ExtendedEnabled && statusInfo.Load1Min > 0 {
+		analysis.Load1, analysis.Load5, analysis.Load15 = statusInfo.Load1Min, statusInfo.Load5Min, statusInfo.Load15Min
+		analysis.LoadSource = "mod_status"
+	}
+
+	for _, worker := range workers {
+		age := now.Sub(worker.StartTime).Seconds()
+		if worker.StartTime.IsZero() || age <= 0 {
+			continue
+		}
+		analysis.ApacheCores += worker.CPUSeconds / age
+		analysis.ApacheSource = "worker CPU time"
+	}
+	if analysis.ApacheSource == "" && statusInfo != nil && statusInfo.CPUUsage > 0 {
+		// CPULoad is Apache's CPU time as a percentage of its uptime
+		analysis.ApacheCores = statusInfo.CPUUsage / 100
+		analysis.ApacheSource = "mod_status CPULoad"
+	}
+
+	analysis.BusyWorkers, analysis.BusySource = busyWorkers(statusInfo, workers, mpm)
+	if analysis.ApacheSource == "" || analysis.BusySource == "" || analysis.CPUs == 0 {
+		return analysis
+	}
+
+	analysis.CoresPerWorker = analysis.ApacheCores / analysis.BusyWorkers
+	if analysis.CoresPerWorker < minWorkerCores {
+		analysis.CoresPerWorker = minWorkerCores
+	}
+	analysis.TargetWorkers = int(analysis.CPUs / analysis.CoresPerWorker * (policy.TargetPercent / 100))
+	analysis.LimitWorkers = int(analysis.CPUs / analysis.CoresPerWorker * (policy.LimitPercent / 100))
+	return analysis
+}
+
+// busyWorkers returns how many workers were busy on average and where that
+// came from, or 0 and "" when no sample is large enough
+func busyWorkers(statusInfo *status.ApacheStatus, workers []process.ProcessInfo, mpm string) (float64, string) {
+	if statusInfo != nil {
+		// Little's law over the same uptime as the CPU averages
+		average := statusInfo.RequestsPerSec * statusInfo.AvgRequestTime / 1000
+		if average > 0 && statusInfo.TotalAccesses >= minBusyRequests {
+			return average, BusyAverage
+		}
+		if busy := statusInfo.ActiveWorkers - 1; busy >= minBusySnapshot {
+			return float64(busy), BusySnapshot
+		}
+	}
+	if !IsThreaded(mpm) && len(workers) > 0 {
+		return float64(len(workers)), BusyPrefork
+	}
+	return 0, ""
+}
+
+// ApplyCPUCeiling attaches cpu to rec and lowers the recommendation to the
+// CPU ceiling when that binds before memory. The cpu.ceiling rule reports a
+// MaxRequestWorkers above it.
+func ApplyCPUCeiling(rec *Recommendations, cpu *CPUAnalysis) {
+	rec.Binding = BindingMemory
+	rec.CPU = cpu
+	if cpu == nil || rec.Status == "ERROR" {
+		return
+	}
+	cpu.MemoryWorkers = rec.RecommendedMaxClients
+	cpu.MemoryMinWorkers, cpu.MemoryMaxWorkers = rec.MinRecommended, rec.MaxRecommended
+	if cpu.TargetWorkers == 0 || cpu.TargetWorkers >= rec.RecommendedMaxClients {
+		return
+	}
+
+	rec.Binding = BindingCPU
+	rec.setWorkers(cpu.TargetWorkers)
+	if rec.MinRecommended > cpu.TargetWorkers {
+		rec.MinRecommended = cpu.TargetWorkers
+	}
+	if rec.MaxRecommended > cpu.LimitWorkers {
+		rec.MaxRecommended = cpu.LimitWorkers
+	}
+}
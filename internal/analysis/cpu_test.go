@@ -0,0 +1,209 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/status"
+	"apache2buddy-go/internal/system"
+)
+
+func TestAnalyzeCPU(t *testing.T) {
+	now := time.Date(2026, 3, 1, 12, 0, 0, 0, time.UTC)
+	// Four workers that each used a quarter of a core over their hour of life
+	var workers []process.ProcessInfo
+	for i := 0; i < 4; i++ {
+		workers = append(workers, process.ProcessInfo{PID: 100 + i, StartTime: now.Add(-time.Hour), CPUSeconds: 900})
+	}
+	cpu := &system.CPUInfo{OnlineCPUs: 8, QuotaCPUs: 2, QuotaSource: "cpu.max", Load1: 1.5, Load5: 2.5, Load15: 1}
+
+	tests := []struct {
+		name        string
+		workers     []process.ProcessInfo
+		statusInfo  *status.ApacheStatus
+		mpm         string
+		wantCores   float64
+		wantBusy    float64
+		wantBusySrc string
+		wantTarget  int
+		wantLimit   int
+		wantSource  string
+		wantLoadSrc string
+	}{
+		{
+			name:        "prefork without mod_status",
+			workers:     workers,
+			mpm:         "prefork",
+			wantCores:   1,
+			wantBusy:    4,
+			wantBusySrc: BusyPrefork,
+			wantTarget:  7, // 2 CPUs / 0.25 per worker * 90%
+			wantLimit:   8,
+			wantSource:  "worker CPU time",
+			wantLoadSrc: "/proc/loadavg",
+		},
+		{
+			name:        "average busy workers from the request rate and duration",
+			workers:     workers,
+			statusInfo:  &status.ApacheStatus{ActiveWorkers: 30, RequestsPerSec: 20, AvgRequestTime: 200, TotalAccesses: 72000, ExtendedEnabled: true, Load1Min: 0.5, Load5Min: 0.4, Load15Min: 0.3},
+			mpm:         "event",
+			wantCores:   1,
+			wantBusy:    4, // 20/s * 0.2 s, not the 30 busy right now
+			wantBusySrc: BusyAverage,
+			wantTarget:  7,
+			wantLimit:   8,
+			wantSource:  "worker CPU time",
+			wantLoadSrc: "mod_status",
+		},
+		{
+			name:        "snapshot less the status request",
+			workers:     workers,
+			statusInfo:  &status.ApacheStatus{ActiveWorkers: 9},
+			mpm:         "event",
+			wantCores:   1,
+			wantBusy:    8,
+			wantBusySrc: BusySnapshot,
+			wantTarget:  14,
+			wantLimit:   16,
+			wantSource:  "worker CPU time",
+			wantLoadSrc: "/proc/loadavg",
+		},
+		{
+			name:        "quiet snapshot on a threaded MPM has no ceiling",
+			workers:     workers,
+			statusInfo:  &status.ApacheStatus{ActiveWorkers: 1, RequestsPerSec: 0.01, AvgRequestTime: 50, TotalAccesses: 40},
+			mpm:         "event",
+			wantCores:   1,
+			wantSource:  "worker CPU time",
+			wantLoadSrc: "/proc/loadavg",
+		},
+		{
+			name:        "quiet snapshot on prefork counts every worker",
+			workers:     workers,
+			statusInfo:  &status.ApacheStatus{ActiveWorkers: 1},
+			mpm:         "prefork",
+			wantCores:   1,
+			wantBusy:    4,
+			wantBusySrc: BusyPrefork,
+			wantTarget:  7,
+			wantLimit:   8,
+			wantSource:  "worker CPU time",
+			wantLoadSrc: "/proc/loadavg",
+		},
+		{
+			name:        "mod_status CPULoad and a floor per worker",
+			statusInfo:  &status.ApacheStatus{ActiveWorkers: 11, CPUUsage: 5},
+			mpm:         "prefork",
+			wantCores:   0.05,
+			wantBusy:    10,
+			wantBusySrc: BusySnapshot,
+			wantTarget:  36, // 0.005 per worker is raised to the 0.05 floor
+			wantLimit:   40,
+			wantSource:  "mod_status CPULoad",
+			wantLoadSrc: "/proc/loadavg",
+		},
+		{
+			name:        "threaded without mod_status has no ceiling",
+			workers:     workers,
+			mpm:         "event",
+			wantCores:   1,
+			wantSource:  "worker CPU time",
+			wantLoadSrc: "/proc/loadavg",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AnalyzeCPU(cpu, tt.workers, tt.statusInfo, tt.mpm, DefaultPolicy(), now)
+			if got.CPUs != 2 {
+				t.Errorf("CPUs = %g, want 2", got.CPUs)
+			}
+			if got.ApacheCores != tt.wantCores || got.BusyWorkers != tt.wantBusy || got.BusySource != tt.wantBusySrc {
+				t.Errorf("ApacheCores = %g over %g busy (%q), want %g over %g (%q)",
+					got.ApacheCores, got.BusyWorkers, got.BusySource, tt.wantCores, tt.wantBusy, tt.wantBusySrc)
+			}
+			if got.TargetWorkers != tt.wantTarget || got.LimitWorkers != tt.wantLimit {
+				t.Errorf("ceiling = %d-%d, want %d-%d", got.TargetWorkers, got.LimitWorkers, tt.wantTarget, tt.wantLimit)
+			}
+			if got.ApacheSource != tt.wantSource || got.LoadSource != tt.wantLoadSrc {
+				t.Errorf("sources = %q, %q", got.ApacheSource, got.LoadSource)
+			}
+		})
+	}
+
+	if AnalyzeCPU(nil, workers, nil, "prefork", DefaultPolicy(), now) != nil {
+		t.Error("AnalyzeCPU(nil) should return nil")
+	}
+	if !AnalyzeCPU(cpu, nil, nil, "prefork", DefaultPolicy(), now).Saturated() {
+		t.Error("5-minute load 2.5 on 2 CPUs should be saturated")
+	}
+}
+
+func TestApplyCPUCeiling(t *testing.T) {
+	tests := []struct {
+		name        string
+		status      string
+		current     int
+		cpu         *CPUAnalysis
+		wantStatus  string
+		wantRec     int
+		wantBinding string
+		wantMessage string
+	}{
+		{
+			name:        "memory binds",
+			status:      "OK",
+			current:     50,
+			cpu:         &CPUAnalysis{CPUs: 8, TargetWorkers: 200, LimitWorkers: 222},
+			wantStatus:  "OK",
+			wantRec:     100,
+			wantBinding: BindingMemory,
+		},
+		{
+			name:        "CPU binds and current exceeds it",
+			status:      "OK",
+			current:     80,
+			cpu:         &CPUAnalysis{CPUs: 2, TargetWorkers: 36, LimitWorkers: 40},
+			wantStatus:  "WARNING",
+			wantRec:     36,
+			wantBinding: BindingCPU,
+			wantMessage: "MaxRequestWorkers 80 is more than 2 CPUs can serve (about 36 busy workers)",
+		},
+		{
+			name:        "CPU binds, current within it",
+			status:      "OK",
+			current:     30,
+			cpu:         &CPUAnalysis{CPUs: 2, TargetWorkers: 36, LimitWorkers: 40},
+			wantStatus:  "OK",
+			wantRec:     36,
+			wantBinding: BindingCPU,
+		},
+		{
+			name:        "unknown CPU ceiling",
+			status:      "CRITICAL",
+			current:     300,
+			cpu:         &CPUAnalysis{CPUs: 2},
+			wantStatus:  "CRITICAL",
+			wantRec:     100,
+			wantBinding: BindingMemory,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &Recommendations{Status: tt.status, CurrentMaxClients: tt.current, RecommendedMaxClients: 100, MinRecommended: 100, MaxRecommended: 111}
+			ApplyCPUCeiling(rec, tt.cpu)
+			Evaluate(rec, Facts(nil, nil, nil, rec, nil))
+			if rec.Status != tt.wantStatus || rec.RecommendedMaxClients != tt.wantRec || rec.Binding != tt.wantBinding {
+				t.Errorf("got %s, %d, binding %s; want %s, %d, binding %s",
+					rec.Status, rec.RecommendedMaxClients, rec.Binding, tt.wantStatus, tt.wantRec, tt.wantBinding)
+			}
+			if got := titles(rec.Findings); !strings.Contains(got, tt.wantMessage) {
+				t.Errorf("findings = %q, want one containing %q", got, tt.wantMessage)
+			}
+			if tt.cpu.MemoryWorkers != 100 {
+				t.Errorf("MemoryWorkers = %d, want the memory recommendation", tt.cpu.MemoryWorkers)
+			}
+		})
+	}
+}
@@ -0,0 +1,88 @@
+package analysis
+
+import (
+	"math"
+	"time"
+
+	"apache2buddy-go/internal/debug"
+	"apache2buddy-go/internal/status"
+)
+
+// DemandHeadroomPercent is added on top of the concurrency traffic needs so
+// that bursts shorter than the measured peak do not queue
+const DemandHeadroomPercent = 50
+
+// RequestPeak is the highest request rate seen over some window
+type RequestPeak struct {
+	PerSec float64
+	At     time.Time // Start of the busiest interval
+	Source string
+}
+
+// DemandAnalysis estimates the workers traffic needs with Little's law:
+// concurrent requests = arrival rate × mean service time
+type DemandAnalysis struct {
+	RatePerSec float64
+	RateSource string
+	PeakAt     time.Time // Zero when the rate is not a peak
+
+	DurationMS  float64 // Mean time to serve a request, from mod_status
+	Concurrency float64 // RatePerSec × DurationMS
+
+	Workers int    // Concurrency plus DemandHeadroomPercent: the demand floor
+	Ceiling int    // Recommended MaxRequestWorkers: the memory or CPU ceiling
+	Binding string // Binding* ceiling Ceiling comes from
+}
+
+// Exceeded reports whether traffic needs more workers than the ceiling allows
+func (d *DemandAnalysis) Exceeded() bool {
+	return d.Workers > d.Ceiling
+}
+
+// Remedy says what closes the gap when demand exceeds the ceiling
+func (d *DemandAnalysis) Remedy() string {
+	if d.Binding == BindingCPU {
+		return "add CPUs or scale out"
+	}
+	return "add RAM or scale out"
+}
+
+// AnalyzeDemand works out how many workers the traffic needs. The rate is
+// the peak when one is known, otherwise mod_status's average since the restart.
+// The service time is mod_status's DurationPerReq, so without ExtendedStatus
+// it returns nil.
+func AnalyzeDemand(statusInfo *status.ApacheStatus, peak *RequestPeak) *DemandAnalysis {
+	if statusInfo == nil || statusInfo.AvgRequestTime <= 0 {
+		debug.Printf("No request duration from mod_status, skipping demand estimate")
+		return nil
+	}
+	demand := &DemandAnalysis{
+		RatePerSec: statusInfo.RequestsPerSec,
+		RateSource: "mod_status average since restart",
+		DurationMS: statusInfo.AvgRequestTime,
+	}
+	if peak != nil && peak.PerSec > 0 {
+		demand.RatePerSec = peak.PerSec
+		demand.RateSource = peak.Source
+		demand.PeakAt = peak.At
+	}
+	if demand.RatePerSec <= 0 {
+		return nil
+	}
+	demand.Concurrency = demand.RatePerSec * demand.DurationMS / 1000
+	demand.Workers = int(math.Ceil(demand.Concurrency * (1 + DemandHeadroomPercent/100.0)))
+	return demand
+}
+
+// AssessDemand attaches demand to rec with the ceiling it is compared to
+func AssessDemand(rec *Recommendations, demand *DemandAnalysis) {
+	rec.Demand = demand
+	if demand == nil {
+		return
+	}
+	demand.Ceiling = rec.RecommendedMaxClients
+	demand.Binding = rec.Binding
+	if demand.Binding == "" {
+		demand.Binding = BindingMemory
+	}
+}
@@ -0,0 +1,128 @@
+package analysis
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"apache2buddy-go/internal/status"
+)
+
+func TestAnalyzeDemand(t *testing.T) {
+	peak := &RequestPeak{PerSec: 50, At: time.Date(2026, 3, 2, 14, 5, 0, 0, time.UTC), Source: "access log"}
+
+	tests := []struct {
+		name            string
+		status          *status.ApacheStatus
+		peak            *RequestPeak
+		wantNil         bool
+		wantRate        float64
+		wantSource      string
+		wantConcurrency float64
+		wantWorkers     int
+	}{
+		{name: "no mod_status", peak: peak, wantNil: true},
+		{name: "no request duration", status: &status.ApacheStatus{RequestsPerSec: 10}, peak: peak, wantNil: true},
+		{name: "no traffic", status: &status.ApacheStatus{AvgRequestTime: 200}, wantNil: true},
+		{
+			name:            "mod_status average",
+			status:          &status.ApacheStatus{RequestsPerSec: 10, AvgRequestTime: 200},
+			wantRate:        10,
+			wantSource:      "mod_status average since restart",
+			wantConcurrency: 2,
+			wantWorkers:     3,
+		},
+		{
+			name:            "peak preferred",
+			status:          &status.ApacheStatus{RequestsPerSec: 10, AvgRequestTime: 250},
+			peak:            peak,
+			wantRate:        50,
+			wantSource:      "access log",
+			wantConcurrency: 12.5,
+			wantWorkers:     19,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := AnalyzeDemand(tt.status, tt.peak)
+			if tt.wantNil {
+				if got != nil {
+					t.Fatalf("AnalyzeDemand() = %+v, want nil", got)
+				}
+				return
+			}
+			if got == nil {
+				t.Fatal("AnalyzeDemand() = nil")
+			}
+			if got.RatePerSec != tt.wantRate || got.RateSource != tt.wantSource {
+				t.Errorf("rate = %g (%s), want %g (%s)", got.RatePerSec, got.RateSource, tt.wantRate, tt.wantSource)
+			}
+			if got.Concurrency != tt.wantConcurrency {
+				t.Errorf("Concurrency = %g, want %g", got.Concurrency, tt.wantConcurrency)
+			}
+			if got.Workers != tt.wantWorkers {
+				t.Errorf("Workers = %d, want %d", got.Workers, tt.wantWorkers)
+			}
+		})
+	}
+}
+
+func TestAssessDemand(t *testing.T) {
+	tests := []struct {
+		name         string
+		status       string
+		binding      string
+		demand       int
+		wantStatus   string
+		wantIDs      []string
+		wantTitle    string
+		wantRemedial string
+	}{
+		{name: "within the ceiling", status: "OK", demand: 40, wantStatus: "OK"},
+		{
+			name: "above the memory ceiling", status: "OK", demand: 80, wantStatus: "WARNING", wantIDs: []string{"demand.exceeds-ceiling"},
+			wantTitle:    "Peak demand needs about 80 workers but the memory ceiling is 50",
+			wantRemedial: "Either add RAM or scale out.",
+		},
+		{
+			name: "above the CPU ceiling", status: "OK", binding: BindingCPU, demand: 80, wantStatus: "WARNING", wantIDs: []string{"demand.exceeds-ceiling"},
+			wantTitle:    "Peak demand needs about 80 workers but the CPU ceiling is 50",
+			wantRemedial: "Either add CPUs or scale out.",
+		},
+		{name: "already critical", status: "CRITICAL", demand: 80, wantStatus: "CRITICAL", wantIDs: []string{"memory.over-budget", "demand.exceeds-ceiling"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := recWithStatus(tt.status)
+			rec.RecommendedMaxClients = 50
+			rec.Binding = tt.binding
+			demand := &DemandAnalysis{Workers: tt.demand}
+			AssessDemand(rec, demand)
+			if rec.Demand != demand || demand.Ceiling != 50 {
+				t.Errorf("demand not attached with its ceiling: %+v", demand)
+			}
+
+			Evaluate(rec, Facts(nil, nil, nil, rec, nil))
+			if rec.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", rec.Status, tt.wantStatus)
+			}
+			if got := ids(rec.Findings); !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("findings = %v, want %v", got, tt.wantIDs)
+			}
+			for _, finding := range rec.Findings {
+				if finding.ID != "demand.exceeds-ceiling" || tt.wantTitle == "" {
+					continue
+				}
+				if finding.Title != tt.wantTitle || finding.Remediation != tt.wantRemedial {
+					t.Errorf("finding = %q / %q, want %q / %q", finding.Title, finding.Remediation, tt.wantTitle, tt.wantRemedial)
+				}
+			}
+		})
+	}
+
+	rec := &Recommendations{Status: "OK"}
+	AssessDemand(rec, nil)
+	if rec.Demand != nil || rec.Status != "OK" {
+		t.Error("nil demand should leave the recommendation alone")
+	}
+}
@@ -0,0 +1,140 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/status"
+)
+
+// HistogramBuckets is how many equal-width buckets worker sizes are counted in
+const HistogramBuckets = 8
+
+// Outliers are workers above the upper Tukey fence, Q3 + OutlierIQRFactor ×
+// IQR, and at least OutlierMedianRatio × the median: with near-identical
+// workers the IQR is close to zero and would flag tiny differences.
+const (
+	OutlierIQRFactor   = 1.5
+	OutlierMedianRatio = 1.25
+	outlierMinWorkers  = 5 // Fewer workers have no meaningful quartiles
+)
+
+// HistogramBucket counts the workers with a size in [FromMB, ToMB); the last
+// bucket includes ToMB
+type HistogramBucket struct {
+	FromMB float64
+	ToMB   float64
+	Count  int
+}
+
+// Outlier is a worker much larger than the rest. Request and VHost are what
+// mod_status's ExtendedStatus table shows the worker serving, empty without it.
+type Outlier struct {
+	PID      int
+	MemoryMB float64
+	Request  string
+	VHost    string
+}
+
+// percentile returns the p-th percentile (nearest rank) of sorted sizes
+func percentile(sizes []float64, p int) float64 {
+	return sizes[(len(sizes)*p+99)/100-1]
+}
+
+// median returns the middle of sorted sizes, the mean of the middle two for
+// an even count
+func median(sizes []float64) float64 {
+	n := len(sizes)
+	if n%2 == 1 {
+		return sizes[n/2]
+	}
+	return (sizes[n/2-1] + sizes[n/2]) / 2
+}
+
+// addDistribution fills the percentiles, spread, histogram and outliers of
+// the measured processes into stats
+func (stats *MemoryStats) addDistribution(processes []process.ProcessInfo) {
+	sizes := make([]float64, len(processes))
+	for i, proc := range processes {
+		sizes[i] = proc.MemoryMB
+	}
+	sort.Float64s(sizes)
+
+	stats.MedianMB = median(sizes)
+	stats.P90MB = percentile(sizes, 90)
+	stats.P95MB = percentile(sizes, 95)
+	stats.P99MB = percentile(sizes, 99)
+
+	var squares float64
+	for _, size := range sizes {
+		squares += (size - stats.AverageMB) * (size - stats.AverageMB)
+	}
+	stats.StdDevMB = math.Sqrt(squares / float64(len(sizes)))
+
+	stats.Histogram = histogram(sizes)
+
+	if len(sizes) < outlierMinWorkers {
+		return
+	}
+	q1, q3 := percentile(sizes, 25), percentile(sizes, 75)
+	fence := q3 + OutlierIQRFactor*(q3-q1)
+	if floor := stats.MedianMB * OutlierMedianRatio; fence < floor {
+		fence = floor
+	}
+	for _, proc := range processes {
+		if proc.MemoryMB > fence {
+			stats.Outliers = append(stats.Outliers, Outlier{PID: proc.PID, MemoryMB: proc.MemoryMB})
+		}
+	}
+	sort.Slice(stats.Outliers, func(i, j int) bool { return stats.Outliers[i].MemoryMB > stats.Outliers[j].MemoryMB })
+}
+
+// histogram counts sorted sizes in HistogramBuckets equal-width buckets from
+// the smallest to the largest; equal sizes make a single bucket
+func histogram(sizes []float64) []HistogramBucket {
+	smallest, largest := sizes[0], sizes[len(sizes)-1]
+	if largest == smallest {
+		return []HistogramBucket{{FromMB: smallest, ToMB: largest, Count: len(sizes)}}
+	}
+	width := (largest - smallest) / HistogramBuckets
+	buckets := make([]HistogramBucket, HistogramBuckets)
+	for i := range buckets {
+		buckets[i].FromMB = smallest + float64(i)*width
+		buckets[i].ToMB = smallest + float64(i+1)*width
+	}
+	buckets[HistogramBuckets-1].ToMB = largest
+	for _, size := range sizes {
+		i := int((size - smallest) / width)
+		if i >= HistogramBuckets {
+			i = HistogramBuckets - 1
+		}
+		buckets[i].Count++
+	}
+	return buckets
+}
+
+// DescribeOutliers adds what each outlier is serving from the ExtendedStatus
+// worker table. Threaded MPMs list a row per thread; a busy thread is
+// preferred over the last request of an idle one.
+func (stats *MemoryStats) DescribeOutliers(statusInfo *status.ApacheStatus) {
+	if statusInfo == nil {
+		return
+	}
+	for i := range stats.Outliers {
+		outlier := &stats.Outliers[i]
+		for _, slot := range statusInfo.Workers {
+			if slot.PID != outlier.PID || slot.Request == "" {
+				continue
+			}
+			busy := slot.Mode != "_" && slot.Mode != "."
+			if outlier.Request == "" || busy {
+				outlier.Request = slot.Request
+				outlier.VHost = slot.VHost
+			}
+			if busy {
+				break
+			}
+		}
+	}
+}
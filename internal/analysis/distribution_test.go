@@ -0,0 +1,101 @@
+package analysis
+
+import (
+	"math"
+	"testing"
+
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/status"
+)
+
+func workersOf(sizes ...float64) []process.ProcessInfo {
+	workers := make([]process.ProcessInfo, len(sizes))
+	for i, size := range sizes {
+		workers[i] = process.ProcessInfo{PID: 100 + i, MemoryMB: size, Method: process.MethodSmaps}
+	}
+	return workers
+}
+
+func TestCalculateMemoryStats_Distribution(t *testing.T) {
+	stats := CalculateMemoryStats(workersOf(20, 22, 24, 26, 28, 30, 32, 34, 36, 38))
+
+	if stats.MedianMB != 29 || stats.P90MB != 36 || stats.P95MB != 38 || stats.P99MB != 38 {
+		t.Errorf("median/p90/p95/p99 = %g/%g/%g/%g, want 29/36/38/38", stats.MedianMB, stats.P90MB, stats.P95MB, stats.P99MB)
+	}
+	if want := math.Sqrt(33); math.Abs(stats.StdDevMB-want) > 1e-9 {
+		t.Errorf("StdDevMB = %g, want %g", stats.StdDevMB, want)
+	}
+	if stats.Outliers != nil {
+		t.Errorf("Outliers = %+v, want none for an even spread", stats.Outliers)
+	}
+
+	if len(stats.Histogram) != HistogramBuckets {
+		t.Fatalf("Histogram has %d buckets, want %d", len(stats.Histogram), HistogramBuckets)
+	}
+	total := 0
+	for _, bucket := range stats.Histogram {
+		total += bucket.Count
+	}
+	first, last := stats.Histogram[0], stats.Histogram[HistogramBuckets-1]
+	if total != 10 || first.FromMB != 20 || last.ToMB != 38 || last.Count != 2 {
+		t.Errorf("Histogram = %+v, want 10 workers from 20 to 38 MB with 36 and 38 in the last bucket", stats.Histogram)
+	}
+
+	same := CalculateMemoryStats(workersOf(25, 25, 25))
+	if len(same.Histogram) != 1 || same.Histogram[0].Count != 3 || same.StdDevMB != 0 {
+		t.Errorf("equal workers: Histogram = %+v, StdDevMB = %g", same.Histogram, same.StdDevMB)
+	}
+}
+
+func TestCalculateMemoryStats_Outliers(t *testing.T) {
+	tests := []struct {
+		name  string
+		sizes []float64
+		want  []int // Outlier PIDs, largest first
+	}{
+		{"upload worker", []float64{30, 31, 29, 30, 32, 31, 180}, []int{106}},
+		{"two outliers", []float64{30, 95, 31, 29, 30, 32, 31, 30, 120}, []int{108, 101}},
+		{"identical workers with a slightly larger one", []float64{30, 30, 30, 30, 30, 33}, nil},
+		{"too few workers", []float64{30, 31, 180}, nil},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			stats := CalculateMemoryStats(workersOf(tt.sizes...))
+			var got []int
+			for _, outlier := range stats.Outliers {
+				got = append(got, outlier.PID)
+			}
+			if len(got) != len(tt.want) {
+				t.Fatalf("Outliers = %v, want %v", got, tt.want)
+			}
+			for i := range got {
+				if got[i] != tt.want[i] {
+					t.Errorf("Outliers = %v, want %v", got, tt.want)
+				}
+			}
+		})
+	}
+}
+
+func TestDescribeOutliers(t *testing.T) {
+	stats := &MemoryStats{Outliers: []Outlier{{PID: 101, MemoryMB: 180}, {PID: 102, MemoryMB: 150}, {PID: 103, MemoryMB: 140}}}
+	stats.DescribeOutliers(&status.ApacheStatus{Workers: []status.WorkerSlot{
+		{PID: 101, Mode: "_", VHost: "shop.example.com", Request: "GET / HTTP/1.1"},
+		{PID: 101, Mode: "W", VHost: "shop.example.com", Request: "POST /upload HTTP/1.1"},
+		{PID: 101, Mode: "_", VHost: "shop.example.com", Request: "GET /cart HTTP/1.1"},
+		{PID: 102, Mode: "_", VHost: "blog.example.com", Request: "GET /feed HTTP/1.1"},
+	}})
+
+	want := []Outlier{
+		{PID: 101, MemoryMB: 180, Request: "POST /upload HTTP/1.1", VHost: "shop.example.com"},
+		{PID: 102, MemoryMB: 150, Request: "GET /feed HTTP/1.1", VHost: "blog.example.com"},
+		{PID: 103, MemoryMB: 140},
+	}
+	for i := range want {
+		if stats.Outliers[i] != want[i] {
+			t.Errorf("Outliers[%d] = %+v, want %+v", i, stats.Outliers[i], want[i])
+		}
+	}
+
+	stats.DescribeOutliers(nil) // Without mod_status nothing changes
+}
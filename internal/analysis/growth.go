@@ -0,0 +1,151 @@
+package analysis
+
+import (
+	"sort"
+	"time"
+
+	"apache2buddy-go/internal/process"
+)
+
+// DefaultGrowthHorizon is how far ahead growing workers are projected when
+// their lifetime is unknown, and the furthest they are ever projected
+const DefaultGrowthHorizon = 24 * time.Hour
+
+// A worker is only flagged as leaking when its growth holds over a window and
+// a number of samples; a single allocation between two samples is not a leak
+const (
+	MinLeakWindow  = 2 * time.Minute
+	minLeakSamples = 3
+)
+
+// WorkerGrowth is the memory trend of one worker over the sampling window
+type WorkerGrowth struct {
+	PID             int
+	Samples         int
+	FirstMB         float64
+	LastMB          float64
+	SlopeMBPerHour  float64 // Least-squares growth rate of RSS
+	ProjectedPeakMB float64 // Expected size at the end of the projection horizon
+	Leaking         bool    // Growth exceeds the threshold and is sustained
+}
+
+// GrowthAnalysis summarises memory growth of all sampled workers
+type GrowthAnalysis struct {
+	Window             time.Duration // Time between the first and last sample
+	Samples            int           // Most samples taken of any worker
+	ThresholdMBPerHour float64
+	Horizon            time.Duration // How far ahead leaking workers are projected
+	MeanSlopeMBPerHour float64
+	ProjectedPeakMB    float64        // Largest projected worker size
+	Workers            []WorkerGrowth // Sorted by growth rate, fastest first
+	Leaking            int            // Number of workers above the threshold
+}
+
+// AnalyzeMemoryGrowth fits a linear trend to each worker's samples and flags
+// workers growing faster than thresholdMBPerHour. Only growth sampled over at
+// least MinLeakWindow that rose in most intervals counts. Leaking workers are
+// projected horizon ahead (their expected remaining lifetime, at most
+// DefaultGrowthHorizon); the others are assumed to stay at their current
+// size. It returns nil when fewer than two samples were taken.
+func AnalyzeMemoryGrowth(processes []process.ProcessInfo, thresholdMBPerHour float64, horizon time.Duration) *GrowthAnalysis {
+	if horizon <= 0 || horizon > DefaultGrowthHorizon {
+		horizon = DefaultGrowthHorizon
+	}
+	growth := &GrowthAnalysis{
+		ThresholdMBPerHour: thresholdMBPerHour,
+		Horizon:            horizon,
+	}
+
+	var totalSlope float64
+	for _, proc := range processes {
+		if len(proc.Samples) < 2 {
+			continue
+		}
+
+		first, last := proc.Samples[0], proc.Samples[len(proc.Samples)-1]
+		if window := last.Time.Sub(first.Time); window > growth.Window {
+			growth.Window = window
+		}
+		if len(proc.Samples) > growth.Samples {
+			growth.Samples = len(proc.Samples)
+		}
+
+		worker := WorkerGrowth{
+			PID:             proc.PID,
+			Samples:         len(proc.Samples),
+			FirstMB:         first.MemoryMB,
+			LastMB:          last.MemoryMB,
+			SlopeMBPerHour:  sampleSlope(proc.Samples),
+			ProjectedPeakMB: last.MemoryMB,
+		}
+		if worker.SlopeMBPerHour > thresholdMBPerHour && sustainedGrowth(proc.Samples) {
+			worker.Leaking = true
+			worker.ProjectedPeakMB += worker.SlopeMBPerHour * horizon.Hours()
+			growth.Leaking++
+		}
+		if worker.ProjectedPeakMB > growth.ProjectedPeakMB {
+			growth.ProjectedPeakMB = worker.ProjectedPeakMB
+		}
+
+		totalSlope += worker.SlopeMBPerHour
+		growth.Workers = append(growth.Workers, worker)
+	}
+
+	if len(growth.Workers) == 0 {
+		return nil
+	}
+	growth.MeanSlopeMBPerHour = totalSlope / float64(len(growth.Workers))
+
+	sort.Slice(growth.Workers, func(i, j int) bool {
+		return growth.Workers[i].SlopeMBPerHour > growth.Workers[j].SlopeMBPerHour
+	})
+	return growth
+}
+
+// ApplyGrowth records the projected peak worker size so that recommendations
+// are sized for where workers are heading rather than where they are now
+func (stats *MemoryStats) ApplyGrowth(growth *GrowthAnalysis) {
+	if growth == nil {
+		return
+	}
+	stats.ProjectedPeakMB = growth.ProjectedPeakMB
+}
+
+// sustainedGrowth reports whether samples cover MinLeakWindow and memory rose
+// in more than half of the intervals between them
+func sustainedGrowth(samples []process.MemorySample) bool {
+	if len(samples) < minLeakSamples {
+		return false
+	}
+	if samples[len(samples)-1].Time.Sub(samples[0].Time) < MinLeakWindow {
+		return false
+	}
+	rises := 0
+	for i := 1; i < len(samples); i++ {
+		if samples[i].MemoryMB > samples[i-1].MemoryMB {
+			rises++
+		}
+	}
+	return rises*2 > len(samples)-1
+}
+
+// sampleSlope returns the least-squares slope of memory over time in MB/hour
+func sampleSlope(samples []process.MemorySample) float64 {
+	hours := make([]float64, len(samples))
+	sizes := make([]float64, len(samples))
+	for i, sample := range samples {
+		hours[i] = sample.Time.Sub(samples[0].Time).Hours()
+		sizes[i] = sample.MemoryMB
+	}
+
+	mh, ms := mean(hours), mean(sizes)
+	var cov, vh float64
+	for i := range hours {
+		cov += (hours[i] - mh) * (sizes[i] - ms)
+		vh += (hours[i] - mh) * (hours[i] - mh)
+	}
+	if vh == 0 {
+		return 0
+	}
+	return cov / vh
+}
@@ -0,0 +1,136 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/system"
+)
+
+// sampled builds a worker with one sample per minute of the given sizes
+func sampled(pid int, sizes ...float64) process.ProcessInfo {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	proc := process.ProcessInfo{PID: pid, Method: process.MethodSmaps}
+	for i, size := range sizes {
+		proc.Samples = append(proc.Samples, process.MemorySample{
+			Time:     start.Add(time.Duration(i) * time.Minute),
+			MemoryMB: size,
+		})
+		proc.MemoryMB = size
+	}
+	return proc
+}
+
+func TestAnalyzeMemoryGrowth(t *testing.T) {
+	processes := []process.ProcessInfo{
+		sampled(100, 30, 30, 30, 30),     // stable
+		sampled(200, 40, 40.5, 41, 41.5), // +30 MB/hour
+		sampled(300, 25, 25.1, 25.1, 25), // noise
+		sampled(400, 50),                 // single sample, ignored
+	}
+
+	growth := AnalyzeMemoryGrowth(processes, 10, 2*time.Hour)
+	if growth == nil {
+		t.Fatal("AnalyzeMemoryGrowth() = nil")
+	}
+
+	if len(growth.Workers) != 3 {
+		t.Fatalf("Workers = %d, want 3", len(growth.Workers))
+	}
+	if growth.Window != 3*time.Minute || growth.Samples != 4 {
+		t.Errorf("Window/Samples = %v/%d, want 3m/4", growth.Window, growth.Samples)
+	}
+	if growth.Leaking != 1 {
+		t.Errorf("Leaking = %d, want 1", growth.Leaking)
+	}
+
+	fastest := growth.Workers[0]
+	if fastest.PID != 200 || !fastest.Leaking {
+		t.Errorf("fastest worker = PID %d (leaking %t), want leaking PID 200", fastest.PID, fastest.Leaking)
+	}
+	if fastest.SlopeMBPerHour < 29.9 || fastest.SlopeMBPerHour > 30.1 {
+		t.Errorf("SlopeMBPerHour = %.2f, want 30", fastest.SlopeMBPerHour)
+	}
+	// 41.5 MB + 30 MB/hour * 2 hours
+	if growth.ProjectedPeakMB < 101.4 || growth.ProjectedPeakMB > 101.6 {
+		t.Errorf("ProjectedPeakMB = %.2f, want 101.5", growth.ProjectedPeakMB)
+	}
+	if growth.MeanSlopeMBPerHour < 9.9 || growth.MeanSlopeMBPerHour > 10.1 {
+		t.Errorf("MeanSlopeMBPerHour = %.2f, want 10", growth.MeanSlopeMBPerHour)
+	}
+}
+
+func TestAnalyzeMemoryGrowth_Unsustained(t *testing.T) {
+	// Ten seconds apart: a 2 MB step is 720 MB/hour but covers 30 seconds
+	brief := sampled(100, 40, 40, 42, 42)
+	for i := range brief.Samples {
+		brief.Samples[i].Time = brief.Samples[0].Time.Add(time.Duration(i) * 10 * time.Second)
+	}
+
+	tests := []struct {
+		name string
+		proc process.ProcessInfo
+	}{
+		{"window too short", brief},
+		{"single step", sampled(200, 40, 42, 42, 42, 42)},
+		{"two samples", sampled(300, 40, 42)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			growth := AnalyzeMemoryGrowth([]process.ProcessInfo{tt.proc}, 10, 30*24*time.Hour)
+			if growth == nil {
+				t.Fatal("AnalyzeMemoryGrowth() = nil")
+			}
+			if growth.Workers[0].SlopeMBPerHour <= 10 {
+				t.Fatalf("SlopeMBPerHour = %.1f, want above the threshold", growth.Workers[0].SlopeMBPerHour)
+			}
+			if growth.Leaking != 0 || growth.ProjectedPeakMB != 42 {
+				t.Errorf("Leaking/ProjectedPeakMB = %d/%.1f, want 0/42", growth.Leaking, growth.ProjectedPeakMB)
+			}
+		})
+	}
+}
+
+func TestAnalyzeMemoryGrowth_HorizonCapped(t *testing.T) {
+	growth := AnalyzeMemoryGrowth([]process.ProcessInfo{sampled(100, 40, 40.5, 41, 41.5)}, 10, 30*24*time.Hour)
+	if growth == nil {
+		t.Fatal("AnalyzeMemoryGrowth() = nil")
+	}
+	if growth.Horizon != DefaultGrowthHorizon {
+		t.Errorf("Horizon = %v, want %v", growth.Horizon, DefaultGrowthHorizon)
+	}
+	// 41.5 MB + 30 MB/hour * 24 hours
+	if growth.ProjectedPeakMB < 761.4 || growth.ProjectedPeakMB > 761.6 {
+		t.Errorf("ProjectedPeakMB = %.2f, want 761.5", growth.ProjectedPeakMB)
+	}
+}
+
+func TestAnalyzeMemoryGrowth_NotSampled(t *testing.T) {
+	processes := []process.ProcessInfo{{PID: 100, MemoryMB: 30, Method: process.MethodSmaps}}
+	if growth := AnalyzeMemoryGrowth(processes, 10, time.Hour); growth != nil {
+		t.Errorf("AnalyzeMemoryGrowth() = %+v, want nil", growth)
+	}
+}
+
+func TestGenerateEnhancedRecommendations_ProjectedPeak(t *testing.T) {
+	sysInfo := &system.SystemInfo{AvailableMemoryMB: 1000}
+	apacheConfig := &config.ApacheConfig{MaxRequestWorkers: 20}
+
+	memStats := &MemoryStats{ProcessCount: 4, LargestMB: 40, AverageMB: 30}
+	before := GenerateEnhancedRecommendations(sysInfo, memStats, apacheConfig, nil, 0)
+
+	memStats.ApplyGrowth(&GrowthAnalysis{ProjectedPeakMB: 100})
+	after := GenerateEnhancedRecommendations(sysInfo, memStats, apacheConfig, nil, 0)
+
+	if before.MaxRecommended != 25 {
+		t.Errorf("MaxRecommended before growth = %d, want 25", before.MaxRecommended)
+	}
+	if after.MaxRecommended != 10 {
+		t.Errorf("MaxRecommended with projected peak = %d, want 10", after.MaxRecommended)
+	}
+	if after.Status != "CRITICAL" {
+		t.Errorf("Status with projected peak = %s, want CRITICAL", after.Status)
+	}
+}
@@ -0,0 +1,68 @@
+package analysis
+
+import (
+	"time"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/system"
+)
+
+// DefaultHistoryDays is the window of past runs the observed peaks are taken from
+const DefaultHistoryDays = 14
+
+// HistoryPeak is the busiest the history log has seen an instance under its
+// current MPM within the window
+type HistoryPeak struct {
+	Days int // Window the peaks are taken from
+	Runs int // Runs recorded in the window
+
+	LargestMB float64 // Largest worker of any run
+	LargestAt time.Time
+
+	BusyWorkers int // Most busy workers mod_status reported, 0 when no run recorded them
+	BusyAt      time.Time
+
+	// Set by AssessHistory
+	SupportedWorkers int // MaxRequestWorkers the busiest workers leave room for
+	CappedFrom       int // Recommendation before it was held to SupportedWorkers, 0 when not held
+}
+
+// ApplyHistory records the largest worker of the history window, which
+// SizeHistory sizes on
+func (stats *MemoryStats) ApplyHistory(peak *HistoryPeak) {
+	if peak == nil {
+		return
+	}
+	stats.HistoryPeakMB = peak.LargestMB
+}
+
+// busyHourSizingMB returns the worker size at the busiest run: the sizing
+// figure scaled by how much larger the largest worker was then than now
+func (stats *MemoryStats) busyHourSizingMB() float64 {
+	size := stats.SizingMB()
+	if stats.Statistic == SizeHistory || stats.HistoryPeakMB <= stats.LargestMB || stats.LargestMB <= 0 {
+		return size
+	}
+	return size * stats.HistoryPeakMB / stats.LargestMB
+}
+
+// AssessHistory attaches peak to rec and keeps a run in quiet hours, when
+// workers are small, from recommending more workers than the busiest run
+// in the window leaves room for. Lowering MaxRequestWorkers is left alone.
+func AssessHistory(rec *Recommendations, peak *HistoryPeak, sysInfo *system.SystemInfo, memStats *MemoryStats, apacheConfig *config.ApacheConfig) {
+	rec.History = peak
+	if peak == nil || rec.Status == "ERROR" || rec.Policy == nil {
+		return
+	}
+	threads := ThreadsPerChild(apacheConfig)
+	peak.SupportedWorkers = rec.Policy.TargetWorkers(sysInfo.AvailableMemoryMB, memStats.busyHourSizingMB()) * threads
+	if rec.RecommendedMaxClients <= rec.CurrentMaxClients || rec.RecommendedMaxClients <= peak.SupportedWorkers {
+		return
+	}
+	peak.CappedFrom = rec.RecommendedMaxClients
+	held := peak.SupportedWorkers
+	if held < rec.CurrentMaxClients {
+		held = rec.CurrentMaxClients
+	}
+	rec.setWorkers(held)
+}
@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/system"
+)
+
+func TestApplyHistory(t *testing.T) {
+	stats := CalculateMemoryStats(workersOf(10, 20))
+	stats.ApplyHistory(nil)
+	stats.Statistic = SizeHistory
+	if stats.SizingMB() != 20 {
+		t.Errorf("SizingMB() without history = %g, want the largest worker 20", stats.SizingMB())
+	}
+
+	stats.ApplyHistory(&HistoryPeak{LargestMB: 40})
+	if stats.SizingMB() != 40 {
+		t.Errorf("SizingMB() = %g, want the history peak 40", stats.SizingMB())
+	}
+	if stats.busyHourSizingMB() != 40 {
+		t.Errorf("busyHourSizingMB() = %g, want 40", stats.busyHourSizingMB())
+	}
+
+	// Other statistics grow with the largest worker
+	stats.Statistic = SizeMedian
+	if stats.SizingMB() != 15 || stats.busyHourSizingMB() != 30 {
+		t.Errorf("median: SizingMB() = %g, busyHourSizingMB() = %g; want 15 and 30", stats.SizingMB(), stats.busyHourSizingMB())
+	}
+}
+
+// TestAssessHistory runs quietly with 20 MB workers on 3000 MB, which fit
+// 135 workers; at the 45 MB peak only 60 fit
+func TestAssessHistory(t *testing.T) {
+	tests := []struct {
+		name       string
+		current    int
+		sizing     string
+		wantRec    int
+		wantCapped int
+	}{
+		{"quiet run held at the current value", 60, SizeLargest, 60, 135},
+		{"quiet run held at the peak", 40, SizeLargest, 60, 135},
+		{"lowering is left alone", 150, SizeLargest, 135, 0},
+		{"sizing on the history", 60, SizeHistory, 60, 0},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sysInfo := &system.SystemInfo{AvailableMemoryMB: 3000}
+			apacheConfig := &config.ApacheConfig{MPMModel: "prefork", MaxRequestWorkers: tt.current}
+			memStats := CalculateMemoryStats(workersOf(20, 20, 20))
+			memStats.Statistic = tt.sizing
+			peak := &HistoryPeak{Days: 14, Runs: 30, LargestMB: 45}
+			memStats.ApplyHistory(peak)
+			policy := DefaultPolicy()
+			policy.Sizing = tt.sizing
+
+			rec := GenerateRecommendationsWithPolicy(sysInfo, memStats, apacheConfig, nil, 1, policy)
+			AssessHistory(rec, peak, sysInfo, memStats, apacheConfig)
+			if rec.RecommendedMaxClients != tt.wantRec || peak.CappedFrom != tt.wantCapped || peak.SupportedWorkers != 60 {
+				t.Errorf("recommended %d, capped from %d, supported %d; want %d, %d, 60",
+					rec.RecommendedMaxClients, peak.CappedFrom, peak.SupportedWorkers, tt.wantRec, tt.wantCapped)
+			}
+			if rec.History != peak {
+				t.Error("AssessHistory() should attach the peak")
+			}
+		})
+	}
+}
+
+func TestCheckHistory(t *testing.T) {
+	busyAt := time.Date(2026, 10, 12, 14, 5, 0, 0, time.UTC)
+	rec := &Recommendations{CurrentMaxClients: 60, RecommendedMaxClients: 60, History: &HistoryPeak{
+		Days: 14, Runs: 30, LargestMB: 45, LargestAt: busyAt, BusyWorkers: 72, BusyAt: busyAt, SupportedWorkers: 60, CappedFrom: 135,
+	}}
+	facts := Facts(&system.SystemInfo{}, &MemoryStats{}, &config.ApacheConfig{}, rec, nil)
+	got := checkHistory(facts)
+	if len(got) != 2 || got[0].ID != "memory.history-held" || got[1].ID != "demand.history-busy-peak" {
+		t.Fatalf("checkHistory() = %+v", got)
+	}
+	if got[0].Title != "MaxRequestWorkers held at 60: this run alone would allow 135" ||
+		got[0].Evidence[0] != "Largest worker 45.0 MB on 2026-10-12 14:05" {
+		t.Errorf("held finding = %+v", got[0])
+	}
+	if got[1].Title != "72 workers were busy on 2026-10-12 14:05, more than the 60 recommended" {
+		t.Errorf("busy peak finding = %+v", got[1])
+	}
+
+	rec.History = &HistoryPeak{Runs: 1, LargestMB: 20, BusyWorkers: 10}
+	if got := checkHistory(facts); len(got) != 0 {
+		t.Errorf("checkHistory() = %+v, want nothing for an unremarkable history", got)
+	}
+}
@@ -0,0 +1,176 @@
+package analysis
+
+import (
+	"math"
+	"sort"
+	"time"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/status"
+)
+
+// Thresholds for declaring older workers "bloated"
+const (
+	minLifetimeWorkers  = 4    // fewer workers give meaningless correlations
+	bloatCorrelation    = 0.5  // age/memory correlation needed
+	bloatGrowthRatio    = 1.25 // old workers must be this much larger than young ones
+	minRecycleThreshold = 500  // never suggest recycling more often than this
+)
+
+// WorkerLifetime relates worker age to memory size to decide whether workers
+// should be recycled with MaxConnectionsPerChild
+type WorkerLifetime struct {
+	Workers          int
+	MeanAge          time.Duration
+	OldestAge        time.Duration
+	MemoryBasis      string  // "PSS" or "RSS"
+	Correlation      float64 // Pearson correlation between age and memory
+	YoungAverageMB   float64 // Workers younger than the median age
+	OldAverageMB     float64 // Workers at or above the median age
+	GrowthPercent    float64 // How much larger old workers are than young ones
+	Bloated          bool    // Older workers are clearly larger
+	UsedAccessCounts bool    // Per-child access counts from ExtendedStatus were available
+
+	CurrentMaxConnectionsPerChild     int
+	RecommendedMaxConnectionsPerChild int // 0 when no change is recommended
+}
+
+// AnalyzeWorkerLifetime correlates worker age with memory. When older workers
+// are clearly bloated it recommends a MaxConnectionsPerChild value that
+// recycles them before they reach that size. It returns nil when too few
+// workers have lifetime data.
+func AnalyzeWorkerLifetime(processes []process.ProcessInfo, apacheConfig *config.ApacheConfig, statusInfo *status.ApacheStatus, now time.Time) *WorkerLifetime {
+	var workers []process.ProcessInfo
+	usePSS := true
+	for _, proc := range processes {
+		if !proc.Measured() || proc.StartTime.IsZero() {
+			continue
+		}
+		if proc.PSSMB <= 0 {
+			usePSS = false
+		}
+		workers = append(workers, proc)
+	}
+	if len(workers) < minLifetimeWorkers {
+		return nil
+	}
+
+	memoryOf := func(proc process.ProcessInfo) float64 {
+		if usePSS {
+			return proc.PSSMB
+		}
+		return proc.MemoryMB
+	}
+
+	sort.Slice(workers, func(i, j int) bool {
+		return workers[i].Age(now) < workers[j].Age(now)
+	})
+
+	lifetime := &WorkerLifetime{
+		Workers:                       len(workers),
+		OldestAge:                     workers[len(workers)-1].Age(now),
+		MemoryBasis:                   "RSS",
+		CurrentMaxConnectionsPerChild: apacheConfig.MaxConnectionsPerChild,
+	}
+	if usePSS {
+		lifetime.MemoryBasis = "PSS"
+	}
+
+	ages := make([]float64, len(workers))
+	sizes := make([]float64, len(workers))
+	var totalAge time.Duration
+	for i, proc := range workers {
+		ages[i] = proc.Age(now).Seconds()
+		sizes[i] = memoryOf(proc)
+		totalAge += proc.Age(now)
+	}
+	lifetime.MeanAge = totalAge / time.Duration(len(workers))
+	lifetime.Correlation = pearson(ages, sizes)
+
+	// Split at the median age into a young and an old half
+	half := len(workers) / 2
+	lifetime.YoungAverageMB = mean(sizes[:half])
+	lifetime.OldAverageMB = mean(sizes[half:])
+	if lifetime.YoungAverageMB > 0 {
+		lifetime.GrowthPercent = (lifetime.OldAverageMB/lifetime.YoungAverageMB - 1) * 100
+	}
+
+	lifetime.Bloated = lifetime.Correlation >= bloatCorrelation &&
+		lifetime.OldAverageMB >= lifetime.YoungAverageMB*bloatGrowthRatio
+	if !lifetime.Bloated {
+		return lifetime
+	}
+
+	// Recycle before the point where the first worker became bloated
+	bloatLimit := lifetime.YoungAverageMB * bloatGrowthRatio
+	var threshold float64
+	if statusInfo != nil {
+		accesses := statusInfo.ChildAccesses()
+		for _, proc := range workers {
+			count, ok := accesses[proc.PID]
+			if !ok || memoryOf(proc) < bloatLimit {
+				continue
+			}
+			lifetime.UsedAccessCounts = true
+			if threshold == 0 || float64(count) < threshold {
+				threshold = float64(count)
+			}
+		}
+
+		// Without per-child counts, estimate from the youngest bloated worker's
+		// age and the average request rate per worker
+		if !lifetime.UsedAccessCounts && statusInfo.RequestsPerSec > 0 {
+			perWorkerRate := statusInfo.RequestsPerSec / float64(len(workers))
+			for _, proc := range workers {
+				if memoryOf(proc) >= bloatLimit {
+					threshold = proc.Age(now).Seconds() * perWorkerRate
+					break
+				}
+			}
+		}
+	}
+
+	if threshold > 0 {
+		recommended := int(threshold/100) * 100
+		if recommended < minRecycleThreshold {
+			recommended = minRecycleThreshold
+		}
+		current := lifetime.CurrentMaxConnectionsPerChild
+		if current == 0 || current > recommended {
+			lifetime.RecommendedMaxConnectionsPerChild = recommended
+		}
+	}
+
+	return lifetime
+}
+
+func mean(values []float64) float64 {
+	if len(values) == 0 {
+		return 0
+	}
+	var sum float64
+	for _, v := range values {
+		sum += v
+	}
+	return sum / float64(len(values))
+}
+
+// pearson returns the correlation coefficient of x and y, 0 if undefined
+func pearson(x, y []float64) float64 {
+	if len(x) != len(y) || len(x) < 2 {
+		return 0
+	}
+	mx, my := mean(x), mean(y)
+	var cov, vx, vy float64
+	for i := range x {
+		dx, dy := x[i]-mx, y[i]-my
+		cov += dx * dy
+		vx += dx * dx
+		vy += dy * dy
+	}
+	if vx == 0 || vy == 0 {
+		return 0
+	}
+	return cov / math.Sqrt(vx*vy)
+}
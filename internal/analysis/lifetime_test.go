@@ -0,0 +1,105 @@
+package analysis
+
+import (
+	"testing"
+	"time"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/status"
+)
+
+// agedWorkers builds measured workers with the given ages (minutes) and PSS sizes
+func agedWorkers(now time.Time, ages []int, sizes []float64) []process.ProcessInfo {
+	var workers []process.ProcessInfo
+	for i := range ages {
+		workers = append(workers, process.ProcessInfo{
+			PID:       5000 + i,
+			User:      "www-data",
+			MemoryMB:  sizes[i] + 10,
+			PSSMB:     sizes[i],
+			Method:    process.MethodSmaps,
+			StartTime: now.Add(-time.Duration(ages[i]) * time.Minute),
+		})
+	}
+	return workers
+}
+
+func TestAnalyzeWorkerLifetime(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+
+	t.Run("too few workers", func(t *testing.T) {
+		workers := agedWorkers(now, []int{5, 10}, []float64{20, 22})
+		if got := AnalyzeWorkerLifetime(workers, &config.ApacheConfig{}, nil, now); got != nil {
+			t.Errorf("AnalyzeWorkerLifetime() = %+v, want nil", got)
+		}
+	})
+
+	t.Run("stable workers", func(t *testing.T) {
+		workers := agedWorkers(now, []int{5, 60, 120, 600}, []float64{21, 20, 22, 21})
+		got := AnalyzeWorkerLifetime(workers, &config.ApacheConfig{}, nil, now)
+		if got == nil {
+			t.Fatal("AnalyzeWorkerLifetime() = nil")
+		}
+		if got.Bloated {
+			t.Errorf("Bloated = true for stable workers (correlation %.2f)", got.Correlation)
+		}
+		if got.MemoryBasis != "PSS" {
+			t.Errorf("MemoryBasis = %s, want PSS", got.MemoryBasis)
+		}
+		if got.OldestAge != 600*time.Minute {
+			t.Errorf("OldestAge = %v, want 10h", got.OldestAge)
+		}
+	})
+
+	t.Run("bloated workers with access counts", func(t *testing.T) {
+		workers := agedWorkers(now, []int{5, 30, 240, 600}, []float64{20, 21, 35, 48})
+		statusInfo := &status.ApacheStatus{Workers: []status.WorkerSlot{
+			{PID: 5000, ChildAccesses: 150},
+			{PID: 5001, ChildAccesses: 900},
+			{PID: 5002, ChildAccesses: 4321},
+			{PID: 5003, ChildAccesses: 12000},
+		}}
+
+		got := AnalyzeWorkerLifetime(workers, &config.ApacheConfig{}, statusInfo, now)
+		if !got.Bloated {
+			t.Fatalf("Bloated = false (correlation %.2f, young %.1f, old %.1f)", got.Correlation, got.YoungAverageMB, got.OldAverageMB)
+		}
+		if !got.UsedAccessCounts {
+			t.Error("UsedAccessCounts = false, want true")
+		}
+		if got.RecommendedMaxConnectionsPerChild != 4300 {
+			t.Errorf("RecommendedMaxConnectionsPerChild = %d, want 4300", got.RecommendedMaxConnectionsPerChild)
+		}
+	})
+
+	t.Run("bloated workers estimated from request rate", func(t *testing.T) {
+		workers := agedWorkers(now, []int{5, 30, 240, 600}, []float64{20, 21, 35, 48})
+		statusInfo := &status.ApacheStatus{RequestsPerSec: 0.4}
+
+		got := AnalyzeWorkerLifetime(workers, &config.ApacheConfig{}, statusInfo, now)
+		// youngest bloated worker: 240 min * 60 s * 0.1 req/s per worker = 1440
+		if got.RecommendedMaxConnectionsPerChild != 1400 {
+			t.Errorf("RecommendedMaxConnectionsPerChild = %d, want 1400", got.RecommendedMaxConnectionsPerChild)
+		}
+	})
+
+	t.Run("existing lower limit is kept", func(t *testing.T) {
+		workers := agedWorkers(now, []int{5, 30, 240, 600}, []float64{20, 21, 35, 48})
+		statusInfo := &status.ApacheStatus{RequestsPerSec: 0.4}
+
+		got := AnalyzeWorkerLifetime(workers, &config.ApacheConfig{MaxConnectionsPerChild: 1000}, statusInfo, now)
+		if got.RecommendedMaxConnectionsPerChild != 0 {
+			t.Errorf("RecommendedMaxConnectionsPerChild = %d, want 0", got.RecommendedMaxConnectionsPerChild)
+		}
+	})
+}
+
+func TestPearson(t *testing.T) {
+	if got := pearson([]float64{1, 2, 3}, []float64{2, 4, 6}); got < 0.999 {
+		t.Errorf("pearson() of perfectly correlated data = %f, want 1", got)
+	}
+	if got := pearson([]float64{1, 2, 3}, []float64{5, 5, 5}); got != 0 {
+		t.Errorf("pearson() with constant data = %f, want 0", got)
+	}
+}
@@ -0,0 +1,33 @@
+package analysis
+
+import (
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/system"
+)
+
+// LimitNeeds works out what the current configuration asks of the kernel and
+// the service manager once all MaxRequestWorkers are busy
+func LimitNeeds(apacheConfig *config.ApacheConfig, memStats *MemoryStats) system.LimitNeeds {
+	workers := apacheConfig.GetCurrentMaxClients()
+	threads := ThreadsPerChild(apacheConfig)
+	children := ProcessesFor(workers, apacheConfig)
+	needs := system.LimitNeeds{
+		Backlog:               apacheConfig.Backlog(),
+		Processes:             children + 1,
+		Tasks:                 children + 1,
+		Connections:           workers,
+		ConnectionsPerProcess: threads,
+		MemoryMB:              int(WorkerMemoryMB(workers, apacheConfig, memStats)),
+	}
+	if IsThreaded(apacheConfig.MPMModel) {
+		// Each child also runs a listener and a main thread
+		needs.Tasks = children*(threads+2) + 1
+	}
+	return needs
+}
+
+// AssessLimits attaches the limit checks to rec; the limits rule reports
+// those the configuration would run into
+func AssessLimits(rec *Recommendations, checks []system.LimitCheck) {
+	rec.Limits = checks
+}
@@ -0,0 +1,80 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/system"
+)
+
+func TestLimitNeeds(t *testing.T) {
+	memStats := &MemoryStats{LargestMB: 20}
+
+	tests := []struct {
+		name   string
+		config *config.ApacheConfig
+		want   system.LimitNeeds
+	}{
+		{
+			name:   "prefork",
+			config: &config.ApacheConfig{MPMModel: "prefork", MaxRequestWorkers: 150},
+			want:   system.LimitNeeds{Backlog: 511, Processes: 151, Tasks: 151, Connections: 150, ConnectionsPerProcess: 1, MemoryMB: 3000},
+		},
+		{
+			name:   "event with default ThreadsPerChild",
+			config: &config.ApacheConfig{MPMModel: "event", MaxRequestWorkers: 400, ListenBacklog: 1024},
+			want:   system.LimitNeeds{Backlog: 1024, Processes: 17, Tasks: 433, Connections: 400, ConnectionsPerProcess: 25, MemoryMB: 320},
+		},
+		{
+			name:   "worker with partial last child",
+			config: &config.ApacheConfig{MPMModel: "worker", MaxRequestWorkers: 100, ThreadsPerChild: 64},
+			want:   system.LimitNeeds{Backlog: 511, Processes: 3, Tasks: 133, Connections: 100, ConnectionsPerProcess: 64, MemoryMB: 40},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := LimitNeeds(tt.config, memStats); got != tt.want {
+				t.Errorf("LimitNeeds() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAssessLimits(t *testing.T) {
+	low := system.LimitCheck{ID: "somaxconn", Limit: "net.core.somaxconn", Value: "128", Status: "WARNING", Message: "ListenBacklog 511 is silently cut to 128"}
+	fine := system.LimitCheck{ID: "file-max", Limit: "fs.file-max", Value: "1000000", Status: "OK"}
+
+	tests := []struct {
+		name        string
+		status      string
+		checks      []system.LimitCheck
+		wantStatus  string
+		wantIDs     []string
+		wantMessage string
+	}{
+		{"all limits fine", "OK", []system.LimitCheck{fine}, "OK", nil, ""},
+		{"low limit raises OK", "OK", []system.LimitCheck{fine, low}, "WARNING", []string{"limits.somaxconn"}, "net.core.somaxconn (128) is too low"},
+		{"CRITICAL kept", "CRITICAL", []system.LimitCheck{low}, "CRITICAL", []string{"memory.over-budget", "limits.somaxconn"}, ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := recWithStatus(tt.status)
+			AssessLimits(rec, tt.checks)
+			if len(rec.Limits) != len(tt.checks) {
+				t.Errorf("Limits = %d checks, want %d", len(rec.Limits), len(tt.checks))
+			}
+			Evaluate(rec, Facts(nil, nil, nil, rec, nil))
+			if rec.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", rec.Status, tt.wantStatus)
+			}
+			if got := ids(rec.Findings); !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("findings = %v, want %v", got, tt.wantIDs)
+			}
+			if got := titles(rec.Findings); !strings.Contains(got, tt.wantMessage) {
+				t.Errorf("findings = %q, want one containing %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
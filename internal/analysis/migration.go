@@ -0,0 +1,210 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"strings"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/status"
+	"apache2buddy-go/internal/system"
+)
+
+// eventThreadMB is what one thread adds to an event child that no longer
+// embeds PHP: its stack in use and per-request pools
+const eventThreadMB = 1.0
+
+// ModuleCheck is a loaded module that blocks or complicates the switch
+// from prefork to a threaded MPM
+type ModuleCheck struct {
+	Module   string
+	Blocking bool   // Apache cannot run event while it is loaded
+	Reason   string // Why it matters under a threaded MPM
+	Action   string // What to do before switching
+}
+
+// threadUnsafeModules are the modules, other than mod_php, that need
+// attention before leaving prefork
+var threadUnsafeModules = []ModuleCheck{
+	{Module: "mpm_itk_module", Blocking: true, Reason: "mpm-itk is a prefork variant that runs each virtual host as its own user",
+		Action: "give each site a PHP-FPM pool running as its user instead"},
+	{Module: "ruid2_module", Blocking: true, Reason: "mod_ruid2 changes user per request and only works under prefork",
+		Action: "give each site a PHP-FPM pool running as its user instead"},
+	{Module: "python_module", Blocking: true, Reason: "mod_python is unmaintained and not thread-safe",
+		Action: "move the applications to mod_wsgi in daemon mode"},
+	{Module: "perl_module", Reason: "mod_perl is only thread-safe with a Perl built with ithreads",
+		Action: "check that perl -V reports useithreads"},
+	{Module: "cgi_module", Reason: "mod_cgi forks from the multithreaded children",
+		Action: "load cgid_module instead"},
+}
+
+// isPHPModule reports whether module is mod_php: php_module, php7_module...
+func isPHPModule(module string) bool {
+	return strings.HasPrefix(module, "php") && strings.HasSuffix(module, "_module")
+}
+
+// MigrationAdvice estimates what moving a prefork + mod_php instance to
+// event + PHP-FPM saves, and lists what has to happen first
+type MigrationAdvice struct {
+	Workers         int     // Concurrent requests compared at: the recommended MaxRequestWorkers
+	PreforkMB       float64 // Memory per concurrent request today: one prefork worker
+	ThreadsPerChild int
+	EventChildMB    float64 // One event child without PHP: the shared part plus its threads
+	PHPMB           float64 // One PHP-FPM child
+	PHPSource       string  // Where PHPMB comes from
+	Estimated       bool    // The memory figures could be worked out
+
+	TodayMB         int // Memory Workers concurrent requests need today
+	ExpectedMB      int // The same under event + PHP-FPM, every request running PHP
+	ExpectedWorkers int // Concurrent requests TodayMB serves after the switch
+
+	Modules       []ModuleCheck // Loaded modules that need attention, blocking first
+	Prerequisites []string      // Steps in order
+	MPMBlock      []MPMDirective
+	Notes         []string
+}
+
+// PerRequestMB returns the expected memory per concurrent request under
+// event + PHP-FPM
+func (a *MigrationAdvice) PerRequestMB() float64 {
+	return a.EventChildMB/float64(a.ThreadsPerChild) + a.PHPMB
+}
+
+// SavingsPercent returns how much less memory a concurrent request needs
+// after the switch; negative when it needs more
+func (a *MigrationAdvice) SavingsPercent() float64 {
+	if !a.Estimated || a.PreforkMB <= 0 {
+		return 0
+	}
+	return (1 - a.PerRequestMB()/a.PreforkMB) * 100
+}
+
+// Blockers returns the modules Apache cannot run event with
+func (a *MigrationAdvice) Blockers() []string {
+	var modules []string
+	for _, check := range a.Modules {
+		if check.Blocking {
+			modules = append(modules, check.Module)
+		}
+	}
+	return modules
+}
+
+// AdviseMigration sets rec.Migration for a prefork instance with mod_php
+// loaded. Today a prefork worker holds Apache and PHP; after the switch an
+// event child holds Apache's shared memory once for all its threads, and
+// each request running PHP adds a PHP-FPM child, measured from the running
+// pools in sysInfo or, without them, taken as the private memory (PSS) of
+// a prefork worker. rec.RecommendedMaxClients must be set.
+func AdviseMigration(rec *Recommendations, sysInfo *system.SystemInfo, memStats *MemoryStats, apacheConfig *config.ApacheConfig, statusInfo *status.ApacheStatus) {
+	if IsThreaded(apacheConfig.MPMModel) {
+		return
+	}
+	phpModule := ""
+	for _, module := range apacheConfig.Modules {
+		if isPHPModule(module) {
+			phpModule = module
+			break
+		}
+	}
+	if phpModule == "" {
+		return
+	}
+
+	advice := &MigrationAdvice{
+		Workers:         rec.RecommendedMaxClients,
+		PreforkMB:       memStats.SizingMB(),
+		ThreadsPerChild: defaultThreadsPerChild,
+	}
+	if advice.Workers <= 0 {
+		advice.Workers = apacheConfig.GetCurrentMaxClients()
+	}
+	advice.Modules = append(advice.Modules, ModuleCheck{Module: phpModule, Blocking: true,
+		Reason: "mod_php is built without thread safety for prefork", Action: "run PHP in PHP-FPM through mod_proxy_fcgi"})
+	for _, check := range threadUnsafeModules {
+		if apacheConfig.HasModule(check.Module) {
+			advice.Modules = append(advice.Modules, check)
+		}
+	}
+	sort.SliceStable(advice.Modules, func(i, j int) bool {
+		return advice.Modules[i].Blocking && !advice.Modules[j].Blocking
+	})
+
+	var pool *system.PHPFPMPool
+	for i := range sysInfo.PHPFPMPools {
+		if candidate := &sysInfo.PHPFPMPools[i]; candidate.LargestMB > 0 && (pool == nil || candidate.LargestMB > pool.LargestMB) {
+			pool = candidate
+		}
+	}
+	privateFraction := 0.0
+	if memStats.LargestPSSMB > 0 && memStats.LargestMB > 0 {
+		privateFraction = math.Min(memStats.LargestPSSMB/memStats.LargestMB, 1)
+	}
+	switch {
+	case advice.PreforkMB <= 0:
+		advice.Notes = append(advice.Notes, "No measured workers to estimate the savings from.")
+	case privateFraction == 0:
+		advice.Notes = append(advice.Notes, "Estimating the savings needs the PSS of the prefork workers (run as root).")
+	default:
+		private := advice.PreforkMB * privateFraction
+		advice.EventChildMB = advice.PreforkMB - private + eventThreadMB*float64(advice.ThreadsPerChild)
+		advice.PHPMB = private
+		advice.PHPSource = "private memory (PSS) of a prefork worker"
+		if pool != nil {
+			advice.PHPMB = pool.LargestMB
+			advice.PHPSource = fmt.Sprintf("largest child of PHP-FPM pool %s", pool.Name)
+		}
+		advice.Estimated = true
+
+		processes := (advice.Workers + advice.ThreadsPerChild - 1) / advice.ThreadsPerChild
+		advice.TodayMB = int(math.Ceil(float64(advice.Workers) * advice.PreforkMB))
+		advice.ExpectedMB = int(math.Ceil(float64(processes)*advice.EventChildMB + float64(advice.Workers)*advice.PHPMB))
+		advice.ExpectedWorkers = int(float64(advice.TodayMB) / advice.PerRequestMB())
+	}
+
+	advice.Prerequisites = migrationSteps(advice, apacheConfig, phpModule, pool != nil)
+
+	eventConfig := *apacheConfig
+	eventConfig.MPMModel = "event"
+	eventConfig.ThreadsPerChild = advice.ThreadsPerChild
+	eventRec := &Recommendations{CurrentMaxClients: apacheConfig.GetCurrentMaxClients(), Policy: rec.Policy, ThreadsPerChild: advice.ThreadsPerChild}
+	eventRec.setWorkers(ProcessesFor(advice.Workers, &eventConfig) * advice.ThreadsPerChild)
+	RecommendMPMBlock(eventRec, &eventConfig, statusInfo)
+	for i := range eventRec.MPMBlock {
+		if eventRec.MPMBlock[i].Name == "MaxRequestWorkers" {
+			eventRec.MPMBlock[i].Rationale = fmt.Sprintf("the %d concurrent requests prefork is sized for", advice.Workers)
+		}
+	}
+	advice.MPMBlock = eventRec.MPMBlock
+	rec.Migration = advice
+}
+
+// migrationSteps lists what has to happen, in order, before Apache can
+// restart under event
+func migrationSteps(advice *MigrationAdvice, apacheConfig *config.ApacheConfig, phpModule string, poolsRunning bool) []string {
+	var steps []string
+	if poolsRunning {
+		steps = append(steps, "Serve PHP from the running PHP-FPM pools")
+	} else {
+		steps = append(steps, "Install PHP-FPM with the same PHP version and extensions as mod_php")
+	}
+	maxChildren := fmt.Sprintf("Set pm.max_children for the PHP requests among %d concurrent requests", advice.Workers)
+	if advice.Estimated {
+		maxChildren += fmt.Sprintf(" (%.1f MB per child)", advice.PHPMB)
+	}
+	steps = append(steps, maxChildren)
+	if !apacheConfig.HasModule("proxy_fcgi_module") {
+		steps = append(steps, "Load proxy_module and proxy_fcgi_module")
+	}
+	steps = append(steps, `Hand .php files to PHP-FPM, e.g. SetHandler "proxy:unix:/run/php/php-fpm.sock|fcgi://localhost"`)
+	steps = append(steps, "Move php_value and php_flag settings from the Apache configuration to the pool or .user.ini")
+	for _, check := range advice.Modules {
+		if check.Module != phpModule {
+			steps = append(steps, fmt.Sprintf("%s: %s", check.Module, check.Action))
+		}
+	}
+	steps = append(steps, fmt.Sprintf("Unload %s and mpm_prefork_module, then load mpm_event_module", phpModule))
+	steps = append(steps, "Apply the event MPM block and restart Apache")
+	return steps
+}
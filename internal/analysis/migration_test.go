@@ -0,0 +1,98 @@
+package analysis
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"apache2buddy-go/internal/system"
+)
+
+func TestAdviseMigration(t *testing.T) {
+	sysInfo, memStats, apacheConfig := planInputs("prefork", 30, 12)
+	apacheConfig.Modules = []string{"mpm_prefork_module", "cgi_module", "php7_module", "ruid2_module"}
+	rec := &Recommendations{RecommendedMaxClients: 100}
+	AdviseMigration(rec, sysInfo, memStats, apacheConfig, nil)
+
+	advice := rec.Migration
+	if advice == nil || !advice.Estimated {
+		t.Fatalf("Migration = %+v, want an estimate", advice)
+	}
+	// An event child keeps the shared 18 MB once plus 1 MB per thread; PHP
+	// takes the private 12 MB per request
+	if math.Abs(advice.EventChildMB-43) > 1e-9 || advice.PHPMB != 12 || math.Abs(advice.PerRequestMB()-13.72) > 1e-9 {
+		t.Errorf("event child %g MB, PHP %g MB; want 43 and 12", advice.EventChildMB, advice.PHPMB)
+	}
+	if advice.TodayMB != 3000 || advice.ExpectedMB != 1372 || advice.ExpectedWorkers != 218 {
+		t.Errorf("TodayMB, ExpectedMB, ExpectedWorkers = %d, %d, %d; want 3000, 1372, 218", advice.TodayMB, advice.ExpectedMB, advice.ExpectedWorkers)
+	}
+	if got := strings.Join(advice.Blockers(), ","); got != "php7_module,ruid2_module" {
+		t.Errorf("Blockers() = %s", got)
+	}
+	if len(advice.Modules) != 3 || advice.Modules[2].Module != "cgi_module" {
+		t.Errorf("Modules = %+v, want cgi_module last", advice.Modules)
+	}
+	steps := strings.Join(advice.Prerequisites, "\n")
+	for _, want := range []string{"Install PHP-FPM", "Load proxy_module and proxy_fcgi_module", "cgi_module: load cgid_module instead", "Unload php7_module"} {
+		if !strings.Contains(steps, want) {
+			t.Errorf("Prerequisites miss %q:\n%s", want, steps)
+		}
+	}
+	for _, directive := range advice.MPMBlock {
+		if directive.Name == "MaxRequestWorkers" && directive.Value != 100 {
+			t.Errorf("event MaxRequestWorkers = %d, want 100", directive.Value)
+		}
+	}
+
+	// A running pool gives the PHP-FPM child size
+	sysInfo.PHPFPMPools = []system.PHPFPMPool{{Name: "www", LargestMB: 20}, {Name: "idle"}}
+	apacheConfig.Modules = append(apacheConfig.Modules, "proxy_fcgi_module")
+	AdviseMigration(rec, sysInfo, memStats, apacheConfig, nil)
+	if rec.Migration.PHPMB != 20 || !strings.Contains(rec.Migration.PHPSource, "www") {
+		t.Errorf("PHPMB = %g from %q, want 20 from pool www", rec.Migration.PHPMB, rec.Migration.PHPSource)
+	}
+	if strings.Contains(strings.Join(rec.Migration.Prerequisites, "\n"), "Load proxy_module") {
+		t.Error("proxy_fcgi_module is already loaded")
+	}
+}
+
+func TestAdviseMigration_NotApplicable(t *testing.T) {
+	sysInfo, memStats, apacheConfig := planInputs("event", 30, 12)
+	apacheConfig.Modules = []string{"php_module"}
+	rec := &Recommendations{RecommendedMaxClients: 100}
+	AdviseMigration(rec, sysInfo, memStats, apacheConfig, nil)
+	if rec.Migration != nil {
+		t.Error("event needs no migration")
+	}
+
+	apacheConfig.MPMModel = "prefork"
+	apacheConfig.Modules = []string{"proxy_fcgi_module"}
+	AdviseMigration(rec, sysInfo, memStats, apacheConfig, nil)
+	if rec.Migration != nil {
+		t.Error("prefork without mod_php needs no migration")
+	}
+
+	// Without PSS the steps are still listed
+	sysInfo, memStats, apacheConfig = planInputs("prefork", 30, 0)
+	apacheConfig.Modules = []string{"php_module"}
+	AdviseMigration(rec, sysInfo, memStats, apacheConfig, nil)
+	if rec.Migration == nil || rec.Migration.Estimated || len(rec.Migration.Prerequisites) == 0 || len(rec.Migration.Notes) != 1 {
+		t.Errorf("Migration = %+v, want steps and a note", rec.Migration)
+	}
+}
+
+func TestCheckMigration(t *testing.T) {
+	sysInfo, memStats, apacheConfig := planInputs("prefork", 30, 12)
+	apacheConfig.Modules = []string{"php_module", "mpm_itk_module"}
+	rec := &Recommendations{RecommendedMaxClients: 100}
+	AdviseMigration(rec, sysInfo, memStats, apacheConfig, nil)
+
+	got := checkMigration(Facts(sysInfo, memStats, apacheConfig, rec, nil))
+	if len(got) != 1 || got[0].ID != "configuration.event-migration" {
+		t.Fatalf("checkMigration() = %+v", got)
+	}
+	if !strings.Contains(got[0].Title, "30.0 to 13.7 MB (54% less)") || len(got[0].Evidence) != 1 ||
+		got[0].Evidence[0] != "Blocked by: php_module, mpm_itk_module" {
+		t.Errorf("finding = %+v", got[0])
+	}
+}
@@ -0,0 +1,78 @@
+package analysis
+
+import "apache2buddy-go/internal/config"
+
+// Compiled-in defaults of the MPMs
+const (
+	defaultThreadsPerChild    = 25  // worker and event
+	defaultThreadLimit        = 64  // worker and event
+	defaultPreforkServerLimit = 256 // prefork
+)
+
+// IsThreaded reports whether mpm serves each request from a thread rather
+// than a process
+func IsThreaded(mpm string) bool {
+	return mpm == "worker" || mpm == "event"
+}
+
+// ThreadsPerChild returns the requests one child process serves at once: 1
+// under prefork, the configured ThreadsPerChild or its default otherwise
+func ThreadsPerChild(apacheConfig *config.ApacheConfig) int {
+	if !IsThreaded(apacheConfig.MPMModel) {
+		return 1
+	}
+	if apacheConfig.ThreadsPerChild > 0 {
+		return apacheConfig.ThreadsPerChild
+	}
+	return defaultThreadsPerChild
+}
+
+// ProcessesFor returns the child processes that serve workers requests
+func ProcessesFor(workers int, apacheConfig *config.ApacheConfig) int {
+	threads := ThreadsPerChild(apacheConfig)
+	return (workers + threads - 1) / threads
+}
+
+// WorkerMemoryMB returns the memory workers concurrent requests need. The
+// measured size is per process, so under worker and event it is paid once
+// per ThreadsPerChild requests.
+func WorkerMemoryMB(workers int, apacheConfig *config.ApacheConfig, memStats *MemoryStats) float64 {
+	return float64(ProcessesFor(workers, apacheConfig)) * memStats.SizingMB()
+}
+
+// setWorkers makes workers the recommended MaxRequestWorkers together with
+// the ServerLimit, and for threaded MPMs the ThreadLimit, it needs.
+// rec.ThreadsPerChild must be set for threaded MPMs; MaxRequestWorkers is
+// then rounded down to whole child processes. Apache needs at least one
+// child, so fewer than one worker becomes one child and sets rec.NoRoom.
+func (rec *Recommendations) setWorkers(workers int) {
+	rec.NoRoom = workers < 1
+	if rec.NoRoom {
+		workers = 1
+		if rec.ThreadsPerChild > 0 {
+			workers = rec.ThreadsPerChild
+		}
+	}
+	rec.RecommendedMaxClients = workers
+	rec.ServerLimit = 0
+	rec.ThreadLimit = 0
+
+	threads := rec.ThreadsPerChild
+	if threads == 0 {
+		// prefork: one process per worker, above 256 ServerLimit must follow
+		if workers > defaultPreforkServerLimit {
+			rec.ServerLimit = workers
+		}
+		return
+	}
+	if workers < threads {
+		threads = workers
+		rec.ThreadsPerChild = threads
+	}
+	rec.ServerLimit = workers / threads
+	rec.RecommendedMaxClients = rec.ServerLimit * threads
+	rec.ThreadLimit = defaultThreadLimit
+	if threads > defaultThreadLimit {
+		rec.ThreadLimit = threads
+	}
+}
@@ -0,0 +1,157 @@
+package analysis
+
+import (
+	"testing"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/findings"
+	"apache2buddy-go/internal/system"
+)
+
+func TestGenerateRecommendations_Threaded(t *testing.T) {
+	memStats := &MemoryStats{ProcessCount: 4, LargestMB: 50, AverageMB: 45}
+
+	tests := []struct {
+		name            string
+		config          *config.ApacheConfig
+		availableMB     int
+		wantStatus      string
+		wantWorkers     int
+		wantServerLimit int
+		wantThreads     int
+		wantThreadLimit int
+		wantUtilization float64
+	}{
+		{
+			name:            "event with default ThreadsPerChild",
+			config:          &config.ApacheConfig{MPMModel: "event", MaxRequestWorkers: 400},
+			availableMB:     2000,
+			wantStatus:      "OK",
+			wantWorkers:     900, // 36 children of 25 threads in 90% of 2000 MB
+			wantServerLimit: 36,
+			wantThreads:     25,
+			wantThreadLimit: 64,
+			wantUtilization: 40, // 16 children of 50 MB
+		},
+		{
+			name:            "worker with ThreadsPerChild above the default ThreadLimit",
+			config:          &config.ApacheConfig{MPMModel: "worker", MaxRequestWorkers: 1000, ThreadsPerChild: 100},
+			availableMB:     400,
+			wantStatus:      "CRITICAL",
+			wantWorkers:     700,
+			wantServerLimit: 7,
+			wantThreads:     100,
+			wantThreadLimit: 100,
+			wantUtilization: 125,
+		},
+		{
+			name:            "prefork is unchanged",
+			config:          &config.ApacheConfig{MPMModel: "prefork", MaxRequestWorkers: 300},
+			availableMB:     20000,
+			wantStatus:      "OK",
+			wantWorkers:     360,
+			wantServerLimit: 360,
+			wantUtilization: 75,
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sysInfo := &system.SystemInfo{AvailableMemoryMB: tt.availableMB, OtherServices: map[string]int{}}
+			got := GenerateRecommendationsWithPolicy(sysInfo, memStats, tt.config, nil, 1, DefaultPolicy())
+			if got.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", got.Status, tt.wantStatus)
+			}
+			if got.RecommendedMaxClients != tt.wantWorkers || got.ServerLimit != tt.wantServerLimit ||
+				got.ThreadsPerChild != tt.wantThreads || got.ThreadLimit != tt.wantThreadLimit {
+				t.Errorf("MaxRequestWorkers %d, ServerLimit %d, ThreadsPerChild %d, ThreadLimit %d; want %d, %d, %d, %d",
+					got.RecommendedMaxClients, got.ServerLimit, got.ThreadsPerChild, got.ThreadLimit,
+					tt.wantWorkers, tt.wantServerLimit, tt.wantThreads, tt.wantThreadLimit)
+			}
+			if got.UtilizationPercent != tt.wantUtilization {
+				t.Errorf("UtilizationPercent = %.1f, want %.1f", got.UtilizationPercent, tt.wantUtilization)
+			}
+		})
+	}
+}
+
+func TestSetWorkers(t *testing.T) {
+	tests := []struct {
+		name            string
+		threads         int
+		workers         int
+		wantWorkers     int
+		wantServerLimit int
+		wantThreads     int
+		wantThreadLimit int
+	}{
+		{"prefork below 256", 0, 150, 150, 0, 0, 0},
+		{"prefork above 256", 0, 300, 300, 300, 0, 0},
+		{"threaded rounds down to whole children", 25, 110, 100, 4, 25, 64},
+		{"fewer workers than ThreadsPerChild", 25, 10, 10, 1, 10, 64},
+		{"prefork without room for a worker", 0, 0, 1, 0, 0, 0},
+		{"threaded without room for a child", 25, 0, 25, 1, 25, 64},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &Recommendations{ThreadsPerChild: tt.threads}
+			rec.setWorkers(tt.workers)
+			if rec.RecommendedMaxClients != tt.wantWorkers || rec.ServerLimit != tt.wantServerLimit ||
+				rec.ThreadsPerChild != tt.wantThreads || rec.ThreadLimit != tt.wantThreadLimit {
+				t.Errorf("got %d/%d/%d/%d, want %d/%d/%d/%d",
+					rec.RecommendedMaxClients, rec.ServerLimit, rec.ThreadsPerChild, rec.ThreadLimit,
+					tt.wantWorkers, tt.wantServerLimit, tt.wantThreads, tt.wantThreadLimit)
+			}
+			if rec.NoRoom != (tt.workers == 0) {
+				t.Errorf("NoRoom = %v", rec.NoRoom)
+			}
+		})
+	}
+}
+
+// TestGenerateRecommendations_NoRoom has less memory than one worker needs
+func TestGenerateRecommendations_NoRoom(t *testing.T) {
+	memStats := &MemoryStats{ProcessCount: 4, LargestMB: 50, AverageMB: 45}
+	for _, mpm := range []string{"prefork", "event"} {
+		t.Run(mpm, func(t *testing.T) {
+			sysInfo := &system.SystemInfo{AvailableMemoryMB: 30, OtherServices: map[string]int{}}
+			apacheConfig := &config.ApacheConfig{MPMModel: mpm, MaxRequestWorkers: 150}
+			rec := GenerateRecommendationsWithPolicy(sysInfo, memStats, apacheConfig, nil, 1, DefaultPolicy())
+			if rec.RecommendedMaxClients < 1 || !rec.NoRoom {
+				t.Fatalf("MaxRequestWorkers %d, NoRoom %v; want at least one worker", rec.RecommendedMaxClients, rec.NoRoom)
+			}
+			if IsThreaded(mpm) && rec.ServerLimit != 1 {
+				t.Errorf("ServerLimit = %d, want one child", rec.ServerLimit)
+			}
+
+			got := Evaluate(rec, Facts(sysInfo, memStats, apacheConfig, rec, nil))
+			if len(got) == 0 || got[0].ID != "memory.no-room-for-worker" || got[0].Severity != findings.SeverityCritical {
+				t.Fatalf("findings = %v, want memory.no-room-for-worker first", ids(got))
+			}
+			if got[0].Evidence[0] != "30 MB available, 50.0 MB per child" {
+				t.Errorf("evidence = %q", got[0].Evidence)
+			}
+			if rec.Status != "CRITICAL" {
+				t.Errorf("Status = %s, want CRITICAL", rec.Status)
+			}
+		})
+	}
+}
+
+func TestWorkerMemoryMB(t *testing.T) {
+	memStats := &MemoryStats{ProcessCount: 1, LargestMB: 40}
+	tests := []struct {
+		config *config.ApacheConfig
+		want   float64
+	}{
+		{&config.ApacheConfig{MPMModel: "prefork", MaxRequestWorkers: 10}, 400},
+		{&config.ApacheConfig{MPMModel: "event", MaxRequestWorkers: 10}, 40},
+		{&config.ApacheConfig{MPMModel: "worker", MaxRequestWorkers: 130, ThreadsPerChild: 64}, 120},
+	}
+	for _, tt := range tests {
+		t.Run(tt.config.MPMModel, func(t *testing.T) {
+			if got := WorkerMemoryMB(tt.config.MaxRequestWorkers, tt.config, memStats); got != tt.want {
+				t.Errorf("WorkerMemoryMB() = %g, want %g", got, tt.want)
+			}
+		})
+	}
+}
@@ -0,0 +1,196 @@
+package analysis
+
+import (
+	"fmt"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/status"
+)
+
+// Compiled-in spare defaults of the MPMs
+const (
+	defaultPreforkStartServers  = 5
+	defaultMinSpareServers      = 5
+	defaultMaxSpareServers      = 10
+	defaultThreadedStartServers = 3
+	defaultMinSpareThreads      = 75
+	defaultMaxSpareThreads      = 250
+)
+
+// MPMDirective is one setting of a recommended MPM block
+type MPMDirective struct {
+	Name      string
+	Value     int
+	Current   int // Configured value, 0 when not set
+	Rationale string
+}
+
+// RecommendMPMBlock recommends every directive of the MPM block around
+// rec.RecommendedMaxClients and stores it in rec.MPMBlock. Spare and start
+// counts follow the busy workers seen by mod_status; without mod_status they
+// keep Apache's defaults.
+func RecommendMPMBlock(rec *Recommendations, apacheConfig *config.ApacheConfig, statusInfo *status.ApacheStatus) []MPMDirective {
+	workers := rec.RecommendedMaxClients
+	if workers < 1 {
+		workers = 1
+	}
+	busy := -1
+	if statusInfo != nil && statusInfo.ActiveWorkers+statusInfo.IdleWorkers > 0 {
+		busy = statusInfo.ActiveWorkers
+	}
+
+	var block []MPMDirective
+	if rec.ThreadsPerChild > 0 {
+		block = threadedBlock(rec, apacheConfig, workers, busy)
+	} else {
+		block = preforkBlock(rec, apacheConfig, workers, busy)
+	}
+	block = append(block, MPMDirective{
+		Name:      "MaxRequestWorkers",
+		Value:     rec.RecommendedMaxClients,
+		Current:   rec.CurrentMaxClients,
+		Rationale: maxWorkersRationale(rec),
+	})
+	if directive, ok := maxConnectionsDirective(rec, apacheConfig); ok {
+		block = append(block, directive)
+	}
+	rec.MPMBlock = block
+	return block
+}
+
+func preforkBlock(rec *Recommendations, apacheConfig *config.ApacheConfig, workers, busy int) []MPMDirective {
+	start := MPMDirective{Name: "StartServers", Value: defaultPreforkStartServers, Current: apacheConfig.StartServers}
+	minSpare := MPMDirective{Name: "MinSpareServers", Value: defaultMinSpareServers, Current: apacheConfig.MinSpareServers}
+	maxSpare := MPMDirective{Name: "MaxSpareServers", Value: defaultMaxSpareServers, Current: apacheConfig.MaxSpareServers}
+
+	if busy < 0 {
+		start.Rationale = "Apache default; enable mod_status to size from observed traffic"
+		minSpare.Rationale = start.Rationale
+		maxSpare.Rationale = start.Rationale
+	} else {
+		minSpare.Value = atLeast(defaultMinSpareServers, (busy+3)/4)
+		minSpare.Rationale = fmt.Sprintf("a quarter of the %d busy workers seen, at least %d, absorbs a burst without forking", busy, defaultMinSpareServers)
+		maxSpare.Value = atLeast(2*minSpare.Value, minSpare.Value+5)
+		maxSpare.Rationale = "twice MinSpareServers, so idle workers are only reaped once a burst has passed"
+		start.Value = busy + minSpare.Value
+		start.Rationale = fmt.Sprintf("the %d busy workers seen plus MinSpareServers, so a restart starts warm", busy)
+	}
+	capDirective(&start, workers, "MaxRequestWorkers")
+	capDirective(&minSpare, workers, "MaxRequestWorkers")
+	capDirective(&maxSpare, workers, "MaxRequestWorkers")
+
+	block := []MPMDirective{start, minSpare, maxSpare}
+	if workers > defaultPreforkServerLimit {
+		block = append(block, MPMDirective{
+			Name:      "ServerLimit",
+			Value:     workers,
+			Current:   apacheConfig.ServerLimit,
+			Rationale: "MaxRequestWorkers above 256 needs a ServerLimit at least as high",
+		})
+	}
+	return block
+}
+
+func threadedBlock(rec *Recommendations, apacheConfig *config.ApacheConfig, workers, busy int) []MPMDirective {
+	threads := rec.ThreadsPerChild
+	children := rec.ServerLimit
+	if children < 1 {
+		children = 1
+	}
+
+	start := MPMDirective{Name: "StartServers", Value: defaultThreadedStartServers, Current: apacheConfig.StartServers}
+	minSpare := MPMDirective{Name: "MinSpareThreads", Value: atLeast(defaultMinSpareThreads, threads), Current: apacheConfig.MinSpareThreads}
+	maxSpare := MPMDirective{Name: "MaxSpareThreads", Current: apacheConfig.MaxSpareThreads}
+
+	if busy < 0 {
+		start.Rationale = "Apache default; enable mod_status to size from observed traffic"
+		minSpare.Rationale = start.Rationale
+		maxSpare.Rationale = start.Rationale
+	} else {
+		minSpare.Value = atLeast(threads, roundUp((busy+3)/4, threads))
+		minSpare.Rationale = fmt.Sprintf("a quarter of the %d busy threads seen, in whole children of %d threads", busy, threads)
+		maxSpare.Rationale = "twice MinSpareThreads and at least one child above it, so children are not stopped and restarted"
+		start.Value = (busy + minSpare.Value + threads - 1) / threads
+		start.Rationale = fmt.Sprintf("children for the %d busy threads seen plus MinSpareThreads", busy)
+	}
+	capDirective(&start, children, "ServerLimit")
+	// MaxSpareThreads must stay a child above MinSpareThreads, so the spares
+	// leave room for it below MaxRequestWorkers; one child is the least
+	capDirective(&minSpare, atLeast(threads, workers-threads), "MaxRequestWorkers less one child")
+	if busy < 0 {
+		maxSpare.Value = atLeast(defaultMaxSpareThreads, minSpare.Value+threads)
+	} else {
+		maxSpare.Value = atLeast(2*minSpare.Value, minSpare.Value+threads)
+	}
+	capDirective(&maxSpare, atLeast(minSpare.Value+threads, workers), "MaxRequestWorkers")
+
+	threadsRationale := "unchanged from the configuration"
+	switch {
+	case apacheConfig.ThreadsPerChild == 0 && threads == defaultThreadsPerChild:
+		threadsRationale = "Apache default"
+	case threads != ThreadsPerChild(apacheConfig):
+		threadsRationale = "lowered to MaxRequestWorkers, which is less than one child"
+	}
+	threadLimitRationale := "Apache default, which is above ThreadsPerChild"
+	if rec.ThreadLimit > defaultThreadLimit {
+		threadLimitRationale = "must be at least ThreadsPerChild"
+	}
+
+	return []MPMDirective{
+		start,
+		{Name: "ServerLimit", Value: rec.ServerLimit, Current: apacheConfig.ServerLimit,
+			Rationale: fmt.Sprintf("MaxRequestWorkers / ThreadsPerChild: %d child processes fit the budget", rec.ServerLimit)},
+		{Name: "ThreadLimit", Value: rec.ThreadLimit, Current: apacheConfig.ThreadLimit, Rationale: threadLimitRationale},
+		{Name: "ThreadsPerChild", Value: threads, Current: apacheConfig.ThreadsPerChild, Rationale: threadsRationale},
+		minSpare,
+		maxSpare,
+	}
+}
+
+// maxWorkersRationale explains which ceiling MaxRequestWorkers comes from
+func maxWorkersRationale(rec *Recommendations) string {
+	if rec.Binding == BindingCPU && rec.CPU != nil {
+		return fmt.Sprintf("CPU ceiling: %g CPUs at %.2f cores per busy worker", rec.CPU.CPUs, rec.CPU.CoresPerWorker)
+	}
+	policy := DefaultPolicy()
+	if rec.Policy != nil {
+		policy = *rec.Policy
+	}
+	return fmt.Sprintf("memory ceiling: %g%% of the memory budget, sizing on %s", policy.TargetPercent, policy.Sizing)
+}
+
+// maxConnectionsDirective recommends MaxConnectionsPerChild when the worker
+// lifetime analysis has an opinion or the configuration sets it
+func maxConnectionsDirective(rec *Recommendations, apacheConfig *config.ApacheConfig) (MPMDirective, bool) {
+	directive := MPMDirective{Name: "MaxConnectionsPerChild", Value: apacheConfig.MaxConnectionsPerChild, Current: apacheConfig.MaxConnectionsPerChild}
+	switch {
+	case rec.Lifetime != nil && rec.Lifetime.RecommendedMaxConnectionsPerChild > 0:
+		directive.Value = rec.Lifetime.RecommendedMaxConnectionsPerChild
+		directive.Rationale = fmt.Sprintf("recycles workers before they bloat: older workers are %.0f%% larger", rec.Lifetime.GrowthPercent)
+	case apacheConfig.MaxConnectionsPerChild > 0:
+		directive.Rationale = "unchanged from the configuration"
+	default:
+		return directive, false
+	}
+	return directive, true
+}
+
+// capDirective keeps a directive at or below limit, the value of the named directive
+func capDirective(directive *MPMDirective, limit int, name string) {
+	if directive.Value > limit {
+		directive.Value = limit
+		directive.Rationale += "; capped at " + name
+	}
+}
+
+func atLeast(floor, value int) int {
+	if value < floor {
+		return floor
+	}
+	return value
+}
+
+// roundUp rounds value up to a multiple of step
+func roundUp(value, step int) int {
+	return (value + step - 1) / step * step
+}
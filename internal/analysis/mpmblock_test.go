@@ -0,0 +1,129 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/status"
+)
+
+func TestRecommendMPMBlock(t *testing.T) {
+	tests := []struct {
+		name       string
+		config     *config.ApacheConfig
+		threads    int
+		workers    int
+		status     *status.ApacheStatus
+		lifetime   *WorkerLifetime
+		wantNames  []string
+		wantValues []int
+	}{
+		{
+			name:       "prefork without mod_status keeps the defaults",
+			config:     &config.ApacheConfig{MPMModel: "prefork"},
+			workers:    46,
+			wantNames:  []string{"StartServers", "MinSpareServers", "MaxSpareServers", "MaxRequestWorkers"},
+			wantValues: []int{5, 5, 10, 46},
+		},
+		{
+			name:       "prefork sized from busy workers",
+			config:     &config.ApacheConfig{MPMModel: "prefork", MaxConnectionsPerChild: 5000},
+			workers:    400,
+			status:     &status.ApacheStatus{ActiveWorkers: 40, IdleWorkers: 10},
+			wantNames:  []string{"StartServers", "MinSpareServers", "MaxSpareServers", "ServerLimit", "MaxRequestWorkers", "MaxConnectionsPerChild"},
+			wantValues: []int{50, 10, 20, 400, 400, 5000},
+		},
+		{
+			name:       "prefork start capped at MaxRequestWorkers",
+			config:     &config.ApacheConfig{MPMModel: "prefork"},
+			workers:    30,
+			status:     &status.ApacheStatus{ActiveWorkers: 40},
+			lifetime:   &WorkerLifetime{RecommendedMaxConnectionsPerChild: 2000, GrowthPercent: 60},
+			wantNames:  []string{"StartServers", "MinSpareServers", "MaxSpareServers", "MaxRequestWorkers", "MaxConnectionsPerChild"},
+			wantValues: []int{30, 10, 20, 30, 2000},
+		},
+		{
+			name:       "event without mod_status keeps the defaults",
+			config:     &config.ApacheConfig{MPMModel: "event"},
+			threads:    25,
+			workers:    900,
+			wantNames:  []string{"StartServers", "ServerLimit", "ThreadLimit", "ThreadsPerChild", "MinSpareThreads", "MaxSpareThreads", "MaxRequestWorkers"},
+			wantValues: []int{3, 36, 64, 25, 75, 250, 900},
+		},
+		{
+			name:       "event sized from busy threads in whole children",
+			config:     &config.ApacheConfig{MPMModel: "event"},
+			threads:    25,
+			workers:    900,
+			status:     &status.ApacheStatus{ActiveWorkers: 110, IdleWorkers: 40},
+			wantNames:  []string{"StartServers", "ServerLimit", "ThreadLimit", "ThreadsPerChild", "MinSpareThreads", "MaxSpareThreads", "MaxRequestWorkers"},
+			wantValues: []int{7, 36, 64, 25, 50, 100, 900},
+		},
+		{
+			name:       "worker capped at ServerLimit and MaxRequestWorkers",
+			config:     &config.ApacheConfig{MPMModel: "worker"},
+			threads:    25,
+			workers:    100,
+			status:     &status.ApacheStatus{ActiveWorkers: 500},
+			wantNames:  []string{"StartServers", "ServerLimit", "ThreadLimit", "ThreadsPerChild", "MinSpareThreads", "MaxSpareThreads", "MaxRequestWorkers"},
+			wantValues: []int{4, 4, 64, 25, 75, 100, 100},
+		},
+		{
+			name:       "a single child keeps MaxSpareThreads a child above MinSpareThreads",
+			config:     &config.ApacheConfig{MPMModel: "event"},
+			threads:    25,
+			workers:    25,
+			status:     &status.ApacheStatus{ActiveWorkers: 10},
+			wantNames:  []string{"StartServers", "ServerLimit", "ThreadLimit", "ThreadsPerChild", "MinSpareThreads", "MaxSpareThreads", "MaxRequestWorkers"},
+			wantValues: []int{1, 1, 64, 25, 25, 50, 25},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := &Recommendations{ThreadsPerChild: tt.threads, Lifetime: tt.lifetime}
+			rec.setWorkers(tt.workers)
+			block := RecommendMPMBlock(rec, tt.config, tt.status)
+
+			var names []string
+			var values []int
+			for _, directive := range block {
+				names = append(names, directive.Name)
+				values = append(values, directive.Value)
+				if directive.Rationale == "" {
+					t.Errorf("%s has no rationale", directive.Name)
+				}
+			}
+			if !reflect.DeepEqual(names, tt.wantNames) {
+				t.Errorf("directives = %v, want %v", names, tt.wantNames)
+			}
+			if !reflect.DeepEqual(values, tt.wantValues) {
+				t.Errorf("values = %v, want %v", values, tt.wantValues)
+			}
+			if !reflect.DeepEqual(rec.MPMBlock, block) {
+				t.Error("block not stored in the recommendations")
+			}
+		})
+	}
+}
+
+func TestRecommendMPMBlock_Rationale(t *testing.T) {
+	rec := &Recommendations{CurrentMaxClients: 20}
+	rec.setWorkers(10)
+	block := RecommendMPMBlock(rec, &config.ApacheConfig{MPMModel: "prefork", StartServers: 8}, &status.ApacheStatus{ActiveWorkers: 12})
+
+	if block[0].Current != 8 {
+		t.Errorf("StartServers current = %d, want 8", block[0].Current)
+	}
+	if !strings.HasSuffix(block[0].Rationale, "capped at MaxRequestWorkers") {
+		t.Errorf("StartServers rationale = %q", block[0].Rationale)
+	}
+
+	rec.Binding = BindingCPU
+	rec.CPU = &CPUAnalysis{CPUs: 2, CoresPerWorker: 0.2}
+	block = RecommendMPMBlock(rec, &config.ApacheConfig{MPMModel: "prefork"}, nil)
+	if got := block[len(block)-1].Rationale; got != "CPU ceiling: 2 CPUs at 0.20 cores per busy worker" {
+		t.Errorf("MaxRequestWorkers rationale = %q", got)
+	}
+}
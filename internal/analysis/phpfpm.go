@@ -0,0 +1,117 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/system"
+)
+
+// PoolRecommendation sizes pm.max_children of one PHP-FPM pool
+type PoolRecommendation struct {
+	Pool                   system.PHPFPMPool
+	BudgetMB               int     // Memory assigned to the pool
+	Statistic              string  // Size* statistic ChildMB is
+	ChildMB                float64 // Child size the pool is sized with
+	RecommendedMaxChildren int     // Target share of the budget, 0 when the pool has no running children
+	MaxRecommended         int     // Limit share of the budget
+	Status                 string
+	Message                string
+}
+
+// PHPFPMBudget divides the memory left after the other services between
+// Apache and PHP-FPM. Apache is charged first, so the two together fit.
+type PHPFPMBudget struct {
+	TotalMB  int // Memory after the other services, PHP-FPM's current use included
+	ApacheMB int // Used by the recommended MaxRequestWorkers of every Apache instance
+	PHPFPMMB int // What is left for the pools
+}
+
+// SplitPHPFPMBudget gives PHP-FPM what apacheMB leaves of totalMB
+func SplitPHPFPMBudget(totalMB, apacheMB int) PHPFPMBudget {
+	budget := PHPFPMBudget{TotalMB: totalMB, ApacheMB: apacheMB, PHPFPMMB: totalMB - apacheMB}
+	if budget.PHPFPMMB < 0 {
+		budget.PHPFPMMB = 0
+	}
+	return budget
+}
+
+// RecommendedMemoryMB returns the memory the recommended MaxRequestWorkers
+// use at the sizing worker size
+func RecommendedMemoryMB(rec *Recommendations, apacheConfig *config.ApacheConfig, memStats *MemoryStats) int {
+	return int(math.Ceil(WorkerMemoryMB(rec.RecommendedMaxClients, apacheConfig, memStats)))
+}
+
+// RecommendPHPFPMPools sizes pm.max_children for each pool the same way
+// MaxRequestWorkers is sized for Apache: availableMB is split between pools
+// in proportion to their current memory use, and each pool's share is divided
+// by the policy's statistic of its children, with the policy's margin band
+// as the acceptable range. Without the size of each child only the largest
+// and the average are known, so the largest stands in for the other
+// statistics. Pools without running children cannot be measured.
+func RecommendPHPFPMPools(pools []system.PHPFPMPool, availableMB int, policy Policy) []PoolRecommendation {
+	weights := make([]float64, len(pools))
+	for i, pool := range pools {
+		weights[i] = pool.TotalMB
+	}
+	budgets := SplitAvailableMemory(availableMB, weights)
+
+	recommendations := make([]PoolRecommendation, len(pools))
+	for i, pool := range pools {
+		rec := PoolRecommendation{Pool: pool, BudgetMB: budgets[i]}
+		if pool.LargestMB <= 0 {
+			rec.Status = "UNKNOWN"
+			rec.Message = "No running children to measure"
+			recommendations[i] = rec
+			continue
+		}
+
+		rec.Statistic, rec.ChildMB = poolChildSize(pool, policy.Sizing)
+		childMB := rec.ChildMB
+		rec.MaxRecommended = policy.LimitWorkers(rec.BudgetMB, childMB)
+		rec.RecommendedMaxChildren = policy.TargetWorkers(rec.BudgetMB, childMB)
+
+		switch {
+		case pool.MaxChildren <= rec.RecommendedMaxChildren:
+			rec.Status = "OK"
+			rec.Message = "pm.max_children appears acceptable"
+		case pool.MaxChildren <= rec.MaxRecommended:
+			rec.Status = "WARNING"
+			rec.Message = "pm.max_children is on the high side but acceptable"
+		default:
+			rec.Status = "CRITICAL"
+			rec.Message = fmt.Sprintf("pm.max_children %d could use %.0f MB, more than the %d MB available",
+				pool.MaxChildren, float64(pool.MaxChildren)*childMB, rec.BudgetMB)
+		}
+		recommendations[i] = rec
+	}
+	return recommendations
+}
+
+// poolChildSize resolves statistic for the children of pool like
+// MemoryStats.StatisticMB does for Apache workers. Pool sizes are PSS when
+// it could be read, so pss is the largest child; the history only covers
+// Apache, so history is the largest child too.
+func poolChildSize(pool system.PHPFPMPool, statistic string) (string, float64) {
+	if statistic == "" {
+		statistic = SizeLargest
+	}
+	if len(pool.ChildMB) == 0 {
+		if statistic == SizeAverage && pool.AverageMB > 0 {
+			return SizeAverage, pool.AverageMB
+		}
+		return SizeLargest, pool.LargestMB
+	}
+	children := make([]process.ProcessInfo, len(pool.ChildMB))
+	for i, size := range pool.ChildMB {
+		children[i] = process.ProcessInfo{MemoryMB: size}
+	}
+	stats := CalculateMemoryStats(children)
+	stats.Statistic = statistic
+	if statistic == SizePSS || statistic == SizeHistory {
+		return statistic, stats.LargestMB
+	}
+	return statistic, stats.StatisticMB()
+}
@@ -0,0 +1,115 @@
+package analysis
+
+import (
+	"testing"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/system"
+)
+
+func TestRecommendPHPFPMPools(t *testing.T) {
+	pools := []system.PHPFPMPool{
+		{Name: "www", MaxChildren: 10, Processes: 4, TotalMB: 200, LargestMB: 60},
+		{Name: "shop", MaxChildren: 50, Processes: 4, TotalMB: 600, LargestMB: 150},
+		{Name: "api", PM: "ondemand", MaxChildren: 4},
+	}
+
+	recs := RecommendPHPFPMPools(pools, 2000, DefaultPolicy())
+	if len(recs) != 3 {
+		t.Fatalf("got %d recommendations, want 3", len(recs))
+	}
+
+	tests := []struct {
+		name            string
+		wantBudget      int
+		wantRecommended int
+		wantMax         int
+		wantStatus      string
+	}{
+		// 2000 MB split 200:600 between the measured pools
+		{"www", 500, 7, 8, "CRITICAL"},
+		{"shop", 1500, 9, 10, "CRITICAL"},
+		{"api", 0, 0, 0, "UNKNOWN"},
+	}
+	for i, tt := range tests {
+		rec := recs[i]
+		if rec.Pool.Name != tt.name {
+			t.Fatalf("recommendation %d is for %s, want %s", i, rec.Pool.Name, tt.name)
+		}
+		if rec.BudgetMB != tt.wantBudget || rec.RecommendedMaxChildren != tt.wantRecommended ||
+			rec.MaxRecommended != tt.wantMax || rec.Status != tt.wantStatus {
+			t.Errorf("%s: budget %d, recommended %d, max %d, status %s; want %d, %d, %d, %s",
+				tt.name, rec.BudgetMB, rec.RecommendedMaxChildren, rec.MaxRecommended, rec.Status,
+				tt.wantBudget, tt.wantRecommended, tt.wantMax, tt.wantStatus)
+		}
+	}
+
+	// Same pool with plenty of memory
+	ok := RecommendPHPFPMPools(pools[:1], 4000, DefaultPolicy())[0]
+	if ok.Status != "OK" || ok.RecommendedMaxChildren != 60 {
+		t.Errorf("www with 4000 MB: status %s, recommended %d; want OK, 60", ok.Status, ok.RecommendedMaxChildren)
+	}
+
+	warning := RecommendPHPFPMPools(pools[:1], 640, DefaultPolicy())[0]
+	if warning.Status != "WARNING" {
+		t.Errorf("www with 640 MB: status %s, want WARNING", warning.Status)
+	}
+}
+
+func TestRecommendPHPFPMPools_Statistic(t *testing.T) {
+	pool := system.PHPFPMPool{Name: "www", MaxChildren: 10, Processes: 10, LargestMB: 100, AverageMB: 55,
+		ChildMB: []float64{10, 20, 30, 40, 50, 60, 70, 80, 90, 100}}
+	tests := []struct {
+		statistic string
+		want      float64
+	}{
+		{SizeLargest, 100},
+		{SizeP95, 100},
+		{SizeP90, 90},
+		{SizeMedian, 55},
+		{SizeAverage, 55},
+		{SizePSS, 100},
+		{SizeHistory, 100},
+	}
+	for _, tt := range tests {
+		policy := DefaultPolicy()
+		policy.Sizing = tt.statistic
+		rec := RecommendPHPFPMPools([]system.PHPFPMPool{pool}, 1000, policy)[0]
+		if rec.Statistic != tt.statistic || rec.ChildMB != tt.want {
+			t.Errorf("sizing on %s: %s %g MB, want %g MB", tt.statistic, rec.Statistic, rec.ChildMB, tt.want)
+		}
+	}
+
+	// Without the size of each child, percentiles fall back to the largest
+	pool.ChildMB = nil
+	policy := DefaultPolicy()
+	policy.Sizing = SizeP90
+	if rec := RecommendPHPFPMPools([]system.PHPFPMPool{pool}, 1000, policy)[0]; rec.Statistic != SizeLargest || rec.ChildMB != 100 {
+		t.Errorf("p90 without child sizes: %s %g MB, want largest 100 MB", rec.Statistic, rec.ChildMB)
+	}
+}
+
+// TestSplitPHPFPMBudget sizes Apache and PHP-FPM on a host where 3000 MB
+// are left for Apache after the other services, PHP-FPM's 800 MB among them
+func TestSplitPHPFPMBudget(t *testing.T) {
+	sysInfo := &system.SystemInfo{AvailableMemoryMB: 3000, OtherServices: map[string]int{"PHP-FPM": 800}}
+	memStats := CalculateMemoryStats(workersOf(30, 30, 30))
+	apacheConfig := &config.ApacheConfig{MPMModel: "prefork", MaxRequestWorkers: 150}
+	rec := GenerateRecommendationsWithPolicy(sysInfo, memStats, apacheConfig, nil, 1, DefaultPolicy())
+	pools := []system.PHPFPMPool{{Name: "www", MaxChildren: 40, Processes: 10, TotalMB: 800, LargestMB: 80}}
+
+	totalMB := sysInfo.AvailableMemoryMB + sysInfo.OtherServices["PHP-FPM"]
+	budget := SplitPHPFPMBudget(totalMB, RecommendedMemoryMB(rec, apacheConfig, memStats))
+	if budget.ApacheMB != 2700 || budget.PHPFPMMB != 1100 {
+		t.Errorf("budget = %+v, want 2700 MB for 90 workers and 1100 MB for PHP-FPM", budget)
+	}
+	pool := RecommendPHPFPMPools(pools, budget.PHPFPMMB, DefaultPolicy())[0]
+	phpfpmMB := float64(pool.RecommendedMaxChildren) * pools[0].LargestMB
+	if used := float64(budget.ApacheMB) + phpfpmMB; used > float64(totalMB) {
+		t.Errorf("Apache %d MB + PHP-FPM %.0f MB = %.0f MB, more than the %d MB there is", budget.ApacheMB, phpfpmMB, used, totalMB)
+	}
+
+	if over := SplitPHPFPMBudget(1000, 1200); over.PHPFPMMB != 0 {
+		t.Errorf("SplitPHPFPMBudget(1000, 1200) = %+v, want nothing left for PHP-FPM", over)
+	}
+}
@@ -0,0 +1,182 @@
+package analysis
+
+import (
+	"fmt"
+	"math"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/status"
+	"apache2buddy-go/internal/system"
+)
+
+// PlanTarget is the load a capacity plan sizes for: a number of concurrent
+// requests, or a request rate served at mod_status's mean duration
+type PlanTarget struct {
+	Concurrency int
+	RatePerSec  float64
+}
+
+// CapacityPlan is the memory a target load needs under one MPM
+type CapacityPlan struct {
+	Workers int    // MaxRequestWorkers the target needs
+	Basis   string // How Workers follows from the target
+
+	MPMModel        string
+	ThreadsPerChild int     // 0 under prefork
+	Processes       int     // Child processes serving Workers
+	ProcessMB       float64 // Size of one child process
+	Estimated       bool    // ProcessMB is derived from another MPM's measurement
+
+	ApacheMB   int // Budget Apache needs, with the policy's margin
+	OtherMB    int // Other services and other Apache instances
+	ReserveMB  int
+	RequiredMB int // RAM the host needs
+	CurrentMB  int // RAM the host has
+
+	Recommendations *Recommendations // MaxRequestWorkers and the MPM block for the plan
+}
+
+// Shortfall returns the RAM missing for the plan, 0 when the host has enough
+func (p *CapacityPlan) Shortfall() int {
+	if p.RequiredMB > p.CurrentMB {
+		return p.RequiredMB - p.CurrentMB
+	}
+	return 0
+}
+
+// PlanWorkers returns the MaxRequestWorkers target needs. A rate is turned
+// into workers like the demand estimate, headroom included.
+func PlanWorkers(target PlanTarget, statusInfo *status.ApacheStatus) (int, string, error) {
+	if target.Concurrency > 0 {
+		return target.Concurrency, fmt.Sprintf("%d concurrent requests", target.Concurrency), nil
+	}
+	if target.RatePerSec <= 0 {
+		return 0, "", fmt.Errorf("no target concurrency or request rate to plan for")
+	}
+	demand := AnalyzeDemand(statusInfo, &RequestPeak{PerSec: target.RatePerSec, Source: "planning target"})
+	if demand == nil {
+		return 0, "", fmt.Errorf("planning for a request rate needs the mean request duration from mod_status (ExtendedStatus On)")
+	}
+	return demand.Workers, fmt.Sprintf("%g req/s × %.0f ms = %.1f concurrent requests, plus %d%% headroom",
+		target.RatePerSec, demand.DurationMS, demand.Concurrency, DemandHeadroomPercent), nil
+}
+
+// CapacityReport is the plan for the current MPM and the alternatives to it
+type CapacityReport struct {
+	Plan         *CapacityPlan
+	Alternatives []*CapacityPlan // Other MPMs, estimated from the measured workers
+	Notes        []string
+}
+
+// Savings returns how much less RAM alternative needs than the current plan;
+// negative when it needs more
+func (r *CapacityReport) Savings(alternative *CapacityPlan) int {
+	return r.Plan.RequiredMB - alternative.RequiredMB
+}
+
+// PlanCapacityReport plans target under the configured MPM and compares it
+// with prefork or event, whichever is not configured. worker needs the same
+// memory as event.
+func PlanCapacityReport(target PlanTarget, sysInfo *system.SystemInfo, memStats *MemoryStats, apacheConfig *config.ApacheConfig, statusInfo *status.ApacheStatus, policy Policy) (*CapacityReport, error) {
+	if memStats.ProcessCount == 0 {
+		return nil, fmt.Errorf("no measured workers to plan with")
+	}
+	workers, basis, err := PlanWorkers(target, statusInfo)
+	if err != nil {
+		return nil, err
+	}
+	plan, err := PlanCapacity(workers, basis, apacheConfig.MPMModel, apacheConfig.ThreadsPerChild, sysInfo, memStats, apacheConfig, statusInfo, policy)
+	if err != nil {
+		return nil, err
+	}
+	report := &CapacityReport{Plan: plan}
+
+	alternative := "event"
+	if IsThreaded(apacheConfig.MPMModel) {
+		alternative = "prefork"
+	}
+	other, err := PlanCapacity(workers, basis, alternative, 0, sysInfo, memStats, apacheConfig, statusInfo, policy)
+	if err != nil {
+		report.Notes = append(report.Notes, fmt.Sprintf("Cannot compare with %s: %v.", alternative, err))
+		return report, nil
+	}
+	report.Alternatives = append(report.Alternatives, other)
+	return report, nil
+}
+
+// PlanCapacity works out the RAM workers need under mpm with threads per
+// child (ignored under prefork), given the measured worker sizes and the
+// other services on the host. apacheConfig is the measured configuration.
+func PlanCapacity(workers int, basis, mpm string, threads int, sysInfo *system.SystemInfo, memStats *MemoryStats, apacheConfig *config.ApacheConfig, statusInfo *status.ApacheStatus, policy Policy) (*CapacityPlan, error) {
+	planConfig := *apacheConfig
+	planConfig.MPMModel = mpm
+	planConfig.ThreadsPerChild = threads
+	threads = ThreadsPerChild(&planConfig)
+
+	processMB, estimated, err := processSizeFor(memStats, apacheConfig, &planConfig)
+	if err != nil {
+		return nil, err
+	}
+	plan := &CapacityPlan{
+		Workers:   workers,
+		Basis:     basis,
+		MPMModel:  mpm,
+		Processes: (workers + threads - 1) / threads,
+		ProcessMB: processMB,
+		Estimated: estimated,
+		OtherMB:   system.GetTotalOtherServicesMemory(sysInfo),
+		CurrentMB: sysInfo.HostMemoryMB,
+	}
+	if plan.CurrentMB == 0 {
+		plan.CurrentMB = sysInfo.TotalMemoryMB
+	}
+	if sysInfo.Budget != nil {
+		plan.OtherMB += sysInfo.Budget.OtherInstancesMB
+	}
+	plan.ApacheMB = int(math.Ceil(float64(plan.Processes) * processMB / (policy.TargetPercent / 100)))
+
+	// The reserve is the larger of a fixed amount and a share of the RAM
+	// being solved for
+	needed := plan.ApacheMB + plan.OtherMB
+	plan.RequiredMB = needed + policy.Reserve.MB
+	if policy.Reserve.Percent > 0 {
+		if byPercent := int(math.Ceil(float64(needed) / (1 - policy.Reserve.Percent/100))); byPercent > plan.RequiredMB {
+			plan.RequiredMB = byPercent
+		}
+	}
+	plan.ReserveMB = plan.RequiredMB - needed
+
+	rec := &Recommendations{CurrentMaxClients: apacheConfig.GetCurrentMaxClients(), Policy: &policy}
+	if IsThreaded(mpm) {
+		rec.ThreadsPerChild = threads
+		plan.ThreadsPerChild = threads
+	}
+	rec.setWorkers(plan.Processes * threads)
+	RecommendMPMBlock(rec, &planConfig, statusInfo)
+	for i := range rec.MPMBlock {
+		if rec.MPMBlock[i].Name == "MaxRequestWorkers" {
+			rec.MPMBlock[i].Rationale = "capacity plan: " + basis
+		}
+	}
+	plan.Recommendations = rec
+	return plan, nil
+}
+
+// processSizeFor returns the size of a child process of planConfig's MPM.
+// When the MPM or thread count differs from what was measured, the size is
+// split into a shared part paid once per process and a private part (PSS)
+// paid per thread.
+func processSizeFor(memStats *MemoryStats, measured, planConfig *config.ApacheConfig) (float64, bool, error) {
+	size := memStats.SizingMB()
+	measuredThreads, planThreads := ThreadsPerChild(measured), ThreadsPerChild(planConfig)
+	if IsThreaded(measured.MPMModel) == IsThreaded(planConfig.MPMModel) && measuredThreads == planThreads {
+		return size, false, nil
+	}
+	if memStats.LargestPSSMB <= 0 || memStats.LargestMB <= 0 {
+		return 0, false, fmt.Errorf("estimating a %s child from %s workers needs their PSS", planConfig.MPMModel, measured.MPMModel)
+	}
+	privateFraction := math.Min(memStats.LargestPSSMB/memStats.LargestMB, 1)
+	private := size * privateFraction
+	shared := size - private
+	return shared + private/float64(measuredThreads)*float64(planThreads), true, nil
+}
@@ -0,0 +1,136 @@
+package analysis
+
+import (
+	"math"
+	"strings"
+	"testing"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/status"
+	"apache2buddy-go/internal/system"
+)
+
+// planInputs is a 4 GB host with MySQL using 1 GB and ten workers of
+// processMB, pssMB each
+func planInputs(mpm string, processMB, pssMB float64) (*system.SystemInfo, *MemoryStats, *config.ApacheConfig) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096, HostMemoryMB: 4096, OtherServices: map[string]int{"MySQL": 1024}}
+	var workers []process.ProcessInfo
+	for i := 0; i < 10; i++ {
+		workers = append(workers, process.ProcessInfo{PID: 100 + i, MemoryMB: processMB, PSSMB: pssMB, Method: process.MethodSmaps})
+	}
+	return sysInfo, CalculateMemoryStats(workers), &config.ApacheConfig{MPMModel: mpm, MaxRequestWorkers: 150}
+}
+
+func TestPlanWorkers(t *testing.T) {
+	statusInfo := &status.ApacheStatus{RequestsPerSec: 5, AvgRequestTime: 250}
+	tests := []struct {
+		name        string
+		target      PlanTarget
+		statusInfo  *status.ApacheStatus
+		wantWorkers int
+		wantBasis   string
+		wantErr     bool
+	}{
+		{"concurrency", PlanTarget{Concurrency: 800}, nil, 800, "800 concurrent requests", false},
+		{"rate", PlanTarget{RatePerSec: 120}, statusInfo, 45, "120 req/s × 250 ms = 30.0 concurrent requests, plus 50% headroom", false},
+		{"rate without duration", PlanTarget{RatePerSec: 120}, &status.ApacheStatus{RequestsPerSec: 5}, 0, "", true},
+		{"no target", PlanTarget{}, statusInfo, 0, "", true},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			workers, basis, err := PlanWorkers(tt.target, tt.statusInfo)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("PlanWorkers() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if workers != tt.wantWorkers || basis != tt.wantBasis {
+				t.Errorf("PlanWorkers() = %d, %q; want %d, %q", workers, basis, tt.wantWorkers, tt.wantBasis)
+			}
+		})
+	}
+}
+
+func TestPlanCapacityReport(t *testing.T) {
+	sysInfo, memStats, apacheConfig := planInputs("prefork", 30, 12)
+	report, err := PlanCapacityReport(PlanTarget{Concurrency: 800}, sysInfo, memStats, apacheConfig, nil, DefaultPolicy())
+	if err != nil {
+		t.Fatalf("PlanCapacityReport() error = %v", err)
+	}
+
+	plan := report.Plan
+	if plan.MPMModel != "prefork" || plan.Processes != 800 || plan.ProcessMB != 30 || plan.Estimated {
+		t.Errorf("plan = %+v, want 800 measured prefork processes of 30 MB", plan)
+	}
+	if plan.ApacheMB != 26667 || plan.OtherMB != 1024 || plan.RequiredMB != 27691 || plan.Shortfall() != 27691-4096 {
+		t.Errorf("plan memory = %d + %d = %d MB, want 26667 + 1024 = 27691 MB", plan.ApacheMB, plan.OtherMB, plan.RequiredMB)
+	}
+	rec := plan.Recommendations
+	if rec.RecommendedMaxClients != 800 || rec.ServerLimit != 800 || len(rec.MPMBlock) == 0 {
+		t.Errorf("recommendations = %+v, want MaxRequestWorkers and ServerLimit 800 with an MPM block", rec)
+	}
+	for _, directive := range rec.MPMBlock {
+		if directive.Name == "MaxRequestWorkers" && directive.Rationale != "capacity plan: 800 concurrent requests" {
+			t.Errorf("MaxRequestWorkers rationale = %q", directive.Rationale)
+		}
+	}
+
+	// An event child keeps the shared 18 MB once and 12 MB per thread
+	if len(report.Alternatives) != 1 {
+		t.Fatalf("Alternatives = %d, want event", len(report.Alternatives))
+	}
+	event := report.Alternatives[0]
+	if event.MPMModel != "event" || event.ThreadsPerChild != 25 || event.Processes != 32 || !event.Estimated ||
+		math.Abs(event.ProcessMB-318) > 1e-9 || event.RequiredMB != 12331 {
+		t.Errorf("event plan = %+v, want 32 estimated children of 318 MB needing 12331 MB", event)
+	}
+	if report.Savings(event) != 27691-12331 {
+		t.Errorf("Savings() = %d", report.Savings(event))
+	}
+}
+
+func TestPlanCapacity_Reserve(t *testing.T) {
+	sysInfo, memStats, apacheConfig := planInputs("prefork", 30, 12)
+	policy := DefaultPolicy()
+	policy.Reserve = system.Reserve{MB: 512, Percent: 10}
+	plan, err := PlanCapacity(800, "800 concurrent requests", "prefork", 0, sysInfo, memStats, apacheConfig, nil, policy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 10% of the RAM solved for is more than 512 MB
+	if plan.RequiredMB != 30768 || plan.ReserveMB != 3077 {
+		t.Errorf("RequiredMB = %d, ReserveMB = %d; want 30768 and 3077", plan.RequiredMB, plan.ReserveMB)
+	}
+}
+
+func TestPlanCapacityReport_FromThreaded(t *testing.T) {
+	sysInfo, memStats, apacheConfig := planInputs("event", 120, 60)
+	apacheConfig.ThreadsPerChild = 25
+	report, err := PlanCapacityReport(PlanTarget{Concurrency: 500}, sysInfo, memStats, apacheConfig, nil, DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if report.Plan.Processes != 20 || report.Plan.Recommendations.RecommendedMaxClients != 500 {
+		t.Errorf("plan = %+v, want 20 children for 500 workers", report.Plan)
+	}
+	prefork := report.Alternatives[0]
+	if prefork.MPMModel != "prefork" || math.Abs(prefork.ProcessMB-62.4) > 1e-9 || report.Savings(prefork) >= 0 {
+		t.Errorf("prefork plan = %+v, want 62.4 MB processes needing more RAM", prefork)
+	}
+}
+
+func TestPlanCapacityReport_WithoutPSS(t *testing.T) {
+	sysInfo, memStats, apacheConfig := planInputs("prefork", 30, 0)
+	report, err := PlanCapacityReport(PlanTarget{Concurrency: 100}, sysInfo, memStats, apacheConfig, nil, DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(report.Alternatives) != 0 || len(report.Notes) != 1 || !strings.Contains(report.Notes[0], "needs their PSS") {
+		t.Errorf("report = %+v, want a note instead of an estimate", report)
+	}
+
+	_, memStats, _ = planInputs("prefork", 30, 0)
+	memStats.ProcessCount = 0
+	if _, err := PlanCapacityReport(PlanTarget{Concurrency: 100}, sysInfo, memStats, apacheConfig, nil, DefaultPolicy()); err == nil {
+		t.Error("PlanCapacityReport() without workers should fail")
+	}
+}
@@ -0,0 +1,205 @@
+package analysis
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"strconv"
+	"strings"
+
+	"apache2buddy-go/internal/debug"
+	"apache2buddy-go/internal/system"
+)
+
+// DefaultPolicyFile is the optional sizing policy read at startup
+const DefaultPolicyFile = "/etc/apache2buddy-go/policy.json"
+
+// Per-worker statistics MaxRequestWorkers can be sized on
+const (
+	SizeLargest = "largest" // Largest worker RSS (apache2buddy.pl behaviour)
+	SizeP99     = "p99"     // 99th percentile of worker RSS
+	SizeP95     = "p95"     // 95th percentile of worker RSS
+	SizeP90     = "p90"     // 90th percentile of worker RSS
+	SizeMedian  = "median"  // Median worker RSS
+	SizeAverage = "average" // Mean worker RSS
+	SizePSS     = "pss"     // Largest worker PSS, i.e. without shared pages counted repeatedly
+	SizeHistory = "history" // Largest worker RSS across the history window
+)
+
+// Policy is how conservatively Apache is sized: memory held back for the OS,
+// the band of the budget a configuration may fill, and the per-worker size
+type Policy struct {
+	Reserve system.Reserve
+
+	// MaxRequestWorkers filling up to TargetPercent of the budget is OK, up
+	// to LimitPercent a WARNING, beyond that CRITICAL. The recommendation is
+	// the TargetPercent figure.
+	TargetPercent float64
+	LimitPercent  float64
+
+	Sizing string // Size* statistic
+}
+
+// DefaultPolicy is apache2buddy's classic policy: no reserve, recommend 90%
+// of the budget, warn up to 100%, size on the largest worker
+func DefaultPolicy() Policy {
+	return Policy{TargetPercent: 90, LimitPercent: 100, Sizing: SizeLargest}
+}
+
+// Validate checks the policy for values that cannot be applied
+func (p Policy) Validate() error {
+	if p.Reserve.MB < 0 || p.Reserve.Percent < 0 || p.Reserve.Percent >= 100 {
+		return fmt.Errorf("reserve %s is out of range", p.Reserve)
+	}
+	if p.TargetPercent <= 0 || p.TargetPercent > p.LimitPercent {
+		return fmt.Errorf("margin %g-%g%% must satisfy 0 < low <= high", p.TargetPercent, p.LimitPercent)
+	}
+	switch p.Sizing {
+	case SizeLargest, SizeP99, SizeP95, SizeP90, SizeMedian, SizeAverage, SizePSS, SizeHistory:
+	default:
+		return fmt.Errorf("unknown sizing statistic %q (use %s, %s, %s, %s, %s, %s, %s or %s)", p.Sizing,
+			SizeLargest, SizeP99, SizeP95, SizeP90, SizeMedian, SizeAverage, SizePSS, SizeHistory)
+	}
+	return nil
+}
+
+// String summarises the policy for reports and history
+func (p Policy) String() string {
+	return fmt.Sprintf("reserve %s, margin %g-%g%%, sizing on %s", p.Reserve, p.TargetPercent, p.LimitPercent, p.Sizing)
+}
+
+// policyFile is the JSON form of a Policy; absent fields keep their defaults
+type policyFile struct {
+	Reserve *string `json:"reserve"` // "512", "512M", "10%"
+	Margin  *string `json:"margin"`  // "90-100"
+	Sizing  *string `json:"sizing"`
+}
+
+// LoadPolicy returns the default policy overridden by the JSON file at path.
+// A missing file is not an error; an invalid one yields the defaults and the
+// error.
+func LoadPolicy(path string) (Policy, error) {
+	policy := DefaultPolicy()
+	if path == "" {
+		return policy, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		debug.Printf("No policy file at %s", path)
+		return policy, nil
+	}
+	if err != nil {
+		return policy, fmt.Errorf("cannot read policy %s: %v", path, err)
+	}
+
+	var file policyFile
+	if err := json.Unmarshal(data, &file); err != nil {
+		return DefaultPolicy(), fmt.Errorf("policy %s: %v", path, err)
+	}
+	if file.Reserve != nil {
+		if policy.Reserve, err = ParseReserve(*file.Reserve); err != nil {
+			return DefaultPolicy(), fmt.Errorf("policy %s: %v", path, err)
+		}
+	}
+	if file.Margin != nil {
+		if policy.TargetPercent, policy.LimitPercent, err = ParseMargin(*file.Margin); err != nil {
+			return DefaultPolicy(), fmt.Errorf("policy %s: %v", path, err)
+		}
+	}
+	if file.Sizing != nil {
+		policy.Sizing = *file.Sizing
+	}
+	if err := policy.Validate(); err != nil {
+		return DefaultPolicy(), fmt.Errorf("policy %s: %v", path, err)
+	}
+	debug.Printf("Loaded policy from %s: %s", path, policy)
+	return policy, nil
+}
+
+// ParseReserve parses an OS reserve: megabytes ("512" or "512M"), gigabytes
+// ("2G"), a percentage of the memory limit ("10%"), or both ("512M,10%"),
+// in which case the larger applies
+func ParseReserve(value string) (system.Reserve, error) {
+	var reserve system.Reserve
+	for _, part := range strings.Split(value, ",") {
+		part = strings.ToUpper(strings.TrimSpace(part))
+		switch {
+		case part == "":
+		case strings.HasSuffix(part, "%"):
+			percent, err := strconv.ParseFloat(strings.TrimSuffix(part, "%"), 64)
+			if err != nil || percent < 0 || percent >= 100 {
+				return reserve, fmt.Errorf("invalid reserve percentage %q", part)
+			}
+			reserve.Percent = percent
+		default:
+			mb, err := ParseMemoryMB(part)
+			if err != nil {
+				return reserve, fmt.Errorf("invalid reserve %q", part)
+			}
+			reserve.MB = mb
+		}
+	}
+	return reserve, nil
+}
+
+// memorySuffixes are the units ParseMemoryMB accepts, in megabytes; longer
+// suffixes come first so that "GB" is not read as "B"
+var memorySuffixes = []struct {
+	suffix string
+	mb     int
+}{{"GB", 1024}, {"MB", 1}, {"G", 1024}, {"M", 1}}
+
+// ParseMemoryMB parses an amount of memory in megabytes ("512", "512M" or
+// "512MB") or gigabytes ("16G" or "16GB"), case-insensitively. Any other
+// suffix is an error.
+func ParseMemoryMB(value string) (int, error) {
+	number := strings.ToUpper(strings.TrimSpace(value))
+	multiplier := 1
+	for _, unit := range memorySuffixes {
+		if strings.HasSuffix(number, unit.suffix) {
+			number, multiplier = strings.TrimSuffix(number, unit.suffix), unit.mb
+			break
+		}
+	}
+	mb, err := strconv.Atoi(number)
+	if err != nil || mb < 0 {
+		return 0, fmt.Errorf("invalid amount of memory %q, want a whole number of M, MB, G or GB", value)
+	}
+	return mb * multiplier, nil
+}
+
+// ParseMargin parses a safety margin band such as "90-100" (percent of the budget)
+func ParseMargin(value string) (low, high float64, err error) {
+	lowText, highText, ok := strings.Cut(strings.TrimSuffix(strings.TrimSpace(value), "%"), "-")
+	if !ok {
+		return 0, 0, fmt.Errorf("invalid margin %q, want LOW-HIGH such as 90-100", value)
+	}
+	if low, err = strconv.ParseFloat(strings.TrimSpace(lowText), 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid margin %q: %v", value, err)
+	}
+	if high, err = strconv.ParseFloat(strings.TrimSpace(highText), 64); err != nil {
+		return 0, 0, fmt.Errorf("invalid margin %q: %v", value, err)
+	}
+	if low <= 0 || low > high {
+		return 0, 0, fmt.Errorf("invalid margin %q: need 0 < low <= high", value)
+	}
+	return low, high, nil
+}
+
+// workers returns how many workers of sizeMB fit in percent of availableMB
+func (p Policy) workers(availableMB int, sizeMB, percent float64) int {
+	if sizeMB <= 0 {
+		return 0
+	}
+	return int(float64(availableMB) / sizeMB * (percent / 100))
+}
+
+// TargetWorkers is the recommended worker count: TargetPercent of the budget
+func (p Policy) TargetWorkers(availableMB int, sizeMB float64) int {
+	return p.workers(availableMB, sizeMB, p.TargetPercent)
+}
+
+// LimitWorkers is the most workers acceptable: LimitPercent of the budget
+func (p Policy) LimitWorkers(availableMB int, sizeMB float64) int {
+	return p.workers(availableMB, sizeMB, p.LimitPercent)
+}
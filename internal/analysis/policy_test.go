@@ -0,0 +1,199 @@
+package analysis
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/system"
+)
+
+func TestParseReserve(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    system.Reserve
+		wantErr bool
+	}{
+		{value: "", want: system.Reserve{}},
+		{value: "512", want: system.Reserve{MB: 512}},
+		{value: "512M", want: system.Reserve{MB: 512}},
+		{value: "2g", want: system.Reserve{MB: 2048}},
+		{value: "10%", want: system.Reserve{Percent: 10}},
+		{value: "512M, 7.5%", want: system.Reserve{MB: 512, Percent: 7.5}},
+		{value: "100%", wantErr: true},
+		{value: "lots", wantErr: true},
+		{value: "-5", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseReserve(tt.value)
+		if (err != nil) != tt.wantErr {
+			t.Errorf("ParseReserve(%q) error = %v, wantErr %v", tt.value, err, tt.wantErr)
+			continue
+		}
+		if !tt.wantErr && got != tt.want {
+			t.Errorf("ParseReserve(%q) = %+v, want %+v", tt.value, got, tt.want)
+		}
+	}
+}
+
+func TestParseMemoryMB(t *testing.T) {
+	tests := []struct {
+		value   string
+		want    int
+		wantErr bool
+	}{
+		{value: "512", want: 512},
+		{value: "512M", want: 512},
+		{value: "512MB", want: 512},
+		{value: "512mb", want: 512},
+		{value: "16g", want: 16384},
+		{value: "16GB", want: 16384},
+		{value: "16Gb", want: 16384},
+		{value: " 2G ", want: 2048},
+		{value: "", wantErr: true},
+		{value: "lots", wantErr: true},
+		{value: "-1G", wantErr: true},
+		{value: "1.5G", wantErr: true},
+		{value: "16GM", wantErr: true},
+		{value: "16MG", wantErr: true},
+		{value: "16MM", wantErr: true},
+		{value: "16K", wantErr: true},
+		{value: "16B", wantErr: true},
+		{value: "GB", wantErr: true},
+	}
+	for _, tt := range tests {
+		got, err := ParseMemoryMB(tt.value)
+		if (err != nil) != tt.wantErr || got != tt.want {
+			t.Errorf("ParseMemoryMB(%q) = %d, %v; want %d, error %v", tt.value, got, err, tt.want, tt.wantErr)
+		}
+	}
+}
+
+func TestParseMargin(t *testing.T) {
+	if low, high, err := ParseMargin("80-95%"); err != nil || low != 80 || high != 95 {
+		t.Errorf("ParseMargin(80-95%%) = %g, %g, %v", low, high, err)
+	}
+	for _, bad := range []string{"90", "95-80", "0-100", "a-b"} {
+		if _, _, err := ParseMargin(bad); err == nil {
+			t.Errorf("ParseMargin(%q) should fail", bad)
+		}
+	}
+}
+
+func TestLoadPolicy(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	policy, err := LoadPolicy(filepath.Join(dir, "missing.json"))
+	if err != nil || policy != DefaultPolicy() {
+		t.Errorf("LoadPolicy(missing) = %+v, %v; want defaults", policy, err)
+	}
+
+	policy, err = LoadPolicy(write("partial.json", `{"reserve": "1G,10%", "sizing": "p95"}`))
+	if err != nil {
+		t.Fatalf("LoadPolicy() error = %v", err)
+	}
+	want := Policy{Reserve: system.Reserve{MB: 1024, Percent: 10}, TargetPercent: 90, LimitPercent: 100, Sizing: SizeP95}
+	if policy != want {
+		t.Errorf("LoadPolicy() = %+v, want %+v", policy, want)
+	}
+	if policy.String() != "reserve max(1024 MB, 10%), margin 90-100%, sizing on p95" {
+		t.Errorf("String() = %q", policy.String())
+	}
+
+	for name, content := range map[string]string{
+		"syntax.json": `{"reserve": `,
+		"margin.json": `{"margin": "100-90"}`,
+		"sizing.json": `{"sizing": "mode"}`,
+	} {
+		if policy, err := LoadPolicy(write(name, content)); err == nil {
+			t.Errorf("LoadPolicy(%s) should fail", name)
+		} else if policy != DefaultPolicy() {
+			t.Errorf("LoadPolicy(%s) should fall back to the defaults, got %+v", name, policy)
+		}
+	}
+}
+
+func TestMemoryStats_StatisticMB(t *testing.T) {
+	var workers []process.ProcessInfo
+	for i := 1; i <= 20; i++ {
+		workers = append(workers, process.ProcessInfo{PID: i, MemoryMB: float64(10 * i), PSSMB: float64(5 * i), Method: process.MethodSmaps})
+	}
+	stats := CalculateMemoryStats(workers)
+
+	tests := []struct {
+		statistic string
+		want      float64
+	}{
+		{"", 200},
+		{SizeLargest, 200},
+		{SizeP99, 200},
+		{SizeP95, 190},
+		{SizeP90, 180},
+		{SizeMedian, 105},
+		{SizeAverage, 105},
+		{SizePSS, 100},
+		{SizeHistory, 200},
+	}
+	for _, tt := range tests {
+		stats.Statistic = tt.statistic
+		if got := stats.StatisticMB(); got != tt.want {
+			t.Errorf("StatisticMB(%q) = %g, want %g", tt.statistic, got, tt.want)
+		}
+	}
+
+	// A leaking worker's projected peak still wins over a smaller statistic
+	stats.Statistic = SizeAverage
+	stats.ProjectedPeakMB = 150
+	if got := stats.SizingMB(); got != 150 {
+		t.Errorf("SizingMB() = %g, want projected peak 150", got)
+	}
+
+	// Without PSS, sizing on PSS falls back to the largest RSS
+	noPSS := &MemoryStats{LargestMB: 80, Statistic: SizePSS}
+	if got := noPSS.StatisticMB(); got != 80 {
+		t.Errorf("StatisticMB(pss) without PSS = %g, want 80", got)
+	}
+}
+
+func TestGenerateRecommendationsWithPolicy(t *testing.T) {
+	sysInfo := &system.SystemInfo{AvailableMemoryMB: 1000}
+	apacheConfig := &config.ApacheConfig{MaxRequestWorkers: 75, MPMModel: "prefork"}
+
+	tests := []struct {
+		name       string
+		policy     Policy
+		statistic  string
+		wantTarget int
+		wantLimit  int
+		wantStatus string
+	}{
+		{"default policy", DefaultPolicy(), "", 45, 50, "CRITICAL"},
+		{"wider band", Policy{TargetPercent: 70, LimitPercent: 160, Sizing: SizeLargest}, "", 35, 80, "WARNING"},
+		{"average sizing", DefaultPolicy(), SizeAverage, 90, 100, "OK"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			memStats := &MemoryStats{ProcessCount: 5, LargestMB: 20, AverageMB: 10, Statistic: tt.statistic}
+			rec := GenerateRecommendationsWithPolicy(sysInfo, memStats, apacheConfig, nil, 0, tt.policy)
+			if rec.MinRecommended != tt.wantTarget || rec.MaxRecommended != tt.wantLimit {
+				t.Errorf("range = %d-%d, want %d-%d", rec.MinRecommended, rec.MaxRecommended, tt.wantTarget, tt.wantLimit)
+			}
+			if rec.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", rec.Status, tt.wantStatus)
+			}
+			if rec.Policy == nil || !strings.Contains(rec.Policy.String(), "margin") {
+				t.Error("Recommendations should record the policy")
+			}
+		})
+	}
+}
@@ -0,0 +1,60 @@
+package analysis
+
+import (
+	"fmt"
+	"time"
+
+	"apache2buddy-go/internal/system"
+)
+
+// MemoryPressure is direct evidence that the server ran out of memory:
+// Apache workers in swap and processes killed by the OOM killer
+type MemoryPressure struct {
+	Swap           *system.SwapInfo
+	WorkerSwapMB   float64 // Swapped-out memory of this instance's workers
+	SwappedWorkers int
+
+	OOM              *system.OOMHistory
+	ApacheKills      []system.OOMEvent // OOM kills of this instance's Apache processes in the window
+	OtherApacheKills int               // OOM kills of Apache not attributed to this instance
+	OtherKills       int               // OOM kills of other processes in the window
+	Window           time.Duration     // OOM look-back window
+}
+
+// HostOOMKills are the OOM kills of Apache that no instance's cgroup claims,
+// because the log does not name the victim's cgroup or no running instance
+// uses it. The memory.host-oom rule reports them once for the host.
+type HostOOMKills struct {
+	Kills  []system.OOMEvent
+	Window time.Duration
+}
+
+// AssessMemoryPressure attaches the swap and OOM evidence to rec. OOM kills of
+// Apache count for the instance when they happened in its cgroup
+// (sysInfo.CgroupPath). The memory.pressure rule turns it into findings.
+func AssessMemoryPressure(rec *Recommendations, sysInfo *system.SystemInfo, memStats *MemoryStats) *MemoryPressure {
+	pressure := &MemoryPressure{
+		Swap:           sysInfo.Swap,
+		WorkerSwapMB:   memStats.SwapMB,
+		SwappedWorkers: memStats.SwappedWorkers,
+		OOM:            sysInfo.OOM,
+	}
+	if sysInfo.OOM != nil {
+		apacheKills := len(sysInfo.OOM.ApacheKills())
+		pressure.ApacheKills = sysInfo.OOM.ApacheKillsIn(sysInfo.CgroupPath)
+		pressure.OtherApacheKills = apacheKills - len(pressure.ApacheKills)
+		pressure.OtherKills = len(sysInfo.OOM.Events) - apacheKills
+		pressure.Window = sysInfo.OOM.Window
+	}
+	rec.Pressure = pressure
+	return pressure
+}
+
+// describeWindow formats a look-back window, in days when it is a whole number of days
+func describeWindow(window time.Duration) string {
+	day := 24 * time.Hour
+	if window >= day && window%day == 0 {
+		return fmt.Sprintf("%d days", window/day)
+	}
+	return window.String()
+}
@@ -0,0 +1,147 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"apache2buddy-go/internal/findings"
+	"apache2buddy-go/internal/system"
+)
+
+func TestAssessMemoryPressure(t *testing.T) {
+	apacheKill := system.OOMEvent{PID: 4242, Process: "apache2", Apache: true, TaskCgroup: "/system.slice/apache2.service"}
+	containerKill := system.OOMEvent{PID: 5151, Process: "httpd", Apache: true, TaskCgroup: "/system.slice/docker-abc.scope"}
+	unknownKill := system.OOMEvent{PID: 6262, Process: "apache2", Apache: true}
+	otherKill := system.OOMEvent{PID: 999, Process: "mysqld"}
+
+	tests := []struct {
+		name        string
+		status      string
+		memStats    *MemoryStats
+		events      []system.OOMEvent
+		wantStatus  string
+		wantIDs     []string
+		wantMessage string
+	}{
+		{
+			name:       "no evidence",
+			status:     "OK",
+			memStats:   &MemoryStats{},
+			wantStatus: "OK",
+		},
+		{
+			name:        "apache OOM kill overrides OK arithmetic",
+			status:      "OK",
+			memStats:    &MemoryStats{},
+			events:      []system.OOMEvent{apacheKill, otherKill},
+			wantStatus:  "CRITICAL",
+			wantIDs:     []string{"memory.oom-killed-apache", "memory.oom-killed-other"},
+			wantMessage: "The OOM killer killed Apache 1 time(s) in the last 7 days",
+		},
+		{
+			name:        "apache OOM kill with CRITICAL arithmetic",
+			status:      "CRITICAL",
+			memStats:    &MemoryStats{},
+			events:      []system.OOMEvent{apacheKill},
+			wantStatus:  "CRITICAL",
+			wantIDs:     []string{"memory.oom-killed-apache", "memory.over-budget"},
+			wantMessage: "The OOM killer killed Apache",
+		},
+		{
+			name:       "apache OOM kills outside the instance's cgroup are not its own",
+			status:     "OK",
+			memStats:   &MemoryStats{},
+			events:     []system.OOMEvent{containerKill, unknownKill},
+			wantStatus: "OK",
+		},
+		{
+			name:        "workers in swap",
+			status:      "OK",
+			memStats:    &MemoryStats{SwapMB: 120, SwappedWorkers: 3},
+			events:      []system.OOMEvent{otherKill},
+			wantStatus:  "WARNING",
+			wantIDs:     []string{"memory.oom-killed-other", "memory.workers-swapped"},
+			wantMessage: "3 Apache worker(s) have 120 MB swapped out",
+		},
+		{
+			name:        "other process killed",
+			status:      "OK",
+			memStats:    &MemoryStats{},
+			events:      []system.OOMEvent{otherKill},
+			wantStatus:  "WARNING",
+			wantIDs:     []string{"memory.oom-killed-other"},
+			wantMessage: "killed 1 other process(es)",
+		},
+		{
+			name:       "swap alongside a WARNING",
+			status:     "WARNING",
+			memStats:   &MemoryStats{SwapMB: 10, SwappedWorkers: 1},
+			wantStatus: "WARNING",
+			wantIDs:    []string{"memory.above-target", "memory.workers-swapped"},
+		},
+		{
+			name:       "ERROR is left alone",
+			status:     "ERROR",
+			memStats:   &MemoryStats{},
+			events:     []system.OOMEvent{apacheKill},
+			wantStatus: "ERROR",
+			wantIDs:    []string{"memory.oom-killed-apache", "memory.no-workers"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sysInfo := &system.SystemInfo{
+				CgroupPath: "/system.slice/apache2.service",
+				Swap:       &system.SwapInfo{TotalMB: 2048, FreeMB: 1024},
+				OOM:        &system.OOMHistory{Window: 7 * 24 * time.Hour, Sources: []string{"/var/log/kern.log"}, Events: tt.events},
+			}
+			rec := recWithStatus(tt.status)
+
+			pressure := AssessMemoryPressure(rec, sysInfo, tt.memStats)
+			if rec.Pressure != pressure {
+				t.Error("AssessMemoryPressure() should attach the pressure to the recommendations")
+			}
+			Evaluate(rec, Facts(sysInfo, tt.memStats, nil, rec, nil))
+			if rec.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", rec.Status, tt.wantStatus)
+			}
+			if got := ids(rec.Findings); !reflect.DeepEqual(got, tt.wantIDs) {
+				t.Errorf("findings = %v, want %v", got, tt.wantIDs)
+			}
+			if got := titles(rec.Findings); !strings.Contains(got, tt.wantMessage) {
+				t.Errorf("findings = %q, want one containing %q", got, tt.wantMessage)
+			}
+		})
+	}
+}
+
+func TestEvaluate_HostOOMKills(t *testing.T) {
+	kill := system.OOMEvent{PID: 6262, Process: "apache2", Apache: true, Source: "/var/log/kern.log"}
+	facts := findings.NewFacts()
+	findings.Put(facts, HostOOMKills{Kills: []system.OOMEvent{kill}, Window: 7 * 24 * time.Hour})
+
+	got := findings.Evaluate(facts)
+	if want := []string{"memory.oom-killed-apache"}; !reflect.DeepEqual(ids(got), want) {
+		t.Fatalf("findings = %v, want %v", ids(got), want)
+	}
+	if got[0].Title != "The OOM killer killed Apache 1 time(s) in the last 7 days" || findings.ExitCode(got) != 2 {
+		t.Errorf("finding = %+v", got[0])
+	}
+
+	findings.Put(facts, HostOOMKills{Window: 7 * 24 * time.Hour})
+	if got := findings.Evaluate(facts); len(got) != 0 {
+		t.Errorf("findings without kills = %v, want none", ids(got))
+	}
+}
+
+func TestDescribeWindow(t *testing.T) {
+	if got := describeWindow(72 * time.Hour); got != "3 days" {
+		t.Errorf("describeWindow(72h) = %q, want \"3 days\"", got)
+	}
+	if got := describeWindow(36 * time.Hour); got != "36h0m0s" {
+		t.Errorf("describeWindow(36h) = %q, want \"36h0m0s\"", got)
+	}
+}
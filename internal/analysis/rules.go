@@ -0,0 +1,470 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/findings"
+	"apache2buddy-go/internal/status"
+	"apache2buddy-go/internal/system"
+)
+
+func init() {
+	findings.RegisterFunc("memory.sizing", checkSizing)
+	findings.RegisterFunc("memory.pressure", checkPressure)
+	findings.RegisterFunc("memory.host-oom", checkHostOOMKills)
+	findings.RegisterFunc("memory.outliers", checkOutliers)
+	findings.RegisterFunc("cpu.ceiling", checkCPUCeiling)
+	findings.RegisterFunc("limits", checkLimits)
+	findings.RegisterFunc("demand", checkDemand)
+	findings.RegisterFunc("history", checkHistory)
+	findings.RegisterFunc("configuration.vhosts", checkVirtualHosts)
+	findings.RegisterFunc("configuration.mpm", checkThreadedMPM)
+	findings.RegisterFunc("configuration.migration", checkMigration)
+	findings.RegisterFunc("configuration.panel", checkControlPanel)
+	findings.RegisterFunc("phpfpm.pool", checkPHPFPMPools)
+}
+
+// Facts collects what the analysis rules check. Packages with rules of their
+// own add their facts to the bag.
+func Facts(sysInfo *system.SystemInfo, memStats *MemoryStats, apacheConfig *config.ApacheConfig, rec *Recommendations, statusInfo *status.ApacheStatus) *findings.Facts {
+	facts := findings.NewFacts()
+	findings.Put(facts, sysInfo)
+	findings.Put(facts, memStats)
+	findings.Put(facts, apacheConfig)
+	findings.Put(facts, rec)
+	findings.Put(facts, statusInfo)
+	return facts
+}
+
+// Evaluate runs every registered rule against facts, stores the findings in
+// rec and derives rec.Status from them. An ERROR status is kept.
+func Evaluate(rec *Recommendations, facts *findings.Facts) []findings.Finding {
+	rec.Findings = findings.Evaluate(facts)
+	if rec.Findings == nil {
+		rec.Findings = []findings.Finding{}
+	}
+	if rec.Status != "ERROR" {
+		rec.Status = findings.Status(rec.Findings)
+	}
+	return rec.Findings
+}
+
+// checkSizing compares MaxRequestWorkers with the memory margin band
+func checkSizing(facts *findings.Facts) []findings.Finding {
+	rec, ok := findings.Get[*Recommendations](facts)
+	if !ok {
+		return nil
+	}
+	if rec.Status == "ERROR" {
+		return []findings.Finding{{
+			ID:       "memory.no-workers",
+			Severity: findings.SeverityInfo,
+			Category: findings.CategoryMemory,
+			Title:    "No worker processes to analyze",
+		}}
+	}
+
+	if rec.NoRoom {
+		finding := findings.Finding{
+			ID:          "memory.no-room-for-worker",
+			Severity:    findings.SeverityCritical,
+			Category:    findings.CategoryMemory,
+			Title:       "The memory available cannot hold a single worker",
+			Detail:      "The recommendation is the minimum of one child, which already exceeds the budget.",
+			Remediation: "Add RAM, lower the reserve or move other services off this host.",
+		}
+		sysInfo, hasSys := findings.Get[*system.SystemInfo](facts)
+		memStats, hasStats := findings.Get[*MemoryStats](facts)
+		if hasSys && hasStats && sysInfo != nil && memStats != nil {
+			finding.Evidence = []string{fmt.Sprintf("%d MB available, %.1f MB per child", sysInfo.AvailableMemoryMB, memStats.SizingMB())}
+		}
+		return []findings.Finding{finding}
+	}
+
+	// The CPU ceiling narrows the band; memory is judged on its own
+	minWorkers, maxWorkers := rec.MinRecommended, rec.MaxRecommended
+	if rec.Binding == BindingCPU && rec.CPU != nil {
+		minWorkers, maxWorkers = rec.CPU.MemoryMinWorkers, rec.CPU.MemoryMaxWorkers
+	}
+	evidence := []string{
+		fmt.Sprintf("Memory budget fits %d-%d workers", minWorkers, maxWorkers),
+		fmt.Sprintf("All %d workers busy would use %.1f%% of the memory available", rec.CurrentMaxClients, rec.UtilizationPercent),
+	}
+	switch {
+	case rec.CurrentMaxClients > maxWorkers:
+		return []findings.Finding{{
+			ID:          "memory.over-budget",
+			Severity:    findings.SeverityCritical,
+			Category:    findings.CategoryMemory,
+			Title:       fmt.Sprintf("MaxRequestWorkers %d is more than the memory available can hold", rec.CurrentMaxClients),
+			Detail:      "Under full load the server would swap or the OOM killer would kill workers.",
+			Evidence:    evidence,
+			Remediation: fmt.Sprintf("Reduce MaxRequestWorkers to %d to prevent memory issues.", rec.RecommendedMaxClients),
+		}}
+	case rec.CurrentMaxClients > minWorkers:
+		return []findings.Finding{{
+			ID:          "memory.above-target",
+			Severity:    findings.SeverityWarning,
+			Category:    findings.CategoryMemory,
+			Title:       fmt.Sprintf("MaxRequestWorkers %d is on the high side of the memory budget", rec.CurrentMaxClients),
+			Detail:      "It fits, but leaves less than the policy's safety margin.",
+			Evidence:    evidence,
+			Remediation: fmt.Sprintf("Consider reducing MaxRequestWorkers to %d to prevent memory issues.", rec.RecommendedMaxClients),
+		}}
+	}
+	return nil
+}
+
+// checkPressure reports swap and OOM-killer evidence. An OOM kill of Apache
+// is critical whatever the arithmetic says.
+func checkPressure(facts *findings.Facts) []findings.Finding {
+	rec, ok := findings.Get[*Recommendations](facts)
+	if !ok || rec.Pressure == nil {
+		return nil
+	}
+	pressure := rec.Pressure
+
+	var list []findings.Finding
+	if len(pressure.ApacheKills) > 0 {
+		remediation := "Reduce MaxRequestWorkers below the current value: the measured worker sizes understate real usage."
+		if rec.RecommendedMaxClients < rec.CurrentMaxClients {
+			remediation = fmt.Sprintf("Reduce MaxRequestWorkers to %d to prevent memory issues.", rec.RecommendedMaxClients)
+		}
+		list = append(list, findings.Finding{
+			ID:          "memory.oom-killed-apache",
+			Severity:    findings.SeverityCritical,
+			Category:    findings.CategoryMemory,
+			Title:       fmt.Sprintf("The OOM killer killed Apache %d time(s) in the last %s", len(pressure.ApacheKills), describeWindow(pressure.Window)),
+			Detail:      "MaxRequestWorkers is too high for the memory available.",
+			Evidence:    oomEvidence(pressure.ApacheKills),
+			Remediation: remediation,
+		})
+	}
+	if pressure.SwappedWorkers > 0 {
+		list = append(list, findings.Finding{
+			ID:          "memory.workers-swapped",
+			Severity:    findings.SeverityWarning,
+			Category:    findings.CategoryMemory,
+			Title:       fmt.Sprintf("%d Apache worker(s) have %.0f MB swapped out", pressure.SwappedWorkers, pressure.WorkerSwapMB),
+			Detail:      "The server is short of memory; swapped workers answer slowly.",
+			Remediation: "Lower MaxRequestWorkers or free memory used by other services.",
+		})
+	}
+	if pressure.OtherKills > 0 {
+		list = append(list, findings.Finding{
+			ID:          "memory.oom-killed-other",
+			Severity:    findings.SeverityWarning,
+			Category:    findings.CategoryMemory,
+			Title:       fmt.Sprintf("The OOM killer killed %d other process(es) in the last %s", pressure.OtherKills, describeWindow(pressure.Window)),
+			Detail:      "The server is short of memory.",
+			Remediation: "Lower MaxRequestWorkers or free memory used by other services.",
+		})
+	}
+	return list
+}
+
+// checkHostOOMKills reports the OOM kills of Apache no instance could be
+// charged with. They are as critical as an instance's own, but only once.
+func checkHostOOMKills(facts *findings.Facts) []findings.Finding {
+	host, ok := findings.Get[HostOOMKills](facts)
+	if !ok || len(host.Kills) == 0 {
+		return nil
+	}
+	return []findings.Finding{{
+		ID:          "memory.oom-killed-apache",
+		Severity:    findings.SeverityCritical,
+		Category:    findings.CategoryMemory,
+		Title:       fmt.Sprintf("The OOM killer killed Apache %d time(s) in the last %s", len(host.Kills), describeWindow(host.Window)),
+		Detail:      "The kernel log does not tie the kills to a running instance's cgroup, so no instance is charged with them.",
+		Evidence:    oomEvidence(host.Kills),
+		Remediation: "Lower MaxRequestWorkers of the instance whose workers were killed.",
+	}}
+}
+
+// oomEvidence lists OOM kills with where they were read from
+func oomEvidence(events []system.OOMEvent) []string {
+	var evidence []string
+	for _, event := range events {
+		line := fmt.Sprintf("%s %s (PID %d), from %s",
+			event.Time.Format("2006-01-02 15:04:05"), event.Process, event.PID, event.Source)
+		if event.TaskCgroup != "" {
+			line += ", cgroup " + event.TaskCgroup
+		}
+		evidence = append(evidence, line)
+	}
+	return evidence
+}
+
+// checkOutliers notes workers much larger than the rest, which decide the
+// recommendation when sizing on the largest worker
+func checkOutliers(facts *findings.Facts) []findings.Finding {
+	memStats, ok := findings.Get[*MemoryStats](facts)
+	if !ok || len(memStats.Outliers) == 0 {
+		return nil
+	}
+	var evidence []string
+	for _, outlier := range memStats.Outliers {
+		line := fmt.Sprintf("PID %d: %.1f MB", outlier.PID, outlier.MemoryMB)
+		if outlier.Request != "" {
+			line += fmt.Sprintf(", serving %s", outlier.Request)
+			if outlier.VHost != "" {
+				line += " on " + outlier.VHost
+			}
+		}
+		evidence = append(evidence, line)
+	}
+	finding := findings.Finding{
+		ID:       "memory.outlier-workers",
+		Severity: findings.SeverityInfo,
+		Category: findings.CategoryMemory,
+		Title:    fmt.Sprintf("%d worker(s) are much larger than the median of %.1f MB", len(memStats.Outliers), memStats.MedianMB),
+		Evidence: evidence,
+	}
+	if memStats.Statistic == "" || memStats.Statistic == SizeLargest {
+		finding.Detail = "Sizing on the largest worker lets them decide MaxRequestWorkers."
+		statistic, size := outlierFreeStatistic(memStats)
+		finding.Remediation = fmt.Sprintf("If such requests are rare, consider -size-on %s (%.1f MB) instead.", statistic, size)
+	}
+	return []findings.Finding{finding}
+}
+
+// outlierFreeStatistic returns the highest percentile below the smallest
+// outlier; with few workers only the median leaves them out
+func outlierFreeStatistic(memStats *MemoryStats) (string, float64) {
+	smallest := memStats.Outliers[len(memStats.Outliers)-1].MemoryMB
+	for _, candidate := range []struct {
+		statistic string
+		size      float64
+	}{
+		{SizeP99, memStats.P99MB},
+		{SizeP95, memStats.P95MB},
+		{SizeP90, memStats.P90MB},
+	} {
+		if candidate.size < smallest {
+			return candidate.statistic, candidate.size
+		}
+	}
+	return SizeMedian, memStats.MedianMB
+}
+
+// checkCPUCeiling reports a MaxRequestWorkers the CPUs cannot serve. An
+// overloaded CPU slows requests down rather than taking the server down, so
+// this is a warning.
+func checkCPUCeiling(facts *findings.Facts) []findings.Finding {
+	rec, ok := findings.Get[*Recommendations](facts)
+	if !ok || rec.CPU == nil || rec.Binding != BindingCPU || rec.CurrentMaxClients <= rec.CPU.TargetWorkers {
+		return nil
+	}
+	cpu := rec.CPU
+	return []findings.Finding{{
+		ID:       "cpu.ceiling",
+		Severity: findings.SeverityWarning,
+		Category: findings.CategoryCPU,
+		Title: fmt.Sprintf("MaxRequestWorkers %d is more than %g CPUs can serve (about %d busy workers)",
+			rec.CurrentMaxClients, cpu.CPUs, cpu.TargetWorkers),
+		Evidence: []string{
+			fmt.Sprintf("Apache used %.2f cores (from %s) over %.1f busy workers (from %s)", cpu.ApacheCores, cpu.ApacheSource, cpu.BusyWorkers, cpu.BusySource),
+			fmt.Sprintf("Memory would allow %d workers", cpu.MemoryWorkers),
+		},
+		Remediation: fmt.Sprintf("Consider reducing MaxRequestWorkers to %d: the CPUs cannot serve more busy workers.", rec.RecommendedMaxClients),
+	}}
+}
+
+// checkLimits reports each kernel or service limit the configuration would run into
+func checkLimits(facts *findings.Facts) []findings.Finding {
+	rec, ok := findings.Get[*Recommendations](facts)
+	if !ok {
+		return nil
+	}
+	var list []findings.Finding
+	for _, check := range rec.Limits {
+		if check.Status == "OK" {
+			continue
+		}
+		severity := findings.SeverityWarning
+		if check.Status == "CRITICAL" {
+			severity = findings.SeverityCritical
+		}
+		list = append(list, findings.Finding{
+			ID:          "limits." + check.ID,
+			Severity:    severity,
+			Category:    findings.CategoryLimits,
+			Title:       fmt.Sprintf("%s (%s) is too low for the configuration", check.Limit, check.Value),
+			Detail:      check.Message,
+			Evidence:    []string{"Needs " + check.Needed},
+			Remediation: check.Fix,
+		})
+	}
+	return list
+}
+
+// checkDemand reports traffic that needs more workers than the ceiling
+// allows: no tuning fits, the server needs more resources or the load must
+// be spread
+func checkDemand(facts *findings.Facts) []findings.Finding {
+	rec, ok := findings.Get[*Recommendations](facts)
+	if !ok || rec.Demand == nil || !rec.Demand.Exceeded() {
+		return nil
+	}
+	demand := rec.Demand
+	return []findings.Finding{{
+		ID:       "demand.exceeds-ceiling",
+		Severity: findings.SeverityWarning,
+		Category: findings.CategoryDemand,
+		Title: fmt.Sprintf("Peak demand needs about %d workers but the %s ceiling is %d",
+			demand.Workers, demand.Binding, demand.Ceiling),
+		Detail: "Tuning cannot close the gap.",
+		Evidence: []string{
+			fmt.Sprintf("%.2f req/s (%s) × %.0f ms per request", demand.RatePerSec, demand.RateSource, demand.DurationMS),
+		},
+		Remediation: "Either " + demand.Remedy() + ".",
+	}}
+}
+
+// checkHistory reports a recommendation held back by the busiest run in the
+// history window, and a busy-hour peak the recommendation could not serve
+func checkHistory(facts *findings.Facts) []findings.Finding {
+	rec, ok := findings.Get[*Recommendations](facts)
+	if !ok || rec.History == nil || rec.Status == "ERROR" {
+		return nil
+	}
+	peak := rec.History
+	var list []findings.Finding
+	if peak.CappedFrom > 0 {
+		list = append(list, findings.Finding{
+			ID:       "memory.history-held",
+			Severity: findings.SeverityInfo,
+			Category: findings.CategoryMemory,
+			Title:    fmt.Sprintf("MaxRequestWorkers held at %d: this run alone would allow %d", rec.RecommendedMaxClients, peak.CappedFrom),
+			Detail:   fmt.Sprintf("Workers are smaller now than at the busiest of %d runs in the last %d days.", peak.Runs, peak.Days),
+			Evidence: []string{
+				fmt.Sprintf("Largest worker %.1f MB on %s", peak.LargestMB, peak.LargestAt.Format("2006-01-02 15:04")),
+				fmt.Sprintf("At that size the memory holds %d workers", peak.SupportedWorkers),
+			},
+		})
+	}
+	if peak.BusyWorkers > rec.RecommendedMaxClients {
+		list = append(list, findings.Finding{
+			ID:       "demand.history-busy-peak",
+			Severity: findings.SeverityInfo,
+			Category: findings.CategoryDemand,
+			Title: fmt.Sprintf("%d workers were busy on %s, more than the %d recommended",
+				peak.BusyWorkers, peak.BusyAt.Format("2006-01-02 15:04"), rec.RecommendedMaxClients),
+			Detail:      "At that load requests would queue for a free worker.",
+			Remediation: "Add memory or make workers smaller before lowering MaxRequestWorkers.",
+		})
+	}
+	return list
+}
+
+// checkVirtualHosts notes more virtual hosts than workers to serve them
+func checkVirtualHosts(facts *findings.Facts) []findings.Finding {
+	rec, ok := findings.Get[*Recommendations](facts)
+	if !ok || rec.Status == "ERROR" || rec.VirtualHosts <= rec.MaxRecommended {
+		return nil
+	}
+	return []findings.Finding{{
+		ID:       "configuration.vhosts-exceed-workers",
+		Severity: findings.SeverityInfo,
+		Category: findings.CategoryConfiguration,
+		Title:    fmt.Sprintf("%d virtual hosts share at most %d workers", rec.VirtualHosts, rec.MaxRecommended),
+		Detail:   "Busy sites can take every worker and leave the others waiting.",
+	}}
+}
+
+// checkThreadedMPM reminds that threaded MPMs hand work to backends this
+// analysis does not size (like the original apache2buddy.pl)
+func checkThreadedMPM(facts *findings.Facts) []findings.Finding {
+	apacheConfig, ok := findings.Get[*config.ApacheConfig](facts)
+	if !ok || !IsThreaded(apacheConfig.MPMModel) {
+		return nil
+	}
+	return []findings.Finding{{
+		ID:       "configuration.threaded-mpm",
+		Severity: findings.SeverityInfo,
+		Category: findings.CategoryConfiguration,
+		Title:    fmt.Sprintf("Apache is running in %s mode", apacheConfig.MPMModel),
+		Detail:   "Check manually for backend processes such as PHP-FPM and pm.max_children.",
+	}}
+}
+
+// checkMigration reports what moving prefork + mod_php to event + PHP-FPM
+// would save per concurrent request
+func checkMigration(facts *findings.Facts) []findings.Finding {
+	rec, ok := findings.Get[*Recommendations](facts)
+	if !ok || rec.Migration == nil || rec.Migration.SavingsPercent() <= 0 {
+		return nil
+	}
+	advice := rec.Migration
+	finding := findings.Finding{
+		ID:       "configuration.event-migration",
+		Severity: findings.SeverityInfo,
+		Category: findings.CategoryConfiguration,
+		Title: fmt.Sprintf("Moving to event + PHP-FPM would cut memory per request from %.1f to %.1f MB (%.0f%% less)",
+			advice.PreforkMB, advice.PerRequestMB(), advice.SavingsPercent()),
+		Detail:      fmt.Sprintf("%d concurrent requests would need %d MB instead of %d MB.", advice.Workers, advice.ExpectedMB, advice.TodayMB),
+		Remediation: "See the MPM migration section for the prerequisites.",
+	}
+	if blockers := advice.Blockers(); len(blockers) > 0 {
+		finding.Evidence = []string{"Blocked by: " + strings.Join(blockers, ", ")}
+	}
+	return []findings.Finding{finding}
+}
+
+// checkControlPanel notes the panel that regenerates the configuration, so
+// that changes are made where they survive
+func checkControlPanel(facts *findings.Facts) []findings.Finding {
+	apacheConfig, ok := findings.Get[*config.ApacheConfig](facts)
+	if !ok || apacheConfig.ControlPanel == nil {
+		return nil
+	}
+	panel := apacheConfig.ControlPanel
+	finding := findings.Finding{
+		ID:       "configuration.control-panel",
+		Severity: findings.SeverityInfo,
+		Category: findings.CategoryConfiguration,
+		Title:    fmt.Sprintf("Apache is managed by %s", panel.Name),
+	}
+	if panel.Managed != "" {
+		finding.Detail = fmt.Sprintf("%s regenerates %s: make changes where the report says, not in the generated files.", panel.Name, panel.Managed)
+	}
+	return []findings.Finding{finding}
+}
+
+// checkPHPFPMPools reports each pool whose pm.max_children is above its
+// share of the memory PHP-FPM has left, like checkSizing does for Apache.
+// The pool name ends the ID so that each pool is acknowledged on its own.
+func checkPHPFPMPools(facts *findings.Facts) []findings.Finding {
+	pools, ok := findings.Get[[]PoolRecommendation](facts)
+	if !ok {
+		return nil
+	}
+	var list []findings.Finding
+	for _, rec := range pools {
+		finding := findings.Finding{
+			Category: findings.CategoryPHPFPM,
+			Evidence: []string{
+				fmt.Sprintf("Budget %d MB at %.1f MB per child (%s)", rec.BudgetMB, rec.ChildMB, rec.Statistic),
+				fmt.Sprintf("Recommended range: %d-%d", rec.RecommendedMaxChildren, rec.MaxRecommended),
+			},
+			Remediation: fmt.Sprintf("Set pm.max_children = %d in %s.", rec.RecommendedMaxChildren, rec.Pool.ConfigFile),
+		}
+		switch rec.Status {
+		case "WARNING":
+			finding.ID = "phpfpm.above-target." + rec.Pool.Name
+			finding.Severity = findings.SeverityWarning
+			finding.Title = fmt.Sprintf("PHP-FPM pool %s: pm.max_children %d is above the recommended %d",
+				rec.Pool.Name, rec.Pool.MaxChildren, rec.RecommendedMaxChildren)
+		case "CRITICAL":
+			finding.ID = "phpfpm.over-budget." + rec.Pool.Name
+			finding.Severity = findings.SeverityCritical
+			finding.Title = fmt.Sprintf("PHP-FPM pool %s: %s", rec.Pool.Name, rec.Message)
+		default:
+			continue
+		}
+		list = append(list, finding)
+	}
+	return list
+}
@@ -0,0 +1,210 @@
+package analysis
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/findings"
+	"apache2buddy-go/internal/system"
+)
+
+// recWithStatus returns recommendations whose memory arithmetic gives status
+func recWithStatus(status string) *Recommendations {
+	rec := &Recommendations{CurrentMaxClients: 10, RecommendedMaxClients: 100, MinRecommended: 100, MaxRecommended: 110}
+	switch status {
+	case "WARNING":
+		rec.CurrentMaxClients = 105
+	case "CRITICAL":
+		rec.CurrentMaxClients = 200
+	case "ERROR":
+		rec = &Recommendations{Status: "ERROR"}
+	}
+	return rec
+}
+
+// ids returns the IDs of list in order
+func ids(list []findings.Finding) []string {
+	var result []string
+	for _, finding := range list {
+		result = append(result, finding.ID)
+	}
+	return result
+}
+
+// titles joins the titles of list, one per line
+func titles(list []findings.Finding) string {
+	var result []string
+	for _, finding := range list {
+		result = append(result, finding.Title)
+	}
+	return strings.Join(result, "\n")
+}
+
+func TestEvaluate_Sizing(t *testing.T) {
+	tests := []struct {
+		name            string
+		status          string
+		wantStatus      string
+		wantIDs         []string
+		wantRemediation string
+	}{
+		{name: "within the target", status: "OK", wantStatus: "OK"},
+		{
+			name: "within the limit", status: "WARNING", wantStatus: "WARNING", wantIDs: []string{"memory.above-target"},
+			wantRemediation: "Consider reducing MaxRequestWorkers to 100 to prevent memory issues.",
+		},
+		{
+			name: "over the limit", status: "CRITICAL", wantStatus: "CRITICAL", wantIDs: []string{"memory.over-budget"},
+			wantRemediation: "Reduce MaxRequestWorkers to 100 to prevent memory issues.",
+		},
+		{name: "nothing to analyze", status: "ERROR", wantStatus: "ERROR", wantIDs: []string{"memory.no-workers"}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rec := recWithStatus(tt.status)
+			got := Evaluate(rec, Facts(nil, nil, nil, rec, nil))
+			if rec.Status != tt.wantStatus {
+				t.Errorf("Status = %s, want %s", rec.Status, tt.wantStatus)
+			}
+			if !reflect.DeepEqual(ids(got), tt.wantIDs) {
+				t.Errorf("findings = %v, want %v", ids(got), tt.wantIDs)
+			}
+			if tt.wantRemediation != "" && got[0].Remediation != tt.wantRemediation {
+				t.Errorf("Remediation = %q, want %q", got[0].Remediation, tt.wantRemediation)
+			}
+			if rec.Findings == nil {
+				t.Error("Evaluate() should leave non-nil findings")
+			}
+		})
+	}
+}
+
+func TestEvaluate_CPUBindingKeepsMemoryBand(t *testing.T) {
+	// Within the memory band, above the CPU ceiling: a CPU warning only
+	rec := &Recommendations{CurrentMaxClients: 90, RecommendedMaxClients: 100, MinRecommended: 100, MaxRecommended: 111}
+	ApplyCPUCeiling(rec, &CPUAnalysis{CPUs: 2, TargetWorkers: 36, LimitWorkers: 40})
+	Evaluate(rec, Facts(nil, nil, nil, rec, nil))
+	if got := ids(rec.Findings); !reflect.DeepEqual(got, []string{"cpu.ceiling"}) {
+		t.Errorf("findings = %v, want only cpu.ceiling", got)
+	}
+	if rec.Status != "WARNING" {
+		t.Errorf("Status = %s, want WARNING", rec.Status)
+	}
+}
+
+func TestEvaluate_ConfigurationNotes(t *testing.T) {
+	rec := &Recommendations{CurrentMaxClients: 50, RecommendedMaxClients: 100, MinRecommended: 100, MaxRecommended: 110, VirtualHosts: 150}
+	apacheConfig := &config.ApacheConfig{MPMModel: "event"}
+	got := Evaluate(rec, Facts(nil, nil, apacheConfig, rec, nil))
+
+	if want := []string{"configuration.threaded-mpm", "configuration.vhosts-exceed-workers"}; !reflect.DeepEqual(ids(got), want) {
+		t.Fatalf("findings = %v, want %v", ids(got), want)
+	}
+	if rec.Status != "OK" {
+		t.Errorf("Status = %s, want OK: notes do not change the status", rec.Status)
+	}
+	if got[0].Title != "Apache is running in event mode" || got[1].Title != "150 virtual hosts share at most 110 workers" {
+		t.Errorf("titles = %q", titles(got))
+	}
+}
+
+func TestEvaluate_ControlPanel(t *testing.T) {
+	rec := recWithStatus("OK")
+	apacheConfig := &config.ApacheConfig{MPMModel: "prefork", ControlPanel: &config.ControlPanel{Name: "cPanel", Managed: "httpd.conf"}}
+	got := Evaluate(rec, Facts(nil, nil, apacheConfig, rec, nil))
+	if len(got) != 1 || got[0].ID != "configuration.control-panel" || got[0].Title != "Apache is managed by cPanel" ||
+		!strings.Contains(got[0].Detail, "cPanel regenerates httpd.conf") {
+		t.Fatalf("findings = %+v", got)
+	}
+	if rec.Status != "OK" {
+		t.Errorf("Status = %s, want OK", rec.Status)
+	}
+}
+
+func TestEvaluate_PHPFPMPools(t *testing.T) {
+	pools := []PoolRecommendation{
+		{Pool: system.PHPFPMPool{Name: "www", MaxChildren: 50, ConfigFile: "/etc/php/8.3/fpm/pool.d/www.conf"}, BudgetMB: 1200,
+			ChildMB: 61.5, Statistic: SizeLargest, RecommendedMaxChildren: 17, MaxRecommended: 19, Status: "CRITICAL",
+			Message: "pm.max_children 50 could use 3075 MB, more than the 1200 MB available"},
+		{Pool: system.PHPFPMPool{Name: "api", MaxChildren: 18}, RecommendedMaxChildren: 17, MaxRecommended: 19, Status: "WARNING"},
+		{Pool: system.PHPFPMPool{Name: "admin", MaxChildren: 5}, RecommendedMaxChildren: 17, MaxRecommended: 19, Status: "OK"},
+		{Pool: system.PHPFPMPool{Name: "cron"}, Status: "UNKNOWN"},
+	}
+	facts := findings.NewFacts()
+	findings.Put(facts, pools)
+	got := findings.Evaluate(facts)
+	if want := []string{"phpfpm.over-budget.www", "phpfpm.above-target.api"}; !reflect.DeepEqual(ids(got), want) {
+		t.Fatalf("findings = %v, want %v", ids(got), want)
+	}
+	if got[0].Title != "PHP-FPM pool www: pm.max_children 50 could use 3075 MB, more than the 1200 MB available" ||
+		got[0].Remediation != "Set pm.max_children = 17 in /etc/php/8.3/fpm/pool.d/www.conf." ||
+		got[1].Title != "PHP-FPM pool api: pm.max_children 18 is above the recommended 17" {
+		t.Errorf("titles = %q", titles(got))
+	}
+	if findings.ExitCode(got) != 2 {
+		t.Errorf("ExitCode() = %d, want 2", findings.ExitCode(got))
+	}
+
+	findings.Put(facts, findings.Suppressions{{ID: "phpfpm.over-budget.www"}})
+	if got := findings.Evaluate(facts); findings.ExitCode(got) != 1 {
+		t.Errorf("ExitCode() with the over-budget pool acknowledged = %d, want 1", findings.ExitCode(got))
+	}
+}
+
+func TestEvaluate_PHPFPMPoolsAcknowledgedApart(t *testing.T) {
+	over := func(name string) PoolRecommendation {
+		return PoolRecommendation{Pool: system.PHPFPMPool{Name: name, MaxChildren: 50},
+			RecommendedMaxChildren: 17, MaxRecommended: 19, Status: "CRITICAL"}
+	}
+	facts := findings.NewFacts()
+	findings.Put(facts, []PoolRecommendation{over("www"), over("shop")})
+	findings.Put(facts, findings.Suppressions{{ID: "phpfpm.over-budget.www"}})
+
+	got := findings.Evaluate(facts)
+	if want := []string{"phpfpm.over-budget.shop", "phpfpm.over-budget.www"}; !reflect.DeepEqual(ids(got), want) {
+		t.Fatalf("findings = %v, want %v", ids(got), want)
+	}
+	if got[0].Acknowledged != nil || got[1].Acknowledged == nil {
+		t.Errorf("acknowledged = %v/%v, want only the www pool", got[0].Acknowledged, got[1].Acknowledged)
+	}
+	if findings.ExitCode(got) != 2 {
+		t.Errorf("ExitCode() = %d, want 2 for the shop pool", findings.ExitCode(got))
+	}
+}
+
+func TestEvaluate_OutlierWorkers(t *testing.T) {
+	memStats := CalculateMemoryStats(workersOf(30, 31, 29, 30, 32, 31, 180))
+	memStats.Outliers[0].Request = "POST /upload HTTP/1.1"
+	memStats.Outliers[0].VHost = "shop.example.com"
+	rec := recWithStatus("OK")
+	got := Evaluate(rec, Facts(nil, memStats, nil, rec, nil))
+
+	if want := []string{"memory.outlier-workers"}; !reflect.DeepEqual(ids(got), want) {
+		t.Fatalf("findings = %v, want %v", ids(got), want)
+	}
+	finding := got[0]
+	if finding.Title != "1 worker(s) are much larger than the median of 31.0 MB" ||
+		finding.Evidence[0] != "PID 106: 180.0 MB, serving POST /upload HTTP/1.1 on shop.example.com" ||
+		finding.Remediation != "If such requests are rare, consider -size-on median (31.0 MB) instead." {
+		t.Errorf("finding = %+v", finding)
+	}
+	if rec.Status != "OK" {
+		t.Errorf("Status = %s, want OK: outliers are a note", rec.Status)
+	}
+
+	// With more workers a percentile leaves the outlier out
+	many := CalculateMemoryStats(workersOf(30, 31, 29, 30, 32, 31, 30, 29, 31, 30, 32, 30, 31, 30, 29, 30, 31, 30, 32, 30, 180))
+	got = Evaluate(rec, Facts(nil, many, nil, rec, nil))
+	if want := "If such requests are rare, consider -size-on p95 (32.0 MB) instead."; got[0].Remediation != want {
+		t.Errorf("Remediation = %q, want %q", got[0].Remediation, want)
+	}
+
+	// Sizing on a percentile already keeps them out of the recommendation
+	memStats.Statistic = SizeP90
+	got = Evaluate(rec, Facts(nil, memStats, nil, rec, nil))
+	if got[0].Remediation != "" {
+		t.Errorf("Remediation = %q, want none when not sizing on the largest worker", got[0].Remediation)
+	}
+}
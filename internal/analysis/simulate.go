@@ -0,0 +1,153 @@
+package analysis
+
+import (
+	"fmt"
+	"strings"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/status"
+	"apache2buddy-go/internal/system"
+)
+
+// Simulation overrides what was measured to answer "what if" questions.
+// Zero fields keep the measured values.
+type Simulation struct {
+	TotalMemoryMB   int      // Host RAM
+	MPMModel        string   // prefork, worker or event
+	ThreadsPerChild int      // Under worker and event
+	ExcludeServices []string // Other services moved off the box, by name
+	WorkerMB        float64  // Per-process size to plan with
+}
+
+// Active reports whether any override is set
+func (s Simulation) Active() bool {
+	return s.TotalMemoryMB > 0 || s.MPMModel != "" || s.ThreadsPerChild > 0 || len(s.ExcludeServices) > 0 || s.WorkerMB > 0
+}
+
+// Validate checks the overrides for values that cannot be simulated
+func (s Simulation) Validate() error {
+	switch s.MPMModel {
+	case "", "prefork", "worker", "event":
+	default:
+		return fmt.Errorf("unknown MPM %q to simulate (use prefork, worker or event)", s.MPMModel)
+	}
+	if s.TotalMemoryMB < 0 || s.ThreadsPerChild < 0 || s.WorkerMB < 0 {
+		return fmt.Errorf("simulated memory, threads and worker size must not be negative")
+	}
+	if s.ThreadsPerChild > 0 && s.MPMModel == "prefork" {
+		return fmt.Errorf("prefork has no ThreadsPerChild to simulate")
+	}
+	return nil
+}
+
+// String lists the overrides, e.g. "16384 MB RAM, event MPM, without MySQL"
+func (s Simulation) String() string {
+	var parts []string
+	if s.TotalMemoryMB > 0 {
+		parts = append(parts, fmt.Sprintf("%d MB RAM", s.TotalMemoryMB))
+	}
+	if s.MPMModel != "" {
+		parts = append(parts, s.MPMModel+" MPM")
+	}
+	if s.ThreadsPerChild > 0 {
+		parts = append(parts, fmt.Sprintf("%d threads per child", s.ThreadsPerChild))
+	}
+	if len(s.ExcludeServices) > 0 {
+		parts = append(parts, "without "+strings.Join(s.ExcludeServices, ", "))
+	}
+	if s.WorkerMB > 0 {
+		parts = append(parts, fmt.Sprintf("%.1f MB per process", s.WorkerMB))
+	}
+	return strings.Join(parts, ", ")
+}
+
+// SimulationResult is an analysis re-run with the overrides of Simulation
+type SimulationResult struct {
+	Simulation      Simulation
+	AvailableMB     int     // Memory for Apache
+	SizingMB        float64 // Per-process size planned with
+	MPMModel        string
+	Recommendations *Recommendations
+	Notes           []string // Assumptions the figures rest on
+}
+
+// Simulate re-runs the analysis of one instance with the overrides of sim.
+// sysInfo, memStats and apacheConfig are what was measured and are not
+// changed; rec is the current analysis, whose CPU ceiling and demand are
+// carried over. Cgroup limits and the limits audit are not simulated.
+func Simulate(sim Simulation, sysInfo *system.SystemInfo, memStats *MemoryStats, apacheConfig *config.ApacheConfig, statusInfo *status.ApacheStatus, rec *Recommendations, policy Policy) *SimulationResult {
+	result := &SimulationResult{Simulation: sim}
+
+	// The host, less the services moved off it
+	info := *sysInfo
+	info.OtherServices = make(map[string]int, len(sysInfo.OtherServices))
+	for name, mb := range sysInfo.OtherServices {
+		info.OtherServices[name] = mb
+	}
+	for _, name := range sim.ExcludeServices {
+		found := false
+		for service := range info.OtherServices {
+			if strings.EqualFold(service, name) {
+				delete(info.OtherServices, service)
+				found = true
+			}
+		}
+		if !found {
+			result.Notes = append(result.Notes, fmt.Sprintf("%s was not detected, so excluding it changes nothing.", name))
+		}
+	}
+	if sim.TotalMemoryMB > 0 {
+		info.HostMemoryMB = sim.TotalMemoryMB
+		info.TotalMemoryMB = sim.TotalMemoryMB
+	}
+
+	// Other Apache instances keep the share they have now
+	host := system.HostMemoryBudget(&info, policy.Reserve)
+	share, apacheMB := host.AvailableMB, int(memStats.TotalMB)
+	if sysInfo.Budget != nil {
+		share -= sysInfo.Budget.OtherInstancesMB
+		apacheMB = sysInfo.Budget.ApacheMB
+		if sysInfo.Budget.LimitSource != host.LimitSource {
+			result.Notes = append(result.Notes, fmt.Sprintf("The %s limit is left out of the simulation.", sysInfo.Budget.LimitSource))
+		}
+	}
+	info.Budget = host.ForInstance(share, apacheMB, nil)
+	info.AvailableMemoryMB = info.Budget.AvailableMB
+	result.AvailableMB = info.AvailableMemoryMB
+
+	simConfig := *apacheConfig
+	if sim.MPMModel != "" {
+		simConfig.MPMModel = sim.MPMModel
+	}
+	if sim.ThreadsPerChild > 0 {
+		simConfig.ThreadsPerChild = sim.ThreadsPerChild
+	}
+	result.MPMModel = simConfig.MPMModel
+
+	stats := *memStats
+	if sim.WorkerMB > 0 {
+		stats.Statistic = SizeLargest
+		stats.LargestMB = sim.WorkerMB
+		stats.ProjectedPeakMB = 0
+	} else if IsThreaded(simConfig.MPMModel) && !IsThreaded(apacheConfig.MPMModel) {
+		result.Notes = append(result.Notes, fmt.Sprintf("Process size was measured under %s; a %s child with %d threads is larger; give its size with -sim-worker-mb for an accurate figure.",
+			apacheConfig.MPMModel, simConfig.MPMModel, ThreadsPerChild(&simConfig)))
+	}
+	result.SizingMB = stats.SizingMB()
+
+	simulated := GenerateRecommendationsWithPolicy(&info, &stats, &simConfig, statusInfo, rec.VirtualHosts, policy)
+	var cpu *CPUAnalysis
+	if rec.CPU != nil {
+		copied := *rec.CPU
+		cpu = &copied
+	}
+	ApplyCPUCeiling(simulated, cpu)
+	if rec.Demand != nil {
+		demand := *rec.Demand
+		AssessDemand(simulated, &demand)
+	}
+	RecommendMPMBlock(simulated, &simConfig, statusInfo)
+	Evaluate(simulated, Facts(&info, &stats, &simConfig, simulated, statusInfo))
+	result.Recommendations = simulated
+	return result
+}
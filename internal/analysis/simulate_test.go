@@ -0,0 +1,95 @@
+package analysis
+
+import (
+	"strings"
+	"testing"
+
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/system"
+)
+
+func TestSimulationValidate(t *testing.T) {
+	tests := []struct {
+		sim     Simulation
+		wantErr bool
+	}{
+		{Simulation{}, false},
+		{Simulation{TotalMemoryMB: 16384, MPMModel: "event", ThreadsPerChild: 25}, false},
+		{Simulation{MPMModel: "threadpool"}, true},
+		{Simulation{MPMModel: "prefork", ThreadsPerChild: 25}, true},
+		{Simulation{WorkerMB: -1}, true},
+	}
+	for _, tt := range tests {
+		if err := tt.sim.Validate(); (err != nil) != tt.wantErr {
+			t.Errorf("%+v.Validate() error = %v, wantErr %v", tt.sim, err, tt.wantErr)
+		}
+	}
+
+	sim := Simulation{TotalMemoryMB: 16384, MPMModel: "event", ThreadsPerChild: 25, ExcludeServices: []string{"MySQL", "Redis"}, WorkerMB: 60}
+	if !sim.Active() || (Simulation{}).Active() {
+		t.Error("Active() should report whether an override is set")
+	}
+	if want := "16384 MB RAM, event MPM, 25 threads per child, without MySQL, Redis, 60.0 MB per process"; sim.String() != want {
+		t.Errorf("String() = %q, want %q", sim.String(), want)
+	}
+}
+
+// simulationInputs is a prefork box with 4 GB, MySQL using 1 GB and 30 MB
+// workers: 3072 MB for Apache, 92 workers recommended
+func simulationInputs() (*system.SystemInfo, *MemoryStats, *config.ApacheConfig, *Recommendations) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096, HostMemoryMB: 4096, OtherServices: map[string]int{"MySQL": 1024}}
+	sysInfo.Budget = system.HostMemoryBudget(sysInfo, system.Reserve{}).ForInstance(3072, 300, nil)
+	sysInfo.AvailableMemoryMB = sysInfo.Budget.AvailableMB
+	memStats := CalculateMemoryStats(workersOf(30, 30, 30, 30, 30, 30, 30, 30, 30, 30))
+	apacheConfig := &config.ApacheConfig{MPMModel: "prefork", MaxRequestWorkers: 150}
+	rec := GenerateRecommendationsWithPolicy(sysInfo, memStats, apacheConfig, nil, 3, DefaultPolicy())
+	ApplyCPUCeiling(rec, nil)
+	Evaluate(rec, Facts(sysInfo, memStats, apacheConfig, rec, nil))
+	return sysInfo, memStats, apacheConfig, rec
+}
+
+func TestSimulate(t *testing.T) {
+	sysInfo, memStats, apacheConfig, rec := simulationInputs()
+	if rec.RecommendedMaxClients != 92 || rec.Status != "CRITICAL" {
+		t.Fatalf("current = %d %s, want 92 CRITICAL", rec.RecommendedMaxClients, rec.Status)
+	}
+
+	tests := []struct {
+		name          string
+		sim           Simulation
+		wantAvailable int
+		wantWorkers   int
+		wantStatus    string
+		wantNote      string
+	}{
+		{"more RAM", Simulation{TotalMemoryMB: 8192}, 7168, 215, "OK", ""},
+		{"MySQL off-box", Simulation{ExcludeServices: []string{"mysql"}}, 4096, 122, "CRITICAL", ""},
+		{"unknown service", Simulation{ExcludeServices: []string{"Redis"}}, 3072, 92, "CRITICAL", "Redis was not detected"},
+		{"larger workers", Simulation{WorkerMB: 60}, 3072, 46, "CRITICAL", ""},
+		{"event with measured size", Simulation{MPMModel: "event", ThreadsPerChild: 25}, 3072, 2300, "OK", "-sim-worker-mb"},
+		{"event with child size", Simulation{MPMModel: "event", ThreadsPerChild: 25, WorkerMB: 120}, 3072, 575, "OK", ""},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			result := Simulate(tt.sim, sysInfo, memStats, apacheConfig, nil, rec, DefaultPolicy())
+			simulated := result.Recommendations
+			if result.AvailableMB != tt.wantAvailable || simulated.RecommendedMaxClients != tt.wantWorkers || simulated.Status != tt.wantStatus {
+				t.Errorf("Simulate() = %d MB, %d workers, %s; want %d MB, %d workers, %s",
+					result.AvailableMB, simulated.RecommendedMaxClients, simulated.Status, tt.wantAvailable, tt.wantWorkers, tt.wantStatus)
+			}
+			notes := strings.Join(result.Notes, "\n")
+			if (tt.wantNote == "") != (notes == "") || !strings.Contains(notes, tt.wantNote) {
+				t.Errorf("Notes = %q, want %q", notes, tt.wantNote)
+			}
+			if len(simulated.MPMBlock) == 0 {
+				t.Error("Simulate() should recommend an MPM block")
+			}
+		})
+	}
+
+	// The measured inputs and the current analysis are left alone
+	if sysInfo.OtherServices["MySQL"] != 1024 || sysInfo.AvailableMemoryMB != 3072 || memStats.LargestMB != 30 ||
+		apacheConfig.MPMModel != "prefork" || rec.RecommendedMaxClients != 92 {
+		t.Error("Simulate() must not change what was measured")
+	}
+}
@@ -18,10 +18,123 @@ type ApacheConfig struct {
 	MaxRequestWorkers int
 	ServerLimit       int
 	ThreadsPerChild   int
+	ThreadLimit       int
 	MPMModel          string
 	ConfigPath        string
 	Version           string
 	ServerName        string
+	ListenPorts       []string // Ports from Listen directives, in config order
+	AccessLogs        []string // Files of CustomLog and TransferLog directives, once each; pipes and syslog are left out
+	InstanceID        string   // Apache instance this config belongs to, stable across restarts (see process.Instance.Key)
+
+	// MaxConnectionsPerChild (or legacy MaxRequestsPerChild), 0 = never recycle
+	MaxConnectionsPerChild int
+
+	ListenBacklog int // ListenBacklog directive, 0 when not set (see Backlog)
+
+	// Process and thread spares, 0 when not set
+	StartServers    int
+	MinSpareServers int // prefork
+	MaxSpareServers int // prefork
+	MinSpareThreads int // worker and event
+	MaxSpareThreads int // worker and event
+
+	ServerRoot string   // ServerRoot directive, base for relative Include paths
+	LoadedMPM  string   // MPM named by a LoadModule line, if any
+	Modules    []string // Modules named by LoadModule lines, e.g. "php_module"
+
+	// RootPath is the filesystem root the configuration was read through, e.g.
+	// /proc/PID/root for an Apache running in a container. Empty for the host.
+	// ConfigPath and other paths in the config are relative to it.
+	RootPath string
+
+	// Container is set when the instance runs in a container, whose network
+	// and binaries are not the host's; a RootPath alone does not make one
+	Container bool
+
+	ControlPanel *ControlPanel // Panel managing this configuration, nil if none
+
+	env map[string]string // Variables of the envvars file next to the config, for ${VAR} in paths
+}
+
+// configSearchPaths are the standard locations of the main config file
+var configSearchPaths = []string{
+	"/etc/apache2/apache2.conf",
+	"/etc/httpd/conf/httpd.conf",
+	"/usr/local/apache2/conf/httpd.conf",
+	"/etc/httpd/httpd.conf",
+	"/etc/apache2/httpd.conf",
+}
+
+// HostPath maps a path from the configuration to where it can be opened from
+// the host, which differs for containerized instances. Symlinks are resolved
+// the way the instance sees them: an absolute target starts at RootPath, not
+// at the host's root.
+func (c *ApacheConfig) HostPath(path string) string {
+	if c.RootPath == "" || path == "" {
+		return path
+	}
+	return filepath.Join(c.RootPath, resolveInRoot(c.RootPath, path))
+}
+
+// maxSymlinks bounds the links resolveInRoot follows, like the kernel's ELOOP limit
+const maxSymlinks = 40
+
+// resolveInRoot resolves the symlinks in path one component at a time as if
+// root were "/", and returns the result as a path inside root. ".." stops at
+// root. The first component that does not exist, such as a wildcard, ends
+// the resolution and the rest is appended as it is.
+func resolveInRoot(root, path string) string {
+	pending := strings.Split(path, "/")
+	current := "/"
+	links := 0
+	for len(pending) > 0 {
+		name := pending[0]
+		pending = pending[1:]
+		switch name {
+		case "", ".":
+			continue
+		case "..":
+			current = filepath.Dir(current)
+			continue
+		}
+
+		next := filepath.Join(current, name)
+		info, err := os.Lstat(filepath.Join(root, next))
+		if err != nil || info.Mode()&os.ModeSymlink == 0 {
+			if err != nil {
+				return filepath.Join(append([]string{next}, pending...)...)
+			}
+			current = next
+			continue
+		}
+		links++
+		target, err := os.Readlink(filepath.Join(root, next))
+		if err != nil || links > maxSymlinks {
+			return filepath.Join(append([]string{next}, pending...)...)
+		}
+		if filepath.IsAbs(target) {
+			current = "/"
+		}
+		pending = append(strings.Split(target, "/"), pending...)
+	}
+	return current
+}
+
+// InContainer reports whether the configuration belongs to a containerized instance
+func (c *ApacheConfig) InContainer() bool {
+	return c.Container
+}
+
+// DefaultListenBacklog is Apache's ListenBacklog when the directive is absent
+const DefaultListenBacklog = 511
+
+// Backlog returns the configured ListenBacklog or Apache's default
+func (c *ApacheConfig) Backlog() int {
+	if c.ListenBacklog > 0 {
+		return c.ListenBacklog
+	}
+	return DefaultListenBacklog
 }
 
 func (c *ApacheConfig) GetCurrentMaxClients() int {
@@ -43,35 +156,110 @@ func Parse() (*ApacheConfig, error) {
 	defer debug.Trace("config.Parse")()
 
 	config := &ApacheConfig{
-		MPMModel: "prefork", // Default
+		MPMModel:     "prefork", // Default
+		ControlPanel: DetectControlPanel(),
 	}
 
 	// Find Apache config file
-	configPaths := []string{
-		"/etc/apache2/apache2.conf",
-		"/etc/httpd/conf/httpd.conf",
-		"/usr/local/apache2/conf/httpd.conf",
-		"/etc/httpd/httpd.conf",
-		"/etc/apache2/httpd.conf",
+	configPath := findConfigFile(config)
+	if configPath == "" {
+		debug.Error(fmt.Errorf("no config file found"), "config file search")
+		return config, fmt.Errorf("apache config file not found")
 	}
 
+	return parseFrom(config, configPath)
+}
+
+// findConfigFile returns the first config file of the control panel, or else
+// the first standard config file, that exists below config.RootPath, or ""
+// if there is none
+func findConfigFile(config *ApacheConfig) string {
 	debug.Printf("Searching for Apache config files...")
-	var configPath string
-	for _, path := range configPaths {
-		debug.DumpFileInfo(path)
-		if _, err := os.Stat(path); err == nil {
-			configPath = path
-			debug.Printf("Found Apache config: %s", path)
-			break
+	searchPaths := configSearchPaths
+	if config.ControlPanel != nil {
+		searchPaths = append(append([]string{}, config.ControlPanel.ConfigFiles...), configSearchPaths...)
+	}
+	for _, path := range searchPaths {
+		debug.DumpFileInfo(config.HostPath(path))
+		if _, err := os.Stat(config.HostPath(path)); err == nil {
+			debug.Printf("Found Apache config: %s", config.HostPath(path))
+			return path
 		}
 	}
+	return ""
+}
 
+// ParseInstance parses the configuration of one Apache instance given the -f
+// and -d values from its master command line. An empty configPath falls back
+// to the standard config file search.
+func ParseInstance(configPath, serverRoot string) (*ApacheConfig, error) {
+	return ParseInstanceInRoot("", configPath, serverRoot, false)
+}
+
+// ParseInstanceInRoot parses the configuration of an Apache instance whose
+// filesystem is visible below rootPath, such as /proc/PID/root for an Apache
+// in its own mount namespace. When container is set, the host's apachectl
+// and httpd binaries describe a different installation, so the MPM is taken
+// from LoadModule lines and the version is left unknown.
+func ParseInstanceInRoot(rootPath, configPath, serverRoot string, container bool) (*ApacheConfig, error) {
+	defer debug.Trace("config.ParseInstance")()
+
+	if rootPath == "" && configPath == "" {
+		return ParseWithVersion()
+	}
+
+	config := &ApacheConfig{MPMModel: "prefork", RootPath: rootPath, Container: container, ControlPanel: detectControlPanel(rootPath)}
+	var resolved string
 	if configPath == "" {
-		debug.Error(fmt.Errorf("no config file found"), "config file search")
-		return config, fmt.Errorf("apache config file not found")
+		resolved = findConfigFile(config)
+		if resolved == "" {
+			return config, fmt.Errorf("apache config file not found below %s", rootPath)
+		}
+	} else {
+		resolved = resolveInstanceConfig(config, configPath, serverRoot)
+		debug.Printf("Instance config %q (ServerRoot %q) resolved to %s", configPath, serverRoot, resolved)
+		if _, err := os.Stat(config.HostPath(resolved)); err != nil {
+			return config, fmt.Errorf("instance config file not found: %v", err)
+		}
 	}
 
+	config, err := parseFrom(config, resolved)
+	if err != nil {
+		return config, err
+	}
+	if config.InContainer() {
+		config.ServerName = "Apache"
+		config.Version = "unknown"
+		debug.Printf("Skipping version detection for containerized instance at %s", rootPath)
+	} else {
+		addVersion(config)
+	}
+	return config, nil
+}
+
+// resolveInstanceConfig makes a relative -f path absolute. Apache resolves it
+// against ServerRoot; when -d was not given, the first common ServerRoot that
+// contains the file is used.
+func resolveInstanceConfig(config *ApacheConfig, configPath, serverRoot string) string {
+	if filepath.IsAbs(configPath) {
+		return configPath
+	}
+	if serverRoot != "" {
+		return filepath.Join(serverRoot, configPath)
+	}
+	for _, root := range []string{"/etc/httpd", "/etc/apache2", "/usr/local/apache2"} {
+		candidate := filepath.Join(root, configPath)
+		if _, err := os.Stat(config.HostPath(candidate)); err == nil {
+			return candidate
+		}
+	}
+	return filepath.Join("/etc/httpd", configPath)
+}
+
+// parseFrom parses configPath into config and detects the MPM model
+func parseFrom(config *ApacheConfig, configPath string) (*ApacheConfig, error) {
 	config.ConfigPath = configPath
+	config.env = readEnvVars(config.HostPath(filepath.Join(filepath.Dir(configPath), "envvars")))
 
 	// Parse config file
 	if err := parseConfigFile(config, configPath); err != nil {
@@ -79,15 +267,40 @@ func Parse() (*ApacheConfig, error) {
 		return config, err
 	}
 
-	// Detect MPM model
-	mpm, err := detectMPMModel()
-	if err == nil {
+	// Detect MPM model. Inside a container the host's apachectl would
+	// describe the wrong server, so only the LoadModule line is trusted.
+	if config.InContainer() {
+		if config.LoadedMPM != "" {
+			config.MPMModel = config.LoadedMPM
+			debug.Printf("Detected MPM model from LoadModule: %s", config.LoadedMPM)
+		} else {
+			debug.Warn("No MPM LoadModule line found below %s", config.RootPath)
+		}
+	} else if mpm, err := detectMPMModel(); err == nil {
 		config.MPMModel = mpm
 		debug.Printf("Detected MPM model: %s", mpm)
+	} else if config.LoadedMPM != "" {
+		config.MPMModel = config.LoadedMPM
+		debug.Printf("Detected MPM model from LoadModule: %s", config.LoadedMPM)
 	} else {
 		debug.Warn("Could not detect MPM model: %v", err)
 	}
 
+	// If we didn't find MaxClients/MaxRequestWorkers, try to detect default values
+	if config.MaxClients == 0 && config.MaxRequestWorkers == 0 {
+		debug.Warn("No MaxClients or MaxRequestWorkers found in config file")
+		debug.Printf("This could mean:")
+		debug.Printf("1. Values are in included files not being parsed")
+		debug.Printf("2. Using compiled-in defaults")
+		debug.Printf("3. Values are set by the system package configuration")
+
+		// Try to get defaults from Apache itself
+		if defaults := tryGetApacheDefaults(config); defaults > 0 {
+			debug.Printf("Using detected Apache defaults: %d", defaults)
+			config.MaxRequestWorkers = defaults
+		}
+	}
+
 	debug.DumpStruct("ParsedConfig", config)
 	return config, nil
 }
@@ -96,7 +309,7 @@ func parseConfigFile(config *ApacheConfig, filePath string) error {
 	defer debug.Trace("parseConfigFile")()
 	debug.Printf("Parsing config file: %s", filePath)
 
-	file, err := os.Open(filePath)
+	file, err := os.Open(config.HostPath(filePath))
 	if err != nil {
 		debug.Error(err, "opening config file")
 		return err
@@ -124,33 +337,50 @@ func parseConfigFile(config *ApacheConfig, filePath string) error {
 
 		debug.Printf("Processing line %d: %s", lineCount, line)
 
-		// Handle Include directives (basic support)
+		// Handle Include directives. Relative paths are based on ServerRoot
+		// when it is set, otherwise on the including file's directory.
 		if strings.HasPrefix(line, "Include ") || strings.HasPrefix(line, "IncludeOptional ") {
-			includePath := extractIncludePath(line, filepath.Dir(filePath))
+			baseDir := filepath.Dir(filePath)
+			if config.ServerRoot != "" {
+				baseDir = config.ServerRoot
+			}
+			includePath := extractIncludePath(line, baseDir)
 			debug.Printf("Found Include directive: %s -> %s", line, includePath)
-			if includePath != "" && !strings.Contains(includePath, "*") {
-				debug.Printf("Recursively parsing include: %s", includePath)
-				if err := parseConfigFile(config, includePath); err != nil {
-					debug.Error(err, "parsing included config file: "+includePath)
+			for _, path := range expandInclude(config, includePath) {
+				debug.Printf("Recursively parsing include: %s", path)
+				if err := parseConfigFile(config, path); err != nil {
+					debug.Error(err, "parsing included config file: "+path)
 				}
-			} else if strings.Contains(includePath, "*") {
-				debug.Printf("Skipping wildcard include: %s", includePath)
-				// TODO: Handle wildcard includes properly
 			}
 			continue
 		}
 
+		if strings.HasPrefix(line, "ServerRoot ") {
+			config.ServerRoot = strings.Trim(strings.TrimSpace(strings.TrimPrefix(line, "ServerRoot ")), `"`)
+			debug.Printf("Found ServerRoot: %s", config.ServerRoot)
+			continue
+		}
+
+		if module := loadedModule(line); module != "" {
+			config.Modules = append(config.Modules, module)
+		}
+		if mpm := loadedMPM(line); mpm != "" {
+			config.LoadedMPM = mpm
+			debug.Printf("Found MPM LoadModule: %s", mpm)
+			continue
+		}
+
 		// Handle MPM sections - fix the logic
 		if strings.Contains(line, "<IfModule") {
-			if strings.Contains(line, "mpm_prefork") && !strings.Contains(line, "!mpm_prefork") {
+			if (strings.Contains(line, "mpm_prefork") || strings.Contains(line, "prefork.c")) && !strings.Contains(line, "!mpm_prefork") && !strings.Contains(line, "!prefork.c") {
 				inMPMSection = true
 				currentMPM = "prefork"
 				debug.Printf("Entering prefork MPM section")
-			} else if strings.Contains(line, "mpm_worker") && !strings.Contains(line, "!mpm_worker") {
+			} else if (strings.Contains(line, "mpm_worker") || strings.Contains(line, "worker.c")) && !strings.Contains(line, "!mpm_worker") && !strings.Contains(line, "!worker.c") {
 				inMPMSection = true
 				currentMPM = "worker"
 				debug.Printf("Entering worker MPM section")
-			} else if strings.Contains(line, "mpm_event") && !strings.Contains(line, "!mpm_event") {
+			} else if (strings.Contains(line, "mpm_event") || strings.Contains(line, "event.c")) && !strings.Contains(line, "!mpm_event") && !strings.Contains(line, "!event.c") {
 				inMPMSection = true
 				currentMPM = "event"
 				debug.Printf("Entering event MPM section")
@@ -175,6 +405,22 @@ func parseConfigFile(config *ApacheConfig, filePath string) error {
 			continue
 		}
 
+		if fields[0] == "Listen" {
+			if port := listenPort(fields[1]); port != "" {
+				config.ListenPorts = append(config.ListenPorts, port)
+				debug.Printf("Found Listen port: %s", port)
+			}
+			continue
+		}
+
+		if fields[0] == "CustomLog" || fields[0] == "TransferLog" {
+			if path := accessLogPath(config, fields[1]); path != "" && !containsString(config.AccessLogs, path) {
+				config.AccessLogs = append(config.AccessLogs, path)
+				debug.Printf("Found access log: %s", path)
+			}
+			continue
+		}
+
 		directive := fields[0]
 		value, err := strconv.Atoi(fields[1])
 		if err != nil {
@@ -201,36 +447,114 @@ func parseConfigFile(config *ApacheConfig, filePath string) error {
 		case "ServerLimit":
 			config.ServerLimit = value
 			debug.Printf("Set ServerLimit to %d", value)
+		case "ThreadLimit":
+			config.ThreadLimit = value
+			debug.Printf("Set ThreadLimit to %d", value)
+		case "StartServers":
+			config.StartServers = value
+			debug.Printf("Set StartServers to %d", value)
+		case "MinSpareServers":
+			config.MinSpareServers = value
+			debug.Printf("Set MinSpareServers to %d", value)
+		case "MaxSpareServers":
+			config.MaxSpareServers = value
+			debug.Printf("Set MaxSpareServers to %d", value)
+		case "MinSpareThreads":
+			config.MinSpareThreads = value
+			debug.Printf("Set MinSpareThreads to %d", value)
+		case "MaxSpareThreads":
+			config.MaxSpareThreads = value
+			debug.Printf("Set MaxSpareThreads to %d", value)
 		case "ThreadsPerChild":
 			config.ThreadsPerChild = value
 			debug.Printf("Set ThreadsPerChild to %d", value)
+		case "MaxConnectionsPerChild", "MaxRequestsPerChild":
+			config.MaxConnectionsPerChild = value
+			debug.Printf("Set MaxConnectionsPerChild to %d", value)
+		case "ListenBacklog":
+			config.ListenBacklog = value
+			debug.Printf("Set ListenBacklog to %d", value)
 		}
 	}
 
 	debug.Printf("Config parsing complete: %d lines processed, %d directives found", lineCount, directivesFound)
 
-	// If we didn't find MaxClients/MaxRequestWorkers, try to detect default values
-	if config.MaxClients == 0 && config.MaxRequestWorkers == 0 {
-		debug.Warn("No MaxClients or MaxRequestWorkers found in config file")
-		debug.Printf("This could mean:")
-		debug.Printf("1. Values are in included files not being parsed")
-		debug.Printf("2. Using compiled-in defaults")
-		debug.Printf("3. Values are set by the system package configuration")
+	return scanner.Err()
+}
 
-		// Try to get defaults from Apache itself
-		if defaults := tryGetApacheDefaults(config.MPMModel); defaults > 0 {
-			debug.Printf("Using detected Apache defaults: %d", defaults)
-			config.MaxRequestWorkers = defaults
+// expandInclude returns the files an Include path refers to, expanding
+// wildcards in lexical order like Apache does
+func expandInclude(config *ApacheConfig, includePath string) []string {
+	if includePath == "" {
+		return nil
+	}
+	if !strings.ContainsAny(includePath, "*?[") {
+		return []string{includePath}
+	}
+
+	matches, err := filepath.Glob(config.HostPath(includePath))
+	if err != nil {
+		debug.Warn("Invalid wildcard include %s: %v", includePath, err)
+		return nil
+	}
+	paths := make([]string, 0, len(matches))
+	for _, match := range matches {
+		if config.RootPath != "" {
+			match = "/" + strings.TrimPrefix(strings.TrimPrefix(match, config.RootPath), "/")
 		}
+		// A match may be a symlink into the instance's own root
+		if info, err := os.Stat(config.HostPath(match)); err != nil || info.IsDir() {
+			continue
+		}
+		paths = append(paths, match)
 	}
+	debug.Printf("Wildcard include %s matched %d files", includePath, len(paths))
+	return paths
+}
 
-	return scanner.Err()
+// loadedModule returns the module a "LoadModule name path" line loads
+func loadedModule(line string) string {
+	fields := strings.Fields(line)
+	if len(fields) < 2 || fields[0] != "LoadModule" {
+		return ""
+	}
+	return fields[1]
+}
+
+// HasModule reports whether a LoadModule line loads module
+func (c *ApacheConfig) HasModule(module string) bool {
+	for _, loaded := range c.Modules {
+		if loaded == module {
+			return true
+		}
+	}
+	return false
+}
+
+// loadedMPM returns the MPM loaded by a "LoadModule mpm_*_module" line
+func loadedMPM(line string) string {
+	switch loadedModule(line) {
+	case "mpm_prefork_module":
+		return "prefork"
+	case "mpm_worker_module":
+		return "worker"
+	case "mpm_event_module":
+		return "event"
+	}
+	return ""
 }
 
 // tryGetApacheDefaults attempts to get default values from Apache configuration
-func tryGetApacheDefaults(mpmModel string) int {
+func tryGetApacheDefaults(config *ApacheConfig) int {
 	defer debug.Trace("tryGetApacheDefaults")()
 
+	// The host's binaries say nothing about a containerized Apache
+	if config.InContainer() {
+		debug.Printf("Skipping Apache defaults detection for containerized instance")
+		return 0
+	}
+	mpmModel := config.MPMModel
+
 	// Try to get defaults from httpd -V output
 	commands := [][]string{
 		{"httpd", "-V"},
@@ -271,11 +595,82 @@ func tryGetApacheDefaults(mpmModel string) int {
 	return 0
 }
 
+// listenPort extracts the port from a Listen argument such as "80",
+// "0.0.0.0:8080" or "[::]:443"
+func listenPort(value string) string {
+	if idx := strings.LastIndex(value, ":"); idx >= 0 {
+		value = value[idx+1:]
+	}
+	if _, err := strconv.Atoi(value); err != nil {
+		return ""
+	}
+	return value
+}
+
+// accessLogPath returns the file a CustomLog or TransferLog target writes
+// to, relative paths taken from ServerRoot like Apache does. Piped and
+// syslog targets, and paths with variables envvars does not define, give "".
+func accessLogPath(config *ApacheConfig, target string) string {
+	target = strings.Trim(target, `"'`)
+	if strings.HasPrefix(target, "|") || strings.HasPrefix(target, "syslog") {
+		return ""
+	}
+	resolved := true
+	target = os.Expand(target, func(name string) string {
+		value, ok := config.env[name]
+		resolved = resolved && ok
+		return value
+	})
+	if !resolved {
+		debug.Printf("Skipping access log %s: undefined variable", target)
+		return ""
+	}
+	if !filepath.IsAbs(target) {
+		if config.ServerRoot == "" {
+			return ""
+		}
+		target = filepath.Join(config.ServerRoot, target)
+	}
+	return filepath.Clean(target)
+}
+
+// readEnvVars reads the "export NAME=value" lines of a Debian-style envvars
+// file; values may refer to variables set before them
+func readEnvVars(path string) map[string]string {
+	env := make(map[string]string)
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return env
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		line = strings.TrimSpace(line)
+		if !strings.HasPrefix(line, "export ") {
+			continue
+		}
+		name, value, ok := strings.Cut(strings.TrimSpace(strings.TrimPrefix(line, "export ")), "=")
+		if !ok {
+			continue
+		}
+		env[name] = os.Expand(strings.Trim(value, `"'`), func(ref string) string { return env[ref] })
+	}
+	return env
+}
+
+// containsString reports whether values has value
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
 func extractIncludePath(line, baseDir string) string {
 	re := regexp.MustCompile(`(?:Include(?:Optional)?)\s+(\S+)`)
 	matches := re.FindStringSubmatch(line)
 	if len(matches) > 1 {
-		path := matches[1]
+		path := strings.Trim(matches[1], `"'`) // Panels such as cPanel quote their includes
 		if !filepath.IsAbs(path) {
 			path = filepath.Join(baseDir, path)
 		}
@@ -329,7 +724,12 @@ func ParseWithVersion() (*ApacheConfig, error) {
 		return config, err
 	}
 
-	// Detect Apache version
+	addVersion(config)
+	return config, nil
+}
+
+// addVersion fills in the Apache version and server name
+func addVersion(config *ApacheConfig) {
 	version, serverName, err := detectApacheVersion()
 	if err == nil {
 		config.Version = version
@@ -338,8 +738,6 @@ func ParseWithVersion() (*ApacheConfig, error) {
 	} else {
 		debug.Warn("Could not detect Apache version: %v", err)
 	}
-
-	return config, nil
 }
 
 func GetVirtualHostCount(configPath string) int {
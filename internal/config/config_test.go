@@ -3,6 +3,8 @@ package config
 import (
 	"os"
 	"path/filepath"
+	"reflect"
+	"strings"
 	"testing"
 )
 
@@ -63,6 +65,9 @@ func TestParseConfigFile(t *testing.T) {
 			name: "basic prefork config",
 			content: `
 <IfModule mpm_prefork_module>
+    StartServers 5
+    MinSpareServers 5
+    MaxSpareServers 10
     MaxRequestWorkers 150
     ServerLimit 150
 </IfModule>`,
@@ -70,6 +75,9 @@ func TestParseConfigFile(t *testing.T) {
 			want: &ApacheConfig{
 				MaxRequestWorkers: 150,
 				ServerLimit:       150,
+				StartServers:      5,
+				MinSpareServers:   5,
+				MaxSpareServers:   10,
 				MPMModel:          "prefork",
 			},
 		},
@@ -80,12 +88,20 @@ func TestParseConfigFile(t *testing.T) {
     MaxRequestWorkers 400
     ThreadsPerChild 25
     ServerLimit 16
+    ThreadLimit 64
+    StartServers 3
+    MinSpareThreads 75
+    MaxSpareThreads 250
 </IfModule>`,
 			filename: "apache_worker.conf",
 			want: &ApacheConfig{
 				MaxRequestWorkers: 400,
 				ThreadsPerChild:   25,
 				ServerLimit:       16,
+				ThreadLimit:       64,
+				StartServers:      3,
+				MinSpareThreads:   75,
+				MaxSpareThreads:   250,
 				MPMModel:          "worker",
 			},
 		},
@@ -117,6 +133,9 @@ ServerName example.com`,
 			filename: "apache_mixed.conf",
 			want: &ApacheConfig{
 				MaxRequestWorkers: 200,
+				StartServers:      8,
+				MinSpareServers:   5,
+				MaxSpareServers:   20,
 				MPMModel:          "prefork",
 			},
 		},
@@ -152,6 +171,16 @@ ServerName example.com`,
 				if config.ThreadsPerChild != tt.want.ThreadsPerChild {
 					t.Errorf("ThreadsPerChild = %d, want %d", config.ThreadsPerChild, tt.want.ThreadsPerChild)
 				}
+				if config.ThreadLimit != tt.want.ThreadLimit {
+					t.Errorf("ThreadLimit = %d, want %d", config.ThreadLimit, tt.want.ThreadLimit)
+				}
+				if config.StartServers != tt.want.StartServers || config.MinSpareThreads != tt.want.MinSpareThreads ||
+					config.MaxSpareThreads != tt.want.MaxSpareThreads || config.MinSpareServers != tt.want.MinSpareServers ||
+					config.MaxSpareServers != tt.want.MaxSpareServers {
+					t.Errorf("spares = %d/%d/%d/%d/%d, want %d/%d/%d/%d/%d",
+						config.StartServers, config.MinSpareServers, config.MaxSpareServers, config.MinSpareThreads, config.MaxSpareThreads,
+						tt.want.StartServers, tt.want.MinSpareServers, tt.want.MaxSpareServers, tt.want.MinSpareThreads, tt.want.MaxSpareThreads)
+				}
 				if tt.want.MPMModel != "" && config.MPMModel != tt.want.MPMModel {
 					t.Errorf("MPMModel = %s, want %s", config.MPMModel, tt.want.MPMModel)
 				}
@@ -160,6 +189,293 @@ ServerName example.com`,
 	}
 }
 
+func TestParseConfigFile_ListenPorts(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "listen.conf")
+	content := `
+Listen 80
+Listen 0.0.0.0:8080
+Listen [::]:8443 https
+`
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	config := &ApacheConfig{MPMModel: "prefork"}
+	if err := parseConfigFile(config, configPath); err != nil {
+		t.Fatalf("parseConfigFile() error = %v", err)
+	}
+
+	want := []string{"80", "8080", "8443"}
+	if len(config.ListenPorts) != len(want) {
+		t.Fatalf("ListenPorts = %v, want %v", config.ListenPorts, want)
+	}
+	for i, port := range want {
+		if config.ListenPorts[i] != port {
+			t.Errorf("ListenPorts[%d] = %s, want %s", i, config.ListenPorts[i], port)
+		}
+	}
+}
+
+func TestParseConfigFile_AccessLogs(t *testing.T) {
+	dir := t.TempDir()
+	configPath := filepath.Join(dir, "apache2.conf")
+	envvars := "export APACHE_RUN_USER=www-data\nexport APACHE_LOG_DIR=/var/log/apache2$SUFFIX\n"
+	content := `
+ServerRoot "/etc/httpd"
+CustomLog ${APACHE_LOG_DIR}/access.log combined
+<VirtualHost *:80>
+    CustomLog "${APACHE_LOG_DIR}/access.log" combined
+    CustomLog logs/shop_access_log common
+</VirtualHost>
+TransferLog /srv/www/transfer.log
+CustomLog "|/usr/bin/rotatelogs /var/log/httpd/piped_log 86400" common
+CustomLog syslog:local1 common
+CustomLog ${UNDEFINED}/access.log combined
+`
+	if err := os.WriteFile(filepath.Join(dir, "envvars"), []byte(envvars), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(configPath, []byte(content), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	config := &ApacheConfig{MPMModel: "prefork", env: readEnvVars(filepath.Join(dir, "envvars"))}
+	if err := parseConfigFile(config, configPath); err != nil {
+		t.Fatalf("parseConfigFile() error = %v", err)
+	}
+	want := []string{"/var/log/apache2/access.log", "/etc/httpd/logs/shop_access_log", "/srv/www/transfer.log"}
+	if !reflect.DeepEqual(config.AccessLogs, want) {
+		t.Errorf("AccessLogs = %v, want %v", config.AccessLogs, want)
+	}
+}
+
+func TestParseConfigFile_MaxConnectionsPerChild(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    int
+	}{
+		{
+			name:    "current directive",
+			content: "<IfModule mpm_prefork_module>\n    MaxConnectionsPerChild 4000\n</IfModule>\n",
+			want:    4000,
+		},
+		{
+			name:    "legacy directive",
+			content: "<IfModule prefork.c>\n    MaxRequestsPerChild 10000\n</IfModule>\n",
+			want:    10000,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			configPath := filepath.Join(t.TempDir(), "recycle.conf")
+			if err := os.WriteFile(configPath, []byte(tt.content), 0644); err != nil {
+				t.Fatalf("Failed to create test config file: %v", err)
+			}
+
+			config := &ApacheConfig{MPMModel: "prefork", MaxRequestWorkers: 150}
+			if err := parseConfigFile(config, configPath); err != nil {
+				t.Fatalf("parseConfigFile() error = %v", err)
+			}
+			if config.MaxConnectionsPerChild != tt.want {
+				t.Errorf("MaxConnectionsPerChild = %d, want %d", config.MaxConnectionsPerChild, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseConfigFile_ListenBacklog(t *testing.T) {
+	configPath := filepath.Join(t.TempDir(), "backlog.conf")
+	if err := os.WriteFile(configPath, []byte("Listen 80\nListenBacklog 4096\n"), 0644); err != nil {
+		t.Fatalf("Failed to create test config file: %v", err)
+	}
+
+	config := &ApacheConfig{MPMModel: "prefork"}
+	if got := config.Backlog(); got != DefaultListenBacklog {
+		t.Errorf("Backlog() without directive = %d, want %d", got, DefaultListenBacklog)
+	}
+	if err := parseConfigFile(config, configPath); err != nil {
+		t.Fatalf("parseConfigFile() error = %v", err)
+	}
+	if config.ListenBacklog != 4096 || config.Backlog() != 4096 {
+		t.Errorf("ListenBacklog = %d, Backlog() = %d, want 4096", config.ListenBacklog, config.Backlog())
+	}
+}
+
+func TestResolveInstanceConfig(t *testing.T) {
+	tests := []struct {
+		name       string
+		configPath string
+		serverRoot string
+		want       string
+	}{
+		{
+			name:       "absolute path",
+			configPath: "/etc/httpd/conf/site2.conf",
+			serverRoot: "/srv",
+			want:       "/etc/httpd/conf/site2.conf",
+		},
+		{
+			name:       "relative to ServerRoot",
+			configPath: "conf/site2.conf",
+			serverRoot: "/opt/apache",
+			want:       "/opt/apache/conf/site2.conf",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := resolveInstanceConfig(&ApacheConfig{}, tt.configPath, tt.serverRoot); got != tt.want {
+				t.Errorf("resolveInstanceConfig() = %s, want %s", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestParseInstanceInRoot(t *testing.T) {
+	// Layout of the official httpd image, seen from the host through /proc/PID/root
+	root := t.TempDir()
+	files := map[string]string{
+		"usr/local/apache2/conf/httpd.conf": `ServerRoot "/usr/local/apache2"
+Listen 8080
+LoadModule mpm_event_module modules/mod_mpm_event.so
+#LoadModule mpm_prefork_module modules/mod_mpm_prefork.so
+LoadModule proxy_fcgi_module modules/mod_proxy_fcgi.so
+Include conf/extra/*.conf
+`,
+		"usr/local/apache2/conf/extra/httpd-mpm.conf": `<IfModule mpm_event_module>
+    ThreadsPerChild 25
+    MaxRequestWorkers 150
+</IfModule>
+`,
+		"usr/local/apache2/conf/extra/z-recycle.conf": "MaxConnectionsPerChild 5000\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config, err := ParseInstanceInRoot(root, "", "", true)
+	if err != nil {
+		t.Fatalf("ParseInstanceInRoot() error = %v", err)
+	}
+
+	if config.ConfigPath != "/usr/local/apache2/conf/httpd.conf" {
+		t.Errorf("ConfigPath = %s, want the path inside the container", config.ConfigPath)
+	}
+	if !config.InContainer() || config.HostPath(config.ConfigPath) != filepath.Join(root, "usr/local/apache2/conf/httpd.conf") {
+		t.Errorf("HostPath(ConfigPath) = %s, want it below %s", config.HostPath(config.ConfigPath), root)
+	}
+	if config.MPMModel != "event" {
+		t.Errorf("MPMModel = %s, want event (from LoadModule)", config.MPMModel)
+	}
+	if len(config.Modules) != 2 || !config.HasModule("proxy_fcgi_module") || config.HasModule("mpm_prefork_module") {
+		t.Errorf("Modules = %v, want mpm_event_module and proxy_fcgi_module", config.Modules)
+	}
+	if config.MaxRequestWorkers != 150 || config.ThreadsPerChild != 25 {
+		t.Errorf("MaxRequestWorkers/ThreadsPerChild = %d/%d, want 150/25 from the wildcard include",
+			config.MaxRequestWorkers, config.ThreadsPerChild)
+	}
+	if config.MaxConnectionsPerChild != 5000 {
+		t.Errorf("MaxConnectionsPerChild = %d, want 5000", config.MaxConnectionsPerChild)
+	}
+	if len(config.ListenPorts) != 1 || config.ListenPorts[0] != "8080" {
+		t.Errorf("ListenPorts = %v, want [8080]", config.ListenPorts)
+	}
+	if config.Version != "unknown" {
+		t.Errorf("Version = %s, want unknown (host binaries must not be used)", config.Version)
+	}
+
+	if _, err := ParseInstanceInRoot(t.TempDir(), "", "", true); err == nil {
+		t.Error("ParseInstanceInRoot() on an empty root should fail")
+	}
+}
+
+// TestHostPath_Symlinks lays out a container root whose config directory
+// and enabled sites are absolute symlinks, which must resolve below the root
+func TestHostPath_Symlinks(t *testing.T) {
+	root := t.TempDir()
+	files := map[string]string{
+		"opt/apache2/apache2.conf": `ServerRoot "/etc/apache2"
+LoadModule mpm_prefork_module modules/mod_mpm_prefork.so
+IncludeOptional sites-enabled/*.conf
+`,
+		"opt/apache2/sites-available/shop.conf": "MaxRequestWorkers 42\n",
+	}
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+	if err := os.MkdirAll(filepath.Join(root, "etc"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll(filepath.Join(root, "opt/apache2/sites-enabled"), 0755); err != nil {
+		t.Fatal(err)
+	}
+	links := map[string]string{
+		"etc/apache2":                         "/opt/apache2",
+		"opt/apache2/sites-enabled/shop.conf": "/etc/apache2/sites-available/shop.conf",
+		"up":                                  "../../../../opt/apache2",
+		"loop":                                "/loop",
+	}
+	for name, target := range links {
+		if err := os.Symlink(target, filepath.Join(root, name)); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	config := &ApacheConfig{RootPath: root}
+	tests := map[string]string{
+		"/etc/apache2/apache2.conf":            "opt/apache2/apache2.conf",
+		"/etc/apache2/sites-enabled/shop.conf": "opt/apache2/sites-available/shop.conf",
+		"/etc/apache2/sites-enabled/*.conf":    "opt/apache2/sites-enabled/*.conf",
+		"/up/apache2.conf":                     "opt/apache2/apache2.conf",
+		"/etc/apache2/missing/../apache2.conf": "opt/apache2/apache2.conf",
+	}
+	for path, want := range tests {
+		if got := config.HostPath(path); got != filepath.Join(root, want) {
+			t.Errorf("HostPath(%q) = %s, want %s", path, got, filepath.Join(root, want))
+		}
+	}
+	if got := config.HostPath("/loop/httpd.conf"); !strings.HasPrefix(got, root) {
+		t.Errorf("HostPath() of a symlink loop = %s, want it below the root", got)
+	}
+
+	parsed, err := ParseInstanceInRoot(root, "/etc/apache2/apache2.conf", "", true)
+	if err != nil {
+		t.Fatalf("ParseInstanceInRoot() error = %v", err)
+	}
+	if parsed.MaxRequestWorkers != 42 {
+		t.Errorf("MaxRequestWorkers = %d, want 42 from the symlinked site", parsed.MaxRequestWorkers)
+	}
+}
+
+func TestLoadedMPM(t *testing.T) {
+	tests := map[string]string{
+		"LoadModule mpm_prefork_module modules/mod_mpm_prefork.so":         "prefork",
+		"LoadModule mpm_worker_module /usr/lib/apache2/modules/mod_mpm.so": "worker",
+		"LoadModule mpm_event_module modules/mod_mpm_event.so":             "event",
+		"LoadModule status_module modules/mod_status.so":                   "",
+		"MaxRequestWorkers 150":                                            "",
+	}
+	for line, want := range tests {
+		if got := loadedMPM(line); got != want {
+			t.Errorf("loadedMPM(%q) = %q, want %q", line, got, want)
+		}
+	}
+}
+
 func TestGetDefaults(t *testing.T) {
 	config := GetDefaults()
 
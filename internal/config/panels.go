@@ -0,0 +1,125 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"strings"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// ControlPanel describes how a hosting control panel lays out the Apache
+// configuration and where changes survive the panel regenerating it
+type ControlPanel struct {
+	Name    string
+	Markers []string // Paths whose existence identifies the panel
+
+	// ConfigFiles are main config files the panel uses, searched before the
+	// standard locations
+	ConfigFiles []string
+
+	Managed string // What the panel regenerates, so direct edits are lost; empty if nothing
+
+	// MPMFiles are where MPM settings are kept across regeneration; the first
+	// whose directory exists applies. {mpm} stands for the MPM name.
+	MPMFiles []string
+
+	Apply []string // Commands that put a change into effect
+	Note  string
+}
+
+// standardMPMFiles hold the MPM settings of distribution packages
+var standardMPMFiles = []string{
+	"/etc/apache2/mods-available/mpm_{mpm}.conf",
+	"/etc/httpd/conf.d/mpm.conf",
+}
+
+// controlPanels are checked in order; panels built on top of another (such as
+// Virtualmin on Webmin) come before it
+var controlPanels = []ControlPanel{
+	{
+		Name:        "cPanel",
+		Markers:     []string{"/usr/local/cpanel"},
+		ConfigFiles: []string{"/etc/apache2/conf/httpd.conf", "/usr/local/apache/conf/httpd.conf"},
+		Managed:     "httpd.conf is rebuilt by EasyApache 4",
+		MPMFiles:    []string{"/etc/apache2/conf.d/includes/pre_main_global.conf"},
+		Apply:       []string{"/scripts/rebuildhttpdconf", "/scripts/restartsrv_httpd"},
+		Note:        "The pre_main include is also editable in WHM » Apache Configuration » Include Editor; MaxRequestWorkers and ServerLimit can be set in WHM » Apache Configuration » Global Configuration instead.",
+	},
+	{
+		Name:     "Plesk",
+		Markers:  []string{"/usr/local/psa"},
+		Managed:  "virtual host files are generated from /usr/local/psa/admin/conf/templates",
+		MPMFiles: []string{"/etc/apache2/conf-enabled/zz-mpm.conf", "/etc/httpd/conf.d/zz-mpm.conf"},
+		Apply:    []string{"plesk sbin httpdmng --reconfigure-all"},
+		Note:     "Virtual host changes belong in /usr/local/psa/admin/conf/templates/custom, never in the generated files.",
+	},
+	{
+		Name:        "DirectAdmin",
+		Markers:     []string{"/usr/local/directadmin"},
+		ConfigFiles: []string{"/etc/httpd/conf/httpd.conf"},
+		Managed:     "/etc/httpd/conf/extra/httpd-mpm.conf is rewritten by CustomBuild",
+		MPMFiles:    []string{"/usr/local/directadmin/custombuild/custom/ap2/conf/extra/httpd-mpm.conf"},
+		Apply:       []string{"cd /usr/local/directadmin/custombuild && ./build rewrite_confs"},
+		Note:        "Copy /usr/local/directadmin/custombuild/configure/ap2/conf/extra/httpd-mpm.conf to the custom path first if it does not exist.",
+	},
+	{
+		Name:     "ISPConfig",
+		Markers:  []string{"/usr/local/ispconfig"},
+		Managed:  "virtual host files are generated from /usr/local/ispconfig/server/conf/*.master",
+		MPMFiles: standardMPMFiles,
+		Note:     "ISPConfig leaves the MPM settings alone; template overrides go in /usr/local/ispconfig/server/conf-custom.",
+	},
+	{
+		Name:     "CyberPanel",
+		Markers:  []string{"/usr/local/CyberCP"},
+		Managed:  "virtual host files are generated per website",
+		MPMFiles: standardMPMFiles,
+		Note:     "CyberPanel serves sites with LiteSpeed by default; these settings only matter for the Apache it runs alongside.",
+	},
+	{
+		Name:     "Virtualmin",
+		Markers:  []string{"/etc/webmin/virtual-server"},
+		Managed:  "virtual host blocks are rewritten when domains change",
+		MPMFiles: standardMPMFiles,
+		Note:     "Settings outside the virtual host blocks are kept; they can also be changed in Webmin » Servers » Apache Webserver.",
+	},
+	{
+		Name:     "Webmin",
+		Markers:  []string{"/etc/webmin"},
+		MPMFiles: standardMPMFiles,
+	},
+}
+
+// DetectControlPanel returns the control panel managing the host's Apache,
+// or nil when there is none
+func DetectControlPanel() *ControlPanel {
+	return detectControlPanel("")
+}
+
+// detectControlPanel looks for panel markers below root
+func detectControlPanel(root string) *ControlPanel {
+	for i := range controlPanels {
+		for _, marker := range controlPanels[i].Markers {
+			if _, err := os.Stat(filepath.Join("/", root, marker)); err == nil {
+				debug.Printf("Found %s marker %s", controlPanels[i].Name, marker)
+				return &controlPanels[i]
+			}
+		}
+	}
+	return nil
+}
+
+// MPMFile returns where the panel keeps settings for mpm below root
+func (p *ControlPanel) MPMFile(root, mpm string) string {
+	for _, file := range p.MPMFiles {
+		file = strings.ReplaceAll(file, "{mpm}", mpm)
+		if _, err := os.Stat(filepath.Join("/", root, filepath.Dir(file))); err == nil {
+			return file
+		}
+	}
+	if len(p.MPMFiles) == 0 {
+		return ""
+	}
+	return strings.ReplaceAll(p.MPMFiles[0], "{mpm}", mpm)
+}
@@ -0,0 +1,114 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeTree creates files (relative path -> content) below root
+func writeTree(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatal(err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+}
+
+func TestDetectControlPanel(t *testing.T) {
+	tests := []struct {
+		name    string
+		markers []string
+		want    string
+	}{
+		{"none", nil, ""},
+		{"cPanel", []string{"usr/local/cpanel/version"}, "cPanel"},
+		{"Plesk", []string{"usr/local/psa/version"}, "Plesk"},
+		{"DirectAdmin", []string{"usr/local/directadmin/conf/directadmin.conf"}, "DirectAdmin"},
+		{"ISPConfig", []string{"usr/local/ispconfig/server/server.php"}, "ISPConfig"},
+		{"CyberPanel", []string{"usr/local/CyberCP/manage.py"}, "CyberPanel"},
+		{"Virtualmin wins over Webmin", []string{"etc/webmin/miniserv.conf", "etc/webmin/virtual-server/config"}, "Virtualmin"},
+		{"plain Webmin", []string{"etc/webmin/miniserv.conf"}, "Webmin"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			files := make(map[string]string)
+			for _, marker := range tt.markers {
+				files[marker] = ""
+			}
+			writeTree(t, root, files)
+
+			panel := detectControlPanel(root)
+			got := ""
+			if panel != nil {
+				got = panel.Name
+			}
+			if got != tt.want {
+				t.Errorf("detectControlPanel() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestControlPanelMPMFile(t *testing.T) {
+	panel := &ControlPanel{MPMFiles: standardMPMFiles}
+
+	debian := t.TempDir()
+	writeTree(t, debian, map[string]string{"etc/apache2/mods-available/mpm_event.load": ""})
+	if got := panel.MPMFile(debian, "event"); got != "/etc/apache2/mods-available/mpm_event.conf" {
+		t.Errorf("MPMFile(debian) = %s", got)
+	}
+
+	rhel := t.TempDir()
+	writeTree(t, rhel, map[string]string{"etc/httpd/conf.d/welcome.conf": ""})
+	if got := panel.MPMFile(rhel, "prefork"); got != "/etc/httpd/conf.d/mpm.conf" {
+		t.Errorf("MPMFile(rhel) = %s", got)
+	}
+
+	// Neither directory exists: the first candidate is still named
+	if got := panel.MPMFile(t.TempDir(), "worker"); got != "/etc/apache2/mods-available/mpm_worker.conf" {
+		t.Errorf("MPMFile(empty) = %s", got)
+	}
+	if got := (&ControlPanel{}).MPMFile("", "event"); got != "" {
+		t.Errorf("MPMFile() without candidates = %q, want empty", got)
+	}
+}
+
+func TestParseInstanceInRoot_ControlPanel(t *testing.T) {
+	// cPanel's EasyApache 4 layout, with a leftover distro config that must lose
+	root := t.TempDir()
+	writeTree(t, root, map[string]string{
+		"usr/local/cpanel/version": "110.0.0\n",
+		"etc/apache2/conf/httpd.conf": `LoadModule mpm_prefork_module modules/mod_mpm_prefork.so
+Include "/etc/apache2/conf.d/includes/pre_main_global.conf"
+`,
+		"etc/apache2/conf.d/includes/pre_main_global.conf": `<IfModule prefork.c>
+    MaxRequestWorkers 120
+</IfModule>
+`,
+		"etc/apache2/apache2.conf": "MaxRequestWorkers 999\n",
+	})
+
+	config, err := ParseInstanceInRoot(root, "", "", true)
+	if err != nil {
+		t.Fatalf("ParseInstanceInRoot() error = %v", err)
+	}
+	if config.ControlPanel == nil || config.ControlPanel.Name != "cPanel" {
+		t.Fatalf("ControlPanel = %+v, want cPanel", config.ControlPanel)
+	}
+	if config.ConfigPath != "/etc/apache2/conf/httpd.conf" {
+		t.Errorf("ConfigPath = %s, want cPanel's httpd.conf", config.ConfigPath)
+	}
+	if config.MaxRequestWorkers != 120 {
+		t.Errorf("MaxRequestWorkers = %d, want 120 from the pre_main include", config.MaxRequestWorkers)
+	}
+	if got := config.ControlPanel.MPMFile(root, config.MPMModel); got != "/etc/apache2/conf.d/includes/pre_main_global.conf" {
+		t.Errorf("MPMFile() = %s", got)
+	}
+}
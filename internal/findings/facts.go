@@ -0,0 +1,32 @@
+package findings
+
+import "reflect"
+
+// Facts is what rules are checked against: one value per type, so rules can
+// ask for exactly what they need without this package knowing the types
+type Facts struct {
+	values map[reflect.Type]interface{}
+}
+
+// NewFacts returns an empty bag
+func NewFacts() *Facts {
+	return &Facts{values: make(map[reflect.Type]interface{})}
+}
+
+// Put stores value under its type, replacing an earlier value of that type
+func Put[T any](facts *Facts, value T) {
+	facts.values[reflect.TypeOf((*T)(nil)).Elem()] = value
+}
+
+// Get returns the value stored under type T. ok is false when there is none
+// or it is a nil pointer.
+func Get[T any](facts *Facts) (value T, ok bool) {
+	stored, found := facts.values[reflect.TypeOf((*T)(nil)).Elem()]
+	if !found {
+		return value, false
+	}
+	if v := reflect.ValueOf(stored); v.Kind() == reflect.Ptr && v.IsNil() {
+		return value, false
+	}
+	return stored.(T), true
+}
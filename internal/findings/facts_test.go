@@ -0,0 +1,32 @@
+package findings
+
+import "testing"
+
+type sample struct{ Name string }
+
+func TestFacts(t *testing.T) {
+	facts := NewFacts()
+
+	if _, ok := Get[*sample](facts); ok {
+		t.Error("Get() on an empty bag should report nothing")
+	}
+
+	Put(facts, &sample{Name: "first"})
+	Put(facts, "text")
+	if got, ok := Get[*sample](facts); !ok || got.Name != "first" {
+		t.Errorf("Get[*sample]() = %v, %v", got, ok)
+	}
+	if got, ok := Get[string](facts); !ok || got != "text" {
+		t.Errorf("Get[string]() = %q, %v", got, ok)
+	}
+
+	Put(facts, &sample{Name: "second"})
+	if got, _ := Get[*sample](facts); got.Name != "second" {
+		t.Errorf("Put() should replace a value of the same type, got %q", got.Name)
+	}
+
+	Put[*sample](facts, nil)
+	if _, ok := Get[*sample](facts); ok {
+		t.Error("Get() of a nil pointer should report nothing")
+	}
+}
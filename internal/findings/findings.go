@@ -0,0 +1,184 @@
+package findings
+
+import (
+	"fmt"
+	"sort"
+	"sync"
+	"time"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// Severity orders findings; the worst one decides the overall status
+type Severity int
+
+const (
+	SeverityInfo     Severity = iota // Worth knowing, does not change the status
+	SeverityWarning                  // Could be improved
+	SeverityCritical                 // Needs immediate attention
+)
+
+// String returns the status a severity maps to
+func (s Severity) String() string {
+	switch s {
+	case SeverityWarning:
+		return "WARNING"
+	case SeverityCritical:
+		return "CRITICAL"
+	default:
+		return "INFO"
+	}
+}
+
+// Categories of findings
+const (
+	CategoryMemory        = "memory"
+	CategoryCPU           = "cpu"
+	CategoryLimits        = "limits"
+	CategoryDemand        = "demand"
+	CategoryLogs          = "logs"
+	CategoryPHPFPM        = "phpfpm"
+	CategoryConfiguration = "configuration"
+)
+
+// Finding is one result of a rule. ID is stable across releases so that
+// findings can be suppressed and alerted on.
+type Finding struct {
+	ID          string // e.g. "memory.over-budget"
+	Severity    Severity
+	Category    string
+	Title       string
+	Detail      string
+	Evidence    []string
+	Remediation string
+
+	Acknowledged *Suppression // Set when a suppression covers the finding
+}
+
+// Rule checks one aspect of the facts gathered about an instance
+type Rule interface {
+	Name() string
+	Check(facts *Facts) []Finding
+}
+
+// RuleFunc adapts a function to the Rule interface
+type RuleFunc struct {
+	RuleName string
+	Func     func(facts *Facts) []Finding
+}
+
+// Name returns the rule's name
+func (r RuleFunc) Name() string { return r.RuleName }
+
+// Check runs the function
+func (r RuleFunc) Check(facts *Facts) []Finding { return r.Func(facts) }
+
+var (
+	registryMu sync.Mutex
+	registry   []Rule
+)
+
+// Register adds a rule to those Evaluate runs. Subsystems register their
+// rules from init; registering a name twice panics.
+func Register(rule Rule) {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	for _, existing := range registry {
+		if existing.Name() == rule.Name() {
+			panic(fmt.Sprintf("findings: rule %q registered twice", rule.Name()))
+		}
+	}
+	registry = append(registry, rule)
+}
+
+// RegisterFunc registers check under name
+func RegisterFunc(name string, check func(facts *Facts) []Finding) {
+	Register(RuleFunc{RuleName: name, Func: check})
+}
+
+// Rules returns the registered rules sorted by name
+func Rules() []Rule {
+	registryMu.Lock()
+	defer registryMu.Unlock()
+	rules := append([]Rule(nil), registry...)
+	sort.Slice(rules, func(i, j int) bool { return rules[i].Name() < rules[j].Name() })
+	return rules
+}
+
+// Evaluate runs every registered rule against facts and returns the
+// findings, worst first. Findings named by the Suppressions in facts are
+// acknowledged.
+func Evaluate(facts *Facts) []Finding {
+	return evaluate(Rules(), facts, time.Now())
+}
+
+func evaluate(rules []Rule, facts *Facts, now time.Time) []Finding {
+	var all []Finding
+	for _, rule := range rules {
+		found := rule.Check(facts)
+		debug.Printf("Rule %s: %d finding(s)", rule.Name(), len(found))
+		all = append(all, found...)
+	}
+	if suppressions, ok := Get[Suppressions](facts); ok {
+		suppressions.Apply(all, now)
+	}
+	Sort(all)
+	return all
+}
+
+// Sort orders findings worst first, then by ID, with acknowledged findings
+// after the rest
+func Sort(list []Finding) {
+	sort.SliceStable(list, func(i, j int) bool {
+		if (list[i].Acknowledged == nil) != (list[j].Acknowledged == nil) {
+			return list[i].Acknowledged == nil
+		}
+		if list[i].Severity != list[j].Severity {
+			return list[i].Severity > list[j].Severity
+		}
+		return list[i].ID < list[j].ID
+	})
+}
+
+// Status returns the overall status of list: OK, WARNING or CRITICAL.
+// Acknowledged findings do not count.
+func Status(list []Finding) string {
+	worst := SeverityInfo
+	for _, finding := range list {
+		if finding.Acknowledged == nil && finding.Severity > worst {
+			worst = finding.Severity
+		}
+	}
+	if worst == SeverityInfo {
+		return "OK"
+	}
+	return worst.String()
+}
+
+// ExitCode returns the process exit code for list: 0 OK, 1 WARNING, 2 CRITICAL
+func ExitCode(list []Finding) int {
+	switch Status(list) {
+	case "CRITICAL":
+		return 2
+	case "WARNING":
+		return 1
+	default:
+		return 0
+	}
+}
+
+// Any reports whether list has an unacknowledged finding of at least
+// severity in one of categories
+func Any(list []Finding, severity Severity, categories ...string) bool {
+	for _, finding := range list {
+		if finding.Acknowledged != nil || finding.Severity < severity {
+			continue
+		}
+		for _, category := range categories {
+			if finding.Category == category {
+				return true
+			}
+		}
+	}
+	return false
+}
@@ -0,0 +1,127 @@
+package findings
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSeverityString(t *testing.T) {
+	tests := map[Severity]string{SeverityInfo: "INFO", SeverityWarning: "WARNING", SeverityCritical: "CRITICAL"}
+	for severity, want := range tests {
+		if got := severity.String(); got != want {
+			t.Errorf("%d.String() = %q, want %q", severity, got, want)
+		}
+	}
+}
+
+func TestEvaluate(t *testing.T) {
+	rules := []Rule{
+		RuleFunc{RuleName: "b", Func: func(*Facts) []Finding {
+			return []Finding{{ID: "b.note", Severity: SeverityInfo}, {ID: "b.warn", Severity: SeverityWarning}}
+		}},
+		RuleFunc{RuleName: "a", Func: func(facts *Facts) []Finding {
+			if count, ok := Get[int](facts); ok && count > 3 {
+				return []Finding{{ID: "a.critical", Severity: SeverityCritical}}
+			}
+			return nil
+		}},
+		RuleFunc{RuleName: "c", Func: func(*Facts) []Finding {
+			return []Finding{{ID: "a.warn", Severity: SeverityWarning}}
+		}},
+	}
+	facts := NewFacts()
+	Put(facts, 5)
+
+	got := evaluate(rules, facts, time.Now())
+	var gotIDs []string
+	for _, finding := range got {
+		gotIDs = append(gotIDs, finding.ID)
+	}
+	if want := []string{"a.critical", "a.warn", "b.warn", "b.note"}; !reflect.DeepEqual(gotIDs, want) {
+		t.Errorf("evaluate() = %v, want %v", gotIDs, want)
+	}
+}
+
+func TestStatusAndExitCode(t *testing.T) {
+	tests := []struct {
+		name       string
+		list       []Finding
+		wantStatus string
+		wantCode   int
+	}{
+		{"no findings", nil, "OK", 0},
+		{"notes only", []Finding{{Severity: SeverityInfo}}, "OK", 0},
+		{"warning", []Finding{{Severity: SeverityInfo}, {Severity: SeverityWarning}}, "WARNING", 1},
+		{"critical", []Finding{{Severity: SeverityWarning}, {Severity: SeverityCritical}}, "CRITICAL", 2},
+		{"acknowledged critical", []Finding{{Severity: SeverityWarning}, {Severity: SeverityCritical, Acknowledged: &Suppression{}}}, "WARNING", 1},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := Status(tt.list); got != tt.wantStatus {
+				t.Errorf("Status() = %s, want %s", got, tt.wantStatus)
+			}
+			if got := ExitCode(tt.list); got != tt.wantCode {
+				t.Errorf("ExitCode() = %d, want %d", got, tt.wantCode)
+			}
+		})
+	}
+}
+
+func TestAny(t *testing.T) {
+	list := []Finding{
+		{Severity: SeverityInfo, Category: CategoryMemory},
+		{Severity: SeverityWarning, Category: CategoryLimits},
+	}
+	if Any(list, SeverityWarning, CategoryMemory, CategoryCPU) {
+		t.Error("Any() should ignore findings below the severity")
+	}
+	if !Any(list, SeverityInfo, CategoryMemory) {
+		t.Error("Any() should find the memory note")
+	}
+	if !Any(list, SeverityWarning, CategoryLimits) {
+		t.Error("Any() should find the limits warning")
+	}
+	list[1].Acknowledged = &Suppression{ID: "limits.somaxconn"}
+	if Any(list, SeverityWarning, CategoryLimits) {
+		t.Error("Any() should ignore acknowledged findings")
+	}
+}
+
+func TestEvaluateAcknowledges(t *testing.T) {
+	rules := []Rule{
+		RuleFunc{RuleName: "a", Func: func(*Facts) []Finding {
+			return []Finding{{ID: "a.critical", Severity: SeverityCritical}, {ID: "a.warn", Severity: SeverityWarning}}
+		}},
+	}
+	facts := NewFacts()
+	Put(facts, Suppressions{{ID: "a.critical", Reason: "known"}})
+
+	got := evaluate(rules, facts, time.Now())
+	if len(got) != 2 || got[0].ID != "a.warn" || got[1].Acknowledged == nil || got[1].Acknowledged.Reason != "known" {
+		t.Fatalf("evaluate() = %+v, want a.warn then acknowledged a.critical", got)
+	}
+	if Status(got) != "WARNING" {
+		t.Errorf("Status() = %s, want WARNING", Status(got))
+	}
+}
+
+func TestRegister(t *testing.T) {
+	saved := registry
+	defer func() { registry = saved }()
+	registry = nil
+
+	RegisterFunc("z", func(*Facts) []Finding { return nil })
+	RegisterFunc("y", func(*Facts) []Finding { return nil })
+	rules := Rules()
+	if len(rules) != 2 || rules[0].Name() != "y" || rules[1].Name() != "z" {
+		t.Errorf("Rules() = %v, want y and z in order", rules)
+	}
+
+	defer func() {
+		if recover() == nil {
+			t.Error("Register() of a duplicate name should panic")
+		}
+	}()
+	RegisterFunc("z", func(*Facts) []Finding { return nil })
+}
@@ -0,0 +1,106 @@
+package findings
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"time"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// DefaultSuppressionFile is the optional list of acknowledged findings read
+// at startup
+const DefaultSuppressionFile = "/etc/apache2buddy-go/suppressions.json"
+
+// suppressionDateLayout is how expiry dates are written
+const suppressionDateLayout = "2006-01-02"
+
+// Suppression acknowledges a finding on this host. An acknowledged finding is
+// still reported but does not count towards the status or exit code.
+type Suppression struct {
+	ID      string    // Finding ID, e.g. "configuration.vhosts-exceed-workers"
+	Expires time.Time // Last day the suppression applies; zero for never
+	Reason  string
+}
+
+// Suppressions is the set of suppressions evaluated findings are matched to
+type Suppressions []Suppression
+
+// Active reports whether s still applies at now. A suppression expires at
+// the end of its expiry date.
+func (s Suppression) Active(now time.Time) bool {
+	return s.Expires.IsZero() || now.Before(s.Expires.AddDate(0, 0, 1))
+}
+
+// suppressionEntry is the JSON form of a Suppression
+type suppressionEntry struct {
+	ID      string `json:"id"`
+	Expires string `json:"expires"` // "2026-12-31"
+	Reason  string `json:"reason"`
+}
+
+// LoadSuppressions reads the JSON array of suppressions at path. A missing
+// file is not an error; an invalid one yields no suppressions and the error.
+func LoadSuppressions(path string) (Suppressions, error) {
+	if path == "" {
+		return nil, nil
+	}
+	data, err := os.ReadFile(path)
+	if os.IsNotExist(err) {
+		debug.Printf("No suppression file at %s", path)
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("cannot read suppressions %s: %v", path, err)
+	}
+	suppressions, err := parseSuppressions(data)
+	if err != nil {
+		return nil, fmt.Errorf("suppressions %s: %v", path, err)
+	}
+	debug.Printf("Loaded %d suppression(s) from %s", len(suppressions), path)
+	return suppressions, nil
+}
+
+// parseSuppressions decodes a JSON array of suppressions and validates them
+func parseSuppressions(data []byte) (Suppressions, error) {
+	var entries []suppressionEntry
+	if err := json.Unmarshal(data, &entries); err != nil {
+		return nil, err
+	}
+	suppressions := make(Suppressions, 0, len(entries))
+	for i, entry := range entries {
+		if entry.ID == "" {
+			return nil, fmt.Errorf("entry %d has no id", i+1)
+		}
+		suppression := Suppression{ID: entry.ID, Reason: entry.Reason}
+		if entry.Expires != "" {
+			expires, err := time.ParseInLocation(suppressionDateLayout, entry.Expires, time.Local)
+			if err != nil {
+				return nil, fmt.Errorf("suppression %s: expiry %q is not a YYYY-MM-DD date", entry.ID, entry.Expires)
+			}
+			suppression.Expires = expires
+		}
+		suppressions = append(suppressions, suppression)
+	}
+	return suppressions, nil
+}
+
+// Apply acknowledges the findings in list that an active suppression names.
+// Expired suppressions are ignored, so the finding counts again.
+func (s Suppressions) Apply(list []Finding, now time.Time) {
+	for i := range list {
+		for _, suppression := range s {
+			if suppression.ID != list[i].ID {
+				continue
+			}
+			if !suppression.Active(now) {
+				debug.Printf("Suppression of %s expired on %s", suppression.ID, suppression.Expires.Format(suppressionDateLayout))
+				continue
+			}
+			acknowledged := suppression
+			list[i].Acknowledged = &acknowledged
+			break
+		}
+	}
+}
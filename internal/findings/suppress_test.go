@@ -0,0 +1,75 @@
+package findings
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestLoadSuppressions(t *testing.T) {
+	dir := t.TempDir()
+	write := func(name, content string) string {
+		path := filepath.Join(dir, name)
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return path
+	}
+
+	if got, err := LoadSuppressions(filepath.Join(dir, "missing.json")); err != nil || got != nil {
+		t.Errorf("LoadSuppressions(missing) = %v, %v; want none", got, err)
+	}
+
+	got, err := LoadSuppressions(write("ok.json", `[
+		{"id": "configuration.vhosts-exceed-workers", "reason": "reseller box"},
+		{"id": "limits.somaxconn", "expires": "2026-12-31", "reason": "kernel upgrade scheduled"}
+	]`))
+	if err != nil {
+		t.Fatalf("LoadSuppressions() error = %v", err)
+	}
+	if len(got) != 2 || got[0].ID != "configuration.vhosts-exceed-workers" || !got[0].Expires.IsZero() || got[0].Reason != "reseller box" {
+		t.Errorf("LoadSuppressions()[0] = %+v", got)
+	}
+	if want := time.Date(2026, 12, 31, 0, 0, 0, 0, time.Local); len(got) == 2 && !got[1].Expires.Equal(want) {
+		t.Errorf("Expires = %s, want %s", got[1].Expires, want)
+	}
+
+	for name, content := range map[string]string{
+		"syntax.json": `{"id": "a"}`,
+		"noid.json":   `[{"reason": "no id"}]`,
+		"date.json":   `[{"id": "a", "expires": "31/12/2026"}]`,
+	} {
+		if got, err := LoadSuppressions(write(name, content)); err == nil || got != nil {
+			t.Errorf("LoadSuppressions(%s) = %v, %v; want an error and no suppressions", name, got, err)
+		}
+	}
+}
+
+func TestSuppressionsApply(t *testing.T) {
+	expires := time.Date(2026, 6, 30, 0, 0, 0, 0, time.Local)
+	suppressions := Suppressions{
+		{ID: "memory.above-target", Expires: expires, Reason: "migration pending"},
+		{ID: "configuration.threaded-mpm"},
+	}
+	tests := []struct {
+		name string
+		now  time.Time
+		want []bool // Acknowledged, per finding
+	}{
+		{"before expiry", time.Date(2026, 6, 1, 12, 0, 0, 0, time.Local), []bool{true, true, false}},
+		{"on expiry date", time.Date(2026, 6, 30, 23, 0, 0, 0, time.Local), []bool{true, true, false}},
+		{"after expiry", time.Date(2026, 7, 1, 0, 0, 0, 0, time.Local), []bool{false, true, false}},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			list := []Finding{{ID: "memory.above-target"}, {ID: "configuration.threaded-mpm"}, {ID: "limits.somaxconn"}}
+			suppressions.Apply(list, tt.now)
+			for i, finding := range list {
+				if got := finding.Acknowledged != nil; got != tt.want[i] {
+					t.Errorf("%s acknowledged = %v, want %v", finding.ID, got, tt.want[i])
+				}
+			}
+		})
+	}
+}
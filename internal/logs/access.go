@@ -0,0 +1,179 @@
+package logs
+
+import (
+	"bufio"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"apache2buddy-go/internal/analysis"
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/debug"
+)
+
+// accessLogPatterns match the access logs of common layouts, one per virtual
+// host included; rotated logs do not match
+var accessLogPatterns = []string{
+	"/var/log/apache2/*access.log",
+	"/var/log/apache2/*access_log",
+	"/var/log/httpd/*access_log",
+	"/var/log/httpd/*access.log",
+	"/usr/local/apache2/logs/*access_log",
+}
+
+// accessLogTail is how much of the end of each access log is read
+const accessLogTail = 8 << 20
+
+// accessTimeLayout is the %t timestamp of the common and combined formats
+const accessTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// AccessRate is the request rate found in the access logs
+type AccessRate struct {
+	Files      []string
+	Requests   int
+	First      time.Time
+	Last       time.Time
+	PeakPerSec float64   // Requests per second in the busiest minute
+	PeakMinute time.Time // Start of the busiest minute
+}
+
+// MeanPerSec returns the average request rate between the first and last request
+func (r *AccessRate) MeanPerSec() float64 {
+	span := r.Last.Sub(r.First).Seconds()
+	if span <= 0 {
+		return 0
+	}
+	return float64(r.Requests) / span
+}
+
+// Peak returns the busiest minute as a demand input, nil when r is nil or empty
+func (r *AccessRate) Peak() *analysis.RequestPeak {
+	if r == nil || r.PeakPerSec == 0 {
+		return nil
+	}
+	return &analysis.RequestPeak{
+		PerSec: r.PeakPerSec,
+		At:     r.PeakMinute,
+		Source: fmt.Sprintf("busiest minute of %d access log(s)", len(r.Files)),
+	}
+}
+
+// AnalyzeAccessLogs counts requests per minute across the access logs of
+// one Apache instance, so that virtual hosts logging separately add up. The
+// logs are those its CustomLog and TransferLog directives name. When they
+// name none and the instance is the only one on the host, the standard
+// locations are read instead; with several instances a log that no config
+// names cannot be attributed. It returns nil when no log has a request in it.
+func AnalyzeAccessLogs(apacheConfig *config.ApacheConfig, onlyInstance bool) *AccessRate {
+	if len(apacheConfig.AccessLogs) > 0 {
+		paths := make([]string, len(apacheConfig.AccessLogs))
+		for i, path := range apacheConfig.AccessLogs {
+			paths[i] = apacheConfig.HostPath(path)
+		}
+		if rate := analyzeAccessLogs(paths); rate != nil {
+			return rate
+		}
+	}
+	if !onlyInstance {
+		return nil
+	}
+	return analyzeAccessLogs(accessLogPatterns)
+}
+
+func analyzeAccessLogs(patterns []string) *AccessRate {
+	rate := &AccessRate{}
+	minutes := make(map[int64]int)
+	for _, pattern := range patterns {
+		files, err := filepath.Glob(pattern)
+		if err != nil {
+			continue
+		}
+		for _, file := range files {
+			if !isReadableLogFile(file) {
+				continue
+			}
+			if err := countAccessLog(file, rate, minutes); err != nil {
+				debug.Error(err, "reading access log "+file)
+				continue
+			}
+			rate.Files = append(rate.Files, file)
+		}
+	}
+	if rate.Requests == 0 {
+		return nil
+	}
+
+	for minute, requests := range minutes {
+		perSec := float64(requests) / 60
+		if perSec > rate.PeakPerSec || (perSec == rate.PeakPerSec && minute < rate.PeakMinute.Unix()) {
+			rate.PeakPerSec = perSec
+			rate.PeakMinute = time.Unix(minute, 0).In(rate.Last.Location())
+		}
+	}
+	debug.Printf("Access logs: %d requests, peak %.2f/s at %s", rate.Requests, rate.PeakPerSec, rate.PeakMinute)
+	return rate
+}
+
+// countAccessLog adds the requests at the end of one log to rate and to the
+// per-minute counts
+func countAccessLog(path string, rate *AccessRate, minutes map[int64]int) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			debug.Error(err, "closing access log")
+		}
+	}()
+
+	skipFirst := false
+	if info, err := file.Stat(); err == nil && info.Size() > accessLogTail {
+		if _, err := file.Seek(-accessLogTail, io.SeekEnd); err != nil {
+			return err
+		}
+		skipFirst = true // Starts mid-line
+	}
+
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if skipFirst {
+			skipFirst = false
+			continue
+		}
+		at, ok := parseAccessTime(scanner.Text())
+		if !ok {
+			continue
+		}
+		rate.Requests++
+		if rate.First.IsZero() || at.Before(rate.First) {
+			rate.First = at
+		}
+		if at.After(rate.Last) {
+			rate.Last = at
+		}
+		minutes[at.Truncate(time.Minute).Unix()]++
+	}
+	return scanner.Err()
+}
+
+// parseAccessTime returns the [%t] timestamp of an access log line
+func parseAccessTime(line string) (time.Time, bool) {
+	start := strings.IndexByte(line, '[')
+	if start < 0 {
+		return time.Time{}, false
+	}
+	end := strings.IndexByte(line[start:], ']')
+	if end < 0 {
+		return time.Time{}, false
+	}
+	at, err := time.Parse(accessTimeLayout, line[start+1:start+end])
+	if err != nil {
+		return time.Time{}, false
+	}
+	return at, true
+}
@@ -0,0 +1,122 @@
+package logs
+
+import (
+	"fmt"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"apache2buddy-go/internal/config"
+)
+
+func TestAnalyzeAccessLogs(t *testing.T) {
+	dir := t.TempDir()
+	logsByName := map[string]string{
+		"access.log": `10.0.0.1 - - [02/Mar/2026:14:04:59 +0000] "GET / HTTP/1.1" 200 512
+10.0.0.2 - - [02/Mar/2026:14:05:01 +0000] "GET /a HTTP/1.1" 200 512
+10.0.0.3 - - [02/Mar/2026:14:05:30 +0000] "GET /b HTTP/1.1" 200 512
+not a request line
+`,
+		"shop_access.log": `shop.example:443 10.0.0.4 - - [02/Mar/2026:14:05:59 +0000] "GET / HTTP/1.1" 200 512
+shop.example:443 10.0.0.5 - - [02/Mar/2026:14:08:59 +0000] "GET / HTTP/1.1" 200 512
+`,
+		"access.log.1": `10.0.0.6 - - [01/Mar/2026:14:05:00 +0000] "GET / HTTP/1.1" 200 512
+`,
+	}
+	for name, content := range logsByName {
+		if err := os.WriteFile(filepath.Join(dir, name), []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	rate := analyzeAccessLogs([]string{filepath.Join(dir, "*access.log")})
+	if rate == nil {
+		t.Fatal("analyzeAccessLogs() = nil")
+	}
+	if len(rate.Files) != 2 {
+		t.Errorf("Files = %v, want the two current logs", rate.Files)
+	}
+	if rate.Requests != 5 {
+		t.Errorf("Requests = %d, want 5", rate.Requests)
+	}
+	if rate.PeakPerSec != 3.0/60 {
+		t.Errorf("PeakPerSec = %g, want %g", rate.PeakPerSec, 3.0/60)
+	}
+	if want := time.Date(2026, 3, 2, 14, 5, 0, 0, time.UTC); !rate.PeakMinute.Equal(want) {
+		t.Errorf("PeakMinute = %s, want %s", rate.PeakMinute, want)
+	}
+	if got := rate.MeanPerSec(); got != 5.0/240 {
+		t.Errorf("MeanPerSec() = %g, want %g", got, 5.0/240)
+	}
+
+	peak := rate.Peak()
+	if peak == nil || peak.PerSec != rate.PeakPerSec || peak.Source != "busiest minute of 2 access log(s)" {
+		t.Errorf("Peak() = %+v", peak)
+	}
+	if (*AccessRate)(nil).Peak() != nil {
+		t.Error("Peak() of nil should be nil")
+	}
+
+	if rate := analyzeAccessLogs([]string{filepath.Join(dir, "missing*.log")}); rate != nil {
+		t.Errorf("analyzeAccessLogs() without logs = %+v, want nil", rate)
+	}
+}
+
+// TestAnalyzeAccessLogs_PerInstance gives two instances their own logs, so
+// that the busy one's peak does not count towards the quiet one
+func TestAnalyzeAccessLogs_PerInstance(t *testing.T) {
+	dir := t.TempDir()
+	busy := filepath.Join(dir, "busy_access.log")
+	quiet := filepath.Join(dir, "quiet_access.log")
+	var lines string
+	for i := 0; i < 6; i++ {
+		lines += fmt.Sprintf("10.0.0.%d - - [02/Mar/2026:14:05:%02d +0000] \"GET / HTTP/1.1\" 200 512\n", i, i)
+	}
+	if err := os.WriteFile(busy, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(quiet, []byte(`10.0.0.9 - - [02/Mar/2026:14:05:00 +0000] "GET / HTTP/1.1" 200 512
+`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	rate := AnalyzeAccessLogs(&config.ApacheConfig{AccessLogs: []string{quiet}}, false)
+	if rate == nil || rate.Requests != 1 || rate.PeakPerSec != 1.0/60 || len(rate.Files) != 1 {
+		t.Errorf("AnalyzeAccessLogs() of the quiet instance = %+v, want its one request", rate)
+	}
+	rate = AnalyzeAccessLogs(&config.ApacheConfig{AccessLogs: []string{busy, quiet}}, false)
+	if rate == nil || rate.Requests != 7 || rate.PeakPerSec != 7.0/60 {
+		t.Errorf("AnalyzeAccessLogs() of both logs = %+v, want 7 requests in one minute", rate)
+	}
+
+	// Containers log below their root
+	rate = AnalyzeAccessLogs(&config.ApacheConfig{RootPath: dir, AccessLogs: []string{"/busy_access.log"}}, false)
+	if rate == nil || rate.Requests != 6 {
+		t.Errorf("AnalyzeAccessLogs() in a root = %+v, want 6 requests", rate)
+	}
+	if rate := AnalyzeAccessLogs(&config.ApacheConfig{}, false); rate != nil {
+		t.Errorf("AnalyzeAccessLogs() of one of several instances without CustomLog = %+v, want nil", rate)
+	}
+}
+
+func TestParseAccessTime(t *testing.T) {
+	tests := []struct {
+		line string
+		ok   bool
+	}{
+		{`127.0.0.1 - frank [10/Oct/2000:13:55:36 -0700] "GET /apache_pb.gif HTTP/1.0" 200 2326`, true},
+		{`127.0.0.1 - - [garbage] "GET / HTTP/1.0" 200 2326`, false},
+		{`127.0.0.1 - - [10/Oct/2000:13:55:36 -0700`, false},
+		{``, false},
+	}
+	for _, tt := range tests {
+		at, ok := parseAccessTime(tt.line)
+		if ok != tt.ok {
+			t.Errorf("parseAccessTime(%q) ok = %v, want %v", tt.line, ok, tt.ok)
+		}
+		if ok && at.UTC() != time.Date(2000, 10, 10, 20, 55, 36, 0, time.UTC) {
+			t.Errorf("parseAccessTime(%q) = %s", tt.line, at)
+		}
+	}
+}
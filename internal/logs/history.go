@@ -0,0 +1,135 @@
+package logs
+
+import (
+	"bufio"
+	"os"
+	"regexp"
+	"strconv"
+	"strings"
+	"time"
+
+	"apache2buddy-go/internal/analysis"
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/debug"
+)
+
+// HistoryFile is where every run appends its figures
+const HistoryFile = "/var/log/apache2buddy-go.log"
+
+const historyTimeFormat = "2006/01/02 15:04:05"
+
+// historyField matches one `Name: "value"` pair of a history line
+var historyField = regexp.MustCompile(`(\w+): "([^"]*)"`)
+
+// HistoryEntry is one run read back from the history log
+type HistoryEntry struct {
+	Time      time.Time
+	Instance  string
+	MPM       string
+	LargestMB float64
+	Busy      int // Busy workers from mod_status, 0 when not recorded
+}
+
+// historyInstance names the instance in the history log
+func historyInstance(config *config.ApacheConfig) string {
+	if config.InstanceID == "" {
+		return "default"
+	}
+	return config.InstanceID
+}
+
+// parseHistoryEntry reads a line written by formatLogEntry. Lines from
+// older versions have no instance; see AdoptLegacyHistory.
+func parseHistoryEntry(line string) (HistoryEntry, bool) {
+	if len(line) < len(historyTimeFormat) {
+		return HistoryEntry{}, false
+	}
+	at, err := time.ParseInLocation(historyTimeFormat, line[:len(historyTimeFormat)], time.Local)
+	if err != nil {
+		return HistoryEntry{}, false
+	}
+	entry := HistoryEntry{Time: at}
+	for _, match := range historyField.FindAllStringSubmatch(line, -1) {
+		value := match[2]
+		switch match[1] {
+		case "Instance":
+			entry.Instance = value
+		case "MPM":
+			entry.MPM = value
+		case "Largest":
+			entry.LargestMB, _ = strconv.ParseFloat(strings.TrimSuffix(value, " MB"), 64)
+		case "Busy":
+			entry.Busy, _ = strconv.Atoi(value)
+		}
+	}
+	return entry, true
+}
+
+// ReadHistory returns the runs recorded in path. A missing file is no history.
+func ReadHistory(path string) ([]HistoryEntry, error) {
+	file, err := os.Open(path)
+	if os.IsNotExist(err) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			debug.Error(err, "closing history file")
+		}
+	}()
+
+	var entries []HistoryEntry
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		if entry, ok := parseHistoryEntry(scanner.Text()); ok {
+			entries = append(entries, entry)
+		}
+	}
+	return entries, scanner.Err()
+}
+
+// AdoptLegacyHistory assigns the lines older versions wrote without an
+// instance to the instance of configs when there is only one: those versions
+// analysed a single Apache, so its history carries over an upgrade. With
+// several instances the lines cannot be told apart and stay unused.
+func AdoptLegacyHistory(entries []HistoryEntry, configs []*config.ApacheConfig) {
+	if len(configs) != 1 {
+		return
+	}
+	instance := historyInstance(configs[0])
+	for i := range entries {
+		if entries[i].Instance == "" {
+			entries[i].Instance = instance
+		}
+	}
+}
+
+// HistoryPeaks returns the largest worker and the most busy workers of the
+// runs of config's instance and MPM in the days before now; nil when there
+// are none. Runs under another MPM are left out, their workers differ.
+func HistoryPeaks(entries []HistoryEntry, config *config.ApacheConfig, days int, now time.Time) *analysis.HistoryPeak {
+	if days <= 0 {
+		return nil
+	}
+	since := now.AddDate(0, 0, -days)
+	instance := historyInstance(config)
+	peak := &analysis.HistoryPeak{Days: days}
+	for _, entry := range entries {
+		if entry.Time.Before(since) || entry.Time.After(now) || entry.Instance != instance || entry.MPM != config.MPMModel {
+			continue
+		}
+		peak.Runs++
+		if entry.LargestMB > peak.LargestMB {
+			peak.LargestMB, peak.LargestAt = entry.LargestMB, entry.Time
+		}
+		if entry.Busy > peak.BusyWorkers {
+			peak.BusyWorkers, peak.BusyAt = entry.Busy, entry.Time
+		}
+	}
+	if peak.Runs == 0 {
+		return nil
+	}
+	return peak
+}
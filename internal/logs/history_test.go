@@ -0,0 +1,134 @@
+package logs
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"apache2buddy-go/internal/analysis"
+	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/process"
+	"apache2buddy-go/internal/system"
+)
+
+const historyLog = `2026/10/01 03:00:00 Memory: "3000 MB" MaxClients: "150" Recommended: "90" Status: "OK" Smallest: "10.00 MB" Avg: "20.00 MB" Largest: "30.00 MB"
+2026/10/10 03:00:00 Memory: "3000 MB" MaxClients: "150" Recommended: "120" Status: "OK" Smallest: "10.00 MB" Avg: "15.00 MB" Largest: "22.00 MB" MPM: "prefork" Instance: "default" Busy: "4"
+2026/10/14 14:05:00 Memory: "3000 MB" MaxClients: "150" Recommended: "60" Status: "WARNING" Smallest: "20.00 MB" Avg: "35.00 MB" Largest: "45.50 MB" MPM: "prefork" Instance: "default" Busy: "72" Policy: "reserve 0 MB, margin 90-100%, sizing on largest"
+2026/10/15 14:05:00 Memory: "3000 MB" MaxClients: "150" Recommended: "10" Status: "OK" Smallest: "80.00 MB" Avg: "90.00 MB" Largest: "99.00 MB" MPM: "event" Instance: "default" Busy: "300"
+2026/10/16 14:05:00 Memory: "3000 MB" MaxClients: "150" Recommended: "50" Status: "OK" Smallest: "50.00 MB" Avg: "55.00 MB" Largest: "60.00 MB" MPM: "prefork" Instance: "1234" Busy: "90"
+2026/10/17 03:00:00 Memory: "3000 MB" MaxClients: "150" Recommended: "130" Status: "OK" Smallest: "10.00 MB" Avg: "14.00 MB" Largest: "20.00 MB" MPM: "prefork" Instance: "default"
+not a history line
+`
+
+func TestReadHistory(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apache2buddy-go.log")
+	if entries, err := ReadHistory(path); err != nil || entries != nil {
+		t.Errorf("ReadHistory() of a missing file = %v, %v; want no history", entries, err)
+	}
+	if err := os.WriteFile(path, []byte(historyLog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ReadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(entries) != 6 {
+		t.Fatalf("ReadHistory() = %d entries, want 6", len(entries))
+	}
+	want := HistoryEntry{Time: time.Date(2026, 10, 14, 14, 5, 0, 0, time.Local), Instance: "default", MPM: "prefork", LargestMB: 45.5, Busy: 72}
+	if entries[2] != want {
+		t.Errorf("entries[2] = %+v, want %+v", entries[2], want)
+	}
+	if entries[0].Instance != "" || entries[0].MPM != "" || entries[0].LargestMB != 30 {
+		t.Errorf("entries[0] = %+v, want an old line without an instance", entries[0])
+	}
+}
+
+func TestHistoryPeaks(t *testing.T) {
+	path := filepath.Join(t.TempDir(), "apache2buddy-go.log")
+	if err := os.WriteFile(path, []byte(historyLog), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, _ := ReadHistory(path)
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+	prefork := &config.ApacheConfig{MPMModel: "prefork"}
+
+	// The old line and the event and other-instance runs are left out
+	peak := HistoryPeaks(entries, prefork, 14, now)
+	if peak == nil || peak.Runs != 3 || peak.LargestMB != 45.5 || peak.BusyWorkers != 72 {
+		t.Fatalf("HistoryPeaks() = %+v, want 3 runs peaking at 45.5 MB and 72 busy workers", peak)
+	}
+	if peak.LargestAt != entries[2].Time || peak.BusyAt != entries[2].Time || peak.Days != 14 {
+		t.Errorf("HistoryPeaks() = %+v, want both peaks on 2026-10-14", peak)
+	}
+
+	if peak := HistoryPeaks(entries, prefork, 3, now); peak == nil || peak.Runs != 1 || peak.LargestMB != 20 || peak.BusyWorkers != 0 {
+		t.Errorf("HistoryPeaks() over 3 days = %+v, want the quiet run only", peak)
+	}
+	if peak := HistoryPeaks(entries, &config.ApacheConfig{MPMModel: "prefork", InstanceID: "1234"}, 14, now); peak == nil || peak.LargestMB != 60 {
+		t.Errorf("HistoryPeaks() of instance 1234 = %+v", peak)
+	}
+	if HistoryPeaks(entries, prefork, 0, now) != nil || HistoryPeaks(entries, &config.ApacheConfig{MPMModel: "worker"}, 14, now) != nil {
+		t.Error("HistoryPeaks() should be nil without a window or matching runs")
+	}
+}
+
+func TestAdoptLegacyHistory(t *testing.T) {
+	legacy := `2026/10/12 14:05:00 Memory: "3000 MB" MaxClients: "150" Recommended: "60" Status: "OK" Smallest: "20.00 MB" Avg: "35.00 MB" Largest: "48.00 MB" MPM: "prefork" Busy: "80"
+2026/10/16 14:05:00 Memory: "3000 MB" MaxClients: "150" Recommended: "90" Status: "OK" Smallest: "20.00 MB" Avg: "25.00 MB" Largest: "30.00 MB" MPM: "prefork" Instance: "/etc/apache2:/etc/apache2/apache2.conf"
+`
+	path := filepath.Join(t.TempDir(), "apache2buddy-go.log")
+	if err := os.WriteFile(path, []byte(legacy), 0644); err != nil {
+		t.Fatal(err)
+	}
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+	web := &config.ApacheConfig{MPMModel: "prefork", InstanceID: "/etc/apache2:/etc/apache2/apache2.conf"}
+	other := &config.ApacheConfig{MPMModel: "prefork", InstanceID: "/etc/apache2-api:/etc/apache2-api/apache2.conf"}
+
+	// With several instances the old line belongs to none of them
+	entries, _ := ReadHistory(path)
+	AdoptLegacyHistory(entries, []*config.ApacheConfig{web, other})
+	if peak := HistoryPeaks(entries, web, 14, now); peak == nil || peak.Runs != 1 || peak.LargestMB != 30 {
+		t.Errorf("HistoryPeaks() with two instances = %+v, want the one new run", peak)
+	}
+
+	// A single instance takes over the history written before the upgrade
+	entries, _ = ReadHistory(path)
+	AdoptLegacyHistory(entries, []*config.ApacheConfig{web})
+	if peak := HistoryPeaks(entries, web, 14, now); peak == nil || peak.Runs != 2 || peak.LargestMB != 48 || peak.BusyWorkers != 80 {
+		t.Errorf("HistoryPeaks() with one instance = %+v, want both runs peaking at 48 MB and 80 busy workers", peak)
+	}
+}
+
+// TestHistoryPeaks_Restart records two runs of the same configuration under
+// different master PIDs, as after an Apache restart
+func TestHistoryPeaks_Restart(t *testing.T) {
+	var lines string
+	now := time.Date(2026, 10, 18, 12, 0, 0, 0, time.Local)
+	for i, run := range []struct {
+		masterPID int
+		largestMB float64
+	}{{1000, 45}, {2345, 20}} {
+		inst := process.Instance{MasterPID: run.masterPID}
+		apacheConfig := &config.ApacheConfig{MPMModel: "prefork", ConfigPath: "/etc/httpd/conf/httpd.conf", ServerRoot: "/etc/httpd"}
+		apacheConfig.InstanceID = inst.Key(apacheConfig.ConfigPath, apacheConfig.ServerRoot)
+		lines += formatLogEntry(now.Add(time.Duration(i-2)*time.Hour), &system.SystemInfo{}, &analysis.MemoryStats{LargestMB: run.largestMB},
+			apacheConfig, &analysis.Recommendations{Status: "OK"}, nil)
+	}
+	path := filepath.Join(t.TempDir(), "apache2buddy-go.log")
+	if err := os.WriteFile(path, []byte(lines), 0644); err != nil {
+		t.Fatal(err)
+	}
+	entries, err := ReadHistory(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	current := &config.ApacheConfig{MPMModel: "prefork", ConfigPath: "/etc/httpd/conf/httpd.conf", ServerRoot: "/etc/httpd"}
+	current.InstanceID = process.Instance{MasterPID: 3456}.Key(current.ConfigPath, current.ServerRoot)
+	peak := HistoryPeaks(entries, current, 14, now)
+	if peak == nil || peak.Runs != 2 || peak.LargestMB != 45 {
+		t.Errorf("HistoryPeaks() = %+v, want both runs and the 45 MB peak from before the restart", peak)
+	}
+}
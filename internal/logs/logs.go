@@ -11,6 +11,7 @@ import (
 	"apache2buddy-go/internal/analysis"
 	"apache2buddy-go/internal/config"
 	"apache2buddy-go/internal/debug"
+	"apache2buddy-go/internal/status"
 	"apache2buddy-go/internal/system"
 )
 
@@ -19,6 +20,8 @@ type LogAnalysis struct {
 	PHPFatalErrors     int
 	RecentErrors       []string
 	AnalyzedLines      int
+
+	Access *AccessRate // Request rate from the access logs, nil when none was readable
 }
 
 func AnalyzeApacheLogs() *LogAnalysis {
@@ -146,14 +149,14 @@ func analyzeLogFile(logPath string, analysis *LogAnalysis) error {
 	return scanner.Err()
 }
 
-func CreateLogEntry(sysInfo *system.SystemInfo, memStats *analysis.MemoryStats, config *config.ApacheConfig, recommendations *analysis.Recommendations) error {
+func CreateLogEntry(sysInfo *system.SystemInfo, memStats *analysis.MemoryStats, config *config.ApacheConfig, recommendations *analysis.Recommendations, statusInfo *status.ApacheStatus) error {
 	// Add timeout for log creation too
 	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
 	defer cancel()
 
 	done := make(chan error, 1)
 	go func() {
-		done <- createLogEntryInternal(sysInfo, memStats, config, recommendations)
+		done <- createLogEntryInternal(sysInfo, memStats, config, recommendations, statusInfo)
 	}()
 
 	select {
@@ -164,10 +167,8 @@ func CreateLogEntry(sysInfo *system.SystemInfo, memStats *analysis.MemoryStats,
 	}
 }
 
-func createLogEntryInternal(sysInfo *system.SystemInfo, memStats *analysis.MemoryStats, config *config.ApacheConfig, recommendations *analysis.Recommendations) error {
-	logFile := "/var/log/apache2buddy-go.log"
-
-	file, err := os.OpenFile(logFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+func createLogEntryInternal(sysInfo *system.SystemInfo, memStats *analysis.MemoryStats, config *config.ApacheConfig, recommendations *analysis.Recommendations, statusInfo *status.ApacheStatus) error {
+	file, err := os.OpenFile(HistoryFile, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
 	if err != nil {
 		return err
 	}
@@ -177,10 +178,17 @@ func createLogEntryInternal(sysInfo *system.SystemInfo, memStats *analysis.Memor
 		}
 	}()
 
-	timestamp := time.Now().Format("2006/01/02 15:04:05")
+	_, err = file.WriteString(formatLogEntry(time.Now(), sysInfo, memStats, config, recommendations, statusInfo))
+	return err
+}
+
+// formatLogEntry renders one history line
+func formatLogEntry(now time.Time, sysInfo *system.SystemInfo, memStats *analysis.MemoryStats, config *config.ApacheConfig, recommendations *analysis.Recommendations, statusInfo *status.ApacheStatus) string {
+	timestamp := now.Format(historyTimeFormat)
+	instance := historyInstance(config)
 
-	// Format: Date Uptime Model Memory MaxClients Recommended Smallest Avg Largest
-	logEntry := fmt.Sprintf(`%s Memory: "%d MB" MaxClients: "%d" Recommended: "%d" Status: "%s" Smallest: "%.2f MB" Avg: "%.2f MB" Largest: "%.2f MB" MPM: "%s"`+"\n",
+	// Format: Date Memory MaxClients Recommended Status Smallest Avg Largest MPM Instance [Busy] [Policy]
+	logEntry := fmt.Sprintf(`%s Memory: "%d MB" MaxClients: "%d" Recommended: "%d" Status: "%s" Smallest: "%.2f MB" Avg: "%.2f MB" Largest: "%.2f MB" MPM: "%s" Instance: "%s"`,
 		timestamp,
 		sysInfo.AvailableMemoryMB,
 		config.GetCurrentMaxClients(),
@@ -190,10 +198,16 @@ func createLogEntryInternal(sysInfo *system.SystemInfo, memStats *analysis.Memor
 		memStats.AverageMB,
 		memStats.LargestMB,
 		config.MPMModel,
+		instance,
 	)
 
-	_, err = file.WriteString(logEntry)
-	return err
+	if statusInfo != nil {
+		logEntry += fmt.Sprintf(` Busy: "%d"`, statusInfo.ActiveWorkers)
+	}
+	if recommendations.Policy != nil {
+		logEntry += fmt.Sprintf(` Policy: "%s"`, recommendations.Policy)
+	}
+	return logEntry + "\n"
 }
 
 func GetRecentLogEntries(count int) ([]string, error) {
@@ -205,9 +219,7 @@ func GetRecentLogEntries(count int) ([]string, error) {
 	errChan := make(chan error, 1)
 
 	go func() {
-		logFile := "/var/log/apache2buddy-go.log"
-
-		file, err := os.Open(logFile)
+		file, err := os.Open(HistoryFile)
 		if err != nil {
 			errChan <- err
 			return
@@ -5,9 +5,11 @@ import (
 	"path/filepath"
 	"strings"
 	"testing"
+	"time"
 
 	"apache2buddy-go/internal/analysis"
 	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/status"
 	"apache2buddy-go/internal/system"
 )
 
@@ -214,6 +216,31 @@ func TestAnalyzeLogFile(t *testing.T) {
 	}
 }
 
+func TestFormatLogEntry(t *testing.T) {
+	sysInfo := &system.SystemInfo{AvailableMemoryMB: 3000}
+	memStats := &analysis.MemoryStats{SmallestMB: 10, AverageMB: 20, LargestMB: 30}
+	config := &config.ApacheConfig{MaxRequestWorkers: 150, MPMModel: "prefork", InstanceID: "1234"}
+	now := time.Date(2026, 3, 1, 9, 30, 0, 0, time.UTC)
+
+	legacy := formatLogEntry(now, sysInfo, memStats, config, &analysis.Recommendations{RecommendedMaxClients: 90, Status: "CRITICAL"}, nil)
+	want := `2026/03/01 09:30:00 Memory: "3000 MB" MaxClients: "150" Recommended: "90" Status: "CRITICAL" Smallest: "10.00 MB" Avg: "20.00 MB" Largest: "30.00 MB" MPM: "prefork" Instance: "1234"` + "\n"
+	if legacy != want {
+		t.Errorf("formatLogEntry() = %q, want %q", legacy, want)
+	}
+
+	policy := analysis.DefaultPolicy()
+	policy.Sizing = analysis.SizeP95
+	withPolicy := formatLogEntry(now, sysInfo, memStats, config, &analysis.Recommendations{Status: "OK", Policy: &policy}, nil)
+	if !strings.HasSuffix(withPolicy, ` Instance: "1234" Policy: "reserve 0 MB, margin 90-100%, sizing on p95"`+"\n") {
+		t.Errorf("formatLogEntry() should record the policy, got %q", withPolicy)
+	}
+
+	withStatus := formatLogEntry(now, sysInfo, memStats, config, &analysis.Recommendations{Status: "OK"}, &status.ApacheStatus{ActiveWorkers: 42})
+	if !strings.HasSuffix(withStatus, ` Instance: "1234" Busy: "42"`+"\n") {
+		t.Errorf("formatLogEntry() should record the busy workers, got %q", withStatus)
+	}
+}
+
 func TestCreateLogEntryInternal(t *testing.T) {
 	// Mock data
 	sysInfo := &system.SystemInfo{
@@ -239,11 +266,10 @@ func TestCreateLogEntryInternal(t *testing.T) {
 		CurrentMaxClients:     150,
 		RecommendedMaxClients: 120,
 		Status:                "WARNING",
-		Message:               "Consider reducing MaxRequestWorkers",
 	}
 
 	// Test log entry creation (this will fail due to permissions, but we test the logic)
-	err := createLogEntryInternal(sysInfo, memStats, config, recommendations)
+	err := createLogEntryInternal(sysInfo, memStats, config, recommendations, nil)
 	if err != nil {
 		// Expected to fail in test environment due to /var/log permissions
 		t.Logf("createLogEntryInternal failed as expected in test environment: %v", err)
@@ -0,0 +1,40 @@
+package logs
+
+import (
+	"fmt"
+
+	"apache2buddy-go/internal/findings"
+)
+
+func init() {
+	findings.RegisterFunc("logs.errors", checkErrorLog)
+}
+
+// checkErrorLog reports what the error log shows; Apache saying it reached
+// MaxRequestWorkers is worth a look but not proof the limit is too low
+func checkErrorLog(facts *findings.Facts) []findings.Finding {
+	logAnalysis, ok := findings.Get[*LogAnalysis](facts)
+	if !ok || logAnalysis.AnalyzedLines == 0 {
+		return nil
+	}
+	var list []findings.Finding
+	if logAnalysis.MaxClientsExceeded > 0 {
+		list = append(list, findings.Finding{
+			ID:       "logs.max-request-workers-reached",
+			Severity: findings.SeverityInfo,
+			Category: findings.CategoryLogs,
+			Title:    fmt.Sprintf("Log analysis shows MaxRequestWorkers was exceeded %d times", logAnalysis.MaxClientsExceeded),
+			Detail:   "Requests queued while every worker was busy.",
+		})
+	}
+	if logAnalysis.PHPFatalErrors > 0 {
+		list = append(list, findings.Finding{
+			ID:       "logs.php-fatal-errors",
+			Severity: findings.SeverityInfo,
+			Category: findings.CategoryLogs,
+			Title:    fmt.Sprintf("Found %d PHP Fatal Errors in logs", logAnalysis.PHPFatalErrors),
+			Evidence: logAnalysis.RecentErrors,
+		})
+	}
+	return list
+}
@@ -0,0 +1,38 @@
+package logs
+
+import (
+	"testing"
+
+	"apache2buddy-go/internal/findings"
+)
+
+func TestCheckErrorLog(t *testing.T) {
+	tests := []struct {
+		name     string
+		analysis *LogAnalysis
+		wantIDs  []string
+	}{
+		{name: "nothing read", analysis: &LogAnalysis{MaxClientsExceeded: 3}},
+		{name: "clean log", analysis: &LogAnalysis{AnalyzedLines: 100}},
+		{
+			name:     "limit reached and PHP errors",
+			analysis: &LogAnalysis{AnalyzedLines: 100, MaxClientsExceeded: 3, PHPFatalErrors: 2, RecentErrors: []string{"PHP Fatal error: x"}},
+			wantIDs:  []string{"logs.max-request-workers-reached", "logs.php-fatal-errors"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			facts := findings.NewFacts()
+			findings.Put(facts, tt.analysis)
+			got := checkErrorLog(facts)
+			if len(got) != len(tt.wantIDs) {
+				t.Fatalf("checkErrorLog() = %d findings, want %d", len(got), len(tt.wantIDs))
+			}
+			for i, finding := range got {
+				if finding.ID != tt.wantIDs[i] || finding.Severity != findings.SeverityInfo {
+					t.Errorf("finding %d = %s (%s), want %s (INFO)", i, finding.ID, finding.Severity, tt.wantIDs[i])
+				}
+			}
+		})
+	}
+}
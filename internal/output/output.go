@@ -3,12 +3,16 @@ package output
 import (
 	"fmt"
 	"os/exec"
+	"sort"
 	"strings"
+	"time"
 
 	"apache2buddy-go/internal/analysis"
 	"apache2buddy-go/internal/config"
 	"apache2buddy-go/internal/debug"
+	"apache2buddy-go/internal/findings"
 	"apache2buddy-go/internal/logs"
+	"apache2buddy-go/internal/process"
 	"apache2buddy-go/internal/status"
 	"apache2buddy-go/internal/system"
 )
@@ -24,13 +28,33 @@ func DisplayEnhancedResults(sysInfo *system.SystemInfo, memStats *analysis.Memor
 
 	// System Memory Info
 	fmt.Printf("Total RAM: %d MB\n", sysInfo.TotalMemoryMB)
-	fmt.Printf("Available RAM: %d MB\n", sysInfo.AvailableMemoryMB)
+	if sysInfo.MemoryLimitSource != "" && sysInfo.TotalMemoryMB < sysInfo.HostMemoryMB {
+		fmt.Printf("Binding memory limit: %s (%d MB, host RAM %d MB)\n",
+			sysInfo.MemoryLimitSource, sysInfo.TotalMemoryMB, sysInfo.HostMemoryMB)
+		fmt.Printf("Current cgroup usage: %d MB\n", sysInfo.CgroupUsageMB)
+	} else if sysInfo.MemoryLimitSource != "" {
+		fmt.Printf("Binding memory limit: %s\n", sysInfo.MemoryLimitSource)
+	}
+	if sysInfo.Budget == nil {
+		fmt.Printf("Available RAM: %d MB\n", sysInfo.AvailableMemoryMB)
+	}
 
 	// Other Services (if any)
 	totalOtherMemory := system.GetTotalOtherServicesMemory(sysInfo)
 	if totalOtherMemory > 0 {
 		fmt.Printf("RAM used by other services: %d MB\n", totalOtherMemory)
-		fmt.Printf("RAM available for Apache: %d MB\n", sysInfo.AvailableMemoryMB)
+		for _, line := range describeServices(sysInfo) {
+			fmt.Printf("  - %s\n", line)
+		}
+		for _, note := range sysInfo.ServiceNotes {
+			fmt.Printf("Note (%s): %s\n", note.Service, note.Message)
+		}
+		if sysInfo.Budget == nil {
+			fmt.Printf("RAM available for Apache: %d MB\n", sysInfo.AvailableMemoryMB)
+		}
+	}
+	if sysInfo.Budget != nil {
+		displayBudget(sysInfo.Budget, sysInfo.MemAvailableMB)
 	}
 	fmt.Println()
 
@@ -46,6 +70,13 @@ func DisplayEnhancedResults(sysInfo *system.SystemInfo, memStats *analysis.Memor
 		fmt.Printf("Apache processes found: %d\n", memStats.ProcessCount)
 		fmt.Printf("Memory usage per process: %.1f MB (smallest), %.1f MB (average), %.1f MB (largest)\n",
 			memStats.SmallestMB, memStats.AverageMB, memStats.LargestMB)
+		if len(memStats.Histogram) > 1 {
+			displayDistribution(memStats)
+		}
+		if recommendations.Confidence == analysis.ConfidenceMedium || recommendations.Confidence == analysis.ConfidenceLow {
+			fmt.Printf("⚠️  Memory figures are estimates (confidence: %s): %s\n",
+				recommendations.Confidence, describeMeasurement(memStats))
+		}
 		fmt.Println()
 	}
 
@@ -64,15 +95,59 @@ func DisplayEnhancedResults(sysInfo *system.SystemInfo, memStats *analysis.Memor
 		fmt.Println()
 	}
 
+	// Worker lifetime and recycling
+	if recommendations.Lifetime != nil {
+		displayLifetime(recommendations.Lifetime)
+	}
+
+	// Memory growth across samples
+	if recommendations.Growth != nil {
+		displayGrowth(recommendations.Growth)
+	}
+
+	// Peaks of past runs
+	if recommendations.History != nil {
+		displayHistory(recommendations.History)
+	}
+
+	// Swap and OOM-killer evidence
+	if recommendations.Pressure != nil {
+		displayPressure(recommendations.Pressure)
+	}
+
+	// Kernel and service limits
+	if len(recommendations.Limits) > 0 {
+		displayLimits(recommendations.Limits)
+	}
+
+	// Sizing policy
+	if recommendations.Policy != nil && memStats.ProcessCount > 0 {
+		unit := "worker"
+		if analysis.IsThreaded(config.MPMModel) {
+			unit = fmt.Sprintf("child process of %d threads", analysis.ThreadsPerChild(config))
+		}
+		fmt.Printf("Sizing policy: %s (%.1f MB per %s)\n", recommendations.Policy, memStats.SizingMB(), unit)
+	}
+
+	// CPU ceiling
+	if recommendations.CPU != nil {
+		displayCPU(recommendations.CPU, recommendations.Binding)
+	}
+
+	// Demand from request rate and duration
+	if recommendations.Demand != nil {
+		displayDemand(recommendations.Demand)
+	}
+
 	// Memory Analysis and Recommendations
-	currentMemoryUsage := float64(config.GetCurrentMaxClients()) * memStats.LargestMB
+	currentMemoryUsage := analysis.WorkerMemoryMB(config.GetCurrentMaxClients(), config, memStats)
 	currentUtilization := (currentMemoryUsage / float64(sysInfo.AvailableMemoryMB)) * 100
 
 	fmt.Printf("Current memory usage: %.1f MB (%.1f%% of available)\n",
 		currentMemoryUsage, currentUtilization)
 
 	if recommendations.RecommendedMaxClients != recommendations.CurrentMaxClients {
-		recommendedMemoryUsage := float64(recommendations.RecommendedMaxClients) * memStats.LargestMB
+		recommendedMemoryUsage := analysis.WorkerMemoryMB(recommendations.RecommendedMaxClients, config, memStats)
 		recommendedUtilization := (recommendedMemoryUsage / float64(sysInfo.AvailableMemoryMB)) * 100
 
 		fmt.Printf("Recommended MaxRequestWorkers: %d\n", recommendations.RecommendedMaxClients)
@@ -81,50 +156,48 @@ func DisplayEnhancedResults(sysInfo *system.SystemInfo, memStats *analysis.Memor
 	}
 	fmt.Println()
 
-	// Status and Recommendations
+	// Status and findings
+	if recommendations.Findings == nil {
+		facts := analysis.Facts(sysInfo, memStats, config, recommendations, statusInfo)
+		findings.Put(facts, logAnalysis)
+		analysis.Evaluate(recommendations, facts)
+	}
 	switch recommendations.Status {
 	case "OK":
 		fmt.Printf("✓ RESULT: Your Apache configuration appears to be optimal.\n")
 	case "WARNING":
 		fmt.Printf("⚠️  RESULT: Your Apache configuration could be improved.\n")
-		if recommendations.RecommendedMaxClients < recommendations.CurrentMaxClients {
-			fmt.Printf("Consider reducing MaxRequestWorkers to %d to prevent memory issues.\n", recommendations.RecommendedMaxClients)
-		} else {
-			fmt.Printf("Consider increasing MaxRequestWorkers to %d for better performance.\n", recommendations.RecommendedMaxClients)
-		}
 	case "CRITICAL":
 		fmt.Printf("🔥 RESULT: Your Apache configuration needs immediate attention!\n")
-		fmt.Printf("Reduce MaxRequestWorkers to %d to prevent memory issues.\n", recommendations.RecommendedMaxClients)
 	}
-
-	// MPM-specific notes
-	if recommendations.MPMNote != "" {
-		fmt.Printf("\nNote: %s\n", recommendations.MPMNote)
-	}
-
-	// Log Analysis Issues
-	if logAnalysis.AnalyzedLines > 0 && (logAnalysis.MaxClientsExceeded > 0 || logAnalysis.PHPFatalErrors > 0) {
-		fmt.Println()
-		if logAnalysis.MaxClientsExceeded > 0 {
-			fmt.Printf("⚠️  Log analysis shows MaxRequestWorkers was exceeded %d times.\n", logAnalysis.MaxClientsExceeded)
-		}
-		if logAnalysis.PHPFatalErrors > 0 {
-			fmt.Printf("⚠️  Found %d PHP Fatal Errors in logs.\n", logAnalysis.PHPFatalErrors)
-		}
+	for _, finding := range recommendations.Findings {
+		displayFinding(finding)
 	}
 
 	// Configuration suggestions
 	fmt.Println()
 	fmt.Printf("Configuration file: %s\n", config.ConfigPath)
-	if recommendations.Status != "OK" {
-		fmt.Printf("\nTo implement changes, edit your Apache configuration:\n")
-		fmt.Printf("<%s %s_module>\n", "IfModule", config.MPMModel)
-		fmt.Printf("    MaxRequestWorkers %d\n", recommendations.RecommendedMaxClients)
-		if config.MPMModel == "prefork" && recommendations.RecommendedMaxClients > 256 {
-			fmt.Printf("    ServerLimit %d\n", recommendations.RecommendedMaxClients)
+	if findings.Any(recommendations.Findings, findings.SeverityWarning, findings.CategoryMemory, findings.CategoryCPU) {
+		panel := config.ControlPanel
+		if panel != nil {
+			fmt.Printf("\nTo implement changes under %s, add to %s:\n", panel.Name, panel.MPMFile(config.RootPath, config.MPMModel))
+		} else {
+			fmt.Printf("\nTo implement changes, edit your Apache configuration:\n")
+		}
+		block := recommendations.MPMBlock
+		if block == nil {
+			block = analysis.RecommendMPMBlock(recommendations, config, statusInfo)
 		}
-		fmt.Printf("</%s>\n", "IfModule")
-		fmt.Printf("\nThen restart Apache to apply changes.\n")
+		displayMPMBlock(config.MPMModel, block)
+		if panel != nil {
+			displayPanelInstructions(panel)
+		} else {
+			fmt.Printf("\nThen restart Apache to apply changes.\n")
+		}
+	}
+
+	if recommendations.Migration != nil {
+		displayMigration(recommendations.Migration)
 	}
 
 	// Debug Information (only shown in debug mode)
@@ -136,6 +209,234 @@ func DisplayEnhancedResults(sysInfo *system.SystemInfo, memStats *analysis.Memor
 	fmt.Printf("Analysis completed. Check /var/log/apache2buddy-go.log for historical data.\n")
 }
 
+// DisplayPHPFPMPools reports the configuration, memory and recommended
+// pm.max_children of each PHP-FPM pool, followed by the pools' findings
+func DisplayPHPFPMPools(recommendations []analysis.PoolRecommendation, budget analysis.PHPFPMBudget, poolFindings []findings.Finding) {
+	if len(recommendations) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("PHP-FPM pools: %d (%d MB available to PHP-FPM)\n", len(recommendations), budget.PHPFPMMB)
+	fmt.Printf("Memory after other services: %d MB; Apache's recommended MaxRequestWorkers use %d MB, leaving %d MB for PHP-FPM\n",
+		budget.TotalMB, budget.ApacheMB, budget.PHPFPMMB)
+	for _, rec := range recommendations {
+		pool := rec.Pool
+		fmt.Println()
+		fmt.Printf("[%s] %s\n", pool.Name, pool.ConfigFile)
+		settings := fmt.Sprintf("pm = %s, pm.max_children = %d", pool.PM, pool.MaxChildren)
+		if pool.PM == "dynamic" {
+			settings += fmt.Sprintf(", pm.start_servers = %d, pm.min_spare_servers = %d, pm.max_spare_servers = %d",
+				pool.StartServers, pool.MinSpareServers, pool.MaxSpareServers)
+		}
+		settings += fmt.Sprintf(", pm.max_requests = %d", pool.MaxRequests)
+		fmt.Printf("  %s\n", settings)
+		if pool.Listen != "" {
+			fmt.Printf("  listen = %s\n", pool.Listen)
+		}
+
+		if rec.Status == "UNKNOWN" {
+			fmt.Printf("  %s; cannot recommend pm.max_children.\n", rec.Message)
+			continue
+		}
+		fmt.Printf("  Children: %d running, %.1f MB (average), %.1f MB (largest)\n",
+			pool.Processes, pool.AverageMB, pool.LargestMB)
+		fmt.Printf("  Memory budget: %d MB, recommended pm.max_children: %d (max %d) at %.1f MB per child (%s)\n",
+			rec.BudgetMB, rec.RecommendedMaxChildren, rec.MaxRecommended, rec.ChildMB, rec.Statistic)
+		if rec.Status == "OK" {
+			fmt.Printf("  ✓ %s\n", rec.Message)
+		}
+	}
+	if len(poolFindings) > 0 {
+		fmt.Println()
+	}
+	for _, finding := range poolFindings {
+		displayFinding(finding)
+	}
+	fmt.Println(strings.Repeat("-", 60))
+}
+
+// DisplayHostFindings reports the findings that concern the host rather than
+// one Apache instance, such as OOM kills no instance could be charged with
+func DisplayHostFindings(hostFindings []findings.Finding) {
+	if len(hostFindings) == 0 {
+		return
+	}
+
+	fmt.Println()
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Println("Host findings")
+	fmt.Println()
+	for _, finding := range hostFindings {
+		displayFinding(finding)
+	}
+	fmt.Println(strings.Repeat("-", 60))
+}
+
+// DisplaySimulation prints the current analysis and the simulated one side
+// by side
+func DisplaySimulation(sysInfo *system.SystemInfo, memStats *analysis.MemoryStats, config *config.ApacheConfig, recommendations *analysis.Recommendations, result *analysis.SimulationResult) {
+	simulated := result.Recommendations
+
+	fmt.Println()
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("What if: %s\n\n", result.Simulation)
+	row := func(label, current, simulated string) {
+		fmt.Printf("  %-30s %-16s %s\n", label, current, simulated)
+	}
+	row("", "Current", "Simulated")
+	row("MPM", config.MPMModel, result.MPMModel)
+	row("Memory for Apache", fmt.Sprintf("%d MB", sysInfo.AvailableMemoryMB), fmt.Sprintf("%d MB", result.AvailableMB))
+	row("Size per process", fmt.Sprintf("%.1f MB", memStats.SizingMB()), fmt.Sprintf("%.1f MB", result.SizingMB))
+	row("MaxRequestWorkers band", fmt.Sprintf("%d-%d", recommendations.MinRecommended, recommendations.MaxRecommended),
+		fmt.Sprintf("%d-%d", simulated.MinRecommended, simulated.MaxRecommended))
+	row("Recommended MaxRequestWorkers", fmt.Sprint(recommendations.RecommendedMaxClients), fmt.Sprint(simulated.RecommendedMaxClients))
+	if recommendations.ThreadsPerChild > 0 || simulated.ThreadsPerChild > 0 {
+		row("ThreadsPerChild", fmt.Sprint(recommendations.ThreadsPerChild), fmt.Sprint(simulated.ThreadsPerChild))
+	}
+	if recommendations.ServerLimit > 0 || simulated.ServerLimit > 0 {
+		row("ServerLimit", fmt.Sprint(recommendations.ServerLimit), fmt.Sprint(simulated.ServerLimit))
+	}
+	row("Binding ceiling", recommendations.Binding, simulated.Binding)
+	row("Result", recommendations.Status, simulated.Status)
+
+	for _, finding := range simulated.Findings {
+		if finding.Severity > findings.SeverityInfo {
+			fmt.Printf("  Simulated %s: %s [%s]\n", finding.Severity, finding.Title, finding.ID)
+		}
+	}
+	for _, note := range result.Notes {
+		fmt.Printf("  Note: %s\n", note)
+	}
+	if len(simulated.MPMBlock) > 0 {
+		fmt.Println()
+		fmt.Println("Simulated MPM block:")
+		displayMPMBlock(result.MPMModel, simulated.MPMBlock)
+	}
+}
+
+// DisplayCapacityPlan prints the RAM a target load needs, the MPM block to
+// serve it and what switching MPM would change
+func DisplayCapacityPlan(report *analysis.CapacityReport) {
+	plan := report.Plan
+
+	fmt.Println()
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("Capacity plan: %s\n", plan.Basis)
+	fmt.Printf("  MaxRequestWorkers: %d (%s: %s)\n", plan.Recommendations.RecommendedMaxClients, plan.MPMModel, describePlanProcesses(plan))
+	fmt.Printf("  Apache:         %6d MB (%g%% of its budget)\n", plan.ApacheMB, plan.Recommendations.Policy.TargetPercent)
+	fmt.Printf("  Other services: %6d MB\n", plan.OtherMB)
+	fmt.Printf("  Reserve:        %6d MB\n", plan.ReserveMB)
+	fmt.Printf("  Required RAM:   %6d MB (%d GB)", plan.RequiredMB, (plan.RequiredMB+1023)/1024)
+	if short := plan.Shortfall(); short > 0 {
+		fmt.Printf(", %d MB more than the %d MB this host has\n", short, plan.CurrentMB)
+	} else {
+		fmt.Printf(", this host has %d MB\n", plan.CurrentMB)
+	}
+
+	fmt.Println("\nTo serve it, configure:")
+	displayMPMBlock(plan.MPMModel, plan.Recommendations.MPMBlock)
+
+	for _, alternative := range report.Alternatives {
+		savings := report.Savings(alternative)
+		fmt.Printf("\nUnder %s: %s, %d MB of RAM", alternative.MPMModel, describePlanProcesses(alternative), alternative.RequiredMB)
+		switch {
+		case savings > 0:
+			fmt.Printf(": switching would save %d MB\n", savings)
+		case savings < 0:
+			fmt.Printf(": switching would need %d MB more\n", -savings)
+		default:
+			fmt.Println(": switching would not change the requirement")
+		}
+	}
+	for _, note := range report.Notes {
+		fmt.Printf("Note: %s\n", note)
+	}
+}
+
+// displayMigration shows the estimate and the steps for moving prefork +
+// mod_php to event + PHP-FPM
+func displayMigration(advice *analysis.MigrationAdvice) {
+	fmt.Printf("\nMPM migration: prefork + mod_php to event + PHP-FPM\n")
+	if advice.Estimated {
+		fmt.Printf("  Memory per concurrent request: %.1f MB today, %.1f MB expected (%.0f%% less)\n",
+			advice.PreforkMB, advice.PerRequestMB(), advice.SavingsPercent())
+		fmt.Printf("    event child:   %.1f MB with %d threads, %.1f MB per request\n",
+			advice.EventChildMB, advice.ThreadsPerChild, advice.EventChildMB/float64(advice.ThreadsPerChild))
+		fmt.Printf("    PHP-FPM child: %.1f MB (%s)\n", advice.PHPMB, advice.PHPSource)
+		fmt.Printf("  %d concurrent requests: %d MB today, %d MB expected; %d MB would serve %d\n",
+			advice.Workers, advice.TodayMB, advice.ExpectedMB, advice.TodayMB, advice.ExpectedWorkers)
+	}
+	for _, blocking := range []bool{true, false} {
+		heading := "Blocking modules:"
+		if !blocking {
+			heading = "Modules to check:"
+		}
+		printed := false
+		for _, check := range advice.Modules {
+			if check.Blocking != blocking {
+				continue
+			}
+			if !printed {
+				fmt.Printf("  %s\n", heading)
+				printed = true
+			}
+			fmt.Printf("    %s: %s\n", check.Module, check.Reason)
+		}
+	}
+	fmt.Println("  Prerequisites:")
+	for i, step := range advice.Prerequisites {
+		fmt.Printf("    %d. %s\n", i+1, step)
+	}
+	fmt.Println()
+	displayMPMBlock("event", advice.MPMBlock)
+	for _, note := range advice.Notes {
+		fmt.Printf("Note: %s\n", note)
+	}
+}
+
+// describePlanProcesses describes the child processes of a plan, e.g.
+// "32 processes × 95.0 MB (estimated), 25 threads each"
+func describePlanProcesses(plan *analysis.CapacityPlan) string {
+	text := fmt.Sprintf("%d processes × %.1f MB", plan.Processes, plan.ProcessMB)
+	if plan.Estimated {
+		text += " (estimated)"
+	}
+	if plan.ThreadsPerChild > 0 {
+		text += fmt.Sprintf(", %d threads each", plan.ThreadsPerChild)
+	}
+	return text
+}
+
+// DisplayInstanceHeader identifies which Apache instance the following report
+// covers when more than one instance runs on the host or it runs in a container
+func DisplayInstanceHeader(index, count int, instance process.Instance, config *config.ApacheConfig, statusInfo *status.ApacheStatus, memoryShareMB, availableMB int) {
+	fmt.Println()
+	fmt.Println(strings.Repeat("-", 60))
+	fmt.Printf("Apache instance %d of %d (master PID %d)\n", index, count, instance.MasterPID)
+	if instance.Container {
+		containerID := instance.ContainerID
+		if containerID == "" {
+			containerID = "unknown"
+		}
+		fmt.Printf("Container: %s (filesystem %s)\n", containerID, instance.RootPath)
+	}
+	fmt.Printf("Configuration file: %s\n", config.ConfigPath)
+	if len(config.ListenPorts) > 0 {
+		fmt.Printf("Listening on: %s\n", strings.Join(config.ListenPorts, ", "))
+	}
+	if statusInfo != nil {
+		fmt.Printf("Status endpoint: %s\n", statusInfo.URL)
+	} else if config.InContainer() {
+		fmt.Printf("Status endpoint: not queried (container network)\n")
+	} else {
+		fmt.Printf("Status endpoint: not reachable\n")
+	}
+	fmt.Printf("Memory share: %d MB of %d MB available to Apache\n", memoryShareMB, availableMB)
+	fmt.Println(strings.Repeat("-", 60))
+}
+
 // detectServerBuilt tries to get the Apache build date
 func detectServerBuilt() string {
 	commands := [][]string{
@@ -162,6 +463,385 @@ func detectServerBuilt() string {
 	return "Unknown"
 }
 
+// displayLifetime shows how worker memory relates to worker age
+func displayLifetime(lifetime *analysis.WorkerLifetime) {
+	fmt.Printf("Worker lifetime: %d workers, mean age %s, oldest %s\n",
+		lifetime.Workers, formatAge(lifetime.MeanAge), formatAge(lifetime.OldestAge))
+	fmt.Printf("Memory vs. age (%s): correlation %.2f, young workers %.1f MB avg, old workers %.1f MB avg (%+.0f%%)\n",
+		lifetime.MemoryBasis, lifetime.Correlation, lifetime.YoungAverageMB, lifetime.OldAverageMB, lifetime.GrowthPercent)
+
+	if lifetime.Bloated {
+		current := "unlimited"
+		if lifetime.CurrentMaxConnectionsPerChild > 0 {
+			current = fmt.Sprintf("%d", lifetime.CurrentMaxConnectionsPerChild)
+		}
+		if lifetime.RecommendedMaxConnectionsPerChild > 0 {
+			basis := "estimated from request rate"
+			if lifetime.UsedAccessCounts {
+				basis = "from per-child access counts"
+			}
+			fmt.Printf("⚠️  Older workers are clearly bloated. Set MaxConnectionsPerChild %d (currently %s, %s).\n",
+				lifetime.RecommendedMaxConnectionsPerChild, current, basis)
+		} else if lifetime.CurrentMaxConnectionsPerChild > 0 {
+			fmt.Printf("⚠️  Older workers are clearly bloated despite MaxConnectionsPerChild %s. Consider lowering it.\n", current)
+		} else {
+			fmt.Printf("⚠️  Older workers are clearly bloated and never recycled. Enable ExtendedStatus for a MaxConnectionsPerChild recommendation.\n")
+		}
+	}
+	fmt.Println()
+}
+
+// displayGrowth shows worker memory growth measured across samples
+func displayGrowth(growth *analysis.GrowthAnalysis) {
+	fmt.Printf("Memory growth: %d samples over %s, mean %+.1f MB/hour per worker\n",
+		growth.Samples, formatAge(growth.Window), growth.MeanSlopeMBPerHour)
+
+	if growth.Leaking == 0 {
+		if growth.Window < analysis.MinLeakWindow {
+			fmt.Printf("Sampling window too short to flag leaks; sample for at least %s.\n", formatAge(analysis.MinLeakWindow))
+			fmt.Println()
+			return
+		}
+		fmt.Printf("No worker grew faster than %.1f MB/hour.\n", growth.ThresholdMBPerHour)
+		fmt.Println()
+		return
+	}
+
+	fmt.Printf("⚠️  %d worker(s) grew faster than %.1f MB/hour:\n", growth.Leaking, growth.ThresholdMBPerHour)
+	for _, worker := range growth.Workers {
+		if !worker.Leaking {
+			continue
+		}
+		fmt.Printf("  - PID %d: %.1f MB -> %.1f MB (%+.1f MB/hour), projected %.1f MB in %s\n",
+			worker.PID, worker.FirstMB, worker.LastMB, worker.SlopeMBPerHour, worker.ProjectedPeakMB, formatAge(growth.Horizon))
+	}
+	fmt.Printf("Sizing uses the projected peak of %.1f MB per worker.\n", growth.ProjectedPeakMB)
+	fmt.Println()
+}
+
+// displayHistory shows the peaks of past runs and whether they held back
+// the recommendation
+func displayHistory(peak *analysis.HistoryPeak) {
+	fmt.Printf("History: %d run(s) in the last %d days\n", peak.Runs, peak.Days)
+	fmt.Printf("  Largest worker: %.1f MB on %s\n", peak.LargestMB, peak.LargestAt.Format("2006-01-02 15:04"))
+	if peak.BusyWorkers > 0 {
+		fmt.Printf("  Busy workers:   %d on %s\n", peak.BusyWorkers, peak.BusyAt.Format("2006-01-02 15:04"))
+	}
+	if peak.CappedFrom > 0 {
+		fmt.Printf("Workers are smaller now than at the peak, so MaxRequestWorkers is not raised past the %d the peak leaves room for.\n", peak.SupportedWorkers)
+	}
+	fmt.Println()
+}
+
+// displayBudget shows how the memory available to Apache was derived
+func displayBudget(budget *system.MemoryBudget, memAvailableMB int) {
+	fmt.Println("Memory budget for Apache:")
+	fmt.Printf("  %-32s %7d MB\n", "Limit ("+budget.LimitSource+")", budget.LimitMB)
+	reserveLabel := "- Reserve for the OS"
+	if budget.Reserve.Percent > 0 {
+		reserveLabel += " (" + budget.Reserve.String() + ")"
+	}
+	fmt.Printf("  %-32s %7d MB\n", reserveLabel, budget.ReserveMB)
+	fmt.Printf("  %-32s %7d MB\n", "- "+budget.OtherLabel, budget.OtherMB)
+	if budget.OtherInstancesMB > 0 {
+		fmt.Printf("  %-32s %7d MB\n", "- Other Apache instances", budget.OtherInstancesMB)
+	}
+	fmt.Printf("  %-32s %7d MB\n", "= Available for Apache", budget.AvailableMB)
+	if budget.ApacheMB > 0 {
+		fmt.Printf("  Apache uses %d MB now, included in the budget", budget.ApacheMB)
+		if memAvailableMB > 0 {
+			fmt.Printf(" (MemAvailable: %d MB, not used for sizing)", memAvailableMB)
+		}
+		fmt.Println()
+	}
+}
+
+// displayPressure shows swap usage and recent OOM-killer activity
+func displayPressure(pressure *analysis.MemoryPressure) {
+	if swap := pressure.Swap; swap != nil {
+		if swap.TotalMB > 0 {
+			fmt.Printf("Swap: %d MB used of %d MB (swappiness %d, overcommit %s)\n",
+				swap.UsedMB(), swap.TotalMB, swap.Swappiness, swap.OvercommitPolicy())
+		} else {
+			fmt.Printf("Swap: none (swappiness %d, overcommit %s)\n", swap.Swappiness, swap.OvercommitPolicy())
+		}
+	}
+	if pressure.SwappedWorkers > 0 {
+		fmt.Printf("⚠️  Apache workers in swap: %d (%.1f MB)\n", pressure.SwappedWorkers, pressure.WorkerSwapMB)
+	}
+
+	if oom := pressure.OOM; oom != nil && len(oom.Sources) > 0 {
+		if len(oom.Events) == 0 {
+			fmt.Printf("OOM killer: no kills since %s\n", oom.Since.Format("2006-01-02 15:04"))
+		} else {
+			fmt.Printf("⚠️  OOM killer: %d kill(s) since %s, %d of them this instance's Apache\n",
+				len(oom.Events), oom.Since.Format("2006-01-02 15:04"), len(pressure.ApacheKills))
+			for _, event := range pressure.ApacheKills {
+				scope := "host"
+				if event.Cgroup {
+					scope = "cgroup"
+				}
+				fmt.Printf("  - %s: %s (PID %d), %s out of memory\n",
+					event.Time.Format("2006-01-02 15:04:05"), event.Process, event.PID, scope)
+			}
+			if pressure.OtherApacheKills > 0 {
+				fmt.Printf("  %d other Apache kill(s) are not charged to this instance\n", pressure.OtherApacheKills)
+			}
+		}
+	}
+	fmt.Println()
+}
+
+// displayLimits compares kernel and service limits with what the
+// configuration needs, with where to raise the ones that are too low
+func displayLimits(checks []system.LimitCheck) {
+	fmt.Println("Kernel and service limits:")
+	for _, check := range checks {
+		if check.Status == "OK" {
+			fmt.Printf("  ✓ %s: %s (needs %s)\n", check.Limit, check.Value, check.Needed)
+			continue
+		}
+		fmt.Printf("  ⚠️  %s: %s (needs %s): %s\n", check.Limit, check.Value, check.Needed, check.Message)
+		fmt.Printf("     Fix: %s\n", check.Fix)
+	}
+	fmt.Println()
+}
+
+// displayCPU shows the cores available, the load and the CPU ceiling, and
+// which of the memory and CPU ceilings binds
+func displayCPU(cpu *analysis.CPUAnalysis, binding string) {
+	fmt.Printf("CPUs: %g (%s), load %.2f / %.2f / %.2f (%s)\n",
+		cpu.CPUs, cpu.CPU.Describe(), cpu.Load1, cpu.Load5, cpu.Load15, cpu.LoadSource)
+	if cpu.Saturated() {
+		fmt.Printf("⚠️  CPU saturated: the 5-minute load %.2f exceeds %g CPUs\n", cpu.Load5, cpu.CPUs)
+	}
+	if cpu.TargetWorkers == 0 {
+		fmt.Println("CPU ceiling: unknown (needs worker CPU times, and mod_status for threaded MPMs)")
+		fmt.Println()
+		return
+	}
+	fmt.Printf("Apache CPU use: %.2f cores (from %s) over %.1f busy workers (from %s), %.2f per worker\n",
+		cpu.ApacheCores, cpu.ApacheSource, cpu.BusyWorkers, cpu.BusySource, cpu.CoresPerWorker)
+	fmt.Printf("CPU ceiling: %d workers, memory ceiling: %d workers (binding: %s)\n",
+		cpu.TargetWorkers, cpu.MemoryWorkers, binding)
+	fmt.Println()
+}
+
+// displayDemand shows the Little's law estimate and the band between the
+// workers traffic needs and the workers the ceiling allows
+func displayDemand(demand *analysis.DemandAnalysis) {
+	rate := fmt.Sprintf("%.2f req/s (%s)", demand.RatePerSec, demand.RateSource)
+	if !demand.PeakAt.IsZero() {
+		rate = fmt.Sprintf("%.2f req/s (%s, %s)", demand.RatePerSec, demand.RateSource, demand.PeakAt.Format("2006-01-02 15:04"))
+	}
+	fmt.Printf("Request rate: %s\n", rate)
+	fmt.Printf("Demand: %.2f req/s × %.0f ms = %.1f busy workers, %d with %d%% headroom\n",
+		demand.RatePerSec, demand.DurationMS, demand.Concurrency, demand.Workers, analysis.DemandHeadroomPercent)
+	if demand.Exceeded() {
+		fmt.Printf("⚠️  Demand exceeds the %s ceiling of %d workers: %s, tuning cannot close the gap\n",
+			demand.Binding, demand.Ceiling, demand.Remedy())
+	} else {
+		fmt.Printf("Recommendation band: %d (demand) to %d (%s ceiling)\n", demand.Workers, demand.Ceiling, demand.Binding)
+	}
+	fmt.Println()
+}
+
+// displayMPMBlock prints a block that can be pasted into the configuration
+// as is; each rationale is a comment line of its own because Apache does not
+// accept comments after a directive
+func displayMPMBlock(mpm string, block []analysis.MPMDirective) {
+	fmt.Printf("<IfModule mpm_%s_module>\n", mpm)
+	for _, directive := range block {
+		rationale := directive.Rationale
+		if directive.Current > 0 && directive.Current != directive.Value {
+			rationale += fmt.Sprintf(" (currently %d)", directive.Current)
+		}
+		if rationale != "" {
+			fmt.Printf("    # %s\n", rationale)
+		}
+		fmt.Printf("    %s %d\n", directive.Name, directive.Value)
+	}
+	fmt.Println("</IfModule>")
+}
+
+// displayPanelInstructions explains how to apply a change under a control panel
+func displayPanelInstructions(panel *config.ControlPanel) {
+	if panel.Managed != "" {
+		fmt.Printf("\nDo not edit the generated files: %s.\n", panel.Managed)
+	}
+	if len(panel.Apply) > 0 {
+		fmt.Println("Then apply the change with:")
+		for _, command := range panel.Apply {
+			fmt.Printf("  %s\n", command)
+		}
+	} else {
+		fmt.Println("Then restart Apache to apply changes.")
+	}
+	if panel.Note != "" {
+		fmt.Printf("Note: %s\n", panel.Note)
+	}
+}
+
+// policyOf returns the policy a recommendation was made with
+func policyOf(recommendations *analysis.Recommendations) analysis.Policy {
+	if recommendations.Policy != nil {
+		return *recommendations.Policy
+	}
+	return analysis.DefaultPolicy()
+}
+
+// histogramWidth is the length of the longest histogram bar
+const histogramWidth = 30
+
+// displayDistribution prints the worker size percentiles and histogram
+func displayDistribution(memStats *analysis.MemoryStats) {
+	fmt.Printf("Distribution: median %.1f MB, p90 %.1f MB, p95 %.1f MB, p99 %.1f MB, std dev %.1f MB\n",
+		memStats.MedianMB, memStats.P90MB, memStats.P95MB, memStats.P99MB, memStats.StdDevMB)
+	largest := 0
+	for _, bucket := range memStats.Histogram {
+		if bucket.Count > largest {
+			largest = bucket.Count
+		}
+	}
+	for _, bucket := range memStats.Histogram {
+		bar := (bucket.Count*histogramWidth + largest - 1) / largest
+		fmt.Printf("  %7.1f - %7.1f MB | %s%s %d\n", bucket.FromMB, bucket.ToMB,
+			strings.Repeat("█", bar), strings.Repeat(" ", histogramWidth-bar), bucket.Count)
+	}
+}
+
+// displayFinding prints one finding with its evidence and what to do about
+// it; an acknowledged finding is listed with the reason only
+func displayFinding(finding findings.Finding) {
+	if ack := finding.Acknowledged; ack != nil {
+		fmt.Printf("✓ Acknowledged %s: %s [%s]\n", finding.Severity, finding.Title, finding.ID)
+		switch {
+		case ack.Reason != "" && !ack.Expires.IsZero():
+			fmt.Printf("   %s (until %s)\n", ack.Reason, ack.Expires.Format("2006-01-02"))
+		case ack.Reason != "":
+			fmt.Printf("   %s\n", ack.Reason)
+		case !ack.Expires.IsZero():
+			fmt.Printf("   Until %s\n", ack.Expires.Format("2006-01-02"))
+		}
+		return
+	}
+	icon := "ℹ️ "
+	switch finding.Severity {
+	case findings.SeverityCritical:
+		icon = "🔥"
+	case findings.SeverityWarning:
+		icon = "⚠️ "
+	}
+	fmt.Printf("%s %s [%s]\n", icon, finding.Title, finding.ID)
+	if finding.Detail != "" {
+		fmt.Printf("   %s\n", finding.Detail)
+	}
+	for _, evidence := range finding.Evidence {
+		fmt.Printf("   - %s\n", evidence)
+	}
+	if finding.Remediation != "" {
+		fmt.Printf("   %s\n", finding.Remediation)
+	}
+}
+
+// formatAge renders a worker age compactly, e.g. "3h12m0s"
+func formatAge(age time.Duration) string {
+	if age >= time.Minute {
+		return age.Round(time.Minute).String()
+	}
+	return age.Round(time.Second).String()
+}
+
+// describeServices lists each detected service with its memory, process
+// count and the registry rules that matched it
+func describeServices(sysInfo *system.SystemInfo) []string {
+	type summary struct {
+		processes int
+		reasons   []string
+	}
+	summaries := make(map[string]*summary)
+	for _, match := range sysInfo.ServiceMatches {
+		sum, ok := summaries[match.Service]
+		if !ok {
+			sum = &summary{}
+			summaries[match.Service] = sum
+		}
+		sum.processes++
+		if !containsString(sum.reasons, match.Reason) {
+			sum.reasons = append(sum.reasons, match.Reason)
+		}
+	}
+
+	var names []string
+	for name, memory := range sysInfo.OtherServices {
+		if memory > 0 {
+			names = append(names, name)
+		}
+	}
+	sort.Strings(names)
+
+	var lines []string
+	for _, name := range names {
+		line := fmt.Sprintf("%s: %d MB", name, sysInfo.OtherServices[name])
+		if sum, ok := summaries[name]; ok {
+			line += fmt.Sprintf(", %d process(es), matched by %s", sum.processes, strings.Join(sum.reasons, "; "))
+		}
+		if plan := findPlan(sysInfo.ServicePlans, name); plan != nil {
+			line += describePlan(plan, sysInfo.ServiceAccounting)
+		}
+		lines = append(lines, line)
+	}
+	return lines
+}
+
+// findPlan returns the planned footprint of service, or nil
+func findPlan(plans []system.ServicePlan, service string) *system.ServicePlan {
+	for i := range plans {
+		if plans[i].Service == service {
+			return &plans[i]
+		}
+	}
+	return nil
+}
+
+// describePlan explains how a service's configured footprint relates to the
+// figure charged against the Apache budget
+func describePlan(plan *system.ServicePlan, accounting string) string {
+	if accounting == system.AccountingPlanned && plan.PlannedMB > plan.CurrentMB {
+		return fmt.Sprintf(" (planned: %s; currently %d MB)", plan.Basis, plan.CurrentMB)
+	}
+	if plan.PlannedMB > plan.CurrentMB {
+		return fmt.Sprintf(" (configured to grow to %d MB: %s; see -service-accounting planned)", plan.PlannedMB, plan.Basis)
+	}
+	return fmt.Sprintf(" (configured limit %d MB)", plan.PlannedMB)
+}
+
+func containsString(values []string, value string) bool {
+	for _, v := range values {
+		if v == value {
+			return true
+		}
+	}
+	return false
+}
+
+// describeMeasurement summarises how the process memory figures were obtained
+func describeMeasurement(memStats *analysis.MemoryStats) string {
+	var parts []string
+	for _, method := range []string{process.MethodSmaps, process.MethodStatus, process.MethodPS} {
+		if count := memStats.MethodCounts[method]; count > 0 {
+			parts = append(parts, fmt.Sprintf("%d measured via %s", count, method))
+		}
+	}
+	if len(memStats.Skipped) > 0 {
+		parts = append(parts, fmt.Sprintf("%d skipped (unreadable)", len(memStats.Skipped)))
+	}
+	if len(parts) == 0 {
+		return "measurement method unknown"
+	}
+	return strings.Join(parts, ", ")
+}
+
 // showDebugInformation displays detailed technical information when debug mode is enabled
 func showDebugInformation(sysInfo *system.SystemInfo, memStats *analysis.MemoryStats, config *config.ApacheConfig, recommendations *analysis.Recommendations, statusInfo *status.ApacheStatus, logAnalysis *logs.LogAnalysis) {
 	debug.Section("DETAILED DEBUG INFORMATION")
@@ -174,14 +854,22 @@ func showDebugInformation(sysInfo *system.SystemInfo, memStats *analysis.MemoryS
 	fmt.Println("\n=== DETAILED SYSTEM INFO ===")
 	fmt.Printf("Total Memory: %d MB\n", sysInfo.TotalMemoryMB)
 	fmt.Printf("Available Memory: %d MB\n", sysInfo.AvailableMemoryMB)
+	fmt.Printf("Host Memory: %d MB\n", sysInfo.HostMemoryMB)
+	fmt.Printf("Memory Limit Source: %s\n", sysInfo.MemoryLimitSource)
+	fmt.Printf("Cgroup Usage: %d MB\n", sysInfo.CgroupUsageMB)
 	fmt.Printf("Other Services Memory: %d MB\n", system.GetTotalOtherServicesMemory(sysInfo))
 
 	if len(sysInfo.OtherServices) > 0 {
 		fmt.Printf("Service Breakdown:\n")
 		for service, memory := range sysInfo.OtherServices {
-			if service != "PHP-FPM-Note" {
-				fmt.Printf("  - %s: %d MB\n", service, memory)
-			}
+			fmt.Printf("  - %s: %d MB\n", service, memory)
+		}
+	}
+	if len(sysInfo.ServiceMatches) > 0 {
+		fmt.Printf("Service Processes:\n")
+		for _, match := range sysInfo.ServiceMatches {
+			fmt.Printf("  - %s PID %d (%s): %.2f MB via %s, %s\n",
+				match.Service, match.PID, match.Comm, match.MemoryMB, match.Method, match.Reason)
 		}
 	}
 
@@ -203,7 +891,39 @@ func showDebugInformation(sysInfo *system.SystemInfo, memStats *analysis.MemoryS
 	fmt.Printf("Smallest Worker: %.2f MB\n", memStats.SmallestMB)
 	fmt.Printf("Average Worker: %.2f MB\n", memStats.AverageMB)
 	fmt.Printf("Largest Worker: %.2f MB\n", memStats.LargestMB)
+	fmt.Printf("Median Worker: %.2f MB\n", memStats.MedianMB)
+	fmt.Printf("P90/P95/P99 Worker: %.2f / %.2f / %.2f MB\n", memStats.P90MB, memStats.P95MB, memStats.P99MB)
+	fmt.Printf("Std Dev: %.2f MB\n", memStats.StdDevMB)
+	for _, outlier := range memStats.Outliers {
+		fmt.Printf("Outlier: PID %d %.2f MB %s %s\n", outlier.PID, outlier.MemoryMB, outlier.VHost, outlier.Request)
+	}
 	fmt.Printf("Total Memory Used: %.2f MB\n", memStats.TotalMB)
+	fmt.Printf("Measurement: %s\n", describeMeasurement(memStats))
+	if len(memStats.Skipped) > 0 {
+		fmt.Printf("Skipped Processes (excluded from statistics):\n")
+		for _, proc := range memStats.Skipped {
+			fmt.Printf("  - PID %d (user %s): memory %s\n", proc.PID, proc.User, proc.Method)
+		}
+	}
+
+	if lifetime := recommendations.Lifetime; lifetime != nil {
+		fmt.Printf("Worker Lifetime: %d workers, mean age %s, oldest %s\n", lifetime.Workers, lifetime.MeanAge, lifetime.OldestAge)
+		fmt.Printf("Age/Memory Correlation (%s): %.3f\n", lifetime.MemoryBasis, lifetime.Correlation)
+		fmt.Printf("Bloated: %t (access counts used: %t)\n", lifetime.Bloated, lifetime.UsedAccessCounts)
+		fmt.Printf("MaxConnectionsPerChild: current %d, recommended %d\n",
+			lifetime.CurrentMaxConnectionsPerChild, lifetime.RecommendedMaxConnectionsPerChild)
+	}
+
+	if growth := recommendations.Growth; growth != nil {
+		fmt.Printf("Memory Growth: %d samples over %s, threshold %.1f MB/hour, horizon %s\n",
+			growth.Samples, growth.Window, growth.ThresholdMBPerHour, growth.Horizon)
+		fmt.Printf("Mean Growth: %.3f MB/hour, leaking workers: %d\n", growth.MeanSlopeMBPerHour, growth.Leaking)
+		for _, worker := range growth.Workers {
+			fmt.Printf("  - PID %d: %d samples, %.2f -> %.2f MB, %.3f MB/hour, projected %.2f MB\n",
+				worker.PID, worker.Samples, worker.FirstMB, worker.LastMB, worker.SlopeMBPerHour, worker.ProjectedPeakMB)
+		}
+	}
+	fmt.Printf("Projected Peak Worker: %.2f MB\n", memStats.ProjectedPeakMB)
 
 	// Detailed Recommendations Analysis
 	fmt.Println("\n=== DETAILED RECOMMENDATIONS ===")
@@ -212,11 +932,15 @@ func showDebugInformation(sysInfo *system.SystemInfo, memStats *analysis.MemoryS
 	fmt.Printf("Min Recommended: %d\n", recommendations.MinRecommended)
 	fmt.Printf("Max Recommended: %d\n", recommendations.MaxRecommended)
 	fmt.Printf("Status: %s\n", recommendations.Status)
-	fmt.Printf("Message: %s\n", recommendations.Message)
 	fmt.Printf("Utilization Percent: %.2f%%\n", recommendations.UtilizationPercent)
-	fmt.Printf("VHost Warning: %t\n", recommendations.VHostWarning)
-	if recommendations.MPMNote != "" {
-		fmt.Printf("MPM Note: %s\n", recommendations.MPMNote)
+	fmt.Printf("Virtual Hosts: %d\n", recommendations.VirtualHosts)
+	fmt.Printf("Confidence: %s\n", recommendations.Confidence)
+	for _, finding := range recommendations.Findings {
+		if finding.Acknowledged != nil {
+			fmt.Printf("Finding: %s %s (acknowledged)\n", finding.Severity, finding.ID)
+		} else {
+			fmt.Printf("Finding: %s %s\n", finding.Severity, finding.ID)
+		}
 	}
 
 	// Detailed Apache Status (mod_status)
@@ -282,27 +1006,36 @@ func showDebugInformation(sysInfo *system.SystemInfo, memStats *analysis.MemoryS
 	// Memory Calculations Debug
 	fmt.Println("\n=== MEMORY CALCULATION DEBUG ===")
 	if memStats.ProcessCount > 0 {
-		currentMemoryUsage := float64(config.GetCurrentMaxClients()) * memStats.LargestMB
-		recommendedMemoryUsage := float64(recommendations.RecommendedMaxClients) * memStats.LargestMB
+		sizingMB := memStats.SizingMB()
+		currentMemoryUsage := analysis.WorkerMemoryMB(config.GetCurrentMaxClients(), config, memStats)
+		recommendedMemoryUsage := analysis.WorkerMemoryMB(recommendations.RecommendedMaxClients, config, memStats)
 
 		fmt.Printf("Current Config Memory Usage:\n")
 		fmt.Printf("  MaxClients: %d\n", config.GetCurrentMaxClients())
-		fmt.Printf("  × Largest Process: %.2f MB\n", memStats.LargestMB)
+		if analysis.IsThreaded(config.MPMModel) {
+			fmt.Printf("  / ThreadsPerChild: %d = %d processes\n", analysis.ThreadsPerChild(config), analysis.ProcessesFor(config.GetCurrentMaxClients(), config))
+		}
+		fmt.Printf("  × Sizing Process: %.2f MB\n", sizingMB)
 		fmt.Printf("  = Total Usage: %.2f MB\n", currentMemoryUsage)
 		fmt.Printf("  / Available: %d MB\n", sysInfo.AvailableMemoryMB)
 		fmt.Printf("  = Utilization: %.1f%%\n", (currentMemoryUsage/float64(sysInfo.AvailableMemoryMB))*100)
 
 		fmt.Printf("\nRecommended Config Memory Usage:\n")
 		fmt.Printf("  Recommended MaxClients: %d\n", recommendations.RecommendedMaxClients)
-		fmt.Printf("  × Largest Process: %.2f MB\n", memStats.LargestMB)
+		if analysis.IsThreaded(config.MPMModel) {
+			fmt.Printf("  / ThreadsPerChild: %d = %d processes\n", analysis.ThreadsPerChild(config), analysis.ProcessesFor(recommendations.RecommendedMaxClients, config))
+		}
+		fmt.Printf("  × Sizing Process: %.2f MB\n", sizingMB)
 		fmt.Printf("  = Total Usage: %.2f MB\n", recommendedMemoryUsage)
 		fmt.Printf("  / Available: %d MB\n", sysInfo.AvailableMemoryMB)
 		fmt.Printf("  = Utilization: %.1f%%\n", (recommendedMemoryUsage/float64(sysInfo.AvailableMemoryMB))*100)
 
 		fmt.Printf("\nMemory Safety Calculations:\n")
 		fmt.Printf("  Available Memory: %d MB\n", sysInfo.AvailableMemoryMB)
-		fmt.Printf("  Max Theoretical (100%%): %d workers\n", int(float64(sysInfo.AvailableMemoryMB)/memStats.LargestMB))
-		fmt.Printf("  Conservative (90%%): %d workers\n", int(float64(sysInfo.AvailableMemoryMB)/memStats.LargestMB*0.9))
+		policy := policyOf(recommendations)
+		threads := analysis.ThreadsPerChild(config)
+		fmt.Printf("  Max Theoretical (%g%%): %d workers\n", policy.LimitPercent, policy.LimitWorkers(sysInfo.AvailableMemoryMB, sizingMB)*threads)
+		fmt.Printf("  Conservative (%g%%): %d workers\n", policy.TargetPercent, policy.TargetWorkers(sysInfo.AvailableMemoryMB, sizingMB)*threads)
 	}
 
 	fmt.Println(strings.Repeat("=", 60))
@@ -6,10 +6,14 @@ import (
 	"os"
 	"strings"
 	"testing"
+	"time"
 
 	"apache2buddy-go/internal/analysis"
 	"apache2buddy-go/internal/config"
+	"apache2buddy-go/internal/debug"
+	"apache2buddy-go/internal/findings"
 	"apache2buddy-go/internal/logs"
+	"apache2buddy-go/internal/process"
 	"apache2buddy-go/internal/status"
 	"apache2buddy-go/internal/system"
 )
@@ -57,8 +61,8 @@ func TestDisplayEnhancedResults_BasicOutput(t *testing.T) {
 	recommendations := &analysis.Recommendations{
 		CurrentMaxClients:     150,
 		RecommendedMaxClients: 120,
-		Status:                "WARNING",
-		Message:               "Consider reducing MaxRequestWorkers",
+		MinRecommended:        120,
+		MaxRecommended:        150,
 		UtilizationPercent:    85.0,
 	}
 
@@ -89,11 +93,11 @@ func TestDisplayEnhancedResults_BasicOutput(t *testing.T) {
 		"Memory usage per process: 20.5 MB (smallest), 27.8 MB (average), 35.2 MB (largest)",
 		"Active workers: 8, Idle workers: 12",
 		"⚠️  RESULT: Your Apache configuration could be improved.",
-		"Consider reducing MaxRequestWorkers to 120",
+		"   Consider reducing MaxRequestWorkers to 120 to prevent memory issues.",
 		"Configuration file: /etc/apache2/apache2.conf",
 		"MaxRequestWorkers 120",
-		"⚠️  Log analysis shows MaxRequestWorkers was exceeded 2 times",
-		"⚠️  Found 1 PHP Fatal Errors in logs",
+		"ℹ️  Log analysis shows MaxRequestWorkers was exceeded 2 times [logs.max-request-workers-reached]",
+		"ℹ️  Found 1 PHP Fatal Errors in logs [logs.php-fatal-errors]",
 		"Analysis completed. Check /var/log/apache2buddy-go.log for historical data.",
 	}
 
@@ -130,8 +134,8 @@ func TestDisplayEnhancedResults_OKStatus(t *testing.T) {
 	recommendations := &analysis.Recommendations{
 		CurrentMaxClients:     80,
 		RecommendedMaxClients: 87,
-		Status:                "OK",
-		Message:               "Configuration appears acceptable",
+		MinRecommended:        87,
+		MaxRecommended:        87,
 		UtilizationPercent:    35.0,
 	}
 
@@ -186,8 +190,8 @@ func TestDisplayEnhancedResults_CriticalStatus(t *testing.T) {
 	recommendations := &analysis.Recommendations{
 		CurrentMaxClients:     256,
 		RecommendedMaxClients: 13,
-		Status:                "CRITICAL",
-		Message:               "Configuration needs immediate attention",
+		MinRecommended:        13,
+		MaxRecommended:        13,
 		UtilizationPercent:    150.0,
 	}
 
@@ -246,8 +250,8 @@ func TestDisplayEnhancedResults_WithOtherServices(t *testing.T) {
 
 	recommendations := &analysis.Recommendations{
 		CurrentMaxClients: 100,
-		Status:            "WARNING",
-		MPMNote:           "Apache is running in worker mode. Check manually for backend processes such as PHP-FPM and pm.max_children.",
+		MinRecommended:    99,
+		MaxRecommended:    100,
 	}
 
 	logAnalysis := &logs.LogAnalysis{}
@@ -292,7 +296,8 @@ func TestDisplayEnhancedResults_WithExtendedStatus(t *testing.T) {
 
 	recommendations := &analysis.Recommendations{
 		CurrentMaxClients: 100,
-		Status:            "OK",
+		MinRecommended:    100,
+		MaxRecommended:    100,
 	}
 
 	statusInfo := &status.ApacheStatus{
@@ -356,7 +361,8 @@ func TestDisplayEnhancedResults_NoLogAnalysis(t *testing.T) {
 
 	recommendations := &analysis.Recommendations{
 		CurrentMaxClients: 80,
-		Status:            "OK",
+		MinRecommended:    80,
+		MaxRecommended:    80,
 	}
 
 	// Test with nil statusInfo and empty logAnalysis
@@ -398,7 +404,8 @@ func TestDisplayEnhancedResults_ServerLimitConfiguration(t *testing.T) {
 	recommendations := &analysis.Recommendations{
 		CurrentMaxClients:     150,
 		RecommendedMaxClients: 300, // Higher than 256, should trigger ServerLimit
-		Status:                "WARNING",
+		MinRecommended:        149,
+		MaxRecommended:        150,
 	}
 
 	logAnalysis := &logs.LogAnalysis{}
@@ -413,6 +420,920 @@ func TestDisplayEnhancedResults_ServerLimitConfiguration(t *testing.T) {
 	}
 }
 
+func TestDisplayEnhancedResults_ThreadedConfiguration(t *testing.T) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096, AvailableMemoryMB: 400, OtherServices: make(map[string]int)}
+	memStats := &analysis.MemoryStats{ProcessCount: 4, LargestMB: 50.0, AverageMB: 45.0}
+	config := &config.ApacheConfig{MaxRequestWorkers: 1000, ThreadsPerChild: 100, MPMModel: "worker"}
+	recommendations := analysis.GenerateRecommendationsWithPolicy(sysInfo, memStats, config, nil, 1, analysis.DefaultPolicy())
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"Sizing policy: reserve 0 MB, margin 90-100%, sizing on largest (50.0 MB per child process of 100 threads)",
+		"Current memory usage: 500.0 MB (125.0% of available)",
+		"Projected memory usage: 350.0 MB (87.5% of available)",
+		"<IfModule mpm_worker_module>\n",
+		"    # MaxRequestWorkers / ThreadsPerChild: 7 child processes fit the budget\n    ServerLimit 7\n",
+		"    # must be at least ThreadsPerChild\n    ThreadLimit 100\n",
+		"    # unchanged from the configuration\n    ThreadsPerChild 100\n",
+		"    MinSpareThreads 100\n",
+		"    MaxSpareThreads 250\n",
+		"    # memory ceiling: 90% of the memory budget, sizing on largest (currently 1000)\n    MaxRequestWorkers 700\n</IfModule>",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+}
+
+func TestDisplayEnhancedResults_EstimatedMemory(t *testing.T) {
+	sysInfo := &system.SystemInfo{
+		TotalMemoryMB:     2048,
+		AvailableMemoryMB: 1500,
+		OtherServices:     make(map[string]int),
+	}
+
+	memStats := &analysis.MemoryStats{
+		ProcessCount: 3,
+		LargestMB:    30.0,
+		AverageMB:    25.0,
+		MethodCounts: map[string]int{process.MethodSmaps: 2, process.MethodPS: 1},
+		Skipped:      []process.ProcessInfo{{PID: 4321, User: "www-data", Method: process.MethodUnavailable}},
+	}
+
+	config := &config.ApacheConfig{
+		MaxRequestWorkers: 40,
+		MPMModel:          "prefork",
+	}
+
+	recommendations := &analysis.Recommendations{
+		CurrentMaxClients: 40,
+		MinRecommended:    40,
+		MaxRecommended:    40,
+		Confidence:        analysis.ConfidenceMedium,
+	}
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	expected := "Memory figures are estimates (confidence: medium): 2 measured via smaps, 1 measured via ps, 1 skipped (unreadable)"
+	if !strings.Contains(output, expected) {
+		t.Errorf("Output should contain: %s", expected)
+	}
+}
+
+func TestDisplayEnhancedResults_CgroupLimit(t *testing.T) {
+	sysInfo := &system.SystemInfo{
+		TotalMemoryMB:     2048,
+		AvailableMemoryMB: 1536,
+		OtherServices:     make(map[string]int),
+		HostMemoryMB:      65536,
+		MemoryLimitSource: "cgroup v2 memory.max on /docker/abc",
+		CgroupUsageMB:     512,
+	}
+
+	memStats := &analysis.MemoryStats{ProcessCount: 5, LargestMB: 30.0, AverageMB: 25.0}
+	config := &config.ApacheConfig{MaxRequestWorkers: 40, MPMModel: "prefork"}
+	recommendations := &analysis.Recommendations{CurrentMaxClients: 40, MinRecommended: 40, MaxRecommended: 40}
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"Total RAM: 2048 MB",
+		"Binding memory limit: cgroup v2 memory.max on /docker/abc (2048 MB, host RAM 65536 MB)",
+		"Current cgroup usage: 512 MB",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s", expected)
+		}
+	}
+}
+
+func TestDisplayEnhancedResults_WorkerLifetime(t *testing.T) {
+	sysInfo := &system.SystemInfo{
+		TotalMemoryMB:     2048,
+		AvailableMemoryMB: 1500,
+		OtherServices:     make(map[string]int),
+	}
+
+	memStats := &analysis.MemoryStats{ProcessCount: 4, LargestMB: 48.0, AverageMB: 31.0}
+	config := &config.ApacheConfig{MaxRequestWorkers: 30, MPMModel: "prefork"}
+	recommendations := &analysis.Recommendations{
+		CurrentMaxClients: 30,
+		MinRecommended:    30,
+		MaxRecommended:    30,
+		Lifetime: &analysis.WorkerLifetime{
+			Workers:                           4,
+			MeanAge:                           3 * time.Hour,
+			OldestAge:                         10 * time.Hour,
+			MemoryBasis:                       "PSS",
+			Correlation:                       0.93,
+			YoungAverageMB:                    20.5,
+			OldAverageMB:                      41.5,
+			GrowthPercent:                     102.4,
+			Bloated:                           true,
+			UsedAccessCounts:                  true,
+			RecommendedMaxConnectionsPerChild: 4300,
+		},
+	}
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"Worker lifetime: 4 workers, mean age 3h0m0s, oldest 10h0m0s",
+		"Memory vs. age (PSS): correlation 0.93, young workers 20.5 MB avg, old workers 41.5 MB avg (+102%)",
+		"Set MaxConnectionsPerChild 4300 (currently unlimited, from per-child access counts)",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s", expected)
+		}
+	}
+}
+
+func TestDisplayEnhancedResults_MemoryGrowth(t *testing.T) {
+	sysInfo := &system.SystemInfo{
+		TotalMemoryMB:     2048,
+		AvailableMemoryMB: 1000,
+		OtherServices:     make(map[string]int),
+	}
+
+	memStats := &analysis.MemoryStats{ProcessCount: 3, LargestMB: 41.5, AverageMB: 32.0, ProjectedPeakMB: 101.5}
+	config := &config.ApacheConfig{MaxRequestWorkers: 10, MPMModel: "prefork"}
+	recommendations := &analysis.Recommendations{
+		CurrentMaxClients:     10,
+		RecommendedMaxClients: 8,
+		MinRecommended:        8,
+		MaxRecommended:        10,
+		Growth: &analysis.GrowthAnalysis{
+			Window:             3 * time.Minute,
+			Samples:            4,
+			ThresholdMBPerHour: 10,
+			Horizon:            2 * time.Hour,
+			MeanSlopeMBPerHour: 10,
+			ProjectedPeakMB:    101.5,
+			Leaking:            1,
+			Workers: []analysis.WorkerGrowth{
+				{PID: 200, Samples: 4, FirstMB: 40, LastMB: 41.5, SlopeMBPerHour: 30, ProjectedPeakMB: 101.5, Leaking: true},
+				{PID: 100, Samples: 4, FirstMB: 30, LastMB: 30, ProjectedPeakMB: 30},
+			},
+		},
+	}
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"Memory growth: 4 samples over 3m0s, mean +10.0 MB/hour per worker",
+		"1 worker(s) grew faster than 10.0 MB/hour",
+		"PID 200: 40.0 MB -> 41.5 MB (+30.0 MB/hour), projected 101.5 MB in 2h0m0s",
+		"Sizing uses the projected peak of 101.5 MB per worker.",
+		// Memory usage is sized at the projected peak, not the current largest
+		"Current memory usage: 1015.0 MB",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s", expected)
+		}
+	}
+	if strings.Contains(output, "PID 100:") {
+		t.Error("Output should not list workers below the growth threshold")
+	}
+}
+
+func TestDisplayEnhancedResults_History(t *testing.T) {
+	sysInfo := &system.SystemInfo{
+		TotalMemoryMB:     4096,
+		AvailableMemoryMB: 3000,
+		OtherServices:     make(map[string]int),
+	}
+
+	memStats := &analysis.MemoryStats{ProcessCount: 3, LargestMB: 20, AverageMB: 20, HistoryPeakMB: 45}
+	config := &config.ApacheConfig{MaxRequestWorkers: 60, MPMModel: "prefork"}
+	peakAt := time.Date(2026, 10, 14, 14, 5, 0, 0, time.UTC)
+	recommendations := &analysis.Recommendations{
+		CurrentMaxClients:     60,
+		RecommendedMaxClients: 60,
+		MinRecommended:        135,
+		MaxRecommended:        150,
+		History: &analysis.HistoryPeak{
+			Days: 14, Runs: 30, LargestMB: 45, LargestAt: peakAt, BusyWorkers: 48, BusyAt: peakAt,
+			SupportedWorkers: 60, CappedFrom: 135,
+		},
+	}
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"History: 30 run(s) in the last 14 days",
+		"  Largest worker: 45.0 MB on 2026-10-14 14:05",
+		"  Busy workers:   48 on 2026-10-14 14:05",
+		"MaxRequestWorkers is not raised past the 60 the peak leaves room for.",
+		"ℹ️  MaxRequestWorkers held at 60: this run alone would allow 135 [memory.history-held]",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+}
+
+func TestDisplayEnhancedResults_ServiceMatches(t *testing.T) {
+	sysInfo := &system.SystemInfo{
+		TotalMemoryMB:     4096,
+		AvailableMemoryMB: 2500,
+		OtherServices: map[string]int{
+			"PHP-FPM":       240,
+			"Elasticsearch": 1024,
+		},
+		ServiceMatches: []system.ServiceMatch{
+			{Service: "Elasticsearch", PID: 300, Comm: "java", Reason: "exe /usr/share/elasticsearch/jdk/bin/java matches \"/elasticsearch/\"", MemoryMB: 1024},
+			{Service: "PHP-FPM", PID: 200, Comm: "php-fpm8.3", Reason: `comm "php-fpm8.3" matches "php-fpm*"`, MemoryMB: 120},
+			{Service: "PHP-FPM", PID: 201, Comm: "php-fpm8.3", Reason: `comm "php-fpm8.3" matches "php-fpm*"`, MemoryMB: 120},
+		},
+		ServiceNotes: []system.ServiceNote{{Service: "PHP-FPM", Message: "PHP capacity is set per pool"}},
+	}
+
+	memStats := &analysis.MemoryStats{ProcessCount: 5, LargestMB: 30.0, AverageMB: 25.0}
+	config := &config.ApacheConfig{MaxRequestWorkers: 50, MPMModel: "event"}
+	recommendations := &analysis.Recommendations{CurrentMaxClients: 50, MinRecommended: 50, MaxRecommended: 50}
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"RAM used by other services: 1264 MB",
+		"  - Elasticsearch: 1024 MB, 1 process(es), matched by exe /usr/share/elasticsearch/jdk/bin/java",
+		`  - PHP-FPM: 240 MB, 2 process(es), matched by comm "php-fpm8.3" matches "php-fpm*"`,
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s", expected)
+		}
+	}
+	if !strings.Contains(output, "Note (PHP-FPM): PHP capacity is set per pool") {
+		t.Error("Output should show service notes")
+	}
+}
+
+func TestDisplayEnhancedResults_ServicePlans(t *testing.T) {
+	for _, tt := range []struct {
+		accounting string
+		mysqlMB    int
+		expected   []string
+	}{
+		{
+			accounting: system.AccountingCurrent,
+			mysqlMB:    400,
+			expected: []string{
+				"  - MySQL: 400 MB (configured to grow to 2819 MB: innodb_buffer_pool_size 2048 MB; see -service-accounting planned)",
+				"  - Redis: 300 MB (configured limit 256 MB)",
+			},
+		},
+		{
+			accounting: system.AccountingPlanned,
+			mysqlMB:    2819,
+			expected: []string{
+				"  - MySQL: 2819 MB (planned: innodb_buffer_pool_size 2048 MB; currently 400 MB)",
+				"RAM used by other services: 3119 MB",
+			},
+		},
+	} {
+		t.Run(tt.accounting, func(t *testing.T) {
+			sysInfo := &system.SystemInfo{
+				TotalMemoryMB:     8192,
+				AvailableMemoryMB: 2500,
+				OtherServices:     map[string]int{"MySQL": tt.mysqlMB, "Redis": 300},
+				ServicePlans: []system.ServicePlan{
+					{Service: "MySQL", CurrentMB: 400, PlannedMB: 2819, Basis: "innodb_buffer_pool_size 2048 MB"},
+					{Service: "Redis", CurrentMB: 300, PlannedMB: 256, Basis: "maxmemory 256 MB across 1 instance(s)"},
+				},
+				ServiceAccounting: tt.accounting,
+			}
+			memStats := &analysis.MemoryStats{ProcessCount: 5, LargestMB: 30.0, AverageMB: 25.0}
+			config := &config.ApacheConfig{MaxRequestWorkers: 50, MPMModel: "prefork"}
+			recommendations := &analysis.Recommendations{CurrentMaxClients: 50, MinRecommended: 50, MaxRecommended: 50}
+
+			output := captureOutput(func() {
+				DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+			})
+			for _, expected := range tt.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("Output should contain: %s\n%s", expected, output)
+				}
+			}
+		})
+	}
+}
+
+func TestDisplayEnhancedResults_MemoryPressure(t *testing.T) {
+	since := time.Date(2026, 1, 3, 12, 0, 0, 0, time.UTC)
+	kill := system.OOMEvent{Time: since.Add(36 * time.Hour), PID: 4242, Process: "apache2", Apache: true, TaskCgroup: "/system.slice/apache2.service"}
+	elsewhere := system.OOMEvent{Time: since.Add(40 * time.Hour), PID: 5151, Process: "httpd", Apache: true, TaskCgroup: "/system.slice/docker-abc.scope"}
+	sysInfo := &system.SystemInfo{
+		CgroupPath:        "/system.slice/apache2.service",
+		TotalMemoryMB:     4096,
+		AvailableMemoryMB: 2500,
+		OtherServices:     map[string]int{},
+		Swap:              &system.SwapInfo{TotalMB: 2048, FreeMB: 1536, Swappiness: 60},
+		OOM: &system.OOMHistory{
+			Window: 7 * 24 * time.Hour, Since: since, Sources: []string{"/var/log/kern.log"},
+			Events: []system.OOMEvent{kill, elsewhere, {Time: since.Add(time.Hour), PID: 99, Process: "mysqld"}},
+		},
+	}
+	memStats := &analysis.MemoryStats{ProcessCount: 5, LargestMB: 30.0, AverageMB: 25.0, SwapMB: 40, SwappedWorkers: 2}
+	config := &config.ApacheConfig{MaxRequestWorkers: 50, MPMModel: "prefork"}
+	recommendations := &analysis.Recommendations{CurrentMaxClients: 50, RecommendedMaxClients: 75, MinRecommended: 75, MaxRecommended: 75}
+	analysis.AssessMemoryPressure(recommendations, sysInfo, memStats)
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"Swap: 512 MB used of 2048 MB (swappiness 60, overcommit heuristic)",
+		"Apache workers in swap: 2 (40.0 MB)",
+		"OOM killer: 3 kill(s) since 2026-01-03 12:00, 1 of them this instance's Apache",
+		"  - 2026-01-05 00:00:00: apache2 (PID 4242), host out of memory",
+		"  1 other Apache kill(s) are not charged to this instance",
+		"RESULT: Your Apache configuration needs immediate attention!",
+		"The OOM killer killed Apache 1 time(s) in the last 7 days",
+		"Reduce MaxRequestWorkers below the current value",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "Reduce MaxRequestWorkers to 75") {
+		t.Error("Output should not recommend raising MaxRequestWorkers after an OOM kill")
+	}
+}
+
+func TestDisplayHostFindings(t *testing.T) {
+	output := captureOutput(func() {
+		DisplayHostFindings([]findings.Finding{{
+			ID: "memory.oom-killed-apache", Severity: findings.SeverityCritical, Category: findings.CategoryMemory,
+			Title:    "The OOM killer killed Apache 1 time(s) in the last 7 days",
+			Evidence: []string{"2026-01-05 00:00:00 httpd (PID 4242), from /var/log/messages"},
+		}})
+	})
+
+	tests := []string{
+		"Host findings",
+		"🔥 The OOM killer killed Apache 1 time(s) in the last 7 days [memory.oom-killed-apache]",
+		"2026-01-05 00:00:00 httpd (PID 4242), from /var/log/messages",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+
+	if output := captureOutput(func() { DisplayHostFindings(nil) }); output != "" {
+		t.Errorf("DisplayHostFindings(nil) printed %q, want nothing", output)
+	}
+}
+
+func TestDisplayEnhancedResults_CPUCeiling(t *testing.T) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 65536, AvailableMemoryMB: 60000, OtherServices: map[string]int{}}
+	memStats := &analysis.MemoryStats{ProcessCount: 4, LargestMB: 30.0, AverageMB: 25.0}
+	config := &config.ApacheConfig{MaxRequestWorkers: 400, MPMModel: "prefork"}
+	recommendations := &analysis.Recommendations{CurrentMaxClients: 400, RecommendedMaxClients: 1800, MinRecommended: 1800, MaxRecommended: 1800}
+	cpu := &analysis.CPUAnalysis{
+		CPU:  &system.CPUInfo{OnlineCPUs: 2},
+		CPUs: 2, Load1: 3.1, Load5: 2.6, Load15: 1.9, LoadSource: "/proc/loadavg",
+		ApacheCores: 1, ApacheSource: "worker CPU time", BusyWorkers: 4, BusySource: analysis.BusyPrefork, CoresPerWorker: 0.25,
+		TargetWorkers: 7, LimitWorkers: 8,
+	}
+	analysis.ApplyCPUCeiling(recommendations, cpu)
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"CPUs: 2 (online CPUs), load 3.10 / 2.60 / 1.90 (/proc/loadavg)",
+		"CPU saturated: the 5-minute load 2.60 exceeds 2 CPUs",
+		"Apache CPU use: 1.00 cores (from worker CPU time) over 4.0 busy workers (from every prefork worker), 0.25 per worker",
+		"CPU ceiling: 7 workers, memory ceiling: 1800 workers (binding: CPU)",
+		"Recommended MaxRequestWorkers: 7",
+		"Consider reducing MaxRequestWorkers to 7: the CPUs cannot serve more busy workers.",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+}
+
+func TestDisplayEnhancedResults_ControlPanel(t *testing.T) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096, AvailableMemoryMB: 2500, OtherServices: map[string]int{}}
+	memStats := &analysis.MemoryStats{ProcessCount: 5, LargestMB: 30.0, AverageMB: 25.0}
+	recommendations := &analysis.Recommendations{CurrentMaxClients: 150, RecommendedMaxClients: 75, MinRecommended: 75, MaxRecommended: 75}
+
+	tests := []struct {
+		name     string
+		panel    *config.ControlPanel
+		expected []string
+	}{
+		{
+			name: "panel with apply commands",
+			panel: &config.ControlPanel{
+				Name:     "DirectAdmin",
+				Managed:  "/etc/httpd/conf/extra/httpd-mpm.conf is rewritten by CustomBuild",
+				MPMFiles: []string{"/usr/local/directadmin/custombuild/custom/ap2/conf/extra/httpd-mpm.conf"},
+				Apply:    []string{"cd /usr/local/directadmin/custombuild && ./build rewrite_confs"},
+			},
+			expected: []string{
+				"To implement changes under DirectAdmin, add to /usr/local/directadmin/custombuild/custom/ap2/conf/extra/httpd-mpm.conf:",
+				"    MaxRequestWorkers 75",
+				"Do not edit the generated files: /etc/httpd/conf/extra/httpd-mpm.conf is rewritten by CustomBuild.",
+				"Then apply the change with:\n  cd /usr/local/directadmin/custombuild && ./build rewrite_confs",
+			},
+		},
+		{
+			name:  "panel that edits in place",
+			panel: &config.ControlPanel{Name: "Webmin", MPMFiles: []string{"/etc/httpd/conf.d/mpm.conf"}},
+			expected: []string{
+				"To implement changes under Webmin, add to /etc/httpd/conf.d/mpm.conf:",
+				"Then restart Apache to apply changes.",
+			},
+		},
+		{
+			name:     "no panel",
+			expected: []string{"To implement changes, edit your Apache configuration:", "Then restart Apache to apply changes."},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			apacheConfig := &config.ApacheConfig{MaxRequestWorkers: 150, MPMModel: "prefork", ControlPanel: tt.panel}
+			output := captureOutput(func() {
+				DisplayEnhancedResults(sysInfo, memStats, apacheConfig, recommendations, nil, &logs.LogAnalysis{})
+			})
+			for _, expected := range tt.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("Output should contain: %s\n%s", expected, output)
+				}
+			}
+		})
+	}
+}
+
+func TestDisplayEnhancedResults_Limits(t *testing.T) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096, AvailableMemoryMB: 2500, OtherServices: map[string]int{}}
+	memStats := &analysis.MemoryStats{ProcessCount: 5, LargestMB: 30.0, AverageMB: 25.0}
+	config := &config.ApacheConfig{MaxRequestWorkers: 75, MPMModel: "prefork"}
+	recommendations := &analysis.Recommendations{CurrentMaxClients: 75, RecommendedMaxClients: 75, MinRecommended: 75, MaxRecommended: 75}
+	analysis.AssessLimits(recommendations, []system.LimitCheck{
+		{ID: "somaxconn", Limit: "net.core.somaxconn", Value: "128", Needed: "ListenBacklog 511", Status: "WARNING",
+			Message: "ListenBacklog 511 is silently cut to 128",
+			Fix:     "net.core.somaxconn = 511 in /etc/sysctl.d/90-apache.conf, then sysctl --system"},
+		{ID: "unit-tasks", Limit: "apache2.service TasksMax", Value: "unlimited", Needed: "76 processes and threads", Status: "OK"},
+	})
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"Kernel and service limits:",
+		"  ⚠️  net.core.somaxconn: 128 (needs ListenBacklog 511): ListenBacklog 511 is silently cut to 128",
+		"     Fix: net.core.somaxconn = 511 in /etc/sysctl.d/90-apache.conf, then sysctl --system",
+		"  ✓ apache2.service TasksMax: unlimited (needs 76 processes and threads)",
+		"RESULT: Your Apache configuration could be improved.",
+		"⚠️  net.core.somaxconn (128) is too low for the configuration [limits.somaxconn]\n   ListenBacklog 511 is silently cut to 128\n   - Needs ListenBacklog 511\n   net.core.somaxconn = 511 in /etc/sysctl.d/90-apache.conf, then sysctl --system",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+	for _, unexpected := range []string{"Consider increasing MaxRequestWorkers", "To implement changes"} {
+		if strings.Contains(output, unexpected) {
+			t.Errorf("Output should not contain %q when only a limit is too low", unexpected)
+		}
+	}
+}
+
+func TestDisplayEnhancedResults_Distribution(t *testing.T) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096, AvailableMemoryMB: 2500, OtherServices: map[string]int{}}
+	var workers []process.ProcessInfo
+	for i, size := range []float64{30, 31, 29, 30, 32, 31, 180} {
+		workers = append(workers, process.ProcessInfo{PID: 100 + i, MemoryMB: size, Method: process.MethodSmaps})
+	}
+	memStats := analysis.CalculateMemoryStats(workers)
+	memStats.DescribeOutliers(&status.ApacheStatus{Workers: []status.WorkerSlot{
+		{PID: 106, Mode: "W", VHost: "shop.example.com", Request: "POST /upload HTTP/1.1"},
+	}})
+	config := &config.ApacheConfig{MaxRequestWorkers: 10, MPMModel: "prefork"}
+	recommendations := &analysis.Recommendations{CurrentMaxClients: 10, RecommendedMaxClients: 12, MinRecommended: 12, MaxRecommended: 13}
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"Distribution: median 31.0 MB, p90 180.0 MB, p95 180.0 MB, p99 180.0 MB, std dev 52.3 MB",
+		"     29.0 -    47.9 MB | ██████████████████████████████ 6",
+		"    161.1 -   180.0 MB | █████                          1",
+		"ℹ️  1 worker(s) are much larger than the median of 31.0 MB [memory.outlier-workers]",
+		"   - PID 106: 180.0 MB, serving POST /upload HTTP/1.1 on shop.example.com",
+		"   If such requests are rare, consider -size-on median (31.0 MB) instead.",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+}
+
+func TestDisplayEnhancedResults_Acknowledged(t *testing.T) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096, AvailableMemoryMB: 2500, OtherServices: map[string]int{}}
+	memStats := &analysis.MemoryStats{ProcessCount: 5, LargestMB: 30.0, AverageMB: 25.0}
+	config := &config.ApacheConfig{MaxRequestWorkers: 75, MPMModel: "prefork"}
+	recommendations := &analysis.Recommendations{CurrentMaxClients: 75, RecommendedMaxClients: 75, MinRecommended: 75, MaxRecommended: 75}
+	analysis.AssessLimits(recommendations, []system.LimitCheck{
+		{ID: "somaxconn", Limit: "net.core.somaxconn", Value: "128", Needed: "ListenBacklog 511", Status: "WARNING",
+			Message: "ListenBacklog 511 is silently cut to 128"},
+	})
+	facts := analysis.Facts(sysInfo, memStats, config, recommendations, nil)
+	findings.Put(facts, findings.Suppressions{
+		{ID: "limits.somaxconn", Expires: time.Now().AddDate(0, 1, 0), Reason: "Kernel upgrade scheduled"},
+	})
+	analysis.Evaluate(recommendations, facts)
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"✓ RESULT: Your Apache configuration appears to be optimal.",
+		"✓ Acknowledged WARNING: net.core.somaxconn (128) is too low for the configuration [limits.somaxconn]\n   Kernel upgrade scheduled (until ",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "   - Needs ListenBacklog 511") {
+		t.Error("Output should not repeat the evidence of an acknowledged finding")
+	}
+}
+
+func TestDisplayEnhancedResults_Demand(t *testing.T) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096, AvailableMemoryMB: 2500, OtherServices: map[string]int{}}
+	memStats := &analysis.MemoryStats{ProcessCount: 5, LargestMB: 30.0, AverageMB: 25.0}
+	config := &config.ApacheConfig{MaxRequestWorkers: 75, MPMModel: "prefork"}
+	peak := &analysis.RequestPeak{PerSec: 200, At: time.Date(2026, 3, 2, 14, 5, 0, 0, time.UTC), Source: "busiest minute of 2 access log(s)"}
+
+	tests := []struct {
+		name       string
+		durationMS float64
+		expected   []string
+		unexpected []string
+	}{
+		{
+			name:       "demand within the ceiling",
+			durationMS: 100,
+			expected: []string{
+				"Request rate: 200.00 req/s (busiest minute of 2 access log(s), 2026-03-02 14:05)",
+				"Demand: 200.00 req/s × 100 ms = 20.0 busy workers, 30 with 50% headroom",
+				"Recommendation band: 30 (demand) to 75 (memory ceiling)",
+				"RESULT: Your Apache configuration appears to be optimal.",
+			},
+		},
+		{
+			name:       "demand above the ceiling",
+			durationMS: 500,
+			expected: []string{
+				"Demand: 200.00 req/s × 500 ms = 100.0 busy workers, 150 with 50% headroom",
+				"⚠️  Demand exceeds the memory ceiling of 75 workers: add RAM or scale out, tuning cannot close the gap",
+				"⚠️  Peak demand needs about 150 workers but the memory ceiling is 75 [demand.exceeds-ceiling]\n   Tuning cannot close the gap.\n   - 200.00 req/s (busiest minute of 2 access log(s)) × 500 ms per request\n   Either add RAM or scale out.",
+			},
+			unexpected: []string{"Recommendation band", "To implement changes"},
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			recommendations := &analysis.Recommendations{CurrentMaxClients: 75, RecommendedMaxClients: 75, MinRecommended: 75, MaxRecommended: 75}
+			statusInfo := &status.ApacheStatus{RequestsPerSec: 12, AvgRequestTime: tt.durationMS}
+			analysis.AssessDemand(recommendations, analysis.AnalyzeDemand(statusInfo, peak))
+
+			output := captureOutput(func() {
+				DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+			})
+			for _, expected := range tt.expected {
+				if !strings.Contains(output, expected) {
+					t.Errorf("Output should contain: %s\n%s", expected, output)
+				}
+			}
+			for _, unexpected := range tt.unexpected {
+				if strings.Contains(output, unexpected) {
+					t.Errorf("Output should not contain %q", unexpected)
+				}
+			}
+		})
+	}
+}
+
+func TestDisplayEnhancedResults_MemoryBudget(t *testing.T) {
+	sysInfo := &system.SystemInfo{
+		TotalMemoryMB:     8192,
+		HostMemoryMB:      8192,
+		AvailableMemoryMB: 3632,
+		MemAvailableMB:    1800,
+		OtherServices:     map[string]int{"MySQL": 2048},
+		MemoryLimitSource: "host RAM",
+		Budget: &system.MemoryBudget{
+			LimitMB: 8192, LimitSource: "host RAM", ReserveMB: 512,
+			OtherMB: 2048, OtherLabel: "Other services", OtherInstancesMB: 2000,
+			ApacheMB: 640, AvailableMB: 3632,
+		},
+	}
+	memStats := &analysis.MemoryStats{ProcessCount: 5, LargestMB: 30.0, AverageMB: 25.0}
+	config := &config.ApacheConfig{MaxRequestWorkers: 50, MPMModel: "prefork"}
+	recommendations := &analysis.Recommendations{CurrentMaxClients: 50, MinRecommended: 50, MaxRecommended: 50}
+
+	output := captureOutput(func() {
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"Memory budget for Apache:",
+		"  Limit (host RAM)                    8192 MB",
+		"  - Reserve for the OS                 512 MB",
+		"  - Other services                    2048 MB",
+		"  - Other Apache instances            2000 MB",
+		"  = Available for Apache              3632 MB",
+		"  Apache uses 640 MB now, included in the budget (MemAvailable: 1800 MB, not used for sizing)",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+	if strings.Contains(output, "RAM available for Apache") {
+		t.Error("The budget breakdown should replace the single available line")
+	}
+}
+
+func TestDisplayEnhancedResults_Policy(t *testing.T) {
+	sysInfo := &system.SystemInfo{
+		TotalMemoryMB:     8192,
+		AvailableMemoryMB: 6553,
+		OtherServices:     map[string]int{},
+		Budget: &system.MemoryBudget{
+			LimitMB: 8192, LimitSource: "host RAM", Reserve: system.Reserve{MB: 512, Percent: 20},
+			ReserveMB: 1638, OtherLabel: "Other services", AvailableMB: 6553,
+		},
+	}
+	policy := analysis.Policy{Reserve: system.Reserve{MB: 512, Percent: 20}, TargetPercent: 80, LimitPercent: 95, Sizing: analysis.SizeP95}
+	memStats := &analysis.MemoryStats{ProcessCount: 20, LargestMB: 60.0, AverageMB: 25.0, P95MB: 40.0, Statistic: analysis.SizeP95}
+	config := &config.ApacheConfig{MaxRequestWorkers: 100, MPMModel: "prefork"}
+	recommendations := analysis.GenerateRecommendationsWithPolicy(sysInfo, memStats, config, nil, 0, policy)
+
+	output := captureOutput(func() {
+		debug.Enable()
+		defer debug.Disable()
+		DisplayEnhancedResults(sysInfo, memStats, config, recommendations, nil, &logs.LogAnalysis{})
+	})
+
+	tests := []string{
+		"  - Reserve for the OS (max(512 MB, 20%))    1638 MB",
+		"Sizing policy: reserve max(512 MB, 20%), margin 80-95%, sizing on p95 (40.0 MB per worker)",
+		"Current memory usage: 4000.0 MB",
+		"  Max Theoretical (95%): 155 workers",
+		"  Conservative (80%): 131 workers",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+}
+
+func TestDisplaySimulation(t *testing.T) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096, HostMemoryMB: 4096, AvailableMemoryMB: 3072, OtherServices: map[string]int{"MySQL": 1024}}
+	var workers []process.ProcessInfo
+	for i := 0; i < 10; i++ {
+		workers = append(workers, process.ProcessInfo{PID: 100 + i, MemoryMB: 30, Method: process.MethodSmaps})
+	}
+	memStats := analysis.CalculateMemoryStats(workers)
+	config := &config.ApacheConfig{MPMModel: "prefork", MaxRequestWorkers: 150}
+	policy := analysis.DefaultPolicy()
+	recommendations := analysis.GenerateRecommendationsWithPolicy(sysInfo, memStats, config, nil, 1, policy)
+	analysis.ApplyCPUCeiling(recommendations, nil)
+	analysis.Evaluate(recommendations, analysis.Facts(sysInfo, memStats, config, recommendations, nil))
+
+	sim := analysis.Simulation{TotalMemoryMB: 8192, MPMModel: "event", ExcludeServices: []string{"MySQL"}}
+	result := analysis.Simulate(sim, sysInfo, memStats, config, nil, recommendations, policy)
+	output := captureOutput(func() {
+		DisplaySimulation(sysInfo, memStats, config, recommendations, result)
+	})
+
+	tests := []string{
+		"What if: 8192 MB RAM, event MPM, without MySQL",
+		"  MPM                            prefork          event",
+		"  Memory for Apache              3072 MB          8192 MB",
+		"  Size per process               30.0 MB          30.0 MB",
+		"  MaxRequestWorkers band         92-102           6125-6825",
+		"  Recommended MaxRequestWorkers  92               6125",
+		"  ThreadsPerChild                0                25",
+		"  Result                         CRITICAL         OK",
+		"  Note: Process size was measured under prefork",
+		"Simulated MPM block:\n<IfModule mpm_event_module>",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+}
+
+func TestDisplayCapacityPlan(t *testing.T) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096, HostMemoryMB: 4096, OtherServices: map[string]int{"MySQL": 1024}}
+	var workers []process.ProcessInfo
+	for i := 0; i < 10; i++ {
+		workers = append(workers, process.ProcessInfo{PID: 100 + i, MemoryMB: 30, PSSMB: 12, Method: process.MethodSmaps})
+	}
+	memStats := analysis.CalculateMemoryStats(workers)
+	config := &config.ApacheConfig{MPMModel: "prefork", MaxRequestWorkers: 150}
+	report, err := analysis.PlanCapacityReport(analysis.PlanTarget{Concurrency: 800}, sysInfo, memStats, config, nil, analysis.DefaultPolicy())
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	output := captureOutput(func() {
+		DisplayCapacityPlan(report)
+	})
+
+	tests := []string{
+		"Capacity plan: 800 concurrent requests",
+		"  MaxRequestWorkers: 800 (prefork: 800 processes × 30.0 MB)",
+		"  Apache:          26667 MB (90% of its budget)",
+		"  Other services:   1024 MB",
+		"  Required RAM:    27691 MB (28 GB), 23595 MB more than the 4096 MB this host has",
+		"To serve it, configure:\n<IfModule mpm_prefork_module>",
+		"    # capacity plan: 800 concurrent requests (currently 150)\n    MaxRequestWorkers 800",
+		"Under event: 32 processes × 318.0 MB (estimated), 25 threads each, 12331 MB of RAM: switching would save 15360 MB",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+}
+
+func TestDisplayMigration(t *testing.T) {
+	sysInfo := &system.SystemInfo{TotalMemoryMB: 4096}
+	var workers []process.ProcessInfo
+	for i := 0; i < 10; i++ {
+		workers = append(workers, process.ProcessInfo{PID: 100 + i, MemoryMB: 30, PSSMB: 12, Method: process.MethodSmaps})
+	}
+	memStats := analysis.CalculateMemoryStats(workers)
+	config := &config.ApacheConfig{MPMModel: "prefork", MaxRequestWorkers: 150, Modules: []string{"php7_module", "cgi_module"}}
+	rec := &analysis.Recommendations{RecommendedMaxClients: 100}
+	analysis.AdviseMigration(rec, sysInfo, memStats, config, nil)
+
+	output := captureOutput(func() {
+		displayMigration(rec.Migration)
+	})
+
+	tests := []string{
+		"MPM migration: prefork + mod_php to event + PHP-FPM",
+		"  Memory per concurrent request: 30.0 MB today, 13.7 MB expected (54% less)",
+		"    event child:   43.0 MB with 25 threads, 1.7 MB per request",
+		"    PHP-FPM child: 12.0 MB (private memory (PSS) of a prefork worker)",
+		"  100 concurrent requests: 3000 MB today, 1372 MB expected; 3000 MB would serve 218",
+		"  Blocking modules:\n    php7_module: mod_php is built without thread safety for prefork",
+		"  Modules to check:\n    cgi_module: mod_cgi forks from the multithreaded children",
+		"    1. Install PHP-FPM",
+		"<IfModule mpm_event_module>",
+		"    MaxRequestWorkers 100",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s\n%s", expected, output)
+		}
+	}
+}
+
+func TestDisplayPHPFPMPools(t *testing.T) {
+	recommendations := []analysis.PoolRecommendation{
+		{
+			Pool: system.PHPFPMPool{
+				Name: "www", ConfigFile: "/etc/php/8.3/fpm/pool.d/www.conf", PM: "dynamic",
+				MaxChildren: 50, StartServers: 5, MinSpareServers: 5, MaxSpareServers: 35, MaxRequests: 500,
+				Listen: "/run/php/php8.3-fpm.sock", Processes: 6, AverageMB: 48.2, LargestMB: 61.5,
+			},
+			BudgetMB:               1200,
+			Statistic:              analysis.SizeLargest,
+			ChildMB:                61.5,
+			RecommendedMaxChildren: 17,
+			MaxRecommended:         19,
+			Status:                 "CRITICAL",
+			Message:                "pm.max_children 50 could use 3075 MB, more than the 1200 MB available",
+		},
+		{
+			Pool:    system.PHPFPMPool{Name: "api", ConfigFile: "/etc/php/8.3/fpm/pool.d/api.conf", PM: "ondemand", MaxChildren: 4},
+			Status:  "UNKNOWN",
+			Message: "No running children to measure",
+		},
+	}
+
+	output := captureOutput(func() {
+		DisplayPHPFPMPools(recommendations, analysis.PHPFPMBudget{TotalMB: 4000, ApacheMB: 2800, PHPFPMMB: 1200}, []findings.Finding{{
+			ID: "phpfpm.over-budget.www", Severity: findings.SeverityCritical, Category: findings.CategoryPHPFPM,
+			Title:       "PHP-FPM pool www: pm.max_children 50 could use 3075 MB, more than the 1200 MB available",
+			Remediation: "Set pm.max_children = 17 in /etc/php/8.3/fpm/pool.d/www.conf.",
+		}})
+	})
+
+	tests := []string{
+		"PHP-FPM pools: 2 (1200 MB available to PHP-FPM)",
+		"Memory after other services: 4000 MB; Apache's recommended MaxRequestWorkers use 2800 MB, leaving 1200 MB for PHP-FPM",
+		"[www] /etc/php/8.3/fpm/pool.d/www.conf",
+		"pm = dynamic, pm.max_children = 50, pm.start_servers = 5, pm.min_spare_servers = 5, pm.max_spare_servers = 35, pm.max_requests = 500",
+		"listen = /run/php/php8.3-fpm.sock",
+		"Children: 6 running, 48.2 MB (average), 61.5 MB (largest)",
+		"Memory budget: 1200 MB, recommended pm.max_children: 17 (max 19) at 61.5 MB per child (largest)",
+		"🔥 PHP-FPM pool www: pm.max_children 50 could use 3075 MB, more than the 1200 MB available [phpfpm.over-budget.www]",
+		"Set pm.max_children = 17 in /etc/php/8.3/fpm/pool.d/www.conf.",
+		"pm = ondemand, pm.max_children = 4, pm.max_requests = 0",
+		"No running children to measure; cannot recommend pm.max_children.",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s", expected)
+		}
+	}
+}
+
+func TestDisplayInstanceHeader(t *testing.T) {
+	instance := process.Instance{MasterPID: 2000, ConfigPath: "/etc/httpd/conf/site2.conf"}
+	config := &config.ApacheConfig{
+		ConfigPath:  "/etc/httpd/conf/site2.conf",
+		ListenPorts: []string{"8080"},
+	}
+	statusInfo := &status.ApacheStatus{URL: "http://localhost:8080/server-status?auto"}
+
+	output := captureOutput(func() {
+		DisplayInstanceHeader(2, 3, instance, config, statusInfo, 400, 1200)
+	})
+
+	tests := []string{
+		"Apache instance 2 of 3 (master PID 2000)",
+		"Configuration file: /etc/httpd/conf/site2.conf",
+		"Listening on: 8080",
+		"Status endpoint: http://localhost:8080/server-status?auto",
+		"Memory share: 400 MB of 1200 MB available to Apache",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s", expected)
+		}
+	}
+}
+
+func TestDisplayInstanceHeader_Container(t *testing.T) {
+	instance := process.Instance{MasterPID: 3100, RootPath: "/proc/3100/root", ContainerID: "4f1c2a8e9b7d", Container: true}
+	config := &config.ApacheConfig{
+		ConfigPath: "/usr/local/apache2/conf/httpd.conf",
+		RootPath:   "/proc/3100/root",
+		Container:  true,
+	}
+
+	output := captureOutput(func() {
+		DisplayInstanceHeader(1, 1, instance, config, nil, 900, 900)
+	})
+
+	tests := []string{
+		"Apache instance 1 of 1 (master PID 3100)",
+		"Container: 4f1c2a8e9b7d (filesystem /proc/3100/root)",
+		"Configuration file: /usr/local/apache2/conf/httpd.conf",
+		"Status endpoint: not queried (container network)",
+	}
+	for _, expected := range tests {
+		if !strings.Contains(output, expected) {
+			t.Errorf("Output should contain: %s", expected)
+		}
+	}
+}
+
 // Benchmark test for performance validation
 func BenchmarkDisplayEnhancedResults(b *testing.B) {
 	sysInfo := &system.SystemInfo{
@@ -440,8 +1361,8 @@ func BenchmarkDisplayEnhancedResults(b *testing.B) {
 	recommendations := &analysis.Recommendations{
 		CurrentMaxClients:     150,
 		RecommendedMaxClients: 120,
-		Status:                "WARNING",
-		Message:               "Consider reducing MaxRequestWorkers",
+		MinRecommended:        120,
+		MaxRecommended:        150,
 	}
 
 	statusInfo := &status.ApacheStatus{
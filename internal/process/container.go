@@ -0,0 +1,80 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// containerIDPattern matches the 64 hex digit IDs used by Docker, containerd,
+// CRI-O and Podman in cgroup paths, e.g. /docker/<id>,
+// /system.slice/docker-<id>.scope or /kubepods/.../cri-containerd-<id>.scope
+var containerIDPattern = regexp.MustCompile(`[0-9a-f]{64}`)
+
+// detectContainer fills in RootPath when the instance runs in a different
+// mount namespace than apache2buddy, and marks it a container when its PID
+// namespace differs too or its cgroup names a container. A private mount
+// namespace alone is what systemd's PrivateTmp gives a plain service.
+func detectContainer(procRoot string, inst *Instance) {
+	pid := inst.MasterPID
+	if pid == 0 && len(inst.Workers) > 0 {
+		pid = inst.Workers[0].PID
+	}
+	if pid == 0 {
+		return
+	}
+
+	pidDir := filepath.Join(procRoot, strconv.Itoa(pid))
+	selfDir := filepath.Join(procRoot, "self")
+	mountNS := otherNamespace(pidDir, selfDir, "mnt")
+	pidNS := otherNamespace(pidDir, selfDir, "pid")
+	if !mountNS && !pidNS {
+		return
+	}
+
+	// Only a private mount namespace hides the config from the host's /etc
+	if mountNS {
+		inst.RootPath = filepath.Join(pidDir, "root")
+	}
+	if data, err := os.ReadFile(filepath.Join(pidDir, "cgroup")); err == nil {
+		inst.ContainerID = parseContainerID(string(data))
+	}
+	inst.Container = pidNS || inst.ContainerID != ""
+	debug.Printf("PID %d runs in another namespace (mnt=%t, pid=%t): root=%q, container=%t %q",
+		pid, mountNS, pidNS, inst.RootPath, inst.Container, inst.ContainerID)
+}
+
+// otherNamespace reports whether pidDir's namespace of the given type differs
+// from selfDir's. Unreadable namespaces are treated as shared.
+func otherNamespace(pidDir, selfDir, nsType string) bool {
+	theirs, err := os.Readlink(filepath.Join(pidDir, "ns", nsType))
+	if err != nil {
+		return false
+	}
+	ours, err := os.Readlink(filepath.Join(selfDir, "ns", nsType))
+	if err != nil {
+		return false
+	}
+	return theirs != ours
+}
+
+// parseContainerID extracts the short (12 digit) container ID from
+// /proc/PID/cgroup content, or "" if no container ID is present
+func parseContainerID(cgroup string) string {
+	for _, line := range strings.Split(cgroup, "\n") {
+		// Format: hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		ids := containerIDPattern.FindAllString(parts[2], -1)
+		if len(ids) > 0 {
+			return ids[len(ids)-1][:12]
+		}
+	}
+	return ""
+}
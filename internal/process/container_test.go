@@ -0,0 +1,132 @@
+package process
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+func TestParseContainerID(t *testing.T) {
+	const id = "4f1c2a8e9b7d6c5a4f1c2a8e9b7d6c5a4f1c2a8e9b7d6c5a4f1c2a8e9b7d6c5a"
+	tests := []struct {
+		name   string
+		cgroup string
+		want   string
+	}{
+		{
+			name:   "docker cgroup v1",
+			cgroup: "12:memory:/docker/" + id + "\n11:cpu,cpuacct:/docker/" + id + "\n",
+			want:   "4f1c2a8e9b7d",
+		},
+		{
+			name:   "docker systemd driver cgroup v2",
+			cgroup: "0::/system.slice/docker-" + id + ".scope\n",
+			want:   "4f1c2a8e9b7d",
+		},
+		{
+			name:   "kubernetes containerd",
+			cgroup: "0::/kubepods.slice/kubepods-burstable.slice/kubepods-burstable-pod1234.slice/cri-containerd-" + id + ".scope\n",
+			want:   "4f1c2a8e9b7d",
+		},
+		{
+			name:   "podman",
+			cgroup: "0::/machine.slice/libpod-" + id + ".scope/container\n",
+			want:   "4f1c2a8e9b7d",
+		},
+		{
+			name:   "systemd service",
+			cgroup: "0::/system.slice/apache2.service\n",
+			want:   "",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := parseContainerID(tt.cgroup); got != tt.want {
+				t.Errorf("parseContainerID() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestDetectContainer(t *testing.T) {
+	// procTree builds a fake /proc with namespace links for self and PID 500
+	procTree := func(t *testing.T, mnt, pid, cgroup string) string {
+		t.Helper()
+		procRoot := t.TempDir()
+		links := map[string]string{
+			"self/ns/mnt": "mnt:[4026531840]",
+			"self/ns/pid": "pid:[4026531836]",
+			"500/ns/mnt":  mnt,
+			"500/ns/pid":  pid,
+		}
+		for name, target := range links {
+			path := filepath.Join(procRoot, name)
+			if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+				t.Fatal(err)
+			}
+			if err := os.Symlink(target, path); err != nil {
+				t.Fatal(err)
+			}
+		}
+		if err := os.WriteFile(filepath.Join(procRoot, "500", "cgroup"), []byte(cgroup), 0644); err != nil {
+			t.Fatal(err)
+		}
+		return procRoot
+	}
+
+	t.Run("host process", func(t *testing.T) {
+		procRoot := procTree(t, "mnt:[4026531840]", "pid:[4026531836]", "0::/system.slice/httpd.service\n")
+		inst := Instance{MasterPID: 500}
+		detectContainer(procRoot, &inst)
+		if inst.RootPath != "" || inst.ContainerID != "" || inst.Container {
+			t.Errorf("host instance detected as container: root=%q id=%q", inst.RootPath, inst.ContainerID)
+		}
+		if inst.ID() != "500" {
+			t.Errorf("ID() = %s, want 500", inst.ID())
+		}
+	})
+
+	t.Run("container", func(t *testing.T) {
+		cgroup := "0::/system.slice/docker-0123456789abcdef0123456789abcdef0123456789abcdef0123456789abcdef.scope\n"
+		procRoot := procTree(t, "mnt:[4026532301]", "pid:[4026532304]", cgroup)
+		inst := Instance{MasterPID: 500}
+		detectContainer(procRoot, &inst)
+		if want := filepath.Join(procRoot, "500", "root"); inst.RootPath != want {
+			t.Errorf("RootPath = %q, want %q", inst.RootPath, want)
+		}
+		if !inst.Container {
+			t.Error("Container = false, want true")
+		}
+		if inst.ID() != "0123456789ab" {
+			t.Errorf("ID() = %s, want container ID 0123456789ab", inst.ID())
+		}
+	})
+
+	t.Run("systemd PrivateTmp, private mount namespace only", func(t *testing.T) {
+		procRoot := procTree(t, "mnt:[4026532301]", "pid:[4026531836]", "0::/system.slice/apache2.service\n")
+		inst := Instance{MasterPID: 500}
+		detectContainer(procRoot, &inst)
+		if want := filepath.Join(procRoot, "500", "root"); inst.RootPath != want {
+			t.Errorf("RootPath = %q, want %q", inst.RootPath, want)
+		}
+		if inst.Container {
+			t.Error("Container = true, want false for a service with only a private mount namespace")
+		}
+		if inst.ID() != "500" {
+			t.Errorf("ID() = %s, want 500", inst.ID())
+		}
+	})
+
+	t.Run("shared filesystem, private PID namespace", func(t *testing.T) {
+		procRoot := procTree(t, "mnt:[4026531840]", "pid:[4026532304]", "0::/lxc/web\n")
+		inst := Instance{Workers: []ProcessInfo{{PID: 500}}}
+		detectContainer(procRoot, &inst)
+		if inst.RootPath != "" {
+			t.Errorf("RootPath = %q, want empty when the mount namespace is shared", inst.RootPath)
+		}
+		if !inst.Container {
+			t.Error("Container = false, want true for a private PID namespace")
+		}
+	})
+}
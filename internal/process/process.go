@@ -4,14 +4,120 @@ import (
 	"fmt"
 	"os"
 	"os/exec"
+	"sort"
 	"strconv"
 	"strings"
+	"time"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// Memory measurement methods recorded on ProcessInfo.Method, in order of preference
+const (
+	MethodSmaps       = "smaps"       // /proc/PID/smaps_rollup or /proc/PID/smaps
+	MethodStatus      = "status"      // VmRSS from /proc/PID/status
+	MethodPS          = "ps"          // RSS column reported by ps
+	MethodUnavailable = "unavailable" // process could not be measured
 )
 
 type ProcessInfo struct {
 	PID      int
+	PPID     int // Parent PID, i.e. the Apache master for worker processes (0 if unknown)
 	User     string
 	MemoryMB float64
+	PSSMB    float64 // Proportional set size, only available via smaps
+	SwapMB   float64 // VmSwap from /proc/PID/status: memory swapped out, not in MemoryMB
+	Method   string  // How MemoryMB was measured (see Method* constants)
+
+	// Lifetime data from /proc/PID/stat (zero when unavailable)
+	StartTime  time.Time
+	CPUSeconds float64 // User + system CPU time consumed so far
+	Threads    int
+
+	Samples []MemorySample // Readings taken by SampleMemory, oldest first
+}
+
+// clockTicks is USER_HZ, the unit of the time fields in /proc/PID/stat. It is
+// 100 on every architecture Linux supports for userspace.
+const clockTicks = 100
+
+// procStat holds the fields of /proc/PID/stat used by apache2buddy
+type procStat struct {
+	PPID       int
+	CPUSeconds float64
+	Threads    int
+	StartTicks uint64 // Start time in clock ticks since boot
+}
+
+// Instance is one running Apache server: a master process and its workers
+type Instance struct {
+	MasterPID  int    // 0 when the master could not be determined
+	ConfigPath string // Value of -f on the master command line, if any
+	ServerRoot string // Value of -d on the master command line, if any
+	Workers    []ProcessInfo
+
+	// RootPath is set when the instance runs in another mount namespace, which
+	// includes systemd services with PrivateTmp; it only locates files
+	RootPath    string // Filesystem root of the instance, e.g. /proc/PID/root
+	ContainerID string // Short container ID taken from the cgroup path
+	Container   bool   // Own PID namespace or a container ID: its network and binaries are not the host's
+}
+
+// ID returns a short identifier for the instance used in reports: the
+// container ID for containerized instances, else the master PID. The PID
+// changes when Apache restarts; see Key for an identifier that does not.
+func (i Instance) ID() string {
+	if i.ContainerID != "" {
+		return i.ContainerID
+	}
+	if i.MasterPID == 0 {
+		return "default"
+	}
+	return strconv.Itoa(i.MasterPID)
+}
+
+// Key identifies the instance across restarts, for the history log: its
+// ServerRoot and configuration file, prefixed by the container ID for
+// containerized instances. It is "default" when none of them is known.
+func (i Instance) Key(configPath, serverRoot string) string {
+	var parts []string
+	for _, part := range []string{i.ContainerID, serverRoot, configPath} {
+		if part != "" {
+			parts = append(parts, part)
+		}
+	}
+	if len(parts) == 0 {
+		return "default"
+	}
+	return strings.Join(parts, ":")
+}
+
+// PIDs returns the master and worker PIDs of all instances, the processes
+// that are Apache whatever their command is called
+func PIDs(instances []Instance) map[int]bool {
+	pids := make(map[int]bool)
+	for _, inst := range instances {
+		if inst.MasterPID > 0 {
+			pids[inst.MasterPID] = true
+		}
+		for _, proc := range inst.Workers {
+			pids[proc.PID] = true
+		}
+	}
+	return pids
+}
+
+// Age returns how long the process has been running, or 0 if unknown
+func (p ProcessInfo) Age(now time.Time) time.Duration {
+	if p.StartTime.IsZero() {
+		return 0
+	}
+	return now.Sub(p.StartTime)
+}
+
+// Measured reports whether the process memory could be read by any method
+func (p ProcessInfo) Measured() bool {
+	return p.Method != MethodUnavailable
 }
 
 func FindApacheProcesses() ([]ProcessInfo, error) {
@@ -27,6 +133,7 @@ func FindApacheProcesses() ([]ProcessInfo, error) {
 
 func parseAuxFormat(output string) ([]ProcessInfo, error) {
 	var processes []ProcessInfo
+	bootTime := readBootTime()
 	lines := strings.Split(string(output), "\n")
 
 	for _, line := range lines {
@@ -82,22 +189,156 @@ func parseAuxFormat(output string) ([]ProcessInfo, error) {
 			continue
 		}
 
-		// Get memory for this process
-		memory, err := getProcessMemory(pid)
-		if err != nil {
-			continue
+		// Get memory for this process. Unmeasurable processes are kept so
+		// callers can report them, but are flagged as unavailable.
+		proc := ProcessInfo{
+			PID:  pid,
+			User: user,
+		}
+		if stat, err := readProcStat(pid); err == nil {
+			proc.PPID = stat.PPID
+			proc.CPUSeconds = stat.CPUSeconds
+			proc.Threads = stat.Threads
+			if !bootTime.IsZero() {
+				proc.StartTime = bootTime.Add(time.Duration(stat.StartTicks) * time.Second / clockTicks)
+			}
+		}
+		if err := measureProcessMemory(&proc); err != nil {
+			debug.Warn("Could not measure memory for PID %d: %v", pid, err)
 		}
 
-		processes = append(processes, ProcessInfo{
-			PID:      pid,
-			User:     user,
-			MemoryMB: memory,
-		})
+		processes = append(processes, proc)
 	}
 
 	return processes, nil
 }
 
+// GroupByMaster splits worker processes into Apache instances keyed by their
+// master (parent) PID, and reads each master's command line for -f and -d.
+// A master not running as root (rootless containers, httpd started by its
+// own user) is listed among the processes too and is dropped here.
+// Instances in containers get their root path and container ID filled in.
+// Instances are returned ordered by master PID.
+func GroupByMaster(processes []ProcessInfo) []Instance {
+	defer debug.Trace("process.GroupByMaster")()
+
+	parents := make(map[int]bool)
+	for _, proc := range processes {
+		if proc.PPID > 0 {
+			parents[proc.PPID] = true
+		}
+	}
+
+	byMaster := make(map[int]*Instance)
+	var masters []int
+	for _, proc := range processes {
+		if parents[proc.PID] {
+			debug.Printf("PID %d (user %s) is the master of other Apache processes, not a worker", proc.PID, proc.User)
+			continue
+		}
+		inst, exists := byMaster[proc.PPID]
+		if !exists {
+			inst = &Instance{MasterPID: proc.PPID}
+			byMaster[proc.PPID] = inst
+			masters = append(masters, proc.PPID)
+		}
+		inst.Workers = append(inst.Workers, proc)
+	}
+
+	sort.Ints(masters)
+	instances := make([]Instance, 0, len(masters))
+	for _, masterPID := range masters {
+		inst := byMaster[masterPID]
+		if masterPID > 0 {
+			if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/cmdline", masterPID)); err == nil {
+				inst.ConfigPath, inst.ServerRoot = parseMasterCmdline(string(data))
+			}
+		}
+		detectContainer("/proc", inst)
+		debug.Printf("Apache instance %s: %d workers, config=%q, serverroot=%q, root=%q",
+			inst.ID(), len(inst.Workers), inst.ConfigPath, inst.ServerRoot, inst.RootPath)
+		instances = append(instances, *inst)
+	}
+	return instances
+}
+
+// parseMasterCmdline extracts the -f (config file) and -d (ServerRoot) options
+// from NUL-separated /proc/PID/cmdline content. Both "-f path" and "-fpath" are accepted.
+func parseMasterCmdline(cmdline string) (configPath, serverRoot string) {
+	args := strings.Split(strings.TrimRight(cmdline, "\x00"), "\x00")
+	for i := 0; i < len(args); i++ {
+		var target *string
+		switch {
+		case strings.HasPrefix(args[i], "-f"):
+			target = &configPath
+		case strings.HasPrefix(args[i], "-d"):
+			target = &serverRoot
+		default:
+			continue
+		}
+		if value := args[i][2:]; value != "" {
+			*target = value
+		} else if i+1 < len(args) {
+			*target = args[i+1]
+			i++
+		}
+	}
+	return configPath, serverRoot
+}
+
+// readProcStat reads /proc/PID/stat
+func readProcStat(pid int) (procStat, error) {
+	data, err := os.ReadFile(fmt.Sprintf("/proc/%d/stat", pid))
+	if err != nil {
+		return procStat{}, err
+	}
+	return parseProcStat(string(data))
+}
+
+// parseProcStat extracts the parent PID, CPU time, thread count and start
+// time from /proc/PID/stat content (see proc(5) for field numbers)
+func parseProcStat(content string) (procStat, error) {
+	// The command name is wrapped in parentheses and may contain spaces
+	end := strings.LastIndex(content, ")")
+	if end < 0 {
+		return procStat{}, fmt.Errorf("malformed stat content")
+	}
+	// fields[0] is field 3 (state) in proc(5) numbering
+	fields := strings.Fields(content[end+1:])
+	if len(fields) < 20 {
+		return procStat{}, fmt.Errorf("stat content has %d fields, want at least 22", len(fields)+2)
+	}
+
+	var stat procStat
+	var err error
+	if stat.PPID, err = strconv.Atoi(fields[1]); err != nil {
+		return procStat{}, fmt.Errorf("invalid ppid: %v", err)
+	}
+	utime, _ := strconv.ParseUint(fields[11], 10, 64)
+	stime, _ := strconv.ParseUint(fields[12], 10, 64)
+	stat.CPUSeconds = float64(utime+stime) / clockTicks
+	stat.Threads, _ = strconv.Atoi(fields[17])
+	stat.StartTicks, _ = strconv.ParseUint(fields[19], 10, 64)
+	return stat, nil
+}
+
+// readBootTime returns the system boot time from the btime line of /proc/stat
+func readBootTime() time.Time {
+	data, err := os.ReadFile("/proc/stat")
+	if err != nil {
+		return time.Time{}
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		fields := strings.Fields(line)
+		if len(fields) == 2 && fields[0] == "btime" {
+			if seconds, err := strconv.ParseInt(fields[1], 10, 64); err == nil {
+				return time.Unix(seconds, 0)
+			}
+		}
+	}
+	return time.Time{}
+}
+
 func isApacheProcess(comm string) bool {
 	apacheNames := []string{"httpd", "apache2", "httpd.worker", "httpd-prefork"}
 	for _, name := range apacheNames {
@@ -130,26 +371,39 @@ func isApacheCommand(command string) bool {
 	return false
 }
 
-func getProcessMemory(pid int) (float64, error) {
-	// Alpine/BusyBox doesn't have pmap, so let's try different approaches
+// measureProcessMemory fills in MemoryMB, PSSMB and Method for proc, trying
+// the most accurate source first. When no source works the process is marked
+// MethodUnavailable and an error is returned.
+func measureProcessMemory(proc *ProcessInfo) error {
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", proc.PID)); err == nil {
+		proc.SwapMB, _ = parseStatusKB(string(data), "VmSwap:")
+		proc.SwapMB /= 1024
+	}
 
-	// Method 1: Try reading /proc/PID/status directly
-	statusFile := fmt.Sprintf("/proc/%d/status", pid)
-	if data, err := os.ReadFile(statusFile); err == nil {
-		lines := strings.Split(string(data), "\n")
-		for _, line := range lines {
-			if strings.HasPrefix(line, "VmRSS:") {
-				fields := strings.Fields(line)
-				if len(fields) >= 2 {
-					if memKB, err := strconv.ParseFloat(fields[1], 64); err == nil {
-						return memKB / 1024, nil // Convert to MB
-					}
-				}
-			}
+	// Method 1: smaps gives both RSS and PSS (smaps_rollup is cheaper on kernels >= 4.14)
+	for _, name := range []string{"smaps_rollup", "smaps"} {
+		data, err := os.ReadFile(fmt.Sprintf("/proc/%d/%s", proc.PID, name))
+		if err != nil {
+			continue
+		}
+		if rssKB, pssKB, ok := parseSmaps(string(data)); ok {
+			proc.MemoryMB = rssKB / 1024
+			proc.PSSMB = pssKB / 1024
+			proc.Method = MethodSmaps
+			return nil
+		}
+	}
+
+	// Method 2: VmRSS from /proc/PID/status
+	if data, err := os.ReadFile(fmt.Sprintf("/proc/%d/status", proc.PID)); err == nil {
+		if rssKB, ok := parseStatusVmRSS(string(data)); ok {
+			proc.MemoryMB = rssKB / 1024
+			proc.Method = MethodStatus
+			return nil
 		}
 	}
 
-	// Method 2: Try ps with simpler format for BusyBox
+	// Method 3: Try ps with simpler format for BusyBox
 	cmd := exec.Command("ps", "-o", "pid,rss")
 	output, err := cmd.Output()
 	if err == nil {
@@ -157,17 +411,73 @@ func getProcessMemory(pid int) (float64, error) {
 		for _, line := range lines {
 			fields := strings.Fields(line)
 			if len(fields) >= 2 {
-				if linePid, err := strconv.Atoi(fields[0]); err == nil && linePid == pid {
+				if linePid, err := strconv.Atoi(fields[0]); err == nil && linePid == proc.PID {
 					if rssKB, err := strconv.ParseFloat(fields[1], 64); err == nil {
-						return rssKB / 1024, nil
+						proc.MemoryMB = rssKB / 1024
+						proc.Method = MethodPS
+						return nil
 					}
 				}
 			}
 		}
 	}
 
-	// Method 3: Default fallback - assume 10MB per process (conservative)
-	return 10.0, nil
+	proc.MemoryMB = 0
+	proc.PSSMB = 0
+	proc.Method = MethodUnavailable
+	return fmt.Errorf("no memory source available for PID %d", proc.PID)
+}
+
+// MeasurePID measures the memory of any process using the same sources, in
+// the same order of preference, as for Apache workers
+func MeasurePID(pid int) ProcessInfo {
+	proc := ProcessInfo{PID: pid}
+	if err := measureProcessMemory(&proc); err != nil {
+		debug.Printf("Could not measure memory for PID %d: %v", pid, err)
+	}
+	return proc
+}
+
+// parseSmaps sums the Rss and Pss lines of smaps or smaps_rollup content (in kB)
+func parseSmaps(content string) (rssKB, pssKB float64, ok bool) {
+	for _, line := range strings.Split(content, "\n") {
+		fields := strings.Fields(line)
+		if len(fields) < 2 {
+			continue
+		}
+		switch fields[0] {
+		case "Rss:":
+			if kb, err := strconv.ParseFloat(fields[1], 64); err == nil {
+				rssKB += kb
+				ok = true
+			}
+		case "Pss:":
+			if kb, err := strconv.ParseFloat(fields[1], 64); err == nil {
+				pssKB += kb
+			}
+		}
+	}
+	return rssKB, pssKB, ok
+}
+
+// parseStatusVmRSS extracts VmRSS (in kB) from /proc/PID/status content
+func parseStatusVmRSS(content string) (float64, bool) {
+	return parseStatusKB(content, "VmRSS:")
+}
+
+// parseStatusKB extracts a kB field such as "VmSwap:" from /proc/PID/status content
+func parseStatusKB(content, field string) (float64, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if strings.HasPrefix(line, field) {
+			fields := strings.Fields(line)
+			if len(fields) >= 2 {
+				if memKB, err := strconv.ParseFloat(fields[1], 64); err == nil {
+					return memKB, true
+				}
+			}
+		}
+	}
+	return 0, false
 }
 
 func parsePmapOutput(output string) float64 {
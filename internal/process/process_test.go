@@ -2,6 +2,7 @@ package process
 
 import (
 	"testing"
+	"time"
 )
 
 func TestProcessInfo_Validation(t *testing.T) {
@@ -292,6 +293,215 @@ mapped: 123456K    shared: 12345K`,
 	}
 }
 
+func TestParseSmaps(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		wantRSS float64
+		wantPSS float64
+		wantOK  bool
+	}{
+		{
+			name: "smaps_rollup",
+			content: `55d0c0a00000-7ffd5a7fe000 ---p 00000000 00:00 0                          [rollup]
+Rss:               20480 kB
+Pss:                8192 kB
+Shared_Clean:      12288 kB
+Private_Dirty:      8192 kB`,
+			wantRSS: 20480,
+			wantPSS: 8192,
+			wantOK:  true,
+		},
+		{
+			name: "full smaps sums mappings",
+			content: `55d0c0a00000-55d0c0a21000 r--p 00000000 08:01 1234 /usr/sbin/httpd
+Rss:                 100 kB
+Pss:                  50 kB
+55d0c0a21000-55d0c0a80000 r-xp 00021000 08:01 1234 /usr/sbin/httpd
+Rss:                 300 kB
+Pss:                 150 kB`,
+			wantRSS: 400,
+			wantPSS: 200,
+			wantOK:  true,
+		},
+		{
+			name:    "empty content",
+			content: "",
+			wantOK:  false,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			rss, pss, ok := parseSmaps(tt.content)
+			if ok != tt.wantOK {
+				t.Fatalf("parseSmaps() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if rss != tt.wantRSS || pss != tt.wantPSS {
+				t.Errorf("parseSmaps() = (%f, %f), want (%f, %f)", rss, pss, tt.wantRSS, tt.wantPSS)
+			}
+		})
+	}
+}
+
+func TestParseStatusVmRSS(t *testing.T) {
+	content := "Name:\thttpd\nVmPeak:\t  300000 kB\nVmRSS:\t   12345 kB\nThreads:\t1\n"
+	got, ok := parseStatusVmRSS(content)
+	if !ok || got != 12345 {
+		t.Errorf("parseStatusVmRSS() = (%f, %v), want (12345, true)", got, ok)
+	}
+
+	if _, ok := parseStatusVmRSS("Name:\thttpd\n"); ok {
+		t.Error("parseStatusVmRSS() should fail when VmRSS is missing (kernel threads)")
+	}
+
+	swapped := "Name:\thttpd\nVmRSS:\t   12345 kB\nVmSwap:\t    2048 kB\n"
+	if got, ok := parseStatusKB(swapped, "VmSwap:"); !ok || got != 2048 {
+		t.Errorf("parseStatusKB(VmSwap) = (%f, %v), want (2048, true)", got, ok)
+	}
+}
+
+func TestMeasureProcessMemory_Unavailable(t *testing.T) {
+	// PID 0 never has a /proc entry and is never listed by ps
+	proc := ProcessInfo{PID: 0, User: "www-data"}
+	if err := measureProcessMemory(&proc); err == nil {
+		t.Fatal("measureProcessMemory() should fail for a missing process")
+	}
+	if proc.Method != MethodUnavailable || proc.Measured() {
+		t.Errorf("Method = %s, want %s", proc.Method, MethodUnavailable)
+	}
+	if proc.MemoryMB != 0 {
+		t.Errorf("MemoryMB = %f, want 0 (no silent fallback)", proc.MemoryMB)
+	}
+}
+
+func TestParseMasterCmdline(t *testing.T) {
+	tests := []struct {
+		name       string
+		cmdline    string
+		wantConfig string
+		wantRoot   string
+	}{
+		{
+			name:    "no options",
+			cmdline: "/usr/sbin/httpd\x00-DFOREGROUND\x00",
+		},
+		{
+			name:       "separate arguments",
+			cmdline:    "/usr/sbin/httpd\x00-f\x00/etc/httpd/conf/site2.conf\x00-d\x00/etc/httpd\x00-k\x00start\x00",
+			wantConfig: "/etc/httpd/conf/site2.conf",
+			wantRoot:   "/etc/httpd",
+		},
+		{
+			name:       "joined arguments",
+			cmdline:    "/usr/sbin/apache2\x00-fconf/b.conf\x00-d/srv/apache\x00",
+			wantConfig: "conf/b.conf",
+			wantRoot:   "/srv/apache",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			gotConfig, gotRoot := parseMasterCmdline(tt.cmdline)
+			if gotConfig != tt.wantConfig || gotRoot != tt.wantRoot {
+				t.Errorf("parseMasterCmdline() = (%q, %q), want (%q, %q)", gotConfig, gotRoot, tt.wantConfig, tt.wantRoot)
+			}
+		})
+	}
+}
+
+func TestParseProcStat(t *testing.T) {
+	// Command names may contain spaces and parentheses
+	content := "4242 (httpd (worker)) S 4000 4000 4000 0 -1 4194624 1500 0 0 0 250 50 0 0 20 0 27 0 123456 300000000 5000 18446744073709551615 1 1 0 0 0 0 0 0 0 0 0 0 17 1 0 0 0 0 0"
+
+	stat, err := parseProcStat(content)
+	if err != nil {
+		t.Fatalf("parseProcStat() error = %v", err)
+	}
+	if stat.PPID != 4000 {
+		t.Errorf("PPID = %d, want 4000", stat.PPID)
+	}
+	if stat.CPUSeconds != 3.0 {
+		t.Errorf("CPUSeconds = %f, want 3.0", stat.CPUSeconds)
+	}
+	if stat.Threads != 27 {
+		t.Errorf("Threads = %d, want 27", stat.Threads)
+	}
+	if stat.StartTicks != 123456 {
+		t.Errorf("StartTicks = %d, want 123456", stat.StartTicks)
+	}
+
+	if _, err := parseProcStat("4242 (httpd) S 1"); err == nil {
+		t.Error("parseProcStat() should fail on truncated content")
+	}
+}
+
+func TestProcessInfo_Age(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	proc := ProcessInfo{StartTime: now.Add(-90 * time.Minute)}
+	if got := proc.Age(now); got != 90*time.Minute {
+		t.Errorf("Age() = %v, want 1h30m", got)
+	}
+	if got := (ProcessInfo{}).Age(now); got != 0 {
+		t.Errorf("Age() without start time = %v, want 0", got)
+	}
+}
+
+func TestGroupByMaster(t *testing.T) {
+	processes := []ProcessInfo{
+		{PID: 2001, PPID: 2000, User: "apache", MemoryMB: 20},
+		{PID: 1001, PPID: 1000, User: "www-data", MemoryMB: 30},
+		{PID: 2002, PPID: 2000, User: "apache", MemoryMB: 25},
+	}
+
+	instances := GroupByMaster(processes)
+	if len(instances) != 2 {
+		t.Fatalf("GroupByMaster() returned %d instances, want 2", len(instances))
+	}
+	if instances[0].MasterPID != 1000 || len(instances[0].Workers) != 1 {
+		t.Errorf("instance 0 = master %d with %d workers, want master 1000 with 1", instances[0].MasterPID, len(instances[0].Workers))
+	}
+	if instances[1].MasterPID != 2000 || len(instances[1].Workers) != 2 {
+		t.Errorf("instance 1 = master %d with %d workers, want master 2000 with 2", instances[1].MasterPID, len(instances[1].Workers))
+	}
+	if instances[1].ID() != "2000" {
+		t.Errorf("ID() = %s, want 2000", instances[1].ID())
+	}
+
+	// A rootless master is listed among the workers, under its own parent
+	rootless := GroupByMaster([]ProcessInfo{
+		{PID: 3000, PPID: 1, User: "apache", MemoryMB: 12},
+		{PID: 3001, PPID: 3000, User: "apache", MemoryMB: 20},
+		{PID: 3002, PPID: 3000, User: "apache", MemoryMB: 22},
+	})
+	if len(rootless) != 1 || rootless[0].MasterPID != 3000 || len(rootless[0].Workers) != 2 {
+		t.Errorf("non-root master: got %+v, want master 3000 with 2 workers", rootless)
+	}
+
+	unknown := GroupByMaster([]ProcessInfo{{PID: 1, User: "apache"}})
+	if len(unknown) != 1 || unknown[0].ID() != "default" {
+		t.Errorf("processes without a known master should form the default instance, got %+v", unknown)
+	}
+}
+
+func TestInstanceKey(t *testing.T) {
+	before := Instance{MasterPID: 1000}
+	after := Instance{MasterPID: 2345}
+	if before.Key("/etc/httpd/conf/httpd.conf", "/etc/httpd") != after.Key("/etc/httpd/conf/httpd.conf", "/etc/httpd") {
+		t.Error("Key() should not change when Apache restarts with a new master PID")
+	}
+	if got := before.Key("/etc/httpd/conf/httpd.conf", "/etc/httpd"); got != "/etc/httpd:/etc/httpd/conf/httpd.conf" {
+		t.Errorf("Key() = %q", got)
+	}
+	container := Instance{MasterPID: 500, ContainerID: "0123456789ab"}
+	if got := container.Key("/etc/apache2/apache2.conf", ""); got != "0123456789ab:/etc/apache2/apache2.conf" {
+		t.Errorf("Key() in a container = %q", got)
+	}
+	if got := before.Key("", ""); got != "default" {
+		t.Errorf("Key() without a configuration = %q, want default", got)
+	}
+}
+
 // Benchmark tests
 func BenchmarkIsApacheProcess(b *testing.B) {
 	commands := []string{"httpd", "apache2", "nginx", "mysqld", "httpd.worker"}
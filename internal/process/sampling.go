@@ -0,0 +1,65 @@
+package process
+
+import (
+	"time"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// MemorySample is one memory reading of a process
+type MemorySample struct {
+	Time     time.Time
+	MemoryMB float64 // RSS
+	PSSMB    float64 // 0 unless measured via smaps
+}
+
+// SampleMemory measures every process count times, interval apart, and
+// records the readings in ProcessInfo.Samples. The current reading counts as
+// the first sample. MemoryMB and PSSMB are updated to the latest reading, so
+// the usual statistics reflect the end of the window. A process that exits
+// during the window keeps the samples taken so far.
+func SampleMemory(processes []ProcessInfo, count int, interval time.Duration) {
+	sampleMemory(processes, count, interval, measureProcessMemory, time.Now, time.Sleep)
+}
+
+func sampleMemory(processes []ProcessInfo, count int, interval time.Duration,
+	measure func(*ProcessInfo) error, now func() time.Time, sleep func(time.Duration)) {
+	defer debug.Trace("process.SampleMemory")()
+
+	if count < 2 {
+		return
+	}
+
+	start := now()
+	active := make([]bool, len(processes))
+	for i := range processes {
+		proc := &processes[i]
+		if !proc.Measured() {
+			continue
+		}
+		active[i] = true
+		proc.Samples = append(proc.Samples[:0], MemorySample{Time: start, MemoryMB: proc.MemoryMB, PSSMB: proc.PSSMB})
+	}
+
+	for n := 1; n < count; n++ {
+		sleep(interval)
+		taken := now()
+		for i := range processes {
+			if !active[i] {
+				continue
+			}
+			proc := &processes[i]
+			reading := *proc
+			if err := measure(&reading); err != nil {
+				debug.Info("PID %d stopped responding after %d samples: %v", proc.PID, len(proc.Samples), err)
+				active[i] = false
+				continue
+			}
+			proc.MemoryMB = reading.MemoryMB
+			proc.PSSMB = reading.PSSMB
+			proc.Method = reading.Method
+			proc.Samples = append(proc.Samples, MemorySample{Time: taken, MemoryMB: reading.MemoryMB, PSSMB: reading.PSSMB})
+		}
+		debug.Printf("Memory sample %d/%d taken", n+1, count)
+	}
+}
@@ -0,0 +1,83 @@
+package process
+
+import (
+	"fmt"
+	"testing"
+	"time"
+)
+
+func TestSampleMemory(t *testing.T) {
+	start := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	clock := start
+	now := func() time.Time { return clock }
+	sleep := func(d time.Duration) { clock = clock.Add(d) }
+
+	processes := []ProcessInfo{
+		{PID: 100, MemoryMB: 20, Method: MethodSmaps},
+		{PID: 200, MemoryMB: 30, Method: MethodStatus},
+		{PID: 300, Method: MethodUnavailable},
+	}
+
+	// PID 100 grows 1 MB per reading, PID 200 exits after the second sample
+	readings := map[int]int{}
+	measure := func(proc *ProcessInfo) error {
+		readings[proc.PID]++
+		switch proc.PID {
+		case 100:
+			proc.MemoryMB = 20 + float64(readings[proc.PID])
+			proc.PSSMB = proc.MemoryMB - 5
+			return nil
+		case 200:
+			if readings[proc.PID] > 1 {
+				proc.Method = MethodUnavailable
+				proc.MemoryMB = 0
+				return fmt.Errorf("process exited")
+			}
+			proc.MemoryMB = 30
+			return nil
+		}
+		return fmt.Errorf("unexpected PID %d", proc.PID)
+	}
+
+	sampleMemory(processes, 4, 10*time.Second, measure, now, sleep)
+
+	grower := processes[0]
+	if len(grower.Samples) != 4 {
+		t.Fatalf("PID 100 samples = %d, want 4", len(grower.Samples))
+	}
+	if grower.MemoryMB != 23 || grower.PSSMB != 18 {
+		t.Errorf("PID 100 latest reading = %.1f/%.1f MB, want 23/18", grower.MemoryMB, grower.PSSMB)
+	}
+	if got := grower.Samples[3].Time.Sub(grower.Samples[0].Time); got != 30*time.Second {
+		t.Errorf("PID 100 window = %v, want 30s", got)
+	}
+
+	exited := processes[1]
+	if len(exited.Samples) != 2 {
+		t.Errorf("PID 200 samples = %d, want 2", len(exited.Samples))
+	}
+	if exited.MemoryMB != 30 || exited.Method != MethodStatus {
+		t.Errorf("PID 200 lost its last good reading: %.1f MB via %s", exited.MemoryMB, exited.Method)
+	}
+
+	if len(processes[2].Samples) != 0 {
+		t.Errorf("unmeasurable PID 300 should not be sampled")
+	}
+	if readings[300] != 0 {
+		t.Errorf("unmeasurable PID 300 was measured %d times", readings[300])
+	}
+}
+
+func TestSampleMemory_SingleSample(t *testing.T) {
+	processes := []ProcessInfo{{PID: 100, MemoryMB: 20, Method: MethodSmaps}}
+	measure := func(proc *ProcessInfo) error {
+		t.Fatal("measure should not be called for a single sample")
+		return nil
+	}
+	sleep := func(time.Duration) { t.Fatal("sleep should not be called for a single sample") }
+
+	sampleMemory(processes, 1, time.Second, measure, time.Now, sleep)
+	if len(processes[0].Samples) != 0 {
+		t.Errorf("Samples = %d, want 0", len(processes[0].Samples))
+	}
+}
@@ -57,6 +57,36 @@ type ApacheStatus struct {
 	OpenSlots         int          // Open/available worker slots
 	UniqueClients     int          // Number of unique client connections
 	TopClients        []ClientInfo // Top clients by activity
+
+	URL string // mod_status endpoint the data was read from
+
+	// Per-slot details from the ExtendedStatus worker table (HTML page only)
+	Workers []WorkerSlot
+}
+
+// WorkerSlot is one row of the ExtendedStatus worker table
+type WorkerSlot struct {
+	Slot          string // Server slot, e.g. "0-0"
+	PID           int
+	ConnAccesses  int // Accesses on the current connection
+	ChildAccesses int // Accesses served by this child process
+	SlotAccesses  int // Accesses served by this slot
+	Mode          string
+	VHost         string
+	Request       string
+}
+
+// ChildAccesses returns the number of requests each child process has served,
+// keyed by PID. Threaded MPMs list one row per thread, so the largest count
+// reported for a PID is used.
+func (s *ApacheStatus) ChildAccesses() map[int]int {
+	accesses := make(map[int]int)
+	for _, worker := range s.Workers {
+		if worker.PID > 0 && worker.ChildAccesses > accesses[worker.PID] {
+			accesses[worker.PID] = worker.ChildAccesses
+		}
+	}
+	return accesses
 }
 
 // ClientInfo represents information about a client connection
@@ -75,11 +105,38 @@ func GetApacheStatus() (*ApacheStatus, error) {
 		"http://localhost:80/server-status?auto",
 	}
 
+	return getApacheStatusFrom(urls, []string{
+		"http://localhost/server-status",
+		"http://127.0.0.1/server-status",
+	})
+}
+
+// GetApacheStatusForPorts queries mod_status on the given Listen ports, so that
+// each Apache instance on a host is read from its own endpoint. With no ports
+// it behaves like GetApacheStatus.
+func GetApacheStatusForPorts(ports []string) (*ApacheStatus, error) {
+	if len(ports) == 0 {
+		return GetApacheStatus()
+	}
+
+	var urls, htmlURLs []string
+	for _, port := range ports {
+		for _, host := range []string{"localhost", "127.0.0.1"} {
+			base := fmt.Sprintf("http://%s:%s/server-status", host, port)
+			urls = append(urls, base+"?auto")
+			htmlURLs = append(htmlURLs, base)
+		}
+	}
+	return getApacheStatusFrom(urls, htmlURLs)
+}
+
+func getApacheStatusFrom(urls, htmlURLs []string) (*ApacheStatus, error) {
 	var status *ApacheStatus
 	var err error
 
 	for _, url := range urls {
 		if status, err = fetchStatus(url); err == nil {
+			status.URL = url
 			break
 		}
 	}
@@ -89,7 +146,7 @@ func GetApacheStatus() (*ApacheStatus, error) {
 	}
 
 	// Try to get detailed worker status from HTML page
-	if htmlContent, err := GetDetailedStatus(); err == nil {
+	if htmlContent, err := getDetailedStatusFrom(htmlURLs); err == nil {
 		workerStats := ParseWorkerStatus(htmlContent)
 		status.WorkersWaiting = workerStats["waiting"]
 		status.WorkersReading = workerStats["reading"]
@@ -108,6 +165,7 @@ func GetApacheStatus() (*ApacheStatus, error) {
 
 		// Parse top clients from HTML content
 		status.TopClients = parseTopClients(htmlContent)
+		status.Workers = parseWorkerTable(htmlContent)
 	} else {
 		// Fallback: provide reasonable defaults based on active/idle workers
 		status.WorkersWaiting = status.IdleWorkers
@@ -304,11 +362,13 @@ func detectServerVersion() string {
 
 // GetDetailedStatus gets the full HTML status page for additional analysis
 func GetDetailedStatus() (string, error) {
-	urls := []string{
+	return getDetailedStatusFrom([]string{
 		"http://localhost/server-status",
 		"http://127.0.0.1/server-status",
-	}
+	})
+}
 
+func getDetailedStatusFrom(urls []string) (string, error) {
 	for _, url := range urls {
 		if content, err := fetchStatusHTML(url); err == nil {
 			return content, nil
@@ -429,6 +489,49 @@ func ParseWorkerStatus(htmlContent string) map[string]int {
 	return workerStats
 }
 
+var (
+	tableRowPattern  = regexp.MustCompile(`(?is)<tr>(.*?)</tr>`)
+	tableCellPattern = regexp.MustCompile(`(?is)<td[^>]*>(.*?)</td>`)
+	htmlTagPattern   = regexp.MustCompile(`<[^>]*>`)
+	slotPattern      = regexp.MustCompile(`^\d+-\d+$`)
+)
+
+// parseWorkerTable extracts the ExtendedStatus worker table. Rows start with
+// Srv, PID and Acc columns; the last two columns are always VHost and Request,
+// while the columns in between vary between Apache versions.
+func parseWorkerTable(htmlContent string) []WorkerSlot {
+	var workers []WorkerSlot
+
+	for _, row := range tableRowPattern.FindAllStringSubmatch(htmlContent, -1) {
+		var cells []string
+		for _, cell := range tableCellPattern.FindAllStringSubmatch(row[1], -1) {
+			cells = append(cells, strings.TrimSpace(htmlTagPattern.ReplaceAllString(cell[1], "")))
+		}
+		if len(cells) < 5 || !slotPattern.MatchString(cells[0]) {
+			continue
+		}
+
+		accesses := strings.Split(cells[2], "/")
+		if len(accesses) != 3 {
+			continue
+		}
+
+		worker := WorkerSlot{
+			Slot:    cells[0],
+			Mode:    cells[3],
+			VHost:   cells[len(cells)-2],
+			Request: cells[len(cells)-1],
+		}
+		worker.PID, _ = strconv.Atoi(cells[1]) // "-" for unused slots
+		worker.ConnAccesses, _ = strconv.Atoi(accesses[0])
+		worker.ChildAccesses, _ = strconv.Atoi(accesses[1])
+		worker.SlotAccesses, _ = strconv.Atoi(accesses[2])
+		workers = append(workers, worker)
+	}
+
+	return workers
+}
+
 // extractUniqueClients tries to extract unique client count from HTML content
 func extractUniqueClients(htmlContent string) int {
 	// Look for patterns like "X requests being processed, Y idle workers"
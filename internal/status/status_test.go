@@ -375,6 +375,45 @@ GET /index.php 10.0.0.1
 	}
 }
 
+func TestParseWorkerTable(t *testing.T) {
+	htmlContent := `<table border="0"><tr><th>Srv</th><th>PID</th><th>Acc</th><th>M</th><th>CPU
+</th><th>SS</th><th>Req</th><th>Dur</th><th>Conn</th><th>Child</th><th>Slot</th><th>Client</th><th>Protocol</th><th>VHost</th><th>Request</th></tr>
+
+<tr><td><b>0-0</b></td><td>4242</td><td>0/1520/1520</td><td>_
+</td><td>1.25</td><td>3</td><td>0</td><td>900</td><td>0.0</td><td>12.50</td><td>12.50
+</td><td>10.0.0.5</td><td>http/1.1</td><td>www.example.com:80</td><td nowrap>GET /index.php HTTP/1.1</td></tr>
+
+<tr><td><b>1-0</b></td><td>4243</td><td>1/37/37</td><td><b>W</b>
+</td><td>0.10</td><td>0</td><td>0</td><td>12</td><td>0.0</td><td>0.30</td><td>0.30
+</td><td>10.0.0.6</td><td>http/1.1</td><td>www.example.com:80</td><td nowrap>POST /upload HTTP/1.1</td></tr>
+
+<tr><td><b>2-0</b></td><td>-</td><td>0/0/0</td><td>.
+</td><td>0.00</td><td>0</td><td>0</td><td>0</td><td>0.0</td><td>0.00</td><td>0.00
+</td><td>::1</td><td>http/1.1</td><td></td><td></td></tr>
+</table>`
+
+	workers := parseWorkerTable(htmlContent)
+	if len(workers) != 3 {
+		t.Fatalf("parseWorkerTable() returned %d rows, want 3", len(workers))
+	}
+
+	if workers[0].PID != 4242 || workers[0].ChildAccesses != 1520 || workers[0].Mode != "_" {
+		t.Errorf("row 0 = %+v", workers[0])
+	}
+	if workers[1].Mode != "W" || workers[1].Request != "POST /upload HTTP/1.1" || workers[1].VHost != "www.example.com:80" {
+		t.Errorf("row 1 = %+v", workers[1])
+	}
+	if workers[2].PID != 0 {
+		t.Errorf("unused slot should have PID 0, got %d", workers[2].PID)
+	}
+
+	status := &ApacheStatus{Workers: workers}
+	accesses := status.ChildAccesses()
+	if len(accesses) != 2 || accesses[4242] != 1520 || accesses[4243] != 37 {
+		t.Errorf("ChildAccesses() = %v", accesses)
+	}
+}
+
 func TestIsLocalIP(t *testing.T) {
 	tests := []struct {
 		ip       string
@@ -0,0 +1,115 @@
+package system
+
+import (
+	"fmt"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// Reserve is memory kept free for the OS, page cache and housekeeping: the
+// larger of an absolute amount and a percentage of the memory limit
+type Reserve struct {
+	MB      int
+	Percent float64
+}
+
+// For returns the reserve for a memory limit of limitMB
+func (r Reserve) For(limitMB int) int {
+	reserve := int(float64(limitMB) * r.Percent / 100)
+	if r.MB > reserve {
+		return r.MB
+	}
+	return reserve
+}
+
+// String describes the reserve, e.g. "512 MB", "10%" or "max(512 MB, 10%)"
+func (r Reserve) String() string {
+	switch {
+	case r.MB > 0 && r.Percent > 0:
+		return fmt.Sprintf("max(%d MB, %g%%)", r.MB, r.Percent)
+	case r.Percent > 0:
+		return fmt.Sprintf("%g%%", r.Percent)
+	default:
+		return fmt.Sprintf("%d MB", r.MB)
+	}
+}
+
+// MemoryBudget is the memory Apache may fill at full MaxRequestWorkers:
+//
+//	limit (host RAM, or a lower cgroup limit)
+//	- reserve for the OS, page cache and housekeeping
+//	- other services (in a cgroup: everything else charged to it)
+//	- shares of other Apache instances
+//	= available for Apache
+//
+// Apache's own current usage is not subtracted; it is part of what the
+// budget pays for. Unlike MemAvailable, which shrinks as Apache and other
+// services grow, the budget gives the same answer at idle and under load.
+type MemoryBudget struct {
+	LimitMB          int
+	LimitSource      string // "host RAM" or the cgroup limit file
+	Reserve          Reserve
+	ReserveMB        int // Reserve applied to LimitMB
+	OtherMB          int
+	OtherLabel       string // What OtherMB covers
+	OtherInstancesMB int    // Given to other Apache instances
+	ApacheMB         int    // Apache's current usage, included in AvailableMB
+	AvailableMB      int
+}
+
+// HostMemoryBudget is the host-wide budget shared by all Apache instances:
+// host RAM minus the reserve and the memory charged to other services
+func HostMemoryBudget(sysInfo *SystemInfo, reserve Reserve) *MemoryBudget {
+	limit := sysInfo.HostMemoryMB
+	if limit == 0 {
+		limit = sysInfo.TotalMemoryMB
+	}
+	budget := &MemoryBudget{
+		LimitMB:     limit,
+		LimitSource: "host RAM",
+		Reserve:     reserve,
+		ReserveMB:   reserve.For(limit),
+		OtherMB:     GetTotalOtherServicesMemory(sysInfo),
+		OtherLabel:  "Other services",
+	}
+	budget.AvailableMB = nonNegative(budget.LimitMB - budget.ReserveMB - budget.OtherMB)
+	debug.DumpStruct("HostMemoryBudget", budget)
+	return budget
+}
+
+// ForInstance narrows the host budget to one Apache instance using apacheMB
+// now and given shareMB of it. When the instance's cgroup limit is lower than
+// host RAM, the cgroup is budgeted the same way (limit, reserve, everything
+// else in the cgroup) and the smaller of the two budgets is used.
+func (b *MemoryBudget) ForInstance(shareMB, apacheMB int, cg *CgroupMemory) *MemoryBudget {
+	host := *b
+	host.OtherInstancesMB = nonNegative(b.AvailableMB - shareMB)
+	host.ApacheMB = apacheMB
+	host.AvailableMB = shareMB
+
+	if cg == nil || cg.LimitMB == 0 || cg.LimitMB >= b.LimitMB {
+		return &host
+	}
+	cgroup := &MemoryBudget{
+		LimitMB:     cg.LimitMB,
+		LimitSource: cg.Describe(),
+		Reserve:     b.Reserve,
+		ReserveMB:   b.Reserve.For(cg.LimitMB),
+		OtherMB:     nonNegative(cg.UsageMB - apacheMB),
+		OtherLabel:  "Other usage in the cgroup",
+		ApacheMB:    apacheMB,
+	}
+	cgroup.AvailableMB = nonNegative(cgroup.LimitMB - cgroup.ReserveMB - cgroup.OtherMB)
+	if host.AvailableMB < cgroup.AvailableMB {
+		debug.Printf("Host share (%d MB) is below the %s budget (%d MB)", host.AvailableMB, cgroup.LimitSource, cgroup.AvailableMB)
+		return &host
+	}
+	return cgroup
+}
+
+func nonNegative(mb int) int {
+	if mb < 0 {
+		return 0
+	}
+	return mb
+}
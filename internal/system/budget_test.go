@@ -0,0 +1,123 @@
+package system
+
+import "testing"
+
+func TestHostMemoryBudget(t *testing.T) {
+	sysInfo := &SystemInfo{
+		TotalMemoryMB:     8192,
+		HostMemoryMB:      8192,
+		AvailableMemoryMB: 1200, // MemAvailable under load must not matter
+		OtherServices:     map[string]int{"MySQL": 2048, "Redis": 512},
+	}
+
+	budget := HostMemoryBudget(sysInfo, Reserve{MB: 1024})
+	if budget.AvailableMB != 8192-1024-2560 {
+		t.Errorf("AvailableMB = %d, want %d", budget.AvailableMB, 8192-1024-2560)
+	}
+	if budget.LimitSource != "host RAM" || budget.OtherMB != 2560 {
+		t.Errorf("budget = %+v", budget)
+	}
+
+	// Idle and loaded hosts get the same budget
+	sysInfo.AvailableMemoryMB = 5000
+	if idle := HostMemoryBudget(sysInfo, Reserve{MB: 1024}); idle.AvailableMB != budget.AvailableMB {
+		t.Errorf("budget changed with MemAvailable: %d vs %d", idle.AvailableMB, budget.AvailableMB)
+	}
+
+	// Never negative
+	if over := HostMemoryBudget(sysInfo, Reserve{MB: 9000}); over.AvailableMB != 0 {
+		t.Errorf("AvailableMB = %d, want 0 when the reserve exceeds RAM", over.AvailableMB)
+	}
+}
+
+func TestMemoryBudget_ForInstance(t *testing.T) {
+	host := &MemoryBudget{LimitMB: 8192, LimitSource: "host RAM", Reserve: Reserve{MB: 512}, ReserveMB: 512, OtherMB: 2048, OtherLabel: "Other services", AvailableMB: 5632}
+
+	tests := []struct {
+		name          string
+		shareMB       int
+		cg            *CgroupMemory
+		wantAvailable int
+		wantSource    string
+		wantOther     int
+		wantInstances int
+	}{
+		{
+			name:          "single instance on the host",
+			shareMB:       5632,
+			wantAvailable: 5632,
+			wantSource:    "host RAM",
+			wantOther:     2048,
+		},
+		{
+			name:          "one of two instances",
+			shareMB:       3632,
+			cg:            &CgroupMemory{Version: 2, Path: "/system.slice/apache2.service", UsageMB: 900},
+			wantAvailable: 3632,
+			wantSource:    "host RAM",
+			wantOther:     2048,
+			wantInstances: 2000,
+		},
+		{
+			name:          "container limit is binding",
+			shareMB:       5632,
+			cg:            &CgroupMemory{Version: 2, Path: "/docker/abc", LimitMB: 2048, LimitFile: "memory.max", UsageMB: 700},
+			wantAvailable: 2048 - 512 - 100, // 100 MB of the cgroup is not Apache
+			wantSource:    "cgroup v2 memory.max on /docker/abc",
+			wantOther:     100,
+		},
+		{
+			name:          "host share below the container budget",
+			shareMB:       1000,
+			cg:            &CgroupMemory{Version: 2, Path: "/docker/abc", LimitMB: 4096, LimitFile: "memory.max", UsageMB: 600},
+			wantAvailable: 1000,
+			wantSource:    "host RAM",
+			wantOther:     2048,
+			wantInstances: 4632,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			budget := host.ForInstance(tt.shareMB, 600, tt.cg)
+			if budget.AvailableMB != tt.wantAvailable {
+				t.Errorf("AvailableMB = %d, want %d", budget.AvailableMB, tt.wantAvailable)
+			}
+			if budget.LimitSource != tt.wantSource {
+				t.Errorf("LimitSource = %s, want %s", budget.LimitSource, tt.wantSource)
+			}
+			if budget.OtherMB != tt.wantOther || budget.OtherInstancesMB != tt.wantInstances {
+				t.Errorf("other = %d, instances = %d; want %d, %d", budget.OtherMB, budget.OtherInstancesMB, tt.wantOther, tt.wantInstances)
+			}
+			if budget.ApacheMB != 600 {
+				t.Errorf("ApacheMB = %d, want 600", budget.ApacheMB)
+			}
+		})
+	}
+	if host.ApacheMB != 0 || host.AvailableMB != 5632 {
+		t.Error("ForInstance() must not modify the host budget")
+	}
+}
+
+func TestReserve(t *testing.T) {
+	tests := []struct {
+		reserve Reserve
+		limitMB int
+		want    int
+		text    string
+	}{
+		{Reserve{}, 8192, 0, "0 MB"},
+		{Reserve{MB: 512}, 8192, 512, "512 MB"},
+		{Reserve{Percent: 10}, 8192, 819, "10%"},
+		{Reserve{MB: 512, Percent: 10}, 2048, 512, "max(512 MB, 10%)"},
+		{Reserve{MB: 512, Percent: 12.5}, 8192, 1024, "max(512 MB, 12.5%)"},
+	}
+	for _, tt := range tests {
+		if got := tt.reserve.For(tt.limitMB); got != tt.want {
+			t.Errorf("%+v.For(%d) = %d, want %d", tt.reserve, tt.limitMB, got, tt.want)
+		}
+		if got := tt.reserve.String(); got != tt.text {
+			t.Errorf("%+v.String() = %q, want %q", tt.reserve, got, tt.text)
+		}
+	}
+}
@@ -0,0 +1,216 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// cgroupUnlimited is the threshold above which a cgroup v1 limit is treated as
+// "no limit" (the kernel reports PAGE_COUNTER_MAX rounded to the page size)
+const cgroupUnlimited = int64(1) << 62
+
+// CgroupMemory describes the memory controller of the cgroup a process runs in
+type CgroupMemory struct {
+	Version   int    // 1 or 2, 0 when no memory controller was found
+	Path      string // cgroup path as listed in /proc/PID/cgroup
+	LimitMB   int    // Effective ceiling, 0 when unlimited
+	LimitFile string // File that sets the ceiling, e.g. "memory.max"
+	UsageMB   int    // Working set of the cgroup: current usage minus inactive page cache
+}
+
+// ReadCgroupMemory reads the memory limit and usage of the cgroup that pid
+// belongs to, supporting both the unified (v2) and legacy (v1) hierarchies.
+func ReadCgroupMemory(pid int) (*CgroupMemory, error) {
+	return readCgroupMemory("/proc", "/sys/fs/cgroup", pid)
+}
+
+func readCgroupMemory(procRoot, cgroupRoot string, pid int) (*CgroupMemory, error) {
+	defer debug.Trace("system.ReadCgroupMemory")()
+
+	v1Paths, v2Path, err := readCgroupPaths(procRoot, pid)
+	if err != nil {
+		return nil, err
+	}
+
+	// On hybrid systems the memory controller stays on v1
+	switch {
+	case v1Paths["memory"] != "":
+		return readCgroupV1(filepath.Join(cgroupRoot, "memory"), v1Paths["memory"]), nil
+	case v2Path != "":
+		return readCgroupV2(cgroupRoot, v2Path), nil
+	}
+	return nil, fmt.Errorf("no memory cgroup found for PID %d", pid)
+}
+
+// readCgroupPaths parses /proc/PID/cgroup into the v1 path of each controller
+// and the unified (v2) path
+func readCgroupPaths(procRoot string, pid int) (map[string]string, string, error) {
+	file, err := os.Open(filepath.Join(procRoot, strconv.Itoa(pid), "cgroup"))
+	if err != nil {
+		return nil, "", fmt.Errorf("cannot read cgroup of PID %d: %v", pid, err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			debug.Error(err, "closing cgroup file")
+		}
+	}()
+
+	v1Paths := make(map[string]string)
+	var v2Path string
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		// Format: hierarchy-ID:controller-list:cgroup-path
+		parts := strings.SplitN(scanner.Text(), ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		if parts[0] == "0" && parts[1] == "" {
+			v2Path = parts[2]
+			continue
+		}
+		for _, controller := range strings.Split(parts[1], ",") {
+			v1Paths[controller] = parts[2]
+		}
+	}
+	return v1Paths, v2Path, scanner.Err()
+}
+
+// readCgroupV2 walks from the process cgroup up to the root, taking the lowest
+// memory.max or memory.high as the effective ceiling
+func readCgroupV2(root, path string) *CgroupMemory {
+	cg := &CgroupMemory{Version: 2, Path: path}
+
+	leaf := cgroupDir(root, path)
+	if usage, ok := readCgroupValue(filepath.Join(leaf, "memory.current")); ok {
+		cg.UsageMB = workingSetMB(usage, filepath.Join(leaf, "memory.stat"), "inactive_file")
+	}
+
+	for dir := leaf; ; dir = filepath.Dir(dir) {
+		for _, name := range []string{"memory.max", "memory.high"} {
+			if limit, ok := readCgroupValue(filepath.Join(dir, name)); ok {
+				cg.setLimit(limit, name)
+			}
+		}
+		if dir == root || !strings.HasPrefix(dir, root) {
+			break
+		}
+	}
+	return cg
+}
+
+// readCgroupV1 reads memory.limit_in_bytes, which is hierarchical in v1 too
+func readCgroupV1(root, path string) *CgroupMemory {
+	cg := &CgroupMemory{Version: 1, Path: path}
+
+	leaf := cgroupDir(root, path)
+	if usage, ok := readCgroupValue(filepath.Join(leaf, "memory.usage_in_bytes")); ok {
+		cg.UsageMB = workingSetMB(usage, filepath.Join(leaf, "memory.stat"), "total_inactive_file")
+	}
+
+	for dir := leaf; ; dir = filepath.Dir(dir) {
+		if limit, ok := readCgroupValue(filepath.Join(dir, "memory.limit_in_bytes")); ok {
+			cg.setLimit(limit, "memory.limit_in_bytes")
+		}
+		if dir == root || !strings.HasPrefix(dir, root) {
+			break
+		}
+	}
+	return cg
+}
+
+// workingSetMB subtracts the inactive file cache listed under key in statPath
+// from usage, as the kernel does before reclaim: that cache is given back
+// under pressure and does not compete with Apache for memory
+func workingSetMB(usage int64, statPath, key string) int {
+	if data, err := os.ReadFile(statPath); err == nil {
+		for _, line := range strings.Split(string(data), "\n") {
+			fields := strings.Fields(line)
+			if len(fields) != 2 || fields[0] != key {
+				continue
+			}
+			if inactive, err := strconv.ParseInt(fields[1], 10, 64); err == nil && inactive < usage {
+				usage -= inactive
+			}
+			break
+		}
+	}
+	return int(usage / 1024 / 1024)
+}
+
+// cgroupDir maps a cgroup path to its directory. Inside a container without a
+// private cgroup namespace the host path is not visible, so the container's
+// own root is used instead.
+func cgroupDir(root, path string) string {
+	dir := filepath.Join(root, path)
+	if _, err := os.Stat(dir); err != nil {
+		debug.Printf("cgroup directory %s not visible, using %s", dir, root)
+		return root
+	}
+	return dir
+}
+
+func (cg *CgroupMemory) setLimit(limitBytes int64, file string) {
+	limitMB := int(limitBytes / 1024 / 1024)
+	if cg.LimitMB == 0 || limitMB < cg.LimitMB {
+		cg.LimitMB = limitMB
+		cg.LimitFile = file
+	}
+}
+
+// readCgroupValue reads a single numeric cgroup file. "max" and v1's
+// near-infinite sentinel both mean unlimited and report ok=false.
+func readCgroupValue(path string) (int64, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return 0, false
+	}
+	value := strings.TrimSpace(string(data))
+	if value == "max" {
+		return 0, false
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	if err != nil || n <= 0 || n >= cgroupUnlimited {
+		return 0, false
+	}
+	return n, true
+}
+
+// Describe returns a human readable description of the cgroup limit
+func (cg *CgroupMemory) Describe() string {
+	path := cg.Path
+	if path == "" {
+		path = "/"
+	}
+	return fmt.Sprintf("cgroup v%d %s on %s", cg.Version, cg.LimitFile, path)
+}
+
+// ApplyCgroupLimit makes the cgroup ceiling the effective memory total when it
+// is lower than the host's RAM. The binding limit is recorded in
+// MemoryLimitSource; the memory left for Apache is set by MemoryBudget.
+func ApplyCgroupLimit(sysInfo *SystemInfo, cg *CgroupMemory) {
+	if sysInfo.HostMemoryMB == 0 {
+		sysInfo.HostMemoryMB = sysInfo.TotalMemoryMB
+	}
+	sysInfo.MemoryLimitSource = "host RAM"
+
+	if cg == nil {
+		return
+	}
+	sysInfo.CgroupUsageMB = cg.UsageMB
+	sysInfo.CgroupPath = cg.Path
+
+	if cg.LimitMB == 0 || cg.LimitMB >= sysInfo.HostMemoryMB {
+		debug.Printf("cgroup limit (%d MB) is not lower than host RAM (%d MB)", cg.LimitMB, sysInfo.HostMemoryMB)
+		return
+	}
+
+	sysInfo.TotalMemoryMB = cg.LimitMB
+	sysInfo.MemoryLimitSource = cg.Describe()
+	debug.Printf("Applied %s: total %d MB", sysInfo.MemoryLimitSource, sysInfo.TotalMemoryMB)
+}
@@ -0,0 +1,225 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// writeFiles creates files (relative path -> content) below root
+func writeFiles(t *testing.T, root string, files map[string]string) {
+	t.Helper()
+	for name, content := range files {
+		path := filepath.Join(root, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0755); err != nil {
+			t.Fatalf("Failed to create directory: %v", err)
+		}
+		if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+			t.Fatalf("Failed to write %s: %v", name, err)
+		}
+	}
+}
+
+func TestReadCgroupMemory(t *testing.T) {
+	tests := []struct {
+		name        string
+		files       map[string]string
+		wantVersion int
+		wantLimitMB int
+		wantFile    string
+		wantUsageMB int
+		wantErr     bool
+	}{
+		{
+			name: "cgroup v2 memory.max",
+			files: map[string]string{
+				"proc/100/cgroup": "0::/system.slice/httpd.service\n",
+				"cgroup/system.slice/httpd.service/memory.max":     "2147483648\n",
+				"cgroup/system.slice/httpd.service/memory.high":    "max\n",
+				"cgroup/system.slice/httpd.service/memory.current": "536870912\n",
+			},
+			wantVersion: 2,
+			wantLimitMB: 2048,
+			wantFile:    "memory.max",
+			wantUsageMB: 512,
+		},
+		{
+			name: "cgroup v2 memory.high lower than parent max",
+			files: map[string]string{
+				"proc/100/cgroup":                                  "0::/system.slice/httpd.service\n",
+				"cgroup/system.slice/memory.max":                   "4294967296\n",
+				"cgroup/system.slice/httpd.service/memory.max":     "max\n",
+				"cgroup/system.slice/httpd.service/memory.high":    "1073741824\n",
+				"cgroup/system.slice/httpd.service/memory.current": "104857600\n",
+			},
+			wantVersion: 2,
+			wantLimitMB: 1024,
+			wantFile:    "memory.high",
+			wantUsageMB: 100,
+		},
+		{
+			name: "cgroup v2 slice limit applies to children",
+			files: map[string]string{
+				"proc/100/cgroup":                               "0::/web.slice/httpd.service\n",
+				"cgroup/web.slice/memory.max":                   "3221225472\n",
+				"cgroup/web.slice/httpd.service/memory.max":     "max\n",
+				"cgroup/web.slice/httpd.service/memory.high":    "max\n",
+				"cgroup/web.slice/httpd.service/memory.current": "0\n",
+			},
+			wantVersion: 2,
+			wantLimitMB: 3072,
+			wantFile:    "memory.max",
+		},
+		{
+			name: "cgroup v1 limit",
+			files: map[string]string{
+				"proc/100/cgroup": "12:cpu,cpuacct:/docker/abc\n9:memory:/docker/abc\n0::/\n",
+				"cgroup/memory/docker/abc/memory.limit_in_bytes": "1073741824\n",
+				"cgroup/memory/docker/abc/memory.usage_in_bytes": "209715200\n",
+				"cgroup/memory/memory.limit_in_bytes":            "9223372036854771712\n",
+			},
+			wantVersion: 1,
+			wantLimitMB: 1024,
+			wantFile:    "memory.limit_in_bytes",
+			wantUsageMB: 200,
+		},
+		{
+			name: "cgroup v2 file cache is not usage",
+			files: map[string]string{
+				"proc/100/cgroup":                  "0::/docker/abc\n",
+				"cgroup/docker/abc/memory.max":     "4294967296\n",
+				"cgroup/docker/abc/memory.current": "3221225472\n",
+				"cgroup/docker/abc/memory.stat":    "anon 524288000\nfile 2684354560\nactive_file 209715200\ninactive_file 2474639360\n",
+			},
+			wantVersion: 2,
+			wantLimitMB: 4096,
+			wantFile:    "memory.max",
+			wantUsageMB: 712,
+		},
+		{
+			name: "cgroup v1 file cache is not usage",
+			files: map[string]string{
+				"proc/100/cgroup": "9:memory:/docker/abc\n",
+				"cgroup/memory/docker/abc/memory.limit_in_bytes": "4294967296\n",
+				"cgroup/memory/docker/abc/memory.usage_in_bytes": "3221225472\n",
+				"cgroup/memory/docker/abc/memory.stat":           "cache 2684354560\ninactive_file 10485760\ntotal_inactive_file 2474639360\n",
+			},
+			wantVersion: 1,
+			wantLimitMB: 4096,
+			wantFile:    "memory.limit_in_bytes",
+			wantUsageMB: 712,
+		},
+		{
+			name: "cgroup v1 unlimited",
+			files: map[string]string{
+				"proc/100/cgroup": "9:memory:/user.slice\n",
+				"cgroup/memory/user.slice/memory.limit_in_bytes": "9223372036854771712\n",
+			},
+			wantVersion: 1,
+		},
+		{
+			name: "container without cgroup namespace falls back to root",
+			files: map[string]string{
+				"proc/100/cgroup":           "0::/docker/0123456789ab\n",
+				"cgroup/memory.max":         "536870912\n",
+				"cgroup/memory.current":     "52428800\n",
+				"cgroup/cgroup.controllers": "memory\n",
+			},
+			wantVersion: 2,
+			wantLimitMB: 512,
+			wantFile:    "memory.max",
+			wantUsageMB: 50,
+		},
+		{
+			name:    "missing process",
+			files:   map[string]string{},
+			wantErr: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			writeFiles(t, root, tt.files)
+			if err := os.MkdirAll(filepath.Join(root, "cgroup"), 0755); err != nil {
+				t.Fatal(err)
+			}
+
+			cg, err := readCgroupMemory(filepath.Join(root, "proc"), filepath.Join(root, "cgroup"), 100)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("readCgroupMemory() error = %v, wantErr %v", err, tt.wantErr)
+			}
+			if tt.wantErr {
+				return
+			}
+			if cg.Version != tt.wantVersion {
+				t.Errorf("Version = %d, want %d", cg.Version, tt.wantVersion)
+			}
+			if cg.LimitMB != tt.wantLimitMB {
+				t.Errorf("LimitMB = %d, want %d", cg.LimitMB, tt.wantLimitMB)
+			}
+			if cg.LimitFile != tt.wantFile {
+				t.Errorf("LimitFile = %s, want %s", cg.LimitFile, tt.wantFile)
+			}
+			if cg.UsageMB != tt.wantUsageMB {
+				t.Errorf("UsageMB = %d, want %d", cg.UsageMB, tt.wantUsageMB)
+			}
+		})
+	}
+}
+
+func TestApplyCgroupLimit(t *testing.T) {
+	tests := []struct {
+		name          string
+		cg            *CgroupMemory
+		wantTotal     int
+		wantAvailable int
+		wantSource    string
+	}{
+		{
+			name:          "no cgroup",
+			cg:            nil,
+			wantTotal:     65536,
+			wantAvailable: 60000,
+			wantSource:    "host RAM",
+		},
+		{
+			name:          "limit above host RAM is not binding",
+			cg:            &CgroupMemory{Version: 2, Path: "/", LimitMB: 131072, LimitFile: "memory.max"},
+			wantTotal:     65536,
+			wantAvailable: 60000,
+			wantSource:    "host RAM",
+		},
+		{
+			name:          "container limit is binding",
+			cg:            &CgroupMemory{Version: 2, Path: "/docker/abc", LimitMB: 2048, LimitFile: "memory.max", UsageMB: 512},
+			wantTotal:     2048,
+			wantAvailable: 60000, // left to MemoryBudget
+			wantSource:    "cgroup v2 memory.max on /docker/abc",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sysInfo := &SystemInfo{
+				TotalMemoryMB:     65536,
+				AvailableMemoryMB: 60000,
+				OtherServices:     make(map[string]int),
+			}
+			ApplyCgroupLimit(sysInfo, tt.cg)
+
+			if sysInfo.TotalMemoryMB != tt.wantTotal {
+				t.Errorf("TotalMemoryMB = %d, want %d", sysInfo.TotalMemoryMB, tt.wantTotal)
+			}
+			if sysInfo.AvailableMemoryMB != tt.wantAvailable {
+				t.Errorf("AvailableMemoryMB = %d, want %d", sysInfo.AvailableMemoryMB, tt.wantAvailable)
+			}
+			if sysInfo.MemoryLimitSource != tt.wantSource {
+				t.Errorf("MemoryLimitSource = %s, want %s", sysInfo.MemoryLimitSource, tt.wantSource)
+			}
+			if sysInfo.HostMemoryMB != 65536 {
+				t.Errorf("HostMemoryMB = %d, want 65536", sysInfo.HostMemoryMB)
+			}
+		})
+	}
+}
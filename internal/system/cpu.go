@@ -0,0 +1,191 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// CPUInfo is the CPU capacity available to an Apache instance
+type CPUInfo struct {
+	OnlineCPUs  int     // CPUs in /proc/stat
+	AllowedCPUs int     // Cpus_allowed_list of the master: cpuset and affinity, 0 if unknown
+	QuotaCPUs   float64 // cgroup CPU quota in CPUs, 0 when unlimited
+	QuotaSource string  // File that sets the quota, e.g. "cpu.max"
+
+	Load1, Load5, Load15 float64 // /proc/loadavg
+}
+
+// CPUs returns the effective core count: the lowest of the online CPUs, the
+// CPUs the master may run on and the cgroup quota
+func (c *CPUInfo) CPUs() float64 {
+	cpus := float64(c.OnlineCPUs)
+	if c.AllowedCPUs > 0 && float64(c.AllowedCPUs) < cpus {
+		cpus = float64(c.AllowedCPUs)
+	}
+	if c.QuotaCPUs > 0 && c.QuotaCPUs < cpus {
+		cpus = c.QuotaCPUs
+	}
+	return cpus
+}
+
+// Describe explains where the effective core count comes from
+func (c *CPUInfo) Describe() string {
+	cpus := c.CPUs()
+	switch {
+	case c.QuotaCPUs > 0 && cpus == c.QuotaCPUs && cpus < float64(c.OnlineCPUs):
+		return fmt.Sprintf("cgroup %s quota", c.QuotaSource)
+	case c.AllowedCPUs > 0 && cpus == float64(c.AllowedCPUs) && c.AllowedCPUs < c.OnlineCPUs:
+		return fmt.Sprintf("cpuset, %d of %d online", c.AllowedCPUs, c.OnlineCPUs)
+	}
+	return "online CPUs"
+}
+
+// ReadCPUInfo reads the CPUs available to pid and the load average
+func ReadCPUInfo(pid int) (*CPUInfo, error) {
+	defer debug.Trace("system.ReadCPUInfo")()
+	return readCPUInfo("/proc", "/sys/fs/cgroup", pid)
+}
+
+func readCPUInfo(procRoot, cgroupRoot string, pid int) (*CPUInfo, error) {
+	info := &CPUInfo{}
+	data, err := os.ReadFile(filepath.Join(procRoot, "stat"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read %s/stat: %v", procRoot, err)
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if len(line) > 3 && strings.HasPrefix(line, "cpu") && line[3] >= '0' && line[3] <= '9' {
+			info.OnlineCPUs++
+		}
+	}
+	if info.OnlineCPUs == 0 {
+		return nil, fmt.Errorf("no CPUs listed in %s/stat", procRoot)
+	}
+
+	if data, err := os.ReadFile(filepath.Join(procRoot, "loadavg")); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) >= 3 {
+			info.Load1, _ = strconv.ParseFloat(fields[0], 64)
+			info.Load5, _ = strconv.ParseFloat(fields[1], 64)
+			info.Load15, _ = strconv.ParseFloat(fields[2], 64)
+		}
+	}
+
+	if pid > 0 {
+		if allowed, ok := readAllowedCPUs(filepath.Join(procRoot, strconv.Itoa(pid), "status")); ok {
+			info.AllowedCPUs = allowed
+		}
+		if v1Paths, v2Path, err := readCgroupPaths(procRoot, pid); err == nil {
+			switch {
+			case v1Paths["cpu"] != "":
+				info.QuotaCPUs = readCPUQuotaV1(filepath.Join(cgroupRoot, "cpu"), v1Paths["cpu"])
+				info.QuotaSource = "cpu.cfs_quota_us"
+			case v2Path != "":
+				info.QuotaCPUs = readCPUQuotaV2(cgroupRoot, v2Path)
+				info.QuotaSource = "cpu.max"
+			}
+		} else {
+			debug.Warn("Could not read the CPU cgroup: %v", err)
+		}
+	}
+	debug.DumpStruct("CPUInfo", info)
+	return info, nil
+}
+
+// readAllowedCPUs counts the CPUs in the Cpus_allowed_list of a status file
+func readAllowedCPUs(path string) (int, bool) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			debug.Error(err, "closing "+path)
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := scanner.Text()
+		if strings.HasPrefix(line, "Cpus_allowed_list:") {
+			return countCPUList(strings.TrimSpace(strings.TrimPrefix(line, "Cpus_allowed_list:")))
+		}
+	}
+	return 0, false
+}
+
+// countCPUList counts the CPUs in a list such as "0-3,6,8-9"
+func countCPUList(list string) (int, bool) {
+	count := 0
+	for _, part := range strings.Split(list, ",") {
+		if part == "" {
+			continue
+		}
+		low, high, isRange := strings.Cut(part, "-")
+		first, err := strconv.Atoi(low)
+		if err != nil {
+			return 0, false
+		}
+		last := first
+		if isRange {
+			if last, err = strconv.Atoi(high); err != nil || last < first {
+				return 0, false
+			}
+		}
+		count += last - first + 1
+	}
+	return count, count > 0
+}
+
+// readCPUQuotaV2 walks from the cgroup up to the root, taking the lowest
+// cpu.max quota ("QUOTA PERIOD" or "max PERIOD") in CPUs
+func readCPUQuotaV2(root, path string) float64 {
+	lowest := 0.0
+	for dir := cgroupDir(root, path); ; dir = filepath.Dir(dir) {
+		if data, err := os.ReadFile(filepath.Join(dir, "cpu.max")); err == nil {
+			fields := strings.Fields(string(data))
+			if len(fields) == 2 && fields[0] != "max" {
+				quota, _ := strconv.ParseFloat(fields[0], 64)
+				period, _ := strconv.ParseFloat(fields[1], 64)
+				lowest = lowerQuota(lowest, quota, period)
+			}
+		}
+		if dir == root || !strings.HasPrefix(dir, root) {
+			break
+		}
+	}
+	return lowest
+}
+
+// readCPUQuotaV1 does the same with cpu.cfs_quota_us, where -1 means unlimited
+func readCPUQuotaV1(root, path string) float64 {
+	lowest := 0.0
+	for dir := cgroupDir(root, path); ; dir = filepath.Dir(dir) {
+		quota, quotaOK := readCgroupValue(filepath.Join(dir, "cpu.cfs_quota_us"))
+		period, periodOK := readCgroupValue(filepath.Join(dir, "cpu.cfs_period_us"))
+		if quotaOK && periodOK {
+			lowest = lowerQuota(lowest, float64(quota), float64(period))
+		}
+		if dir == root || !strings.HasPrefix(dir, root) {
+			break
+		}
+	}
+	return lowest
+}
+
+func lowerQuota(lowest, quota, period float64) float64 {
+	if quota <= 0 || period <= 0 {
+		return lowest
+	}
+	cpus := math.Round(quota/period*100) / 100
+	if lowest == 0 || cpus < lowest {
+		return cpus
+	}
+	return lowest
+}
@@ -0,0 +1,113 @@
+package system
+
+import (
+	"path/filepath"
+	"testing"
+)
+
+const procStat = `cpu  1000 0 500 90000 0 0 0 0 0 0
+cpu0 250 0 125 22500 0 0 0 0 0 0
+cpu1 250 0 125 22500 0 0 0 0 0 0
+cpu2 250 0 125 22500 0 0 0 0 0 0
+cpu3 250 0 125 22500 0 0 0 0 0 0
+intr 12345
+ctxt 67890
+`
+
+func TestReadCPUInfo(t *testing.T) {
+	tests := []struct {
+		name     string
+		files    map[string]string
+		wantCPUs float64
+		wantDesc string
+	}{
+		{
+			name: "no limits",
+			files: map[string]string{
+				"proc/100/cgroup": "0::/system.slice/httpd.service\n",
+				"proc/100/status": "Name:\thttpd\nCpus_allowed_list:\t0-3\n",
+			},
+			wantCPUs: 4,
+			wantDesc: "online CPUs",
+		},
+		{
+			name: "cgroup v2 cpu.max on the parent",
+			files: map[string]string{
+				"proc/100/cgroup": "0::/system.slice/httpd.service\n",
+				"proc/100/status": "Cpus_allowed_list:\t0-3\n",
+				"cgroup/system.slice/httpd.service/cpu.max": "max 100000\n",
+				"cgroup/system.slice/cpu.max":               "150000 100000\n",
+			},
+			wantCPUs: 1.5,
+			wantDesc: "cgroup cpu.max quota",
+		},
+		{
+			name: "cgroup v1 cfs quota",
+			files: map[string]string{
+				"proc/100/cgroup":                         "4:cpu,cpuacct:/docker/abc\n3:memory:/docker/abc\n",
+				"cgroup/cpu/docker/abc/cpu.cfs_quota_us":  "200000\n",
+				"cgroup/cpu/docker/abc/cpu.cfs_period_us": "100000\n",
+				"cgroup/cpu/cpu.cfs_quota_us":             "-1\n",
+			},
+			wantCPUs: 2,
+			wantDesc: "cgroup cpu.cfs_quota_us quota",
+		},
+		{
+			name: "cpuset",
+			files: map[string]string{
+				"proc/100/cgroup": "0::/\n",
+				"proc/100/status": "Cpus_allowed_list:\t1,3\n",
+			},
+			wantCPUs: 2,
+			wantDesc: "cpuset, 2 of 4 online",
+		},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			root := t.TempDir()
+			tt.files["proc/stat"] = procStat
+			tt.files["proc/loadavg"] = "3.50 2.25 1.00 2/300 4242\n"
+			writeFiles(t, root, tt.files)
+
+			info, err := readCPUInfo(filepath.Join(root, "proc"), filepath.Join(root, "cgroup"), 100)
+			if err != nil {
+				t.Fatalf("readCPUInfo() error = %v", err)
+			}
+			if info.OnlineCPUs != 4 || info.Load1 != 3.5 || info.Load5 != 2.25 || info.Load15 != 1 {
+				t.Errorf("online %d, load %.2f %.2f %.2f", info.OnlineCPUs, info.Load1, info.Load5, info.Load15)
+			}
+			if info.CPUs() != tt.wantCPUs {
+				t.Errorf("CPUs() = %g, want %g", info.CPUs(), tt.wantCPUs)
+			}
+			if info.Describe() != tt.wantDesc {
+				t.Errorf("Describe() = %q, want %q", info.Describe(), tt.wantDesc)
+			}
+		})
+	}
+
+	if _, err := readCPUInfo(t.TempDir(), t.TempDir(), 0); err == nil {
+		t.Error("readCPUInfo() without /proc/stat should fail")
+	}
+}
+
+func TestCountCPUList(t *testing.T) {
+	tests := []struct {
+		list string
+		want int
+		ok   bool
+	}{
+		{"0-3", 4, true},
+		{"0-1,4,6-7", 5, true},
+		{"5", 1, true},
+		{"", 0, false},
+		{"3-1", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.list, func(t *testing.T) {
+			got, ok := countCPUList(tt.list)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("countCPUList(%q) = %d, %v, want %d, %v", tt.list, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,430 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"math"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// unitDirs are the systemd unit search paths, highest precedence first
+var unitDirs = []string{
+	"/etc/systemd/system",
+	"/run/systemd/system",
+	"/usr/local/lib/systemd/system",
+	"/usr/lib/systemd/system",
+	"/lib/systemd/system",
+}
+
+// fdsPerProcess is assumed when the master's open files cannot be counted
+const fdsPerProcess = 32
+
+// LimitNeeds is what the Apache configuration requires at full MaxRequestWorkers
+type LimitNeeds struct {
+	Backlog               int // ListenBacklog
+	Processes             int // Apache processes, master included
+	Tasks                 int // Processes plus threads
+	Connections           int // Concurrent connections (MaxRequestWorkers)
+	ConnectionsPerProcess int
+	MemoryMB              int // Memory all workers need together
+}
+
+// LimitCheck compares one kernel or service limit with what Apache needs
+type LimitCheck struct {
+	ID      string // Stable short name, e.g. "somaxconn"
+	Limit   string // e.g. "net.core.somaxconn"
+	Value   string // Current value
+	Needed  string // What the configuration needs
+	Status  string // OK, WARNING or CRITICAL
+	Message string
+	Fix     string // Where to change it, empty when OK
+}
+
+// UnitLimits are the resource settings of a systemd service unit
+type UnitLimits struct {
+	Unit        string
+	Files       []string // Unit file and drop-ins that were read
+	LimitNOFILE string
+	TasksMax    string
+	MemoryMax   string
+}
+
+// AuditLimits checks the kernel limits, the rlimits of the Apache master
+// masterPID and its systemd unit against needs
+func AuditLimits(masterPID int, needs LimitNeeds) []LimitCheck {
+	defer debug.Trace("system.AuditLimits")()
+	return auditLimits("/proc", unitDirs, masterPID, needs)
+}
+
+func auditLimits(procRoot string, dirs []string, masterPID int, needs LimitNeeds) []LimitCheck {
+	var checks []LimitCheck
+
+	// The kernel caps every listen backlog at somaxconn
+	somaxconn := readSysctl(procRoot, "net/core/somaxconn", 4096)
+	check := LimitCheck{
+		ID:     "somaxconn",
+		Limit:  "net.core.somaxconn",
+		Value:  strconv.Itoa(somaxconn),
+		Needed: fmt.Sprintf("ListenBacklog %d", needs.Backlog),
+		Status: "OK",
+	}
+	if somaxconn < needs.Backlog {
+		check.Status = "WARNING"
+		check.Message = fmt.Sprintf("ListenBacklog %d is silently cut to %d", needs.Backlog, somaxconn)
+		check.Fix = fmt.Sprintf("net.core.somaxconn = %d in /etc/sysctl.d/90-apache.conf, then sysctl --system", needs.Backlog)
+	}
+	checks = append(checks, check)
+
+	pidDir := filepath.Join(procRoot, strconv.Itoa(masterPID))
+	baseFDs := fdsPerProcess
+	if masterPID > 0 {
+		if entries, err := os.ReadDir(filepath.Join(pidDir, "fd")); err == nil && len(entries) > 0 {
+			baseFDs = len(entries)
+		}
+	}
+	filesPerProcess := baseFDs + 2*needs.ConnectionsPerProcess // client socket plus a file or backend each
+	filesTotal := needs.Processes*baseFDs + 2*needs.Connections
+
+	// System-wide file handles
+	if data, err := os.ReadFile(filepath.Join(procRoot, "sys/fs/file-nr")); err == nil {
+		fields := strings.Fields(string(data))
+		if len(fields) == 3 {
+			allocated, _ := strconv.ParseInt(fields[0], 10, 64)
+			max, _ := strconv.ParseInt(fields[2], 10, 64)
+			check := LimitCheck{
+				ID:     "file-max",
+				Limit:  "fs.file-max",
+				Value:  fmt.Sprintf("%d (%d in use)", max, allocated),
+				Needed: fmt.Sprintf("%d more for Apache", filesTotal),
+				Status: "OK",
+			}
+			if max-allocated < int64(filesTotal) {
+				check.Status = "WARNING"
+				check.Message = fmt.Sprintf("only %d file handles are free", max-allocated)
+				check.Fix = fmt.Sprintf("fs.file-max = %d in /etc/sysctl.d/90-apache.conf, then sysctl --system", allocated+int64(2*filesTotal))
+			}
+			checks = append(checks, check)
+		}
+	}
+
+	if masterPID <= 0 {
+		return checks
+	}
+
+	// Limits the workers inherit from the master
+	if data, err := os.ReadFile(filepath.Join(pidDir, "limits")); err == nil {
+		content := string(data)
+		if soft, ok := parseRlimit(content, "Max open files"); ok {
+			checks = append(checks, compareLimit("rlimit-nofile", "RLIMIT_NOFILE (master)", soft, int64(filesPerProcess),
+				"open files per process", "LimitNOFILE= in the service unit, or ulimit -n in the init script"))
+		}
+		if soft, ok := parseRlimit(content, "Max processes"); ok {
+			if check, ok := nprocCheck(procRoot, masterPID, soft, needs); ok {
+				checks = append(checks, check)
+			}
+		}
+	}
+
+	// Limits configured in the systemd unit
+	cgroup, err := os.ReadFile(filepath.Join(pidDir, "cgroup"))
+	if err != nil {
+		return checks
+	}
+	unit := systemdUnit(string(cgroup))
+	if unit == "" {
+		return checks
+	}
+	limits := ReadUnitLimits(dirs, unit)
+	fix := "systemctl edit " + unit
+	if limits.LimitNOFILE != "" {
+		soft, _, _ := strings.Cut(limits.LimitNOFILE, ":")
+		if value, ok := parseUnitNumber(soft); ok {
+			checks = append(checks, compareLimit("unit-nofile", unit+" LimitNOFILE", value, int64(filesPerProcess),
+				"open files per process", fix+", [Service] LimitNOFILE="))
+		}
+	}
+	if limits.TasksMax != "" {
+		value, ok := parseUnitNumber(limits.TasksMax)
+		if strings.HasSuffix(limits.TasksMax, "%") {
+			percent, err := strconv.ParseFloat(strings.TrimSuffix(limits.TasksMax, "%"), 64)
+			threadsMax := readSysctl(procRoot, "kernel/threads-max", 0)
+			value, ok = int64(percent*float64(threadsMax)/100), err == nil && threadsMax > 0
+		}
+		if ok {
+			checks = append(checks, compareLimit("unit-tasks", unit+" TasksMax", value, int64(needs.Tasks),
+				"processes and threads", fix+", [Service] TasksMax="))
+		}
+	}
+	if limits.MemoryMax != "" && needs.MemoryMB > 0 {
+		if bytes, ok := parseUnitBytes(limits.MemoryMax); ok {
+			check := compareLimit("unit-memory", unit+" MemoryMax", bytes>>20, int64(needs.MemoryMB),
+				"MB for all workers", fix+", [Service] MemoryMax=")
+			if bytes != math.MaxInt64 {
+				check.Value = fmt.Sprintf("%d MB", bytes>>20)
+			}
+			checks = append(checks, check)
+		}
+	}
+	return checks
+}
+
+// nprocCheck compares RLIMIT_NPROC with the tasks of the user Apache's
+// workers run as. The limit is per real uid and counts every process and
+// thread of that user, Apache's or not; root is exempt. A root master forks
+// the children unchecked, so only the threads the children start as the
+// worker user count, and under prefork there are none: no check is made.
+func nprocCheck(procRoot string, masterPID int, limit int64, needs LimitNeeds) (LimitCheck, bool) {
+	statuses := processStatuses(procRoot)
+	master, ok := statuses[masterPID]
+	if !ok {
+		return LimitCheck{}, false
+	}
+	uid, tasks := master.UID, needs.Tasks
+	if master.UID == 0 {
+		if needs.Tasks <= needs.Processes {
+			debug.Printf("RLIMIT_NPROC not checked: the root master forks every prefork child")
+			return LimitCheck{}, false
+		}
+		tasks-- // The master is root's
+		uid = 0
+		for _, status := range statuses {
+			if status.PPID == masterPID {
+				uid = status.UID
+				break
+			}
+		}
+		if uid == 0 {
+			return LimitCheck{}, false
+		}
+	}
+
+	other := 0
+	for pid, status := range statuses {
+		if status.UID == uid && pid != masterPID && status.PPID != masterPID {
+			other += status.Threads
+		}
+	}
+	check := compareLimit("rlimit-nproc", fmt.Sprintf("RLIMIT_NPROC (uid %d)", uid), limit, int64(tasks+other),
+		fmt.Sprintf("tasks of the user (%d outside Apache)", other),
+		"LimitNPROC= in the service unit, or /etc/security/limits.d for the Apache user")
+	return check, true
+}
+
+// procStatus is what nprocCheck needs from /proc/PID/status
+type procStatus struct {
+	UID     int // Real uid, which RLIMIT_NPROC is counted against
+	PPID    int
+	Threads int
+}
+
+// processStatuses reads the status of every process below procRoot, by PID
+func processStatuses(procRoot string) map[int]procStatus {
+	statuses := make(map[int]procStatus)
+	dirs, _ := filepath.Glob(filepath.Join(procRoot, "[0-9]*"))
+	for _, dir := range dirs {
+		pid, err := strconv.Atoi(filepath.Base(dir))
+		if err != nil {
+			continue
+		}
+		if status, ok := readProcStatus(filepath.Join(dir, "status")); ok {
+			statuses[pid] = status
+		}
+	}
+	return statuses
+}
+
+// readProcStatus reads the real uid, parent and thread count from a
+// /proc/PID/status file
+func readProcStatus(path string) (procStatus, bool) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return procStatus{}, false
+	}
+	status := procStatus{Threads: 1}
+	hasUID := false
+	for _, line := range strings.Split(string(data), "\n") {
+		key, value, ok := strings.Cut(line, ":")
+		if !ok {
+			continue
+		}
+		fields := strings.Fields(value)
+		if len(fields) == 0 {
+			continue
+		}
+		n, err := strconv.Atoi(fields[0])
+		if err != nil {
+			continue
+		}
+		switch key {
+		case "Uid":
+			status.UID, hasUID = n, true
+		case "PPid":
+			status.PPID = n
+		case "Threads":
+			status.Threads = n
+		}
+	}
+	return status, hasUID
+}
+
+// compareLimit builds a check for a numeric limit; math.MaxInt64 means
+// unlimited. A fix ending in "=" gets the suggested value appended.
+func compareLimit(id, name string, value, needed int64, unit, fix string) LimitCheck {
+	check := LimitCheck{
+		ID:     id,
+		Limit:  name,
+		Value:  strconv.FormatInt(value, 10),
+		Needed: fmt.Sprintf("%d %s", needed, unit),
+		Status: "OK",
+	}
+	if value == math.MaxInt64 {
+		check.Value = "unlimited"
+		return check
+	}
+	if value < needed {
+		check.Status = "WARNING"
+		check.Message = fmt.Sprintf("%d is below the %d %s the configuration needs", value, needed, unit)
+		check.Fix = fix
+		if strings.HasSuffix(fix, "=") {
+			check.Fix = fmt.Sprintf("%s%d", fix, needed*2) // Leave headroom for growth
+		}
+	}
+	return check
+}
+
+// parseRlimit returns the soft limit of name from /proc/PID/limits content
+func parseRlimit(content, name string) (int64, bool) {
+	for _, line := range strings.Split(content, "\n") {
+		if !strings.HasPrefix(line, name) {
+			continue
+		}
+		fields := strings.Fields(strings.TrimPrefix(line, name))
+		if len(fields) < 2 {
+			return 0, false
+		}
+		return parseUnitNumber(fields[0])
+	}
+	return 0, false
+}
+
+// parseUnitNumber parses a count that may be "unlimited" or "infinity"
+func parseUnitNumber(value string) (int64, bool) {
+	switch value {
+	case "unlimited", "infinity":
+		return math.MaxInt64, true
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	return n, err == nil
+}
+
+// parseUnitBytes parses a systemd size such as "2G", "512M" or "infinity".
+// Percentages of RAM are not resolved.
+func parseUnitBytes(value string) (int64, bool) {
+	if value == "infinity" {
+		return math.MaxInt64, true
+	}
+	if value == "" || strings.HasSuffix(value, "%") {
+		return 0, false
+	}
+	multiplier := int64(1)
+	switch strings.ToUpper(value[len(value)-1:]) {
+	case "K":
+		multiplier = 1 << 10
+	case "M":
+		multiplier = 1 << 20
+	case "G":
+		multiplier = 1 << 30
+	case "T":
+		multiplier = 1 << 40
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+	n, err := strconv.ParseInt(value, 10, 64)
+	return n * multiplier, err == nil
+}
+
+// ReadUnitLimits reads the resource settings of unit from its unit file and
+// drop-ins. As in systemd, the first directory holding the unit file wins,
+// drop-ins are applied in file name order and later settings override.
+func ReadUnitLimits(dirs []string, unit string) UnitLimits {
+	limits := UnitLimits{Unit: unit}
+	for _, dir := range dirs {
+		path := filepath.Join(dir, unit)
+		if _, err := os.Stat(path); err == nil {
+			limits.Files = append(limits.Files, path)
+			break
+		}
+	}
+
+	dropIns := make(map[string]string) // file name -> path of highest precedence
+	for _, dir := range dirs {
+		files, _ := filepath.Glob(filepath.Join(dir, unit+".d", "*.conf"))
+		for _, file := range files {
+			if _, ok := dropIns[filepath.Base(file)]; !ok {
+				dropIns[filepath.Base(file)] = file
+			}
+		}
+	}
+	var names []string
+	for name := range dropIns {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	for _, name := range names {
+		limits.Files = append(limits.Files, dropIns[name])
+	}
+
+	for _, path := range limits.Files {
+		if err := parseUnitFile(path, &limits); err != nil {
+			debug.Warn("Could not read %s: %v", path, err)
+		}
+	}
+	debug.DumpStruct("UnitLimits", limits)
+	return limits
+}
+
+func parseUnitFile(path string, limits *UnitLimits) error {
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			debug.Error(err, "closing "+path)
+		}
+	}()
+
+	inService := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+		if strings.HasPrefix(line, "[") {
+			inService = line == "[Service]"
+			continue
+		}
+		key, value, ok := strings.Cut(line, "=")
+		if !inService || !ok {
+			continue
+		}
+		value = strings.TrimSpace(value)
+		switch strings.TrimSpace(key) {
+		case "LimitNOFILE":
+			limits.LimitNOFILE = value
+		case "TasksMax":
+			limits.TasksMax = value
+		case "MemoryMax", "MemoryLimit":
+			limits.MemoryMax = value
+		}
+	}
+	return scanner.Err()
+}
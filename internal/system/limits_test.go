@@ -0,0 +1,151 @@
+package system
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+const procLimits = `Limit                     Soft Limit           Hard Limit           Units     
+Max cpu time              unlimited            unlimited            seconds   
+Max processes             200                  63432                processes 
+Max open files            1024                 524288               files     
+`
+
+func TestAuditLimits(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"proc/sys/net/core/somaxconn":   "128\n",
+		"proc/sys/fs/file-nr":           "1500\t0\t1000000\n",
+		"proc/sys/kernel/threads-max":   "10000\n",
+		"proc/42/limits":                procLimits,
+		"proc/42/status":                "Name:\tapache2\nPPid:\t1\nUid:\t0\t0\t0\t0\nThreads:\t1\n",
+		"proc/43/status":                "Name:\tapache2\nPPid:\t42\nUid:\t33\t33\t33\t33\nThreads:\t27\n",
+		"proc/50/status":                "Name:\tphp-fpm\nPPid:\t1\nUid:\t33\t33\t33\t33\nThreads:\t5\n",
+		"proc/42/cgroup":                "0::/system.slice/apache2.service\n",
+		"proc/42/fd/0":                  "",
+		"proc/42/fd/1":                  "",
+		"lib/apache2.service":           "[Service]\nLimitNOFILE=8192\nTasksMax=infinity\n",
+		"etc/apache2.service.d/10.conf": "[Service]\nTasksMax=1%\nMemoryMax=1G\n",
+	})
+	dirs := []string{filepath.Join(root, "etc"), filepath.Join(root, "lib")}
+	needs := LimitNeeds{Backlog: 511, Processes: 11, Tasks: 251, Connections: 150, ConnectionsPerProcess: 1, MemoryMB: 3000}
+
+	checks := auditLimits(filepath.Join(root, "proc"), dirs, 42, needs)
+	got := make(map[string]LimitCheck)
+	for _, check := range checks {
+		got[check.Limit] = check
+	}
+
+	tests := []struct {
+		limit  string
+		status string
+		value  string
+		fix    string
+	}{
+		{"net.core.somaxconn", "WARNING", "128", "net.core.somaxconn = 511"},
+		{"fs.file-max", "OK", "1000000 (1500 in use)", ""},
+		{"RLIMIT_NOFILE (master)", "OK", "1024", ""},
+		{"RLIMIT_NPROC (uid 33)", "WARNING", "200", "LimitNPROC="},
+		{"apache2.service LimitNOFILE", "OK", "8192", ""},
+		{"apache2.service TasksMax", "WARNING", "100", "systemctl edit apache2.service, [Service] TasksMax=502"},
+		{"apache2.service MemoryMax", "WARNING", "1024 MB", "MemoryMax=6000"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.limit, func(t *testing.T) {
+			check, ok := got[tt.limit]
+			if !ok {
+				t.Fatalf("no check for %s in %+v", tt.limit, checks)
+			}
+			if check.Status != tt.status || check.Value != tt.value {
+				t.Errorf("got %s %q, want %s %q", check.Status, check.Value, tt.status, tt.value)
+			}
+			if !strings.Contains(check.Fix, tt.fix) {
+				t.Errorf("Fix = %q, want it to contain %q", check.Fix, tt.fix)
+			}
+		})
+	}
+
+	if check := got["RLIMIT_NPROC (uid 33)"]; check.Needed != "255 tasks of the user (5 outside Apache)" {
+		t.Errorf("RLIMIT_NPROC needed = %q, want the threads less the root master plus PHP-FPM's 5", check.Needed)
+	}
+
+	// A root master forks prefork children unchecked
+	prefork := needs
+	prefork.Tasks = prefork.Processes
+	for _, check := range auditLimits(filepath.Join(root, "proc"), dirs, 42, prefork) {
+		if check.ID == "rlimit-nproc" {
+			t.Errorf("prefork under a root master got %+v, want no RLIMIT_NPROC check", check)
+		}
+	}
+
+	// Without a master PID only the kernel-wide limits are checked
+	if checks := auditLimits(filepath.Join(root, "proc"), dirs, 0, needs); len(checks) != 2 {
+		t.Errorf("auditLimits(pid 0) = %d checks, want 2", len(checks))
+	}
+}
+
+// TestNprocCheck_UnprivilegedMaster runs Apache as an ordinary user, whose
+// other processes count against the same RLIMIT_NPROC
+func TestNprocCheck_UnprivilegedMaster(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"proc/70/status": "PPid:\t1\nUid:\t1000\t1000\t1000\t1000\nThreads:\t1\n",
+		"proc/71/status": "PPid:\t70\nUid:\t1000\t1000\t1000\t1000\nThreads:\t1\n",
+		"proc/72/status": "PPid:\t1\nUid:\t1000\t1000\t1000\t1000\nThreads:\t3\n",
+	})
+	check, ok := nprocCheck(filepath.Join(root, "proc"), 70, 12, LimitNeeds{Processes: 11, Tasks: 11})
+	if !ok || check.Limit != "RLIMIT_NPROC (uid 1000)" || check.Status != "WARNING" || check.Needed != "14 tasks of the user (3 outside Apache)" {
+		t.Errorf("nprocCheck() = %+v, %v", check, ok)
+	}
+	if _, ok := nprocCheck(filepath.Join(root, "proc"), 99, 12, LimitNeeds{Processes: 11, Tasks: 11}); ok {
+		t.Error("nprocCheck() without the master's status should make no check")
+	}
+}
+
+func TestReadUnitLimits(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"etc/httpd.service":           "[Unit]\nTasksMax=1\n[Service]\nLimitNOFILE=4096:8192\n",
+		"lib/httpd.service":           "[Service]\nLimitNOFILE=1024\nMemoryLimit=2G\n",
+		"lib/httpd.service.d/20.conf": "[Service]\nTasksMax=512\n",
+		"etc/httpd.service.d/20.conf": "[Service]\n# TasksMax=1\nTasksMax=4096\n",
+		"lib/httpd.service.d/10.conf": "[Service]\nTasksMax=64\nMemoryMax=infinity\n",
+	})
+	limits := ReadUnitLimits([]string{filepath.Join(root, "etc"), filepath.Join(root, "lib")}, "httpd.service")
+
+	if len(limits.Files) != 3 {
+		t.Errorf("Files = %v, want the /etc unit and two drop-ins", limits.Files)
+	}
+	if limits.LimitNOFILE != "4096:8192" {
+		t.Errorf("LimitNOFILE = %q, want the /etc unit's value", limits.LimitNOFILE)
+	}
+	if limits.TasksMax != "4096" {
+		t.Errorf("TasksMax = %q, want the /etc drop-in overriding 10.conf", limits.TasksMax)
+	}
+	if limits.MemoryMax != "infinity" {
+		t.Errorf("MemoryMax = %q, want infinity", limits.MemoryMax)
+	}
+}
+
+func TestParseUnitBytes(t *testing.T) {
+	tests := []struct {
+		value string
+		want  int64
+		ok    bool
+	}{
+		{"2G", 2 << 30, true},
+		{"512M", 512 << 20, true},
+		{"1048576", 1 << 20, true},
+		{"50%", 0, false},
+		{"", 0, false},
+	}
+	for _, tt := range tests {
+		t.Run(tt.value, func(t *testing.T) {
+			got, ok := parseUnitBytes(tt.value)
+			if got != tt.want || ok != tt.ok {
+				t.Errorf("parseUnitBytes(%q) = %d, %v, want %d, %v", tt.value, got, ok, tt.want, tt.ok)
+			}
+		})
+	}
+}
@@ -0,0 +1,287 @@
+package system
+
+import (
+	"bufio"
+	"context"
+	"fmt"
+	"os"
+	"os/exec"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// DefaultOOMWindow is how far back OOM-killer events are considered
+const DefaultOOMWindow = 7 * 24 * time.Hour
+
+// kernelLogPaths hold kernel messages on Debian/Ubuntu and RHEL-family systems
+var kernelLogPaths = []string{"/var/log/kern.log", "/var/log/messages", "/var/log/syslog"}
+
+// oomKillPattern matches the kernel's report of the process it killed, e.g.
+// "Out of memory: Killed process 1234 (httpd) total-vm:..." and the older
+// "Out of memory: Kill process 1234 (apache2) score 52 or sacrifice child"
+var oomKillPattern = regexp.MustCompile(`(Memory cgroup out of memory|Out of memory): Kill(?:ed)? process (\d+) \(([^)]*)\)`)
+
+// oomContextPattern matches the summary line kernels since 4.19 log just
+// before the kill, which names the victim's memory cgroup, e.g.
+// "oom-kill:constraint=CONSTRAINT_NONE,...,task_memcg=/system.slice/apache2.service,task=apache2,pid=1234,uid=33"
+var oomContextPattern = regexp.MustCompile(`oom-kill:.*\btask_memcg=([^,]*),task=[^,]*,pid=(\d+)`)
+
+// OOMEvent is one process killed by the OOM killer
+type OOMEvent struct {
+	Time    time.Time
+	PID     int
+	Process string // comm of the victim
+	Cgroup  bool   // killed because a memory cgroup hit its limit, not the host
+	Apache  bool   // the victim was an Apache process
+	Source  string // log file or "journal" the event was read from
+
+	// TaskCgroup is the memory cgroup the victim ran in, "" when the log
+	// does not name it (kernels before 4.19)
+	TaskCgroup string
+}
+
+// OOMHistory holds the OOM-killer events within a look-back window
+type OOMHistory struct {
+	Window  time.Duration
+	Since   time.Time
+	Sources []string // Logs that were read
+	Events  []OOMEvent
+}
+
+// ApacheKills returns the events whose victim was an Apache process
+func (h *OOMHistory) ApacheKills() []OOMEvent {
+	var kills []OOMEvent
+	for _, event := range h.Events {
+		if event.Apache {
+			kills = append(kills, event)
+		}
+	}
+	return kills
+}
+
+// ApacheKillsIn returns the Apache kills whose victim ran in cgroupPath
+func (h *OOMHistory) ApacheKillsIn(cgroupPath string) []OOMEvent {
+	var kills []OOMEvent
+	for _, event := range h.ApacheKills() {
+		if event.InCgroup(cgroupPath) {
+			kills = append(kills, event)
+		}
+	}
+	return kills
+}
+
+// UnattributedApacheKills returns the Apache kills whose victim ran in none
+// of cgroupPaths, including those whose cgroup the log does not name
+func (h *OOMHistory) UnattributedApacheKills(cgroupPaths []string) []OOMEvent {
+	var kills []OOMEvent
+	for _, event := range h.ApacheKills() {
+		claimed := false
+		for _, path := range cgroupPaths {
+			if event.InCgroup(path) {
+				claimed = true
+				break
+			}
+		}
+		if !claimed {
+			kills = append(kills, event)
+		}
+	}
+	return kills
+}
+
+// InCgroup reports whether the victim ran in cgroupPath or below it. A kill
+// whose cgroup is not known is in none; the root cgroup only holds its own.
+func (e OOMEvent) InCgroup(cgroupPath string) bool {
+	if e.TaskCgroup == "" || cgroupPath == "" {
+		return false
+	}
+	if e.TaskCgroup == cgroupPath {
+		return true
+	}
+	return cgroupPath != "/" && strings.HasPrefix(e.TaskCgroup, cgroupPath+"/")
+}
+
+// ReadOOMHistory collects OOM-killer events from the last window out of the
+// kernel logs, the systemd journal when no kernel log file exists, and an
+// optional extra file (e.g. saved `journalctl -k -o short-iso` output).
+// Events reported by several logs are counted once.
+func ReadOOMHistory(window time.Duration, extraFile string) *OOMHistory {
+	defer debug.Trace("system.ReadOOMHistory")()
+
+	now := time.Now()
+	history := &OOMHistory{Window: window, Since: now.Add(-window)}
+	var events []OOMEvent
+	for _, path := range kernelLogPaths {
+		found, err := readOOMFile(path, now)
+		if err != nil {
+			if !os.IsNotExist(err) {
+				debug.Warn("Could not read %s: %v", path, err)
+			}
+			continue
+		}
+		history.Sources = append(history.Sources, path)
+		events = append(events, found...)
+	}
+	if len(history.Sources) == 0 {
+		if found, err := readOOMJournal(window, now); err == nil {
+			history.Sources = append(history.Sources, "journal")
+			events = append(events, found...)
+		} else {
+			debug.Warn("Could not read the kernel journal: %v", err)
+		}
+	}
+	if extraFile != "" {
+		if found, err := readOOMFile(extraFile, now); err == nil {
+			history.Sources = append(history.Sources, extraFile)
+			events = append(events, found...)
+		} else {
+			debug.Warn("Could not read %s: %v", extraFile, err)
+		}
+	}
+
+	history.Events = filterOOMEvents(events, history.Since)
+	debug.Printf("Found %d OOM kill(s) since %s in %v", len(history.Events), history.Since.Format(time.RFC3339), history.Sources)
+	return history
+}
+
+// filterOOMEvents keeps events since the given time, oldest first, dropping
+// the same kill reported by more than one log
+func filterOOMEvents(events []OOMEvent, since time.Time) []OOMEvent {
+	seen := make(map[string]bool)
+	var kept []OOMEvent
+	for _, event := range events {
+		if event.Time.Before(since) {
+			continue
+		}
+		key := fmt.Sprintf("%d/%d", event.PID, event.Time.Unix())
+		if seen[key] {
+			continue
+		}
+		seen[key] = true
+		kept = append(kept, event)
+	}
+	sort.SliceStable(kept, func(i, j int) bool { return kept[i].Time.Before(kept[j].Time) })
+	return kept
+}
+
+func readOOMFile(path string, now time.Time) ([]OOMEvent, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			debug.Error(err, "closing "+path)
+		}
+	}()
+
+	var events []OOMEvent
+	cgroups := make(map[int]string)
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		if event, ok := parseOOMContext(scanner.Text(), now, cgroups); ok {
+			event.Source = path
+			events = append(events, event)
+		}
+	}
+	return events, scanner.Err()
+}
+
+// readOOMJournal reads kernel messages from the systemd journal
+func readOOMJournal(window time.Duration, now time.Time) ([]OOMEvent, error) {
+	if _, err := exec.LookPath("journalctl"); err != nil {
+		return nil, err
+	}
+	ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+	defer cancel()
+
+	since := now.Add(-window).Format("2006-01-02 15:04:05")
+	output, err := exec.CommandContext(ctx, "journalctl", "-k", "-o", "short-iso", "--no-pager", "--since", since).Output()
+	if err != nil {
+		return nil, err
+	}
+	var events []OOMEvent
+	cgroups := make(map[int]string)
+	for _, line := range strings.Split(string(output), "\n") {
+		if event, ok := parseOOMContext(line, now, cgroups); ok {
+			event.Source = "journal"
+			events = append(events, event)
+		}
+	}
+	return events, nil
+}
+
+// parseOOMContext parses a kill like parseOOMLine, filling in its cgroup from
+// the oom-kill summary line that preceded it. Summary lines are recorded in
+// cgroups by PID.
+func parseOOMContext(line string, now time.Time, cgroups map[int]string) (OOMEvent, bool) {
+	if match := oomContextPattern.FindStringSubmatch(line); match != nil {
+		if pid, err := strconv.Atoi(match[2]); err == nil {
+			cgroups[pid] = match[1]
+		}
+		return OOMEvent{}, false
+	}
+	event, ok := parseOOMLine(line, now)
+	if ok {
+		event.TaskCgroup = cgroups[event.PID]
+	}
+	return event, ok
+}
+
+// parseOOMLine extracts a kill from a syslog or journal line. Lines without a
+// parseable timestamp are ignored, since they cannot be placed in the window.
+func parseOOMLine(line string, now time.Time) (OOMEvent, bool) {
+	match := oomKillPattern.FindStringSubmatch(line)
+	if match == nil {
+		return OOMEvent{}, false
+	}
+	timestamp, ok := parseLogTime(line, now)
+	if !ok {
+		return OOMEvent{}, false
+	}
+	pid, _ := strconv.Atoi(match[2])
+	return OOMEvent{
+		Time:    timestamp,
+		PID:     pid,
+		Process: match[3],
+		Cgroup:  match[1] == "Memory cgroup out of memory",
+		Apache:  isApacheComm(match[3]),
+	}, true
+}
+
+// parseLogTime reads the timestamp at the start of a log line: ISO 8601 as
+// written by rsyslog and journalctl -o short-iso, or the classic syslog
+// "Oct 18 10:15:02", which has no year and is assumed to be in the past year
+func parseLogTime(line string, now time.Time) (time.Time, bool) {
+	if fields := strings.Fields(line); len(fields) > 0 {
+		for _, layout := range []string{"2006-01-02T15:04:05.999999999Z07:00", "2006-01-02T15:04:05.999999999-0700"} {
+			if t, err := time.Parse(layout, fields[0]); err == nil {
+				return t, true
+			}
+		}
+	}
+
+	if len(line) < 15 {
+		return time.Time{}, false
+	}
+	t, err := time.ParseInLocation("Jan _2 15:04:05", line[:15], now.Location())
+	if err != nil {
+		return time.Time{}, false
+	}
+	t = t.AddDate(now.Year(), 0, 0)
+	if t.After(now.Add(24 * time.Hour)) {
+		t = t.AddDate(-1, 0, 0)
+	}
+	return t, true
+}
+
+// isApacheComm reports whether a process name is an Apache server
+func isApacheComm(comm string) bool {
+	return comm == "httpd" || comm == "apache2" || strings.HasPrefix(comm, "httpd-") || strings.HasPrefix(comm, "apache2-")
+}
@@ -0,0 +1,141 @@
+package system
+
+import (
+	"path/filepath"
+	"testing"
+	"time"
+)
+
+func TestParseOOMLine(t *testing.T) {
+	now := time.Date(2026, 1, 10, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		line    string
+		wantOK  bool
+		want    OOMEvent
+		wantISO string
+	}{
+		{
+			name:    "syslog, current kernel",
+			line:    "Jan  9 03:12:44 web1 kernel: [812345.678901] Out of memory: Killed process 4242 (apache2) total-vm:512000kB, anon-rss:204800kB, file-rss:0kB, shmem-rss:0kB, UID:33 pgtables:800kB oom_score_adj:0",
+			wantOK:  true,
+			want:    OOMEvent{PID: 4242, Process: "apache2", Apache: true},
+			wantISO: "2026-01-09T03:12:44Z",
+		},
+		{
+			name:    "syslog from last year",
+			line:    "Dec 30 23:59:01 web1 kernel: Out of memory: Kill process 812 (httpd) score 52 or sacrifice child",
+			wantOK:  true,
+			want:    OOMEvent{PID: 812, Process: "httpd", Apache: true},
+			wantISO: "2025-12-30T23:59:01Z",
+		},
+		{
+			name:    "journal short-iso, cgroup",
+			line:    "2026-01-08T10:15:02+0000 web1 kernel: Memory cgroup out of memory: Killed process 999 (mysqld) total-vm:2048000kB",
+			wantOK:  true,
+			want:    OOMEvent{PID: 999, Process: "mysqld", Cgroup: true},
+			wantISO: "2026-01-08T10:15:02Z",
+		},
+		{
+			name:    "rsyslog high precision",
+			line:    "2026-01-07T08:00:00.123456+00:00 web1 kernel: [ 100.1] Out of memory: Killed process 77 (php-fpm8.3) total-vm:1kB",
+			wantOK:  true,
+			want:    OOMEvent{PID: 77, Process: "php-fpm8.3"},
+			wantISO: "2026-01-07T08:00:00.123456Z",
+		},
+		{
+			name: "companion oom-kill line is not counted",
+			line: "Jan  9 03:12:44 web1 kernel: oom-kill:constraint=CONSTRAINT_NONE,nodemask=(null),task=apache2,pid=4242,uid=33",
+		},
+		{
+			name: "no timestamp",
+			line: "Out of memory: Killed process 1 (httpd)",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := parseOOMLine(tt.line, now)
+			if ok != tt.wantOK {
+				t.Fatalf("parseOOMLine() ok = %v, want %v", ok, tt.wantOK)
+			}
+			if !ok {
+				return
+			}
+			if got.PID != tt.want.PID || got.Process != tt.want.Process || got.Apache != tt.want.Apache || got.Cgroup != tt.want.Cgroup {
+				t.Errorf("parseOOMLine() = %+v, want %+v", got, tt.want)
+			}
+			if iso := got.Time.UTC().Format(time.RFC3339Nano); iso != tt.wantISO {
+				t.Errorf("time = %s, want %s", iso, tt.wantISO)
+			}
+		})
+	}
+}
+
+func TestReadOOMFileAndFilter(t *testing.T) {
+	now := time.Now()
+	root := t.TempDir()
+	recent := now.Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	old := now.Add(-30 * 24 * time.Hour).UTC().Format(time.RFC3339)
+	writeFiles(t, root, map[string]string{
+		"kern.log": old + " web1 kernel: Out of memory: Killed process 10 (httpd) total-vm:1kB\n" +
+			recent + " web1 kernel: Out of memory: Killed process 20 (httpd) total-vm:1kB\n" +
+			recent + " web1 kernel: some other message\n",
+		"syslog": recent + " web1 kernel: Out of memory: Killed process 20 (httpd) total-vm:1kB\n",
+	})
+
+	var events []OOMEvent
+	for _, name := range []string{"kern.log", "syslog"} {
+		found, err := readOOMFile(filepath.Join(root, name), now)
+		if err != nil {
+			t.Fatalf("readOOMFile(%s) error = %v", name, err)
+		}
+		events = append(events, found...)
+	}
+	if len(events) != 3 {
+		t.Fatalf("read %d events, want 3", len(events))
+	}
+
+	history := &OOMHistory{Events: filterOOMEvents(events, now.Add(-DefaultOOMWindow))}
+	if len(history.Events) != 1 || history.Events[0].PID != 20 {
+		t.Fatalf("filtered events = %+v, want only PID 20 once", history.Events)
+	}
+	if len(history.ApacheKills()) != 1 {
+		t.Errorf("ApacheKills() = %d, want 1", len(history.ApacheKills()))
+	}
+}
+
+func TestOOMKillAttribution(t *testing.T) {
+	now := time.Now()
+	root := t.TempDir()
+	recent := now.Add(-2 * time.Hour).UTC().Format(time.RFC3339)
+	writeFiles(t, root, map[string]string{
+		"kern.log": recent + " web1 kernel: oom-kill:constraint=CONSTRAINT_NONE,nodemask=(null),cpuset=/,mems_allowed=0,global_oom,task_memcg=/system.slice/apache2.service,task=apache2,pid=20,uid=33\n" +
+			recent + " web1 kernel: Out of memory: Killed process 20 (apache2) total-vm:1kB\n" +
+			recent + " web1 kernel: oom-kill:constraint=CONSTRAINT_MEMCG,nodemask=(null),cpuset=docker-abc.scope,mems_allowed=0,oom_memcg=/system.slice/docker-abc.scope,task_memcg=/system.slice/docker-abc.scope,task=httpd,pid=30,uid=0\n" +
+			recent + " web1 kernel: Memory cgroup out of memory: Killed process 30 (httpd) total-vm:1kB\n" +
+			recent + " web1 kernel: Out of memory: Killed process 40 (httpd) total-vm:1kB\n",
+	})
+
+	events, err := readOOMFile(filepath.Join(root, "kern.log"), now)
+	if err != nil {
+		t.Fatalf("readOOMFile() error = %v", err)
+	}
+	if len(events) != 3 || events[0].TaskCgroup != "/system.slice/apache2.service" ||
+		events[1].TaskCgroup != "/system.slice/docker-abc.scope" || events[2].TaskCgroup != "" {
+		t.Fatalf("events = %+v, want the cgroups of PIDs 20 and 30 and none for PID 40", events)
+	}
+
+	history := &OOMHistory{Events: events}
+	if kills := history.ApacheKillsIn("/system.slice/apache2.service"); len(kills) != 1 || kills[0].PID != 20 {
+		t.Errorf("ApacheKillsIn(apache2.service) = %+v, want PID 20", kills)
+	}
+	if kills := history.ApacheKillsIn("/"); len(kills) != 0 {
+		t.Errorf("ApacheKillsIn(/) = %+v, want none below the root cgroup", kills)
+	}
+	unattributed := history.UnattributedApacheKills([]string{"/system.slice/apache2.service", "/system.slice"})
+	if len(unattributed) != 1 || unattributed[0].PID != 40 {
+		t.Errorf("UnattributedApacheKills() = %+v, want only PID 40", unattributed)
+	}
+}
@@ -0,0 +1,332 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// phpfpmConfigPaths are searched when no running PHP-FPM master names its config
+var phpfpmConfigPaths = []string{
+	"/etc/php/*/fpm/php-fpm.conf", // Debian, Ubuntu
+	"/etc/php-fpm.conf",           // RHEL, CentOS, Rocky, Alma
+	"/etc/php*/php-fpm.conf",      // Alpine
+	"/usr/local/etc/php-fpm.conf", // Official php Docker images, source builds
+	"/opt/remi/php*/root/etc/php-fpm.conf",
+}
+
+// ServiceNote is an advisory about a detected service, shown with the
+// service list. It replaces the old negative "PHP-FPM-Note" memory marker.
+type ServiceNote struct {
+	Service string
+	Message string
+}
+
+// PHPFPMPool is one pool from a PHP-FPM configuration, with the memory of
+// the children running for it
+type PHPFPMPool struct {
+	Name       string
+	ConfigFile string // File the pool section was read from
+	MasterPID  int    // PHP-FPM master the pool belongs to, 0 if not running
+
+	PM              string // static, dynamic or ondemand
+	MaxChildren     int
+	StartServers    int
+	MinSpareServers int
+	MaxSpareServers int
+	MaxRequests     int
+	Listen          string
+
+	// Running children (PSS when available, else RSS)
+	Processes int
+	TotalMB   float64
+	LargestMB float64
+	AverageMB float64
+	ChildMB   []float64 // Each running child, for the sizing statistic
+}
+
+// DetectPHPFPM notes that with a threaded MPM PHP runs in PHP-FPM, so the
+// pools rather than MaxRequestWorkers limit PHP capacity. PHP-FPM memory is
+// attributed by DetectServices, which must run first.
+func DetectPHPFPM(sysInfo *SystemInfo, mpmModel string) {
+	if sysInfo.OtherServices["PHP-FPM"] > 0 && (mpmModel == "worker" || mpmModel == "event") {
+		sysInfo.ServiceNotes = append(sysInfo.ServiceNotes, ServiceNote{
+			Service: "PHP-FPM",
+			Message: fmt.Sprintf("Apache runs the %s MPM, so PHP capacity is set by pm.max_children of each PHP-FPM pool", mpmModel),
+		})
+	}
+}
+
+// DetectPHPFPMPools reads the pools of every running PHP-FPM master (or the
+// standard config locations if none is found) and attributes the PHP-FPM
+// processes found by DetectServices to their pools.
+func DetectPHPFPMPools(sysInfo *SystemInfo) []PHPFPMPool {
+	defer debug.Trace("system.DetectPHPFPMPools")()
+	return detectPHPFPMPools("/proc", sysInfo.ServiceMatches, phpfpmConfigPaths)
+}
+
+// phpfpmProcess is a PHP-FPM process identified from its title
+type phpfpmProcess struct {
+	pid      int
+	ppid     int
+	pool     string // "" for the master
+	memoryMB float64
+}
+
+func detectPHPFPMPools(procRoot string, matches []ServiceMatch, fallbackConfigs []string) []PHPFPMPool {
+	masters := make(map[int]string) // master PID -> config file
+	var children []phpfpmProcess
+	for _, match := range matches {
+		if match.Service != "PHP-FPM" {
+			continue
+		}
+		pidDir := filepath.Join(procRoot, strconv.Itoa(match.PID))
+		data, err := os.ReadFile(filepath.Join(pidDir, "cmdline"))
+		if err != nil {
+			continue
+		}
+		title := strings.TrimSpace(strings.ReplaceAll(string(data), "\x00", " "))
+		if configFile, ok := parseMasterTitle(title); ok {
+			masters[match.PID] = configFile
+			continue
+		}
+		if pool, ok := parsePoolTitle(title); ok {
+			children = append(children, phpfpmProcess{
+				pid:      match.PID,
+				ppid:     readPPID(pidDir),
+				pool:     pool,
+				memoryMB: match.MemoryMB,
+			})
+		}
+	}
+
+	var pools []PHPFPMPool
+	if len(masters) > 0 {
+		var pids []int
+		for pid := range masters {
+			pids = append(pids, pid)
+		}
+		sort.Ints(pids)
+		for _, pid := range pids {
+			parsed, err := ParsePHPFPMConfig(masters[pid])
+			if err != nil {
+				debug.Warn("Could not parse PHP-FPM config of master %d: %v", pid, err)
+				continue
+			}
+			for i := range parsed {
+				parsed[i].MasterPID = pid
+			}
+			pools = append(pools, parsed...)
+		}
+	} else {
+		seen := make(map[string]bool)
+		for _, pattern := range fallbackConfigs {
+			files, _ := filepath.Glob(pattern)
+			for _, file := range files {
+				if seen[file] {
+					continue
+				}
+				seen[file] = true
+				parsed, err := ParsePHPFPMConfig(file)
+				if err != nil {
+					debug.Warn("Could not parse PHP-FPM config %s: %v", file, err)
+					continue
+				}
+				pools = append(pools, parsed...)
+			}
+		}
+	}
+
+	for _, child := range children {
+		for i := range pools {
+			pool := &pools[i]
+			if pool.Name != child.pool || (pool.MasterPID != 0 && pool.MasterPID != child.ppid) {
+				continue
+			}
+			pool.Processes++
+			pool.TotalMB += child.memoryMB
+			pool.ChildMB = append(pool.ChildMB, child.memoryMB)
+			if child.memoryMB > pool.LargestMB {
+				pool.LargestMB = child.memoryMB
+			}
+			break
+		}
+	}
+	for i := range pools {
+		if pools[i].Processes > 0 {
+			pools[i].AverageMB = pools[i].TotalMB / float64(pools[i].Processes)
+		}
+		debug.DumpStruct("PHPFPMPool", pools[i])
+	}
+	return pools
+}
+
+// parseMasterTitle extracts the config file from a master process title such
+// as "php-fpm: master process (/etc/php/8.3/fpm/php-fpm.conf)"
+func parseMasterTitle(title string) (string, bool) {
+	const marker = "master process ("
+	idx := strings.Index(title, marker)
+	if idx < 0 {
+		return "", false
+	}
+	rest := title[idx+len(marker):]
+	end := strings.LastIndex(rest, ")")
+	if end < 0 {
+		return "", false
+	}
+	return rest[:end], true
+}
+
+// parsePoolTitle extracts the pool name from a child title such as "php-fpm: pool www"
+func parsePoolTitle(title string) (string, bool) {
+	const marker = "pool "
+	idx := strings.Index(title, marker)
+	if idx < 0 {
+		return "", false
+	}
+	fields := strings.Fields(title[idx+len(marker):])
+	if len(fields) == 0 {
+		return "", false
+	}
+	return fields[0], true
+}
+
+// readPPID reads the parent PID from pidDir/status, 0 if unknown
+func readPPID(pidDir string) int {
+	data, err := os.ReadFile(filepath.Join(pidDir, "status"))
+	if err != nil {
+		return 0
+	}
+	for _, line := range strings.Split(string(data), "\n") {
+		if fields := strings.Fields(line); len(fields) == 2 && fields[0] == "PPid:" {
+			ppid, _ := strconv.Atoi(fields[1])
+			return ppid
+		}
+	}
+	return 0
+}
+
+// ParsePHPFPMConfig parses a php-fpm.conf and the files it includes, returning
+// the pools in the order they are defined
+func ParsePHPFPMConfig(path string) ([]PHPFPMPool, error) {
+	var pools []PHPFPMPool
+	if err := parsePHPFPMFile(path, &pools); err != nil {
+		return nil, err
+	}
+	return pools, nil
+}
+
+func parsePHPFPMFile(path string, pools *[]PHPFPMPool) error {
+	debug.Printf("Parsing PHP-FPM config: %s", path)
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			debug.Error(err, "closing PHP-FPM config")
+		}
+	}()
+
+	var current *PHPFPMPool
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, ";") || strings.HasPrefix(line, "#") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			name := strings.TrimSpace(line[1 : len(line)-1])
+			current = nil
+			if name == "global" {
+				continue
+			}
+			// A pool may be continued in a later section with the same name
+			for i := range *pools {
+				if (*pools)[i].Name == name {
+					current = &(*pools)[i]
+				}
+			}
+			if current == nil {
+				*pools = append(*pools, PHPFPMPool{Name: name, ConfigFile: path, PM: "dynamic"})
+				current = &(*pools)[len(*pools)-1]
+			}
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.TrimSpace(key)
+		value = strings.Trim(strings.TrimSpace(value), `"'`)
+
+		if key == "include" {
+			// Relative includes are based on the install prefix, the parent
+			// of the directory holding php-fpm.conf (e.g. /usr/local)
+			if !filepath.IsAbs(value) {
+				value = filepath.Join(filepath.Dir(filepath.Dir(path)), value)
+			}
+			files, err := filepath.Glob(value)
+			if err != nil {
+				debug.Warn("Invalid PHP-FPM include %s: %v", value, err)
+				continue
+			}
+			for _, included := range files {
+				// Re-resolve the current pool afterwards: the slice may grow
+				name := ""
+				if current != nil {
+					name = current.Name
+				}
+				if err := parsePHPFPMFile(included, pools); err != nil {
+					debug.Warn("Could not parse PHP-FPM include %s: %v", included, err)
+				}
+				current = findPool(*pools, name)
+			}
+			continue
+		}
+
+		if current == nil {
+			continue
+		}
+		value = strings.ReplaceAll(value, "$pool", current.Name)
+		number, _ := strconv.Atoi(value)
+		switch key {
+		case "pm":
+			current.PM = value
+		case "pm.max_children":
+			current.MaxChildren = number
+		case "pm.start_servers":
+			current.StartServers = number
+		case "pm.min_spare_servers":
+			current.MinSpareServers = number
+		case "pm.max_spare_servers":
+			current.MaxSpareServers = number
+		case "pm.max_requests":
+			current.MaxRequests = number
+		case "listen":
+			current.Listen = value
+		}
+	}
+	return scanner.Err()
+}
+
+// findPool returns the pool called name, or nil
+func findPool(pools []PHPFPMPool, name string) *PHPFPMPool {
+	if name == "" {
+		return nil
+	}
+	for i := range pools {
+		if pools[i].Name == name {
+			return &pools[i]
+		}
+	}
+	return nil
+}
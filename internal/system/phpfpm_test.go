@@ -0,0 +1,174 @@
+package system
+
+import (
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestParsePHPFPMConfig(t *testing.T) {
+	t.Run("debian layout with pool.d include", func(t *testing.T) {
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"fpm/php-fpm.conf": "[global]\npid = /run/php/php8.3-fpm.pid\n; comment\ninclude=" + filepath.Join(root, "fpm/pool.d") + "/*.conf\n",
+			"fpm/pool.d/www.conf": `[www]
+user = www-data
+listen = /run/php/php8.3-fpm.sock
+pm = dynamic
+pm.max_children = 50
+pm.start_servers = 5
+pm.min_spare_servers = 5
+pm.max_spare_servers = 35
+;pm.max_requests = 500
+`,
+			"fpm/pool.d/shop.conf": `[shop]
+listen = /run/php/$pool.sock
+pm = static
+pm.max_children = 20
+pm.max_requests = 1000
+`,
+			"fpm/pool.d/readme.txt": "[ignored]\npm.max_children = 1\n",
+		})
+
+		pools, err := ParsePHPFPMConfig(filepath.Join(root, "fpm/php-fpm.conf"))
+		if err != nil {
+			t.Fatalf("ParsePHPFPMConfig() error = %v", err)
+		}
+		if len(pools) != 2 {
+			t.Fatalf("got %d pools, want 2: %+v", len(pools), pools)
+		}
+
+		// Glob results are sorted, so shop.conf comes before www.conf
+		shop, www := pools[0], pools[1]
+		if shop.Name != "shop" || shop.PM != "static" || shop.MaxChildren != 20 || shop.MaxRequests != 1000 {
+			t.Errorf("shop pool = %+v", shop)
+		}
+		if shop.Listen != "/run/php/shop.sock" {
+			t.Errorf("shop listen = %s, want $pool expanded", shop.Listen)
+		}
+		if www.MaxChildren != 50 || www.StartServers != 5 || www.MinSpareServers != 5 || www.MaxSpareServers != 35 || www.MaxRequests != 0 {
+			t.Errorf("www pool = %+v", www)
+		}
+		if www.ConfigFile != filepath.Join(root, "fpm/pool.d/www.conf") {
+			t.Errorf("www ConfigFile = %s", www.ConfigFile)
+		}
+	})
+
+	t.Run("docker layout with relative include", func(t *testing.T) {
+		root := t.TempDir()
+		writeFiles(t, root, map[string]string{
+			"usr/local/etc/php-fpm.conf":             "[global]\ninclude=etc/php-fpm.d/*.conf\n",
+			"usr/local/etc/php-fpm.d/www.conf":       "[www]\npm = dynamic\npm.max_children = 5\n",
+			"usr/local/etc/php-fpm.d/zz-docker.conf": "[www]\nlisten = 9000\n",
+		})
+
+		pools, err := ParsePHPFPMConfig(filepath.Join(root, "usr/local/etc/php-fpm.conf"))
+		if err != nil {
+			t.Fatalf("ParsePHPFPMConfig() error = %v", err)
+		}
+		if len(pools) != 1 {
+			t.Fatalf("got %d pools, want 1 (www continued in zz-docker.conf)", len(pools))
+		}
+		if pools[0].MaxChildren != 5 || pools[0].Listen != "9000" {
+			t.Errorf("www pool = %+v", pools[0])
+		}
+	})
+
+	t.Run("missing file", func(t *testing.T) {
+		if _, err := ParsePHPFPMConfig(filepath.Join(t.TempDir(), "php-fpm.conf")); err == nil {
+			t.Error("expected an error for a missing file")
+		}
+	})
+}
+
+func TestDetectPHPFPMPools(t *testing.T) {
+	root := t.TempDir()
+	configDir := filepath.Join(root, "etc")
+	writeFiles(t, configDir, map[string]string{
+		"php-fpm.conf": "[www]\npm = dynamic\npm.max_children = 10\n[api]\npm = ondemand\npm.max_children = 4\n",
+	})
+	configFile := filepath.Join(configDir, "php-fpm.conf")
+
+	procRoot := filepath.Join(root, "proc")
+	procs := map[int]struct {
+		cmdline string
+		ppid    int
+	}{
+		1000: {"php-fpm: master process (" + configFile + ")\x00\x00", 1},
+		1001: {"php-fpm: pool www\x00\x00\x00", 1000},
+		1002: {"php-fpm: pool www\x00\x00\x00", 1000},
+		2001: {"php-fpm: pool www\x00", 2000}, // child of a master we did not see
+	}
+	for pid, proc := range procs {
+		writeFiles(t, filepath.Join(procRoot, strconv.Itoa(pid)), map[string]string{
+			"cmdline": proc.cmdline,
+			"status":  "Name:\tphp-fpm8.3\nPPid:\t" + strconv.Itoa(proc.ppid) + "\n",
+		})
+	}
+
+	matches := []ServiceMatch{
+		{Service: "PHP-FPM", PID: 1000, MemoryMB: 8},
+		{Service: "PHP-FPM", PID: 1001, MemoryMB: 40},
+		{Service: "PHP-FPM", PID: 1002, MemoryMB: 60},
+		{Service: "PHP-FPM", PID: 2001, MemoryMB: 99},
+		{Service: "MySQL", PID: 3000, MemoryMB: 500},
+	}
+
+	pools := detectPHPFPMPools(procRoot, matches, nil)
+	if len(pools) != 2 {
+		t.Fatalf("got %d pools, want 2", len(pools))
+	}
+	www, api := pools[0], pools[1]
+	if www.MasterPID != 1000 || www.Processes != 2 || www.TotalMB != 100 || www.LargestMB != 60 || www.AverageMB != 50 {
+		t.Errorf("www pool = %+v", www)
+	}
+	if api.PM != "ondemand" || api.Processes != 0 {
+		t.Errorf("api pool = %+v", api)
+	}
+
+	t.Run("falls back to config search without a master", func(t *testing.T) {
+		pools := detectPHPFPMPools(procRoot, nil, []string{filepath.Join(configDir, "*.conf"), configFile})
+		if len(pools) != 2 {
+			t.Errorf("got %d pools, want 2 (each config parsed once)", len(pools))
+		}
+	})
+}
+
+func TestDetectPHPFPM(t *testing.T) {
+	tests := []struct {
+		name     string
+		services map[string]int
+		mpm      string
+		wantNote bool
+	}{
+		{"event with PHP-FPM", map[string]int{"PHP-FPM": 200}, "event", true},
+		{"worker with PHP-FPM", map[string]int{"PHP-FPM": 200}, "worker", true},
+		{"prefork with PHP-FPM", map[string]int{"PHP-FPM": 200}, "prefork", false},
+		{"event without PHP-FPM", map[string]int{"MySQL": 200}, "event", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			sysInfo := &SystemInfo{OtherServices: tt.services}
+			DetectPHPFPM(sysInfo, tt.mpm)
+			if got := len(sysInfo.ServiceNotes) > 0; got != tt.wantNote {
+				t.Errorf("note added = %t, want %t", got, tt.wantNote)
+			}
+			if _, ok := sysInfo.OtherServices["PHP-FPM-Note"]; ok {
+				t.Error("the PHP-FPM-Note marker should no longer be used")
+			}
+		})
+	}
+}
+
+func TestParseFPMTitles(t *testing.T) {
+	if got, ok := parseMasterTitle("php-fpm: master process (/etc/php/8.3/fpm/php-fpm.conf)"); !ok || got != "/etc/php/8.3/fpm/php-fpm.conf" {
+		t.Errorf("parseMasterTitle() = %q, %t", got, ok)
+	}
+	if got, ok := parsePoolTitle("php-fpm: pool www"); !ok || got != "www" {
+		t.Errorf("parsePoolTitle() = %q, %t", got, ok)
+	}
+	if _, ok := parsePoolTitle("/usr/sbin/php-fpm8.3 --nodaemonize"); ok {
+		t.Error("parsePoolTitle() matched a command line without a pool")
+	}
+}
@@ -0,0 +1,340 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"sort"
+	"strconv"
+	"strings"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// Service memory accounting modes for the MaxRequestWorkers budget
+const (
+	AccountingCurrent = "current" // what services use right now
+	AccountingPlanned = "planned" // what their configured limits allow them to grow to
+)
+
+// ServicePlan is the memory a service is configured to reach
+type ServicePlan struct {
+	Service   string
+	CurrentMB int
+	PlannedMB int
+	Basis     string   // How PlannedMB was derived
+	Sources   []string // Config files read
+}
+
+// Config locations; the first existing MySQL file is the root of its include tree
+var (
+	mysqlConfigPaths = []string{"/etc/mysql/my.cnf", "/etc/my.cnf", "/etc/mysql/mariadb.cnf"}
+	redisConfigGlobs = []string{"/etc/redis/redis.conf", "/etc/redis.conf", "/etc/redis/*.conf"}
+)
+
+// mysqlSections are the my.cnf groups read by the server
+var mysqlSections = map[string]bool{
+	"mysqld": true, "server": true, "mariadb": true, "mariadbd": true,
+}
+
+// mysqlDefaults are MySQL 8.0 defaults in bytes for the variables used by planMySQL
+var mysqlDefaults = map[string]int64{
+	"innodb_buffer_pool_size": 128 << 20,
+	"innodb_log_buffer_size":  16 << 20,
+	"key_buffer_size":         8 << 20,
+	"query_cache_size":        0,
+	"tmp_table_size":          16 << 20,
+	"max_heap_table_size":     16 << 20,
+	"read_buffer_size":        128 << 10,
+	"read_rnd_buffer_size":    256 << 10,
+	"sort_buffer_size":        256 << 10,
+	"join_buffer_size":        256 << 10,
+	"thread_stack":            1 << 20,
+	"binlog_cache_size":       32 << 10,
+	"max_connections":         151,
+}
+
+// PlanServiceMemory computes planned footprints for running services whose
+// limits can be read from their configuration (MySQL/MariaDB and Redis)
+func PlanServiceMemory(sysInfo *SystemInfo) {
+	defer debug.Trace("system.PlanServiceMemory")()
+
+	if current, ok := sysInfo.OtherServices["MySQL"]; ok {
+		if plan, err := planMySQL(mysqlConfigPaths); err == nil {
+			plan.CurrentMB = current
+			sysInfo.ServicePlans = append(sysInfo.ServicePlans, plan)
+		} else {
+			debug.Warn("Could not plan MySQL memory: %v", err)
+		}
+	}
+	if current, ok := sysInfo.OtherServices["Redis"]; ok {
+		if plan, err := planRedis(redisConfigGlobs); err == nil {
+			plan.CurrentMB = current
+			sysInfo.ServicePlans = append(sysInfo.ServicePlans, plan)
+		} else {
+			debug.Warn("Could not plan Redis memory: %v", err)
+		}
+	}
+}
+
+// ApplyServiceAccounting selects which figure OtherServices holds for planned
+// services. In planned mode a service is charged its planned footprint when
+// that is larger than what it uses now.
+func ApplyServiceAccounting(sysInfo *SystemInfo, mode string) error {
+	switch mode {
+	case AccountingCurrent:
+	case AccountingPlanned:
+		for _, plan := range sysInfo.ServicePlans {
+			if plan.PlannedMB > plan.CurrentMB {
+				debug.Printf("Charging %s its planned %d MB instead of the current %d MB", plan.Service, plan.PlannedMB, plan.CurrentMB)
+				sysInfo.OtherServices[plan.Service] = plan.PlannedMB
+			}
+		}
+	default:
+		return fmt.Errorf("unknown service accounting %q (use %s or %s)", mode, AccountingCurrent, AccountingPlanned)
+	}
+	sysInfo.ServiceAccounting = mode
+	return nil
+}
+
+// planMySQL estimates the worst-case footprint of mysqld: global buffers plus
+// per-connection buffers for every allowed connection
+func planMySQL(configPaths []string) (ServicePlan, error) {
+	values := make(map[string]int64)
+	var sources []string
+	for _, path := range configPaths {
+		if _, err := os.Stat(path); err != nil {
+			continue
+		}
+		if err := parseMyCnf(path, values, &sources, 0); err != nil {
+			return ServicePlan{}, err
+		}
+		break
+	}
+	if len(sources) == 0 {
+		return ServicePlan{}, fmt.Errorf("no MySQL configuration found")
+	}
+
+	get := func(name string) int64 {
+		if value, ok := values[name]; ok {
+			return value
+		}
+		return mysqlDefaults[name]
+	}
+	tmpTable := get("tmp_table_size")
+	if heap := get("max_heap_table_size"); heap < tmpTable {
+		tmpTable = heap // the smaller of the two limits in-memory temporary tables
+	}
+	global := get("innodb_buffer_pool_size") + get("innodb_log_buffer_size") +
+		get("key_buffer_size") + get("query_cache_size") + tmpTable
+	perConnection := get("read_buffer_size") + get("read_rnd_buffer_size") + get("sort_buffer_size") +
+		get("join_buffer_size") + get("thread_stack") + get("binlog_cache_size")
+	connections := get("max_connections")
+
+	planned := global + perConnection*connections
+	return ServicePlan{
+		Service:   "MySQL",
+		PlannedMB: int(planned >> 20),
+		Basis: fmt.Sprintf("innodb_buffer_pool_size %d MB + other global buffers %d MB + max_connections %d × %.1f MB per connection",
+			get("innodb_buffer_pool_size")>>20, (global-get("innodb_buffer_pool_size"))>>20, connections, float64(perConnection)/(1<<20)),
+		Sources: sources,
+	}, nil
+}
+
+// parseMyCnf reads server options from a my.cnf file, following !include and
+// !includedir. Later values override earlier ones, as in mysqld.
+func parseMyCnf(path string, values map[string]int64, sources *[]string, depth int) error {
+	if depth > 10 {
+		return fmt.Errorf("too many nested includes at %s", path)
+	}
+	file, err := os.Open(path)
+	if err != nil {
+		return err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			debug.Error(err, "closing "+path)
+		}
+	}()
+	*sources = append(*sources, path)
+
+	inServer := false
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		line := strings.TrimSpace(scanner.Text())
+		if line == "" || strings.HasPrefix(line, "#") || strings.HasPrefix(line, ";") {
+			continue
+		}
+
+		if strings.HasPrefix(line, "!includedir") {
+			dir := strings.TrimSpace(strings.TrimPrefix(line, "!includedir"))
+			files, _ := filepath.Glob(filepath.Join(dir, "*.cnf"))
+			sort.Strings(files)
+			for _, included := range files {
+				if err := parseMyCnf(included, values, sources, depth+1); err != nil {
+					debug.Warn("Could not read %s: %v", included, err)
+				}
+			}
+			continue
+		}
+		if strings.HasPrefix(line, "!include") {
+			included := strings.TrimSpace(strings.TrimPrefix(line, "!include"))
+			if err := parseMyCnf(included, values, sources, depth+1); err != nil {
+				debug.Warn("Could not read %s: %v", included, err)
+			}
+			continue
+		}
+
+		if strings.HasPrefix(line, "[") && strings.HasSuffix(line, "]") {
+			section := strings.ToLower(strings.TrimSpace(line[1 : len(line)-1]))
+			// Version-specific groups such as [mysqld-8.0] and [mariadb-10.11]
+			if idx := strings.Index(section, "-"); idx > 0 {
+				section = section[:idx]
+			}
+			inServer = mysqlSections[section]
+			continue
+		}
+		if !inServer {
+			continue
+		}
+
+		key, value, ok := strings.Cut(line, "=")
+		if !ok {
+			continue
+		}
+		key = strings.ReplaceAll(strings.TrimSpace(strings.ToLower(key)), "-", "_")
+		key = strings.TrimPrefix(key, "loose_")
+		if _, known := mysqlDefaults[key]; !known {
+			continue
+		}
+		if size, err := parseMySQLSize(strings.Trim(strings.TrimSpace(value), `"'`)); err == nil {
+			values[key] = size
+		} else {
+			debug.Warn("Ignoring %s = %s in %s: %v", key, value, path, err)
+		}
+	}
+	return scanner.Err()
+}
+
+// parseMySQLSize parses a my.cnf size such as "512M", "2G" or "134217728"
+func parseMySQLSize(value string) (int64, error) {
+	if value == "" {
+		return 0, fmt.Errorf("empty value")
+	}
+	multiplier := int64(1)
+	switch strings.ToUpper(value[len(value)-1:]) {
+	case "K":
+		multiplier = 1 << 10
+	case "M":
+		multiplier = 1 << 20
+	case "G":
+		multiplier = 1 << 30
+	case "T":
+		multiplier = 1 << 40
+	}
+	if multiplier > 1 {
+		value = value[:len(value)-1]
+	}
+	number, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return 0, err
+	}
+	return int64(number * float64(multiplier)), nil
+}
+
+// planRedis sums maxmemory over all Redis server configuration files, skipping
+// Sentinel configs that share /etc/redis. Redis also needs memory for buffers
+// and fragmentation, so the plan is a floor.
+func planRedis(configGlobs []string) (ServicePlan, error) {
+	seen := make(map[string]bool)
+	var total int64
+	var sources []string
+	var unlimited []string
+	for _, pattern := range configGlobs {
+		files, _ := filepath.Glob(pattern)
+		for _, path := range files {
+			if seen[path] {
+				continue
+			}
+			seen[path] = true
+
+			maxmemory, sentinel, err := readRedisMaxmemory(path)
+			if err != nil {
+				debug.Warn("Could not read %s: %v", path, err)
+				continue
+			}
+			if sentinel {
+				debug.Printf("Skipping Redis Sentinel config %s", path)
+				continue
+			}
+			sources = append(sources, path)
+			if maxmemory == 0 {
+				unlimited = append(unlimited, path)
+			}
+			total += maxmemory
+		}
+	}
+	if len(sources) == 0 {
+		return ServicePlan{}, fmt.Errorf("no Redis configuration found")
+	}
+	if len(unlimited) > 0 {
+		return ServicePlan{}, fmt.Errorf("maxmemory is not set in %s", strings.Join(unlimited, ", "))
+	}
+	return ServicePlan{
+		Service:   "Redis",
+		PlannedMB: int(total >> 20),
+		Basis:     fmt.Sprintf("maxmemory %d MB across %d instance(s)", total>>20, len(sources)),
+		Sources:   sources,
+	}, nil
+}
+
+// readRedisMaxmemory returns the maxmemory of a redis.conf in bytes, 0 when
+// unset. sentinel is set for a Sentinel config, which holds no data.
+func readRedisMaxmemory(path string) (maxmemory int64, sentinel bool, err error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return 0, false, err
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			debug.Error(err, "closing "+path)
+		}
+	}()
+
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) >= 2 && strings.EqualFold(fields[0], "sentinel") {
+			sentinel = true
+		}
+		if len(fields) == 2 && strings.EqualFold(fields[0], "maxmemory") {
+			if maxmemory, err = parseRedisSize(fields[1]); err != nil {
+				return 0, false, err
+			}
+		}
+	}
+	return maxmemory, sentinel, scanner.Err()
+}
+
+// parseRedisSize parses a redis.conf size: 1k = 1000 bytes, 1kb = 1024 bytes
+func parseRedisSize(value string) (int64, error) {
+	value = strings.ToLower(value)
+	units := []struct {
+		suffix     string
+		multiplier int64
+	}{
+		{"kb", 1 << 10}, {"mb", 1 << 20}, {"gb", 1 << 30},
+		{"k", 1000}, {"m", 1000 * 1000}, {"g", 1000 * 1000 * 1000},
+	}
+	for _, unit := range units {
+		if strings.HasSuffix(value, unit.suffix) {
+			number, err := strconv.ParseInt(strings.TrimSuffix(value, unit.suffix), 10, 64)
+			if err != nil {
+				return 0, err
+			}
+			return number * unit.multiplier, nil
+		}
+	}
+	return strconv.ParseInt(value, 10, 64)
+}
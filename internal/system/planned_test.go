@@ -0,0 +1,144 @@
+package system
+
+import (
+	"path/filepath"
+	"strings"
+	"testing"
+)
+
+func TestPlanMySQL(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"my.cnf": "[client]\nkey_buffer_size = 1G\n\n!includedir " + filepath.Join(root, "conf.d") + "\n",
+		"conf.d/mysqld.cnf": `[mysqld]
+# Tuned for a 8 GB host
+innodb_buffer_pool_size = 2G
+max_connections = 100
+sort-buffer-size=2M
+loose-join_buffer_size = 256K
+`,
+		"conf.d/zz-override.cnf": "[mysqld-8.0]\nmax_connections = 200\n",
+		"conf.d/notes.txt":       "[mysqld]\nmax_connections = 1\n",
+	})
+
+	plan, err := planMySQL([]string{filepath.Join(root, "missing.cnf"), filepath.Join(root, "my.cnf")})
+	if err != nil {
+		t.Fatalf("planMySQL() error = %v", err)
+	}
+	// Global: 2048 + 16 log + 8 key + 16 tmp = 2088 MB; per connection:
+	// 128K + 256K + 2M + 256K + 1M + 32K = 3744K, times 200 = 731.25 MB
+	if plan.PlannedMB != 2819 {
+		t.Errorf("PlannedMB = %d, want 2819", plan.PlannedMB)
+	}
+	want := "innodb_buffer_pool_size 2048 MB + other global buffers 40 MB + max_connections 200 × 3.7 MB per connection"
+	if plan.Basis != want {
+		t.Errorf("Basis = %q, want %q", plan.Basis, want)
+	}
+	if len(plan.Sources) != 3 {
+		t.Errorf("Sources = %v, want my.cnf and two conf.d files", plan.Sources)
+	}
+
+	if _, err := planMySQL([]string{filepath.Join(root, "missing.cnf")}); err == nil {
+		t.Error("planMySQL() without config should fail")
+	}
+}
+
+func TestPlanRedis(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"redis/redis.conf":       "bind 127.0.0.1\n# maxmemory 8gb\nmaxmemory 2gb\n",
+		"redis/redis-cache.conf": "maxmemory 512mb\nmaxmemory-policy allkeys-lru\n",
+		"redis/sentinel.conf":    "port 26379\nsentinel monitor mymaster 127.0.0.1 6379 2\nsentinel down-after-milliseconds mymaster 5000\n",
+		"other/redis.conf":       "port 6380\n",
+	})
+
+	globs := []string{filepath.Join(root, "redis/redis.conf"), filepath.Join(root, "redis/*.conf")}
+	plan, err := planRedis(globs)
+	if err != nil {
+		t.Fatalf("planRedis() error = %v", err)
+	}
+	if plan.PlannedMB != 2560 || len(plan.Sources) != 2 {
+		t.Errorf("plan = %+v, want 2560 MB from 2 files", plan)
+	}
+
+	_, err = planRedis([]string{filepath.Join(root, "other/redis.conf")})
+	if err == nil || !strings.Contains(err.Error(), "maxmemory is not set") {
+		t.Errorf("planRedis() error = %v, want maxmemory not set", err)
+	}
+}
+
+func TestParseSizes(t *testing.T) {
+	mysql := []struct {
+		value string
+		want  int64
+	}{
+		{"134217728", 134217728},
+		{"512K", 512 << 10},
+		{"128M", 128 << 20},
+		{"1.5G", 1536 << 20},
+		{"2g", 2 << 30},
+	}
+	for _, tt := range mysql {
+		if got, err := parseMySQLSize(tt.value); err != nil || got != tt.want {
+			t.Errorf("parseMySQLSize(%q) = %d, %v, want %d", tt.value, got, err, tt.want)
+		}
+	}
+	if _, err := parseMySQLSize("lots"); err == nil {
+		t.Error("parseMySQLSize(\"lots\") should fail")
+	}
+
+	redis := []struct {
+		value string
+		want  int64
+	}{
+		{"1048576", 1048576},
+		{"1k", 1000},
+		{"1kb", 1024},
+		{"100mb", 100 << 20},
+		{"2GB", 2 << 30},
+		{"1g", 1000 * 1000 * 1000},
+	}
+	for _, tt := range redis {
+		if got, err := parseRedisSize(tt.value); err != nil || got != tt.want {
+			t.Errorf("parseRedisSize(%q) = %d, %v, want %d", tt.value, got, err, tt.want)
+		}
+	}
+}
+
+func TestApplyServiceAccounting(t *testing.T) {
+	newInfo := func() *SystemInfo {
+		return &SystemInfo{
+			OtherServices: map[string]int{"MySQL": 400, "Redis": 300, "Nginx": 50},
+			ServicePlans: []ServicePlan{
+				{Service: "MySQL", CurrentMB: 400, PlannedMB: 2819},
+				{Service: "Redis", CurrentMB: 300, PlannedMB: 256},
+			},
+		}
+	}
+
+	current := newInfo()
+	if err := ApplyServiceAccounting(current, AccountingCurrent); err != nil {
+		t.Fatal(err)
+	}
+	if current.OtherServices["MySQL"] != 400 || current.ServiceAccounting != AccountingCurrent {
+		t.Errorf("current accounting changed services: %v", current.OtherServices)
+	}
+
+	planned := newInfo()
+	if err := ApplyServiceAccounting(planned, AccountingPlanned); err != nil {
+		t.Fatal(err)
+	}
+	if planned.OtherServices["MySQL"] != 2819 {
+		t.Errorf("MySQL = %d, want planned 2819", planned.OtherServices["MySQL"])
+	}
+	if planned.OtherServices["Redis"] != 300 {
+		t.Errorf("Redis = %d, want current 300 (above its plan)", planned.OtherServices["Redis"])
+	}
+	if planned.OtherServices["Nginx"] != 50 {
+		t.Errorf("Nginx = %d, want unchanged 50", planned.OtherServices["Nginx"])
+	}
+
+	if err := ApplyServiceAccounting(newInfo(), "peak"); err == nil {
+		t.Error("ApplyServiceAccounting() should reject unknown modes")
+	}
+}
@@ -0,0 +1,265 @@
+package system
+
+import (
+	_ "embed"
+	"encoding/json"
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+
+	"apache2buddy-go/internal/debug"
+	"apache2buddy-go/internal/process"
+)
+
+// DefaultServicesFile is the optional user registry merged over the built-in one
+const DefaultServicesFile = "/etc/apache2buddy-go/services.json"
+
+//go:embed services.json
+var builtinServices []byte
+
+// ServiceDefinition describes how to recognise the processes of one service.
+// A process belongs to the service if any criterion matches.
+type ServiceDefinition struct {
+	Name  string   `json:"name"`
+	Comm  []string `json:"comm,omitempty"`  // Glob patterns for /proc/PID/comm
+	Exe   string   `json:"exe,omitempty"`   // Regular expression for the /proc/PID/exe target
+	Units []string `json:"units,omitempty"` // Glob patterns for the systemd unit
+
+	exe *regexp.Regexp
+}
+
+// ServiceRegistry is an ordered list of service definitions. The first
+// matching definition claims a process, so specific entries (Elasticsearch)
+// must come before generic ones (Java).
+type ServiceRegistry struct {
+	Services []ServiceDefinition
+}
+
+// ServiceMatch records one process attributed to a service and why
+type ServiceMatch struct {
+	Service  string
+	PID      int
+	Comm     string
+	Reason   string  // e.g. `comm "mysqld" matches "mysqld"`
+	MemoryMB float64 // PSS when available, else RSS
+	Method   string  // process.Method* used for MemoryMB
+}
+
+// LoadServiceRegistry returns the built-in registry merged with the user
+// file at userPath. User entries take precedence: they are matched first and
+// replace built-in entries of the same name. A missing user file is not an
+// error.
+func LoadServiceRegistry(userPath string) (*ServiceRegistry, error) {
+	defer debug.Trace("system.LoadServiceRegistry")()
+
+	builtin, err := parseServiceDefinitions(builtinServices)
+	if err != nil {
+		return nil, fmt.Errorf("built-in service registry: %v", err)
+	}
+	registry := &ServiceRegistry{Services: builtin}
+	if userPath == "" {
+		return registry, nil
+	}
+
+	data, err := os.ReadFile(userPath)
+	if os.IsNotExist(err) {
+		debug.Printf("No user service registry at %s", userPath)
+		return registry, nil
+	}
+	if err != nil {
+		return registry, fmt.Errorf("cannot read service registry %s: %v", userPath, err)
+	}
+	user, err := parseServiceDefinitions(data)
+	if err != nil {
+		return registry, fmt.Errorf("service registry %s: %v", userPath, err)
+	}
+
+	overridden := make(map[string]bool)
+	for _, def := range user {
+		overridden[def.Name] = true
+	}
+	merged := user
+	for _, def := range builtin {
+		if !overridden[def.Name] {
+			merged = append(merged, def)
+		}
+	}
+	registry.Services = merged
+	debug.Printf("Loaded %d user service definitions from %s", len(user), userPath)
+	return registry, nil
+}
+
+// parseServiceDefinitions decodes a JSON array of definitions and validates them
+func parseServiceDefinitions(data []byte) ([]ServiceDefinition, error) {
+	var defs []ServiceDefinition
+	if err := json.Unmarshal(data, &defs); err != nil {
+		return nil, err
+	}
+	for i := range defs {
+		def := &defs[i]
+		if def.Name == "" {
+			return nil, fmt.Errorf("entry %d has no name", i+1)
+		}
+		if len(def.Comm) == 0 && def.Exe == "" && len(def.Units) == 0 {
+			return nil, fmt.Errorf("service %s has no comm, exe or units to match", def.Name)
+		}
+		for _, pattern := range append(append([]string{}, def.Comm...), def.Units...) {
+			if _, err := path.Match(pattern, ""); err != nil {
+				return nil, fmt.Errorf("service %s: bad pattern %q: %v", def.Name, pattern, err)
+			}
+		}
+		if def.Exe != "" {
+			re, err := regexp.Compile(def.Exe)
+			if err != nil {
+				return nil, fmt.Errorf("service %s: bad exe expression: %v", def.Name, err)
+			}
+			def.exe = re
+		}
+	}
+	return defs, nil
+}
+
+// match returns why a process matches the definition, or "" if it does not
+func (def *ServiceDefinition) match(comm, exe, unit string) string {
+	for _, pattern := range def.Comm {
+		if ok, _ := path.Match(pattern, comm); ok {
+			return fmt.Sprintf("comm %q matches %q", comm, pattern)
+		}
+	}
+	if def.exe != nil && exe != "" && def.exe.MatchString(exe) {
+		return fmt.Sprintf("exe %s matches %q", exe, def.Exe)
+	}
+	if unit != "" {
+		for _, pattern := range def.Units {
+			if ok, _ := path.Match(pattern, unit); ok {
+				return fmt.Sprintf("systemd unit %s matches %q", unit, pattern)
+			}
+		}
+	}
+	return ""
+}
+
+// DetectServices attributes memory to other services using the built-in
+// registry. See DetectServicesWith.
+func DetectServices(sysInfo *SystemInfo, apachePIDs map[int]bool) {
+	registry, err := LoadServiceRegistry("")
+	if err != nil {
+		debug.Error(err, "loading service registry")
+		return
+	}
+	DetectServicesWith(sysInfo, registry, apachePIDs)
+}
+
+// DetectServicesWith scans every process once, attributes each to the first
+// matching service in the registry and sums their memory (PSS, so shared
+// pages of multi-process services are not counted repeatedly). The Apache
+// processes in apachePIDs are never charged to another service, whatever
+// their command is called.
+func DetectServicesWith(sysInfo *SystemInfo, registry *ServiceRegistry, apachePIDs map[int]bool) {
+	defer debug.Trace("system.DetectServices")()
+
+	matches := matchServices("/proc", registry, apachePIDs, func(pid int) (float64, string) {
+		proc := process.MeasurePID(pid)
+		if proc.PSSMB > 0 {
+			return proc.PSSMB, proc.Method
+		}
+		return proc.MemoryMB, proc.Method
+	})
+
+	totals := make(map[string]float64)
+	for _, match := range matches {
+		totals[match.Service] += match.MemoryMB
+		debug.Printf("Service %s: PID %d (%s) %.1f MB via %s", match.Service, match.PID, match.Reason, match.MemoryMB, match.Method)
+	}
+	for service, total := range totals {
+		if total > 0 {
+			sysInfo.OtherServices[service] = int(total)
+		}
+	}
+	sysInfo.ServiceMatches = matches
+}
+
+// matchServices walks procRoot and returns the processes claimed by a
+// service, ordered by service and PID. The Apache processes in apachePIDs
+// and apache2buddy itself are never attributed to another service.
+func matchServices(procRoot string, registry *ServiceRegistry, apachePIDs map[int]bool, measure func(pid int) (float64, string)) []ServiceMatch {
+	entries, err := os.ReadDir(procRoot)
+	if err != nil {
+		debug.Error(err, "reading "+procRoot)
+		return nil
+	}
+
+	self := os.Getpid()
+	var matches []ServiceMatch
+	for _, entry := range entries {
+		pid, err := strconv.Atoi(entry.Name())
+		if err != nil || pid == self || apachePIDs[pid] {
+			continue
+		}
+		pidDir := filepath.Join(procRoot, entry.Name())
+
+		data, err := os.ReadFile(filepath.Join(pidDir, "comm"))
+		if err != nil {
+			continue
+		}
+		comm := strings.TrimSpace(string(data))
+		exe, _ := os.Readlink(filepath.Join(pidDir, "exe"))
+		if exe == "" {
+			// Kernel threads have no executable
+			continue
+		}
+		var unit string
+		if data, err := os.ReadFile(filepath.Join(pidDir, "cgroup")); err == nil {
+			unit = systemdUnit(string(data))
+		}
+
+		for i := range registry.Services {
+			def := &registry.Services[i]
+			reason := def.match(comm, exe, unit)
+			if reason == "" {
+				continue
+			}
+			memoryMB, method := measure(pid)
+			matches = append(matches, ServiceMatch{
+				Service:  def.Name,
+				PID:      pid,
+				Comm:     comm,
+				Reason:   reason,
+				MemoryMB: memoryMB,
+				Method:   method,
+			})
+			break
+		}
+	}
+
+	sort.Slice(matches, func(i, j int) bool {
+		if matches[i].Service != matches[j].Service {
+			return matches[i].Service < matches[j].Service
+		}
+		return matches[i].PID < matches[j].PID
+	})
+	return matches
+}
+
+// systemdUnit returns the systemd service unit from /proc/PID/cgroup content,
+// e.g. "mysql.service" for "0::/system.slice/mysql.service"
+func systemdUnit(cgroup string) string {
+	for _, line := range strings.Split(cgroup, "\n") {
+		parts := strings.SplitN(line, ":", 3)
+		if len(parts) != 3 {
+			continue
+		}
+		elements := strings.Split(parts[2], "/")
+		for i := len(elements) - 1; i >= 0; i-- {
+			if strings.HasSuffix(elements[i], ".service") {
+				return elements[i]
+			}
+		}
+	}
+	return ""
+}
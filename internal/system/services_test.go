@@ -0,0 +1,185 @@
+package system
+
+import (
+	"os"
+	"path/filepath"
+	"strconv"
+	"testing"
+)
+
+func TestLoadServiceRegistry(t *testing.T) {
+	t.Run("built-in only", func(t *testing.T) {
+		registry, err := LoadServiceRegistry(filepath.Join(t.TempDir(), "missing.json"))
+		if err != nil {
+			t.Fatalf("LoadServiceRegistry() error = %v", err)
+		}
+		names := make(map[string]bool)
+		for _, def := range registry.Services {
+			names[def.Name] = true
+		}
+		for _, name := range []string{"MySQL", "PHP-FPM", "Elasticsearch", "OpenSearch", "RabbitMQ", "Tomcat", "Node.js", "Java"} {
+			if !names[name] {
+				t.Errorf("built-in registry is missing %s", name)
+			}
+		}
+	})
+
+	t.Run("user entries override and come first", func(t *testing.T) {
+		userPath := filepath.Join(t.TempDir(), "services.json")
+		content := `[
+			{"name": "Solr", "exe": "/solr/"},
+			{"name": "MySQL", "comm": ["mysqld-custom"]}
+		]`
+		if err := os.WriteFile(userPath, []byte(content), 0644); err != nil {
+			t.Fatal(err)
+		}
+
+		registry, err := LoadServiceRegistry(userPath)
+		if err != nil {
+			t.Fatalf("LoadServiceRegistry() error = %v", err)
+		}
+		if registry.Services[0].Name != "Solr" || registry.Services[1].Name != "MySQL" {
+			t.Errorf("user entries should be matched first, got %s, %s", registry.Services[0].Name, registry.Services[1].Name)
+		}
+		mysql := 0
+		for _, def := range registry.Services {
+			if def.Name == "MySQL" {
+				mysql++
+			}
+		}
+		if mysql != 1 {
+			t.Errorf("MySQL defined %d times, want the user entry only", mysql)
+		}
+	})
+
+	t.Run("invalid user file", func(t *testing.T) {
+		tests := map[string]string{
+			"not json":       `{`,
+			"missing name":   `[{"comm": ["x"]}]`,
+			"no criteria":    `[{"name": "X"}]`,
+			"bad expression": `[{"name": "X", "exe": "("}]`,
+			"bad glob":       `[{"name": "X", "comm": ["["]}]`,
+		}
+		for name, content := range tests {
+			userPath := filepath.Join(t.TempDir(), "services.json")
+			if err := os.WriteFile(userPath, []byte(content), 0644); err != nil {
+				t.Fatal(err)
+			}
+			registry, err := LoadServiceRegistry(userPath)
+			if err == nil {
+				t.Errorf("%s: expected an error", name)
+			}
+			if registry == nil {
+				t.Errorf("%s: the built-in registry should still be returned", name)
+			}
+		}
+	})
+}
+
+func TestMatchServices(t *testing.T) {
+	procRoot := t.TempDir()
+	procs := []struct {
+		pid    int
+		comm   string
+		exe    string
+		cgroup string
+	}{
+		{100, "mysqld", "/usr/sbin/mysqld", "0::/system.slice/mysql.service"},
+		{200, "php-fpm8.3", "/usr/sbin/php-fpm8.3", "0::/system.slice/php8.3-fpm.service"},
+		{201, "php-fpm8.3", "/usr/sbin/php-fpm8.3", "0::/system.slice/php8.3-fpm.service"},
+		{300, "java", "/usr/share/elasticsearch/jdk/bin/java", "0::/system.slice/elasticsearch.service"},
+		{400, "java", "/usr/lib/jvm/java-17/bin/java", "0::/system.slice/tomcat10.service"},
+		{500, "java", "/usr/lib/jvm/java-17/bin/java", "0::/user.slice"},
+		{600, "beam.smp", "/usr/lib/erlang/erts-13/bin/beam.smp", "0::/system.slice/rabbitmq-server.service"},
+		{700, "apache2", "/usr/sbin/apache2", "0::/system.slice/apache2.service"},
+		{800, "sshd", "/usr/sbin/sshd", "0::/system.slice/ssh.service"},
+		{900, "kworker/0:1", "", ""},
+	}
+	for _, proc := range procs {
+		dir := filepath.Join(procRoot, strconv.Itoa(proc.pid))
+		writeFiles(t, dir, map[string]string{
+			"comm":   proc.comm + "\n",
+			"cgroup": proc.cgroup + "\n",
+		})
+		if proc.exe != "" {
+			if err := os.Symlink(proc.exe, filepath.Join(dir, "exe")); err != nil {
+				t.Fatal(err)
+			}
+		}
+	}
+
+	registry, err := LoadServiceRegistry("")
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := matchServices(procRoot, registry, map[int]bool{700: true}, func(pid int) (float64, string) {
+		return float64(pid) / 10, "smaps"
+	})
+
+	want := map[int]string{
+		100: "MySQL",
+		200: "PHP-FPM",
+		201: "PHP-FPM",
+		300: "Elasticsearch",
+		400: "Tomcat",
+		500: "Java",
+		600: "RabbitMQ",
+	}
+	if len(matches) != len(want) {
+		t.Errorf("got %d matches, want %d: %+v", len(matches), len(want), matches)
+	}
+	for _, match := range matches {
+		if want[match.PID] != match.Service {
+			t.Errorf("PID %d matched %s, want %s", match.PID, match.Service, want[match.PID])
+		}
+		if match.Reason == "" {
+			t.Errorf("PID %d has no match reason", match.PID)
+		}
+		if match.MemoryMB != float64(match.PID)/10 {
+			t.Errorf("PID %d memory = %.1f, want %.1f", match.PID, match.MemoryMB, float64(match.PID)/10)
+		}
+	}
+	if len(matches) > 0 && matches[0].Service != "Elasticsearch" {
+		t.Errorf("matches should be sorted by service, first is %s", matches[0].Service)
+	}
+}
+
+func TestMatchServices_ApacheByPID(t *testing.T) {
+	procRoot := t.TempDir()
+	for pid, comm := range map[int]string{700: "httpd.worker", 701: "httpd-prefork", 800: "nginx"} {
+		dir := filepath.Join(procRoot, strconv.Itoa(pid))
+		writeFiles(t, dir, map[string]string{
+			"comm":   comm + "\n",
+			"cgroup": "0::/system.slice/web.service\n",
+		})
+		if err := os.Symlink("/usr/sbin/"+comm, filepath.Join(dir, "exe")); err != nil {
+			t.Fatal(err)
+		}
+	}
+
+	defs, err := parseServiceDefinitions([]byte(`[{"name": "Web", "units": ["web.service"]}]`))
+	if err != nil {
+		t.Fatal(err)
+	}
+	matches := matchServices(procRoot, &ServiceRegistry{Services: defs}, map[int]bool{700: true, 701: true}, func(pid int) (float64, string) {
+		return 10, "smaps"
+	})
+	if len(matches) != 1 || matches[0].PID != 800 {
+		t.Errorf("matches = %+v, want only the non-Apache PID 800", matches)
+	}
+}
+
+func TestSystemdUnit(t *testing.T) {
+	tests := map[string]string{
+		"0::/system.slice/mysql.service\n":                       "mysql.service",
+		"1:name=systemd:/system.slice/postfix@-.service\n0::/\n": "postfix@-.service",
+		"0::/system.slice/php8.3-fpm.service/pool-www\n":         "php8.3-fpm.service",
+		"0::/user.slice/user-1000.slice/session-2.scope\n":       "",
+		"0::/system.slice/docker-0123456789abcdef.scope\n":       "",
+	}
+	for cgroup, want := range tests {
+		if got := systemdUnit(cgroup); got != want {
+			t.Errorf("systemdUnit(%q) = %q, want %q", cgroup, got, want)
+		}
+	}
+}
@@ -0,0 +1,107 @@
+package system
+
+import (
+	"bufio"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"apache2buddy-go/internal/debug"
+)
+
+// SwapInfo describes swap and the kernel's memory overcommit policy
+type SwapInfo struct {
+	TotalMB int
+	FreeMB  int
+
+	Swappiness       int // vm.swappiness
+	OvercommitMemory int // vm.overcommit_memory: 0 heuristic, 1 always, 2 strict
+	OvercommitRatio  int // vm.overcommit_ratio, used when OvercommitMemory is 2
+	CommitLimitMB    int // CommitLimit from /proc/meminfo
+	CommittedMB      int // Committed_AS from /proc/meminfo
+}
+
+// UsedMB returns the swap currently in use
+func (s *SwapInfo) UsedMB() int {
+	return s.TotalMB - s.FreeMB
+}
+
+// OvercommitPolicy describes vm.overcommit_memory in words
+func (s *SwapInfo) OvercommitPolicy() string {
+	switch s.OvercommitMemory {
+	case 0:
+		return "heuristic"
+	case 1:
+		return "always"
+	case 2:
+		return fmt.Sprintf("strict, ratio %d%%", s.OvercommitRatio)
+	default:
+		return strconv.Itoa(s.OvercommitMemory)
+	}
+}
+
+// ReadSwapInfo reads swap usage from /proc/meminfo and the vm sysctls
+func ReadSwapInfo() (*SwapInfo, error) {
+	defer debug.Trace("system.ReadSwapInfo")()
+	return readSwapInfo("/proc")
+}
+
+func readSwapInfo(procRoot string) (*SwapInfo, error) {
+	file, err := os.Open(filepath.Join(procRoot, "meminfo"))
+	if err != nil {
+		return nil, fmt.Errorf("cannot read meminfo: %v", err)
+	}
+	defer func() {
+		if err := file.Close(); err != nil {
+			debug.Error(err, "closing meminfo")
+		}
+	}()
+
+	info := &SwapInfo{}
+	scanner := bufio.NewScanner(file)
+	for scanner.Scan() {
+		fields := strings.Fields(scanner.Text())
+		if len(fields) < 2 {
+			continue
+		}
+		kb, err := strconv.Atoi(fields[1])
+		if err != nil {
+			continue
+		}
+		switch fields[0] {
+		case "SwapTotal:":
+			info.TotalMB = kb / 1024
+		case "SwapFree:":
+			info.FreeMB = kb / 1024
+		case "CommitLimit:":
+			info.CommitLimitMB = kb / 1024
+		case "Committed_AS:":
+			info.CommittedMB = kb / 1024
+		}
+	}
+	if err := scanner.Err(); err != nil {
+		return nil, err
+	}
+
+	// Defaults of the running kernel apply when a sysctl cannot be read
+	info.Swappiness = readSysctl(procRoot, "vm/swappiness", 60)
+	info.OvercommitMemory = readSysctl(procRoot, "vm/overcommit_memory", 0)
+	info.OvercommitRatio = readSysctl(procRoot, "vm/overcommit_ratio", 50)
+	return info, nil
+}
+
+// readSysctl reads an integer from procRoot/sys/name, or returns fallback
+func readSysctl(procRoot, name string, fallback int) int {
+	data, err := os.ReadFile(filepath.Join(procRoot, "sys", name))
+	if err != nil {
+		debug.Warn("Could not read sysctl %s: %v", name, err)
+		return fallback
+	}
+	value, err := strconv.Atoi(strings.TrimSpace(string(data)))
+	if err != nil {
+		return fallback
+	}
+	return value
+}
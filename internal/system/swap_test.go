@@ -0,0 +1,48 @@
+package system
+
+import "testing"
+
+func TestReadSwapInfo(t *testing.T) {
+	root := t.TempDir()
+	writeFiles(t, root, map[string]string{
+		"meminfo": `MemTotal:        4028416 kB
+MemFree:          204800 kB
+SwapTotal:       2097148 kB
+SwapFree:        1572860 kB
+CommitLimit:     4111356 kB
+Committed_AS:    5242880 kB
+`,
+		"sys/vm/swappiness":        "10\n",
+		"sys/vm/overcommit_memory": "2\n",
+		"sys/vm/overcommit_ratio":  "80\n",
+	})
+
+	info, err := readSwapInfo(root)
+	if err != nil {
+		t.Fatalf("readSwapInfo() error = %v", err)
+	}
+	if info.TotalMB != 2047 || info.FreeMB != 1535 || info.UsedMB() != 512 {
+		t.Errorf("swap = %d total, %d free, %d used; want 2047, 1535, 512", info.TotalMB, info.FreeMB, info.UsedMB())
+	}
+	if info.CommitLimitMB != 4014 || info.CommittedMB != 5120 {
+		t.Errorf("commit = %d/%d, want 5120/4014", info.CommittedMB, info.CommitLimitMB)
+	}
+	if info.Swappiness != 10 || info.OvercommitPolicy() != "strict, ratio 80%" {
+		t.Errorf("swappiness %d, overcommit %q", info.Swappiness, info.OvercommitPolicy())
+	}
+
+	// Missing sysctls fall back to the kernel defaults
+	bare := t.TempDir()
+	writeFiles(t, bare, map[string]string{"meminfo": "SwapTotal: 0 kB\nSwapFree: 0 kB\n"})
+	info, err = readSwapInfo(bare)
+	if err != nil {
+		t.Fatalf("readSwapInfo() error = %v", err)
+	}
+	if info.Swappiness != 60 || info.OvercommitPolicy() != "heuristic" {
+		t.Errorf("defaults: swappiness %d, overcommit %q", info.Swappiness, info.OvercommitPolicy())
+	}
+
+	if _, err := readSwapInfo(t.TempDir()); err == nil {
+		t.Error("readSwapInfo() without meminfo should fail")
+	}
+}
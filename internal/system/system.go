@@ -15,6 +15,25 @@ type SystemInfo struct {
 	TotalMemoryMB     int
 	AvailableMemoryMB int
 	OtherServices     map[string]int // service name -> memory MB
+	ServiceMatches    []ServiceMatch // processes attributed to OtherServices (see DetectServicesWith)
+	ServiceNotes      []ServiceNote  // advisories about detected services
+	ServicePlans      []ServicePlan  // configured footprints (see PlanServiceMemory)
+	ServiceAccounting string         // AccountingCurrent or AccountingPlanned
+	PHPFPMPools       []PHPFPMPool   // pools of the running PHP-FPM (see DetectPHPFPMPools)
+
+	// Memory ceiling (see ApplyCgroupLimit)
+	HostMemoryMB      int    // Physical RAM of the host, before any cgroup limit
+	MemoryLimitSource string // Which limit is binding: "host RAM" or the cgroup file
+	CgroupUsageMB     int    // Current usage of the Apache cgroup
+	CgroupPath        string // Memory cgroup of the Apache master, "" when unknown
+
+	// Memory Apache may use (see MemoryBudget); AvailableMemoryMB holds its AvailableMB
+	Budget         *MemoryBudget
+	MemAvailableMB int // MemAvailable from /proc/meminfo, for reference only
+
+	// Evidence of memory pressure (nil when not read)
+	Swap *SwapInfo
+	OOM  *OOMHistory
 }
 
 func CheckRequiredCommands() error {
@@ -74,103 +93,19 @@ func GetInfo() (*SystemInfo, error) {
 	return &SystemInfo{
 		TotalMemoryMB:     totalKB / 1024,
 		AvailableMemoryMB: availableKB / 1024,
+		MemAvailableMB:    availableKB / 1024,
 		OtherServices:     make(map[string]int),
+		HostMemoryMB:      totalKB / 1024,
+		MemoryLimitSource: "host RAM",
 	}, nil
 }
 
-func DetectServices(sysInfo *SystemInfo) {
-	services := map[string][]string{
-		"MySQL":     {"mysqld", "mariadb"},
-		"Redis":     {"redis-server"},
-		"Memcached": {"memcached"},
-		"PHP-FPM":   {"php-fpm", "php5-fpm", "php7.0-fpm", "php7.4-fpm", "php8.0-fpm", "php8.1-fpm", "php8.2-fpm"},
-		"Nginx":     {"nginx"},
-		"Varnish":   {"varnishd"},
-		"Java":      {"java"},
-		"Postfix":   {"postfix", "master"},
-	}
-
-	for serviceName, processNames := range services {
-		totalMemory := 0.0
-		for _, procName := range processNames {
-			if memory, err := getServiceMemory(procName); err == nil {
-				totalMemory += memory
-			}
-		}
-		if totalMemory > 0 {
-			sysInfo.OtherServices[serviceName] = int(totalMemory)
-		}
-	}
-}
-
-func getServiceMemory(serviceName string) (float64, error) {
-	cmd := exec.Command("ps", "-C", serviceName, "-o", "rss=", "--no-headers")
-	output, err := cmd.Output()
-	if err != nil {
-		return 0, err
-	}
-
-	lines := strings.Split(strings.TrimSpace(string(output)), "\n")
-	if len(lines) == 0 || (len(lines) == 1 && lines[0] == "") {
-		return 0, fmt.Errorf("no processes found")
-	}
-
-	var totalMemoryKB float64
-	for _, line := range lines {
-		line = strings.TrimSpace(line)
-		if line == "" {
-			continue
-		}
-		if memKB, err := strconv.ParseFloat(line, 64); err == nil {
-			totalMemoryKB += memKB
-		}
-	}
-
-	return totalMemoryKB / 1024, nil // Convert to MB
-}
-
-func DetectControlPanels() string {
-	controlPanels := map[string]string{
-		"/usr/local/cpanel":      "cPanel",
-		"/usr/local/psa":         "Plesk",
-		"/etc/webmin":            "Webmin",
-		"/usr/local/directadmin": "DirectAdmin",
-	}
-
-	for path, name := range controlPanels {
-		if _, err := os.Stat(path); err == nil {
-			return name
-		}
-	}
-
-	return ""
-}
-
-func DetectPHPFPM(sysInfo *SystemInfo, mpmModel string) {
-	// Enhanced PHP-FPM detection with MPM-specific warnings
-	phpfpmMemory := 0.0
-	phpfpmProcesses := []string{"php-fpm", "php5-fpm", "php7.0-fpm", "php7.4-fpm", "php8.0-fpm", "php8.1-fpm", "php8.2-fpm"}
-
-	for _, procName := range phpfpmProcesses {
-		if memory, err := getServiceMemory(procName); err == nil {
-			phpfpmMemory += memory
-		}
-	}
-
-	if phpfpmMemory > 0 {
-		sysInfo.OtherServices["PHP-FPM"] = int(phpfpmMemory)
-
-		// Add special note for worker/event MPM
-		if mpmModel == "worker" || mpmModel == "event" {
-			sysInfo.OtherServices["PHP-FPM-Note"] = -1 // Special marker for display
-		}
-	}
-}
-
+// GetTotalOtherServicesMemory sums the memory charged to other services.
+// Only positive entries count; a service is never charged below zero.
 func GetTotalOtherServicesMemory(sysInfo *SystemInfo) int {
 	total := 0
-	for service, memory := range sysInfo.OtherServices {
-		if service != "PHP-FPM-Note" { // Skip special markers
+	for _, memory := range sysInfo.OtherServices {
+		if memory > 0 {
 			total += memory
 		}
 	}
@@ -69,19 +69,6 @@ func TestSystemInfo_TotalOtherServicesMemory(t *testing.T) {
 	}
 }
 
-func TestDetectControlPanels(t *testing.T) {
-	// This test is environment-dependent, so we mainly test the function exists
-	// and returns a string (empty or with a control panel name)
-	result := DetectControlPanels()
-
-	// Should return a string (could be empty if no control panels are detected)
-	if result != "" {
-		t.Logf("Control panel detected: %s", result)
-	} else {
-		t.Log("No control panel detected")
-	}
-}
-
 func TestSystemInfoValidation(t *testing.T) {
 	tests := []struct {
 		name    string
@@ -209,10 +196,3 @@ func BenchmarkGetTotalOtherServicesMemory(b *testing.B) {
 		GetTotalOtherServicesMemory(sysInfo)
 	}
 }
-
-func BenchmarkDetectControlPanels(b *testing.B) {
-	b.ResetTimer()
-	for i := 0; i < b.N; i++ {
-		DetectControlPanels()
-	}
-}
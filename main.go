@@ -6,10 +6,12 @@ import (
 	"log"
 	"os"
 	"strings"
+	"time"
 
 	"apache2buddy-go/internal/analysis"
 	"apache2buddy-go/internal/config"
 	"apache2buddy-go/internal/debug"
+	"apache2buddy-go/internal/findings"
 	"apache2buddy-go/internal/logs"
 	"apache2buddy-go/internal/output"
 	"apache2buddy-go/internal/process"
@@ -24,6 +26,31 @@ func main() {
 		helpFlag    = flag.Bool("help", false, "Show help information")
 		versionFlag = flag.Bool("version", false, "Show version information")
 		historyFlag = flag.Int("history", 0, "Show last N entries from apache2buddy log file")
+
+		samplesFlag        = flag.Int("samples", 0, "Sample worker memory N times to detect growing workers")
+		sampleIntervalFlag = flag.Duration("sample-interval", 10*time.Second, "Time between memory samples")
+		leakThresholdFlag  = flag.Float64("leak-threshold", 10, "Growth in MB/hour above which a worker is flagged")
+
+		servicesFlag    = flag.String("services", system.DefaultServicesFile, "JSON file with additional service definitions")
+		oomWindowFlag   = flag.Duration("oom-window", system.DefaultOOMWindow, "How far back to look for OOM-killer events")
+		oomLogFlag      = flag.String("oom-log", "", "Extra kernel log to search for OOM kills (e.g. saved journalctl -k output)")
+		policyFlag      = flag.String("policy", analysis.DefaultPolicyFile, "JSON file with the sizing policy")
+		reserveFlag     = flag.String("reserve", "", "Memory kept free for the OS: MB, a percentage of RAM, or both (e.g. 512M,10%)")
+		marginFlag      = flag.String("margin", "", "Safety margin band in percent of the budget (default 90-100)")
+		sizeOnFlag      = flag.String("size-on", "", "Worker statistic to size on: largest, p99, p95, p90, median, average, pss or history (default largest)")
+		historyDaysFlag = flag.Int("history-days", analysis.DefaultHistoryDays, "Days of past runs to take worker size and concurrency peaks from (0 ignores the history)")
+		accountingFlag  = flag.String("service-accounting", system.AccountingCurrent, "Charge other services their current or planned memory (current, planned)")
+
+		suppressionsFlag = flag.String("suppressions", findings.DefaultSuppressionFile, "JSON file with acknowledged finding IDs")
+
+		simMemoryFlag   = flag.String("sim-memory", "", "Simulate host RAM, e.g. 16G")
+		simMPMFlag      = flag.String("sim-mpm", "", "Simulate an MPM: prefork, worker or event")
+		simThreadsFlag  = flag.Int("sim-threads", 0, "Simulate ThreadsPerChild")
+		simExcludeFlag  = flag.String("sim-exclude", "", "Simulate moving services off the box, e.g. MySQL,Redis")
+		simWorkerMBFlag = flag.Float64("sim-worker-mb", 0, "Simulate the memory per Apache process in MB")
+
+		planConcurrencyFlag = flag.Int("plan-concurrency", 0, "Plan the RAM needed for N concurrent requests")
+		planRateFlag        = flag.Float64("plan-rate", 0, "Plan the RAM needed for N requests per second at the measured duration")
 	)
 	flag.Parse()
 
@@ -51,6 +78,25 @@ func main() {
 		debug.DumpSystemInfo()
 	}
 
+	policy, err := loadPolicy(*policyFlag, *reserveFlag, *marginFlag, *sizeOnFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+	debug.Info("Sizing policy: %s", policy)
+
+	sim, err := simulation(*simMemoryFlag, *simMPMFlag, *simThreadsFlag, *simExcludeFlag, *simWorkerMBFlag)
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	// Like an invalid service registry, an invalid suppression file is
+	// reported and ignored
+	suppressions, err := findings.LoadSuppressions(*suppressionsFlag)
+	if err != nil {
+		debug.Warn("Ignoring suppressions: %v", err)
+		fmt.Printf("Warning: %v\n", err)
+	}
+
 	fmt.Println("Apache2Buddy Go")
 	fmt.Println("==================================")
 
@@ -84,56 +130,189 @@ func main() {
 		debug.Error(err, "system info")
 		log.Fatalf("Failed to get system info: %v", err)
 	}
+	if sysInfo.Swap, err = system.ReadSwapInfo(); err != nil {
+		debug.Warn("Could not read swap info: %v", err)
+	}
+	sysInfo.OOM = system.ReadOOMHistory(*oomWindowFlag, *oomLogFlag)
 	sysTimer.Stop()
 	debug.DumpStruct("SystemInfo", sysInfo)
 
+	// Find Apache processes
+	debug.Section("FINDING APACHE PROCESSES")
+	processTimer := debug.StartTimer("Process Discovery")
+	processes, err := process.FindApacheProcesses()
+	if err != nil {
+		debug.Error(err, "process discovery")
+		log.Fatalf("Failed to find Apache processes: %v", err)
+	}
+	processTimer.Stop()
+
+	if len(processes) == 0 {
+		debug.Error(fmt.Errorf("no processes found"), "process discovery")
+		log.Fatal("No Apache worker processes found. Is Apache running?")
+	}
+
+	debug.Info("Found %d Apache worker processes", len(processes))
+
+	if *samplesFlag > 1 {
+		fmt.Printf("Sampling worker memory %d times, %s apart...\n", *samplesFlag, *sampleIntervalFlag)
+		sampleTimer := debug.StartTimer("Memory Sampling")
+		process.SampleMemory(processes, *samplesFlag, *sampleIntervalFlag)
+		sampleTimer.Stop()
+	}
+	debug.DumpSlice("ApacheProcesses", processes)
+
+	instances := process.GroupByMaster(processes)
+	debug.Info("Found %d Apache instance(s)", len(instances))
+
 	// Parse Apache configuration with enhanced version detection
 	debug.Section("PARSING APACHE CONFIGURATION")
 	configTimer := debug.StartTimer("Config Parse")
-	apacheConfig, err := config.ParseWithVersion()
-	if err != nil {
-		debug.Warn("Could not parse Apache config: %v", err)
-		// Only show warning in debug mode, not in normal output
-		if !debug.IsEnabled() {
-			fmt.Printf("Warning: Could not parse Apache config, using defaults\n")
+	configs := make([]*config.ApacheConfig, len(instances))
+	for i, inst := range instances {
+		apacheConfig, err := config.ParseInstanceInRoot(inst.RootPath, inst.ConfigPath, inst.ServerRoot, inst.Container)
+		if err != nil {
+			debug.Warn("Could not parse Apache config for instance %s: %v", inst.ID(), err)
+			// Only show warning in debug mode, not in normal output
+			if !debug.IsEnabled() {
+				fmt.Printf("Warning: Could not parse Apache config, using defaults\n")
+			}
+			apacheConfig = config.GetDefaults()
+			apacheConfig.RootPath = inst.RootPath
+			apacheConfig.Container = inst.Container
+			debug.Info("Using default Apache configuration")
 		}
-		apacheConfig = config.GetDefaults()
-		debug.Info("Using default Apache configuration")
+		apacheConfig.InstanceID = inst.Key(apacheConfig.ConfigPath, apacheConfig.ServerRoot)
+		configs[i] = apacheConfig
+		debug.DumpStruct("ApacheConfig", apacheConfig)
 	}
 	configTimer.Stop()
-	debug.DumpStruct("ApacheConfig", apacheConfig)
-
-	// Check for control panels
-	debug.Info("Checking for control panels")
-	if controlPanel := system.DetectControlPanels(); controlPanel != "" {
-		fmt.Printf("⚠️  Control Panel Detected: %s - Be careful modifying config files manually!\n", controlPanel)
-		debug.Info("Control panel detected: %s", controlPanel)
-	} else {
-		debug.Info("No control panel detected")
-	}
 
 	// Detect additional services
 	debug.Section("DETECTING SERVICES")
 	serviceTimer := debug.StartTimer("Service Detection")
-	system.DetectServices(sysInfo)
-	system.DetectPHPFPM(sysInfo, apacheConfig.MPMModel) // Enhanced PHP-FPM detection
+	registry, err := system.LoadServiceRegistry(*servicesFlag)
+	if err != nil {
+		debug.Warn("Ignoring service registry: %v", err)
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if registry != nil {
+		system.DetectServicesWith(sysInfo, registry, process.PIDs(instances))
+	}
+	system.PlanServiceMemory(sysInfo)
+	if err := system.ApplyServiceAccounting(sysInfo, *accountingFlag); err != nil {
+		log.Fatal(err)
+	}
+	system.DetectPHPFPM(sysInfo, threadedMPM(configs)) // Enhanced PHP-FPM detection
+	phpfpmPools := system.DetectPHPFPMPools(sysInfo)
+	sysInfo.PHPFPMPools = phpfpmPools
 	serviceTimer.Stop()
 	debug.DumpMap("DetectedServices", sysInfo.OtherServices)
 
-	totalOtherMemory := system.GetTotalOtherServicesMemory(sysInfo)
-	if totalOtherMemory > 0 {
-		// Only show this in debug mode or if significant
-		if debug.IsEnabled() || totalOtherMemory > 100 {
-			fmt.Printf("Other services using %d MB RAM\n", totalOtherMemory)
+	// Budget Apache from total RAM rather than MemAvailable, which already
+	// excludes what Apache and other services use and varies with load
+	hostBudget := system.HostMemoryBudget(sysInfo, policy.Reserve)
+	sysInfo.AvailableMemoryMB = hostBudget.AvailableMB
+	debug.Info("Memory budget for Apache: %d MB", sysInfo.AvailableMemoryMB)
+
+	// Check Apache logs for issues
+	debug.Section("ANALYZING APACHE LOGS")
+	logTimer := debug.StartTimer("Log Analysis")
+	logAnalysis := logs.AnalyzeApacheLogs()
+	logTimer.Stop()
+	debug.DumpStruct("LogAnalysis", logAnalysis)
+
+	// Past runs, read before this run adds to them
+	history, err := logs.ReadHistory(logs.HistoryFile)
+	if err != nil {
+		debug.Warn("Ignoring history: %v", err)
+	}
+	logs.AdoptLegacyHistory(history, configs)
+
+	// Split the memory left for Apache between instances by their current usage
+	weights := make([]float64, len(instances))
+	for i, inst := range instances {
+		weights[i] = analysis.CalculateMemoryStats(inst.Workers).TotalMB
+	}
+	shares := analysis.SplitAvailableMemory(sysInfo.AvailableMemoryMB, weights)
+
+	exitCode := 0
+	apacheMB := 0
+	var cgroupPaths []string
+	for i, inst := range instances {
+		instanceInfo := *sysInfo
+		cg := applyInstanceCgroup(&instanceInfo, inst)
+		cgroupPaths = append(cgroupPaths, instanceInfo.CgroupPath)
+		instanceInfo.Budget = hostBudget.ForInstance(shares[i], int(weights[i]), cg)
+		instanceInfo.AvailableMemoryMB = instanceInfo.Budget.AvailableMB
+		recommendations, recommendedMB := analyzeInstance(i+1, len(instances), inst, configs[i], &instanceInfo, sysInfo.AvailableMemoryMB, logAnalysis, *leakThresholdFlag, policy, suppressions, sim,
+			analysis.PlanTarget{Concurrency: *planConcurrencyFlag, RatePerSec: *planRateFlag}, logs.HistoryPeaks(history, configs[i], *historyDaysFlag, time.Now()))
+		apacheMB += recommendedMB
+		if code := findings.ExitCode(recommendations.Findings); code > exitCode {
+			exitCode = code
+		}
+	}
+
+	// OOM kills of Apache that no instance's cgroup claims are reported once
+	// for the host rather than by every instance
+	if sysInfo.OOM != nil {
+		facts := findings.NewFacts()
+		findings.Put(facts, analysis.HostOOMKills{Kills: sysInfo.OOM.UnattributedApacheKills(cgroupPaths), Window: sysInfo.OOM.Window})
+		findings.Put(facts, suppressions)
+		hostFindings := findings.Evaluate(facts)
+		output.DisplayHostFindings(hostFindings)
+		if code := findings.ExitCode(hostFindings); code > exitCode {
+			exitCode = code
+		}
+	}
+
+	// PHP-FPM pools share the memory left after other services with Apache:
+	// the memory PHP-FPM already uses is added back, Apache's recommended
+	// footprint is taken out. The pools' findings count towards the exit code
+	// like an instance's.
+	if len(phpfpmPools) > 0 {
+		budget := analysis.SplitPHPFPMBudget(sysInfo.AvailableMemoryMB+sysInfo.OtherServices["PHP-FPM"], apacheMB)
+		pools := analysis.RecommendPHPFPMPools(phpfpmPools, budget.PHPFPMMB, policy)
+		facts := findings.NewFacts()
+		findings.Put(facts, pools)
+		findings.Put(facts, suppressions)
+		poolFindings := findings.Evaluate(facts)
+		output.DisplayPHPFPMPools(pools, budget, poolFindings)
+		if code := findings.ExitCode(poolFindings); code > exitCode {
+			exitCode = code
 		}
-		sysInfo.AvailableMemoryMB -= totalOtherMemory
-		debug.Info("Adjusted available memory after services: %d MB", sysInfo.AvailableMemoryMB)
 	}
 
-	// Get Apache status information (mod_status)
+	// Exit with status code based on the worst instance
+	debug.Info("Exiting with code %d", exitCode)
+	os.Exit(exitCode)
+}
+
+// analyzeInstance runs the memory analysis and report for one Apache instance.
+// sysInfo must already carry the instance's share of the available memory.
+// Workers growing faster than leakThreshold MB/hour across samples are
+// sized at their projected peak; otherwise policy decides the worker size.
+// Findings named in suppressions are acknowledged rather than counted. An
+// active sim is run after the report and shown next to it, followed by a
+// capacity plan when target is set. The peaks of past runs in history
+// provide the history sizing and keep a quiet run from raising
+// MaxRequestWorkers. Demand is sized from the access logs the instance's
+// configuration names. It returns the memory the recommended MaxRequestWorkers
+// use along with the recommendations.
+func analyzeInstance(index, count int, inst process.Instance, apacheConfig *config.ApacheConfig, sysInfo *system.SystemInfo, totalAvailableMB int, logAnalysis *logs.LogAnalysis, leakThreshold float64, policy analysis.Policy, suppressions findings.Suppressions, sim analysis.Simulation, target analysis.PlanTarget, history *analysis.HistoryPeak) (*analysis.Recommendations, int) {
+	debug.Section(fmt.Sprintf("ANALYZING INSTANCE %s", inst.ID()))
+
+	// Get Apache status information (mod_status). A container's ports are in
+	// its own network namespace, so localhost would reach a different server.
 	debug.Section("RETRIEVING APACHE STATUS")
 	statusTimer := debug.StartTimer("Apache Status")
-	statusInfo, err := status.GetApacheStatus()
+	var statusInfo *status.ApacheStatus
+	var err error
+	if apacheConfig.InContainer() {
+		err = fmt.Errorf("mod_status of containerized instance %s is not reachable from the host", inst.ID())
+	} else {
+		statusInfo, err = status.GetApacheStatusForPorts(apacheConfig.ListenPorts)
+	}
 	if err != nil {
 		debug.Warn("Could not get Apache status info: %v", err)
 		// Only show this warning in debug mode
@@ -146,56 +325,87 @@ func main() {
 	}
 	statusTimer.Stop()
 
-	// Find Apache processes
-	debug.Section("FINDING APACHE PROCESSES")
-	processTimer := debug.StartTimer("Process Discovery")
-	processes, err := process.FindApacheProcesses()
-	if err != nil {
-		debug.Error(err, "process discovery")
-		log.Fatalf("Failed to find Apache processes: %v", err)
-	}
-	processTimer.Stop()
-
-	if len(processes) == 0 {
-		debug.Error(fmt.Errorf("no processes found"), "process discovery")
-		log.Fatal("No Apache worker processes found. Is Apache running?")
-	}
-
-	debug.Info("Found %d Apache worker processes", len(processes))
-	debug.DumpSlice("ApacheProcesses", processes)
-
 	// Get virtual host count
 	debug.Info("Counting virtual hosts")
-	vhostCount := config.GetVirtualHostCount(apacheConfig.ConfigPath)
+	vhostCount := config.GetVirtualHostCount(apacheConfig.HostPath(apacheConfig.ConfigPath))
 	debug.Info("Virtual hosts found: %d", vhostCount)
 
-	// Check Apache logs for issues
-	debug.Section("ANALYZING APACHE LOGS")
-	logTimer := debug.StartTimer("Log Analysis")
-	logAnalysis := logs.AnalyzeApacheLogs()
-	logTimer.Stop()
-	debug.DumpStruct("LogAnalysis", logAnalysis)
-
 	// Calculate memory statistics and enhanced recommendations
 	debug.Section("CALCULATING RECOMMENDATIONS")
 	memTimer := debug.StartTimer("Memory Analysis")
-	memStats := analysis.CalculateMemoryStats(processes)
-	recommendations := analysis.GenerateEnhancedRecommendations(sysInfo, memStats, apacheConfig, statusInfo, vhostCount)
+	memStats := analysis.CalculateMemoryStats(inst.Workers)
+	memStats.Statistic = policy.Sizing
+	memStats.DescribeOutliers(statusInfo)
+	for _, proc := range memStats.Skipped {
+		debug.Warn("Skipping PID %d (user %s): memory could not be measured", proc.PID, proc.User)
+	}
+	lifetime := analysis.AnalyzeWorkerLifetime(inst.Workers, apacheConfig, statusInfo, time.Now())
+
+	// Leaking workers are projected over the lifetime workers have shown so
+	// far, capped at DefaultGrowthHorizon
+	horizon := analysis.DefaultGrowthHorizon
+	if lifetime != nil && lifetime.OldestAge > 0 && lifetime.OldestAge < horizon {
+		horizon = lifetime.OldestAge
+	}
+	growth := analysis.AnalyzeMemoryGrowth(inst.Workers, leakThreshold, horizon)
+	memStats.ApplyGrowth(growth)
+	memStats.ApplyHistory(history)
+
+	recommendations := analysis.GenerateRecommendationsWithPolicy(sysInfo, memStats, apacheConfig, statusInfo, vhostCount, policy)
+	recommendations.Lifetime = lifetime
+	recommendations.Growth = growth
+	analysis.ApplyCPUCeiling(recommendations, analyzeCPU(inst, statusInfo, apacheConfig, policy))
+	analysis.AssessHistory(recommendations, history, sysInfo, memStats, apacheConfig)
+	analysis.AssessMemoryPressure(recommendations, sysInfo, memStats)
+	analysis.AssessLimits(recommendations, system.AuditLimits(inst.MasterPID, analysis.LimitNeeds(apacheConfig, memStats)))
+	instanceLogs := *logAnalysis
+	instanceLogs.Access = logs.AnalyzeAccessLogs(apacheConfig, count == 1)
+	logAnalysis = &instanceLogs
+	analysis.AssessDemand(recommendations, analysis.AnalyzeDemand(statusInfo, logAnalysis.Access.Peak()))
+	analysis.RecommendMPMBlock(recommendations, apacheConfig, statusInfo)
+	analysis.AdviseMigration(recommendations, sysInfo, memStats, apacheConfig, statusInfo)
 	memTimer.Stop()
 
+	facts := analysis.Facts(sysInfo, memStats, apacheConfig, recommendations, statusInfo)
+	findings.Put(facts, logAnalysis)
+	findings.Put(facts, suppressions)
+	analysis.Evaluate(recommendations, facts)
+
 	debug.DumpStruct("MemoryStats", memStats)
 	debug.DumpStruct("Recommendations", recommendations)
 
 	// Display enhanced results (this handles all the main output)
 	debug.Section("GENERATING REPORT")
 	reportTimer := debug.StartTimer("Report Generation")
+	if count > 1 || inst.Container {
+		output.DisplayInstanceHeader(index, count, inst, apacheConfig, statusInfo, sysInfo.AvailableMemoryMB, totalAvailableMB)
+	}
 	output.DisplayEnhancedResults(sysInfo, memStats, apacheConfig, recommendations, statusInfo, logAnalysis)
 	reportTimer.Stop()
 
+	if sim.Active() {
+		debug.Section("SIMULATING")
+		result := analysis.Simulate(sim, sysInfo, memStats, apacheConfig, statusInfo, recommendations, policy)
+		debug.DumpStruct("Simulation", result)
+		output.DisplaySimulation(sysInfo, memStats, apacheConfig, recommendations, result)
+	}
+
+	if target.Concurrency > 0 || target.RatePerSec > 0 {
+		debug.Section("CAPACITY PLANNING")
+		report, err := analysis.PlanCapacityReport(target, sysInfo, memStats, apacheConfig, statusInfo, policy)
+		if err != nil {
+			debug.Error(err, "capacity planning")
+			fmt.Printf("\nCannot plan capacity: %v\n", err)
+		} else {
+			debug.DumpStruct("CapacityPlan", report.Plan)
+			output.DisplayCapacityPlan(report)
+		}
+	}
+
 	// Create log entry for historical tracking
 	debug.Info("Creating log entry")
 	logEntryTimer := debug.StartTimer("Log Entry Creation")
-	if err := logs.CreateLogEntry(sysInfo, memStats, apacheConfig, recommendations); err != nil {
+	if err := logs.CreateLogEntry(sysInfo, memStats, apacheConfig, recommendations, statusInfo); err != nil {
 		debug.Warn("Could not create log entry: %v", err)
 		if debug.IsEnabled() {
 			fmt.Printf("Note: Could not create log entry: %v\n", err)
@@ -203,22 +413,98 @@ func main() {
 	}
 	logEntryTimer.Stop()
 
-	// Exit with status code based on recommendations
-	debug.Info("Exiting with status based on recommendations: %s", recommendations.Status)
-	switch recommendations.Status {
-	case "OK":
-		debug.Info("Exiting with code 0 (OK)")
-		os.Exit(0)
-	case "WARNING":
-		debug.Info("Exiting with code 1 (WARNING)")
-		os.Exit(1)
-	case "CRITICAL":
-		debug.Info("Exiting with code 2 (CRITICAL)")
-		os.Exit(2)
-	default:
-		debug.Info("Exiting with code 0 (default)")
-		os.Exit(0)
+	return recommendations, analysis.RecommendedMemoryMB(recommendations, apacheConfig, memStats)
+}
+
+// analyzeCPU reads the CPUs available to the instance and derives its CPU
+// ceiling (nil if the CPUs could not be read)
+func analyzeCPU(inst process.Instance, statusInfo *status.ApacheStatus, apacheConfig *config.ApacheConfig, policy analysis.Policy) *analysis.CPUAnalysis {
+	pid := inst.MasterPID
+	if pid == 0 && len(inst.Workers) > 0 {
+		pid = inst.Workers[0].PID
+	}
+	cpu, err := system.ReadCPUInfo(pid)
+	if err != nil {
+		debug.Warn("Could not read CPU information: %v", err)
+		return nil
+	}
+	return analysis.AnalyzeCPU(cpu, inst.Workers, statusInfo, apacheConfig.MPMModel, policy, time.Now())
+}
+
+// applyInstanceCgroup caps the instance's memory to its cgroup limit, read
+// from the master process (or a worker when the master is unknown), and
+// returns the cgroup (nil if it could not be read)
+func applyInstanceCgroup(sysInfo *system.SystemInfo, inst process.Instance) *system.CgroupMemory {
+	pid := inst.MasterPID
+	if pid == 0 && len(inst.Workers) > 0 {
+		pid = inst.Workers[0].PID
+	}
+
+	cg, err := system.ReadCgroupMemory(pid)
+	if err != nil {
+		debug.Warn("Could not read cgroup memory limit for PID %d: %v", pid, err)
+	} else {
+		debug.DumpStruct("CgroupMemory", cg)
+	}
+	system.ApplyCgroupLimit(sysInfo, cg)
+	return cg
+}
+
+// loadPolicy reads the policy file and applies the flags that were given on
+// top of it. An invalid policy file is reported and ignored, like an invalid
+// service registry; invalid flags are an error.
+func loadPolicy(path, reserve, margin, sizeOn string) (analysis.Policy, error) {
+	policy, err := analysis.LoadPolicy(path)
+	if err != nil {
+		debug.Warn("Ignoring policy file: %v", err)
+		fmt.Printf("Warning: %v\n", err)
+	}
+	if reserve != "" {
+		if policy.Reserve, err = analysis.ParseReserve(reserve); err != nil {
+			return policy, err
+		}
+	}
+	if margin != "" {
+		if policy.TargetPercent, policy.LimitPercent, err = analysis.ParseMargin(margin); err != nil {
+			return policy, err
+		}
+	}
+	if sizeOn != "" {
+		policy.Sizing = sizeOn
+	}
+	return policy, policy.Validate()
+}
+
+// simulation builds the what-if overrides from the -sim-* flags
+func simulation(memory, mpm string, threads int, exclude string, workerMB float64) (analysis.Simulation, error) {
+	sim := analysis.Simulation{MPMModel: strings.ToLower(mpm), ThreadsPerChild: threads, WorkerMB: workerMB}
+	if memory != "" {
+		mb, err := analysis.ParseMemoryMB(memory)
+		if err != nil {
+			return sim, err
+		}
+		sim.TotalMemoryMB = mb
+	}
+	for _, name := range strings.Split(exclude, ",") {
+		if name = strings.TrimSpace(name); name != "" {
+			sim.ExcludeServices = append(sim.ExcludeServices, name)
+		}
+	}
+	return sim, sim.Validate()
+}
+
+// threadedMPM returns the first threaded (worker/event) MPM in use by any
+// instance, falling back to the first instance's MPM
+func threadedMPM(configs []*config.ApacheConfig) string {
+	for _, apacheConfig := range configs {
+		if apacheConfig.MPMModel == "worker" || apacheConfig.MPMModel == "event" {
+			return apacheConfig.MPMModel
+		}
+	}
+	if len(configs) > 0 {
+		return configs[0].MPMModel
 	}
+	return "prefork"
 }
 
 func showHelp() {
@@ -233,6 +519,26 @@ func showHelp() {
 	fmt.Println("  -help          Show this help information")
 	fmt.Println("  -version       Show version information")
 	fmt.Println("  -history N     Show last N entries from apache2buddy log file")
+	fmt.Println("  -samples N     Sample worker memory N times to detect growing (leaking) workers")
+	fmt.Println("  -sample-interval D  Time between memory samples (default 10s)")
+	fmt.Println("  -leak-threshold MB  Growth in MB/hour above which a worker is flagged (default 10)")
+	fmt.Println("  -services FILE Additional service definitions (default /etc/apache2buddy-go/services.json)")
+	fmt.Println("  -oom-window D  How far back to look for OOM-killer events (default 168h)")
+	fmt.Println("  -oom-log FILE  Extra kernel log to search for OOM kills, e.g. saved journalctl -k -o short-iso output")
+	fmt.Println("  -policy FILE   Sizing policy (default /etc/apache2buddy-go/policy.json)")
+	fmt.Println("  -reserve R     Memory kept free for the OS: MB, a percentage of RAM, or both, e.g. 512M,10% (default 0)")
+	fmt.Println("  -margin LOW-HIGH  Recommend LOW% of the budget, warn up to HIGH% (default 90-100)")
+	fmt.Println("  -size-on STAT  Worker size to plan with: largest, p99, p95, p90, median, average, pss or history (default largest)")
+	fmt.Println("  -history-days N  Days of past runs to take worker size and concurrency peaks from, 0 to ignore (default 14)")
+	fmt.Println("  -service-accounting MODE  Charge MySQL and Redis their current or planned (configured) memory (default current)")
+	fmt.Println("  -suppressions FILE  Acknowledged findings (default /etc/apache2buddy-go/suppressions.json)")
+	fmt.Println("  -sim-memory SIZE    What if the host had SIZE of RAM, e.g. 16G")
+	fmt.Println("  -sim-mpm MPM        What if Apache ran prefork, worker or event")
+	fmt.Println("  -sim-threads N      What if ThreadsPerChild were N")
+	fmt.Println("  -sim-exclude LIST   What if these services moved off the box, e.g. MySQL,Redis")
+	fmt.Println("  -sim-worker-mb MB   What if each Apache process used MB")
+	fmt.Println("  -plan-concurrency N Plan the RAM and MPM settings needed for N concurrent requests")
+	fmt.Println("  -plan-rate R        Plan for R requests per second at mod_status's mean request duration")
 	fmt.Println()
 	fmt.Println("DESCRIPTION:")
 	fmt.Println("  Analyzes Apache HTTP Server configuration and provides tuning recommendations")
@@ -252,6 +558,9 @@ func showHelp() {
 	fmt.Println("  sudo ./apache2buddy-go                    # Normal analysis")
 	fmt.Println("  sudo ./apache2buddy-go -debug             # Debug mode with detailed output")
 	fmt.Println("  sudo ./apache2buddy-go -history 10        # Show last 10 log entries")
+	fmt.Println("  sudo ./apache2buddy-go -samples 7 -sample-interval 30s  # Watch workers for 3 minutes")
+	fmt.Println("  sudo ./apache2buddy-go -sim-memory 16G -sim-exclude MySQL  # What if: more RAM, no MySQL")
+	fmt.Println("  sudo ./apache2buddy-go -plan-concurrency 800            # RAM needed for 800 concurrent requests")
 	fmt.Println()
 	fmt.Println("LOG FILE:")
 	fmt.Println("  Historical data is logged to /var/log/apache2buddy-go.log")